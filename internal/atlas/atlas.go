@@ -0,0 +1,258 @@
+// Package atlas is a small client of the Atlas Administration API, for the
+// `atlas` commands: listing projects and clusters, reading connection
+// strings, and pausing and resuming clusters.
+package atlas
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultBaseURL is the Atlas Administration API.
+const DefaultBaseURL = "https://cloud.mongodb.com/api/atlas/v2"
+
+const mediaType = "application/vnd.atlas.2023-01-01+json"
+
+// Client calls the API with a programmatic API key, which authenticates
+// with HTTP digest authentication.
+type Client struct {
+	PublicKey, PrivateKey string
+	BaseURL               string // DefaultBaseURL if empty
+	HTTP                  *http.Client
+}
+
+// Project is an Atlas project, called a group in the API.
+type Project struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	OrgID string `json:"orgId"`
+}
+
+// Cluster is an Atlas cluster.
+type Cluster struct {
+	Name              string `json:"name"`
+	StateName         string `json:"stateName"`
+	Paused            bool   `json:"paused"`
+	ClusterType       string `json:"clusterType"`
+	MongoDBVersion    string `json:"mongoDBVersion"`
+	ConnectionStrings struct {
+		Standard    string `json:"standard"`
+		StandardSrv string `json:"standardSrv"`
+		Private     string `json:"private"`
+		PrivateSrv  string `json:"privateSrv"`
+	} `json:"connectionStrings"`
+}
+
+// Error is an error response of the API.
+type Error struct {
+	Status    int    `json:"error"`
+	ErrorCode string `json:"errorCode"`
+	Detail    string `json:"detail"`
+}
+
+func (e *Error) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("atlas: HTTP %d", e.Status)
+	}
+	return fmt.Sprintf("atlas: %s (%s)", e.Detail, e.ErrorCode)
+}
+
+// Projects lists the projects the API key can see.
+func (c *Client) Projects(ctx context.Context) ([]Project, error) {
+	var page struct {
+		Results []Project `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/groups?itemsPerPage=500", nil, &page); err != nil {
+		return nil, err
+	}
+	return page.Results, nil
+}
+
+// Clusters lists the clusters of a project.
+func (c *Client) Clusters(ctx context.Context, projectID string) ([]Cluster, error) {
+	var page struct {
+		Results []Cluster `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/groups/"+url.PathEscape(projectID)+"/clusters?itemsPerPage=500", nil, &page); err != nil {
+		return nil, err
+	}
+	return page.Results, nil
+}
+
+// Cluster returns a cluster of a project.
+func (c *Client) Cluster(ctx context.Context, projectID, name string) (Cluster, error) {
+	var cluster Cluster
+	err := c.do(ctx, http.MethodGet, clusterPath(projectID, name), nil, &cluster)
+	return cluster, err
+}
+
+// SetPaused pauses or resumes a cluster and returns it as updated.
+func (c *Client) SetPaused(ctx context.Context, projectID, name string, paused bool) (Cluster, error) {
+	var cluster Cluster
+	err := c.do(ctx, http.MethodPatch, clusterPath(projectID, name), map[string]bool{"paused": paused}, &cluster)
+	return cluster, err
+}
+
+func clusterPath(projectID, name string) string {
+	return "/groups/" + url.PathEscape(projectID) + "/clusters/" + url.PathEscape(name)
+}
+
+// do sends a request, answering the digest challenge of the first attempt,
+// and decodes the JSON response into out.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	send := func(authorization string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(base, "/")+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", mediaType)
+		if body != nil {
+			req.Header.Set("Content-Type", mediaType)
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		client := c.HTTP
+		if client == nil {
+			client = http.DefaultClient
+		}
+		return client.Do(req)
+	}
+
+	resp, err := send("")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		authorization, err := c.digest(challenge, method, resp.Request.URL.RequestURI())
+		if err != nil {
+			return err
+		}
+		if resp, err = send(authorization); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		apiErr := &Error{Status: resp.StatusCode}
+		json.Unmarshal(data, apiErr) // Keep the status if the body is not JSON
+		if apiErr.Status == 0 {
+			apiErr.Status = resp.StatusCode
+		}
+		return apiErr
+	}
+	return json.Unmarshal(data, out)
+}
+
+// digest answers a Digest challenge (RFC 7616, MD5 with qop=auth, which is
+// what Atlas asks for).
+func (c *Client) digest(challenge, method, uri string) (string, error) {
+	scheme, rest, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Digest") {
+		return "", fmt.Errorf("atlas: unauthorized, and no digest challenge to answer")
+	}
+	params := parseChallenge(rest)
+	if alg := params["algorithm"]; alg != "" && !strings.EqualFold(alg, "MD5") {
+		return "", fmt.Errorf("atlas: unsupported digest algorithm %s", alg)
+	}
+
+	realm, nonce, qop := params["realm"], params["nonce"], params["qop"] != ""
+	cnonceBytes := make([]byte, 8)
+	if _, err := rand.Read(cnonceBytes); err != nil {
+		return "", err
+	}
+	cnonce := hex.EncodeToString(cnonceBytes)
+
+	fields := []string{
+		fmt.Sprintf(`username="%s"`, c.PublicKey),
+		fmt.Sprintf(`realm="%s"`, realm),
+		fmt.Sprintf(`nonce="%s"`, nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		"algorithm=MD5",
+	}
+	if qop {
+		fields = append(fields, "qop=auth", "nc="+digestNC, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	fields = append(fields, fmt.Sprintf(`response="%s"`, digestResponse(c.PublicKey, c.PrivateKey, realm, nonce, method, uri, cnonce, qop)))
+	if opaque, ok := params["opaque"]; ok {
+		fields = append(fields, fmt.Sprintf(`opaque="%s"`, opaque))
+	}
+	return "Digest " + strings.Join(fields, ", "), nil
+}
+
+// digestNC is the nonce count; each challenge is answered once.
+const digestNC = "00000001"
+
+// digestResponse computes the response of a digest answer, with qop=auth
+// if qop is set.
+func digestResponse(username, password, realm, nonce, method, uri, cnonce string, qop bool) string {
+	ha1 := md5Hex(username + ":" + realm + ":" + password)
+	ha2 := md5Hex(method + ":" + uri)
+	if !qop {
+		return md5Hex(ha1 + ":" + nonce + ":" + ha2)
+	}
+	return md5Hex(ha1 + ":" + nonce + ":" + digestNC + ":" + cnonce + ":auth:" + ha2)
+}
+
+// parseChallenge reads the comma-separated key=value pairs of a challenge,
+// whose values may be quoted and contain commas.
+func parseChallenge(s string) map[string]string {
+	params := map[string]string{}
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			value, rest = rest[1:end+1], rest[end+1:]
+			if len(rest) > 0 {
+				rest = rest[1:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+		params[key] = value
+		s = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+	return params
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
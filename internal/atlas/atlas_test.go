@@ -0,0 +1,104 @@
+package atlas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDigestResponse(t *testing.T) {
+	// The example of RFC 2617, section 3.5.
+	got := digestResponse("Mufasa", "Circle Of Life", "testrealm@host.com", "dcd98b7102dd2f0e8b11d0f600bfb0c093", "GET", "/dir/index.html", "0a4f113b", true)
+	if got != "6629fae49393a05397450978507c4ef1" {
+		t.Errorf("digestResponse = %s", got)
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	got := parseChallenge(`realm="MMS Public API", domain="", nonce="a,b", algorithm=MD5, qop="auth", stale=false`)
+	want := map[string]string{"realm": "MMS Public API", "domain": "", "nonce": "a,b", "algorithm": "MD5", "qop": "auth", "stale": "false"}
+	if len(got) != len(want) {
+		t.Fatalf("parseChallenge = %v", got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+// newTestServer serves a few API routes behind digest authentication with
+// the key public/private.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	const nonce = "n0nce"
+	paused := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if auth == "" {
+			w.Header().Set("WWW-Authenticate", `Digest realm="MMS Public API", domain="", nonce="`+nonce+`", algorithm=MD5, qop="auth", stale=false`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		p := parseChallenge(strings.TrimPrefix(auth, "Digest "))
+		if p["response"] != digestResponse("public", "private", "MMS Public API", nonce, r.Method, r.URL.RequestURI(), p["cnonce"], true) {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(Error{Status: 401, ErrorCode: "UNAUTHORIZED", Detail: "bad digest"})
+			return
+		}
+		switch {
+		case r.URL.Path == "/groups":
+			w.Write([]byte(`{"results": [{"id": "5f1a2b3c4d5e6f7a8b9c0d1e", "name": "shop", "orgId": "o1"}]}`))
+		case r.URL.Path == "/groups/5f1a2b3c4d5e6f7a8b9c0d1e/clusters/main" && r.Method == http.MethodPatch:
+			var body map[string]bool
+			json.NewDecoder(r.Body).Decode(&body)
+			paused = body["paused"]
+			w.Write([]byte(`{"name": "main", "stateName": "REPAIRING", "paused": ` + map[bool]string{true: "true", false: "false"}[paused] + `}`))
+		case r.URL.Path == "/groups/5f1a2b3c4d5e6f7a8b9c0d1e/clusters/main":
+			w.Write([]byte(`{"name": "main", "stateName": "IDLE", "paused": ` + map[bool]string{true: "true", false: "false"}[paused] + `, "connectionStrings": {"standardSrv": "mongodb+srv://main.abc.mongodb.net"}}`))
+		case r.URL.Path == "/groups/5f1a2b3c4d5e6f7a8b9c0d1e/clusters":
+			w.Write([]byte(`{"results": [{"name": "main", "stateName": "IDLE", "clusterType": "REPLICASET", "mongoDBVersion": "7.0.12"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": 404, "errorCode": "CLUSTER_NOT_FOUND", "detail": "No cluster named x exists."}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient(t *testing.T) {
+	srv := newTestServer(t)
+	c := &Client{PublicKey: "public", PrivateKey: "private", BaseURL: srv.URL}
+	ctx := context.Background()
+
+	projects, err := c.Projects(ctx)
+	if err != nil || len(projects) != 1 || projects[0].Name != "shop" {
+		t.Fatalf("Projects = %v, %v", projects, err)
+	}
+	clusters, err := c.Clusters(ctx, projects[0].ID)
+	if err != nil || len(clusters) != 1 || clusters[0].MongoDBVersion != "7.0.12" {
+		t.Errorf("Clusters = %v, %v", clusters, err)
+	}
+	cluster, err := c.SetPaused(ctx, projects[0].ID, "main", true)
+	if err != nil || !cluster.Paused {
+		t.Errorf("SetPaused = %v, %v", cluster, err)
+	}
+	cluster, err = c.Cluster(ctx, projects[0].ID, "main")
+	if err != nil || !cluster.Paused || cluster.ConnectionStrings.StandardSrv == "" {
+		t.Errorf("Cluster = %v, %v", cluster, err)
+	}
+
+	var apiErr *Error
+	if _, err := c.Cluster(ctx, projects[0].ID, "x"); !errors.As(err, &apiErr) || apiErr.ErrorCode != "CLUSTER_NOT_FOUND" {
+		t.Errorf("missing cluster: err = %v", err)
+	}
+	c.PrivateKey = "wrong"
+	if _, err := c.Projects(ctx); err == nil || !strings.Contains(err.Error(), "bad digest") {
+		t.Errorf("wrong key: err = %v", err)
+	}
+}
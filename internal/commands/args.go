@@ -0,0 +1,233 @@
+// Package commands parses the arguments of mon-go commands.
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SplitArgs splits a command line into arguments the way a shell would for
+// the simple cases: whitespace separates arguments, single quotes keep their
+// contents literally and double quotes allow backslash escapes. This lets
+// JSON arguments such as '{"a": 1}' contain spaces and double quotes. An
+// argument starting with { or [ is also taken literally up to the bracket
+// closing it, so documents such as {name: 'bob', age: {$gt: 21}} need no
+// quoting at all.
+func SplitArgs(input string) ([]string, error) {
+	split, quote := splitPositions(input)
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	var args []string
+	for _, a := range split {
+		args = append(args, a.text)
+	}
+	return args, nil
+}
+
+// arg is an argument of a command line with where each of its runes was
+// typed, quotes and escapes taken out.
+type arg struct {
+	text      string
+	positions []int // Rune index in the line of each rune of text
+}
+
+// splitPositions splits a line for SplitArgs, keeping where the runes of
+// each argument were typed. It also returns the quote left unterminated,
+// if any, which ends the last argument.
+func splitPositions(input string) ([]arg, rune) {
+	var args []arg
+	var current arg
+	var text strings.Builder
+	inArg := false
+	var quote rune
+
+	end := func() {
+		current.text = text.String()
+		args = append(args, current)
+		current, inArg = arg{}, false
+		text.Reset()
+	}
+	add := func(r rune, i int) {
+		text.WriteRune(r)
+		current.positions = append(current.positions, i)
+		inArg = true
+	}
+	runes := []rune(input)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				add(r, i)
+			}
+		case quote == '"':
+			if r == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+				i++
+				add(runes[i], i)
+			} else if r == '"' {
+				quote = 0
+			} else {
+				add(r, i)
+			}
+		case (r == '{' || r == '[') && !inArg:
+			for to := bracketed(runes, i); i < to; i++ {
+				add(runes[i], i)
+			}
+			i--
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				end()
+			}
+		default:
+			add(r, i)
+		}
+	}
+	if inArg {
+		end()
+	}
+	return args, quote
+}
+
+// bracketed returns the index just past the bracket closing the one at
+// from, skipping brackets in strings, or the end of the line if it is not
+// closed.
+func bracketed(runes []rune, from int) int {
+	depth := 0
+	var quote rune
+	for i := from; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == '\\' {
+				i++
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '{' || r == '[':
+			depth++
+		case r == '}' || r == ']':
+			if depth--; depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(runes)
+}
+
+// StripFlag removes every occurrence of flag from args and reports whether
+// it was present. It is used for flags that apply to any command.
+func StripFlag(args []string, flag string) ([]string, bool) {
+	found := false
+	kept := args[:0:0]
+	for _, arg := range args {
+		if arg == flag {
+			found = true
+			continue
+		}
+		kept = append(kept, arg)
+	}
+	return kept, found
+}
+
+// StripOption removes every occurrence of flag and its value, written as
+// "flag value" or "flag=value", from args and returns the last value, or ""
+// if flag is absent. Like StripFlag, it is for options of any command.
+func StripOption(args []string, flag string) ([]string, string, error) {
+	value := ""
+	kept := args[:0:0]
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == flag:
+			if i+1 == len(args) {
+				return nil, "", fmt.Errorf("%s needs a value", flag)
+			}
+			i++
+			value = args[i]
+		case strings.HasPrefix(args[i], flag+"="):
+			value = strings.TrimPrefix(args[i], flag+"=")
+		default:
+			kept = append(kept, args[i])
+		}
+	}
+	return kept, value, nil
+}
+
+// NewFlagSet returns a flag set for a command's options that reports errors
+// instead of printing them.
+func NewFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// ParseFlags parses args with fs, allowing flags and positional arguments to
+// be interleaved, and returns the positional arguments.
+func ParseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%s: %w", fs.Name(), err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// ParseByteSize parses sizes such as 4096, 512KB or 100MB into bytes.
+func ParseByteSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	factor := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(upper, u.suffix) {
+			upper = strings.TrimSuffix(upper, u.suffix)
+			factor = u.factor
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	return n * factor, nil
+}
+
+// FormatByteSize formats a number of bytes the way ParseByteSize reads them,
+// e.g. 1.5MB.
+func FormatByteSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1fGB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
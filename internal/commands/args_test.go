@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"ls -la", []string{"ls", "-la"}},
+		{"  cd   db/coll  ", []string{"cd", "db/coll"}},
+		{`find '{"a": 1}'`, []string{"find", `{"a": 1}`}},
+		{`find "{\"a\": \"b c\"}"`, []string{"find", `{"a": "b c"}`}},
+		{`insert ''`, []string{"insert", ""}},
+		{`find {name: 'bob', tags: ["a b"]} --limit 1`, []string{"find", `{name: 'bob', tags: ["a b"]}`, "--limit", "1"}},
+		{`find '{"a": 1}'{b`, []string{"find", `{"a": 1}{b`}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := SplitArgs(tt.input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.input, got, tt.want)
+		}
+	}
+	if _, err := SplitArgs(`find '{"a": 1}`); err == nil {
+		t.Error("unterminated quote: no error")
+	}
+}
+
+func TestParseFlagsInterleaved(t *testing.T) {
+	fs := NewFlagSet("find")
+	limit := fs.Int("limit", 5, "")
+	positional, err := ParseFlags(fs, []string{"--limit", "3", "{}", "extra"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *limit != 3 || !reflect.DeepEqual(positional, []string{"{}", "extra"}) {
+		t.Errorf("got limit %d, positional %q", *limit, positional)
+	}
+	if _, err := ParseFlags(NewFlagSet("x"), []string{"--nope"}); err == nil {
+		t.Error("unknown flag: no error")
+	}
+}
+
+func TestStripFlag(t *testing.T) {
+	args, found := StripFlag([]string{"a", "--unmask", "b"}, "--unmask")
+	if !found || !reflect.DeepEqual(args, []string{"a", "b"}) {
+		t.Errorf("got %q, %v", args, found)
+	}
+}
+
+func TestStripOption(t *testing.T) {
+	args, value, err := StripOption([]string{"a", "--chart", "bar", "b"}, "--chart")
+	if err != nil || value != "bar" || !reflect.DeepEqual(args, []string{"a", "b"}) {
+		t.Errorf("got %q, %q, %v", args, value, err)
+	}
+	if _, value, _ := StripOption([]string{"--chart=line"}, "--chart"); value != "line" {
+		t.Errorf("--chart=line: got %q", value)
+	}
+	if _, _, err := StripOption([]string{"a", "--chart"}, "--chart"); err == nil {
+		t.Error("missing value: no error")
+	}
+}
+
+func TestByteSizes(t *testing.T) {
+	for input, want := range map[string]int64{"4096": 4096, "512KB": 512 << 10, "100mb": 100 << 20, "2GB": 2 << 30} {
+		got, err := ParseByteSize(input)
+		if err != nil || got != want {
+			t.Errorf("%s: got %d, %v, want %d", input, got, err, want)
+		}
+	}
+	if _, err := ParseByteSize("-1"); err == nil {
+		t.Error("negative size: no error")
+	}
+	if got := FormatByteSize(1536 << 10); got != "1.5MB" {
+		t.Errorf("FormatByteSize: got %s", got)
+	}
+}
+
+func TestParseCollation(t *testing.T) {
+	c, err := ParseCollation("fr")
+	if err != nil || c.Locale != "fr" {
+		t.Errorf("locale: got %+v, %v", c, err)
+	}
+	c, err = ParseCollation(`{"locale": "en", "strength": 2}`)
+	if err != nil || c.Strength != 2 {
+		t.Errorf("document: got %+v, %v", c, err)
+	}
+	if _, err := ParseCollation(`{"strength": 2}`); err == nil {
+		t.Error("collation without a locale: no error")
+	}
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// CheckJSON looks for a JSON argument of a command line, one starting with {
+// or [, that is not valid JSON or relaxed JSON, for reporting mistakes while
+// the line is being typed. It returns the rune index in the line of the
+// mistake and what it is. The last argument may be incomplete, since it may
+// still be being typed; any other incomplete one is a mistake, usually a
+// quote in the wrong place.
+func CheckJSON(line string) (int, error) {
+	args, _ := splitPositions(line)
+	for i, a := range args {
+		trimmed := strings.TrimSpace(a.text)
+		if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+			continue
+		}
+		strict, from, err := relax(a.text)
+		var literal *literalError
+		if errors.As(err, &literal) {
+			return a.positions[runeIndex(a.text, literal.at)], err
+		}
+		var v interface{}
+		err = json.Unmarshal([]byte(strict), &v)
+		var syntax *json.SyntaxError
+		if err == nil || !errors.As(err, &syntax) {
+			continue
+		}
+		last := i == len(args)-1
+		if int(syntax.Offset) >= len(strict) {
+			if last {
+				continue // Not finished yet
+			}
+			return a.positions[len(a.positions)-1], fmt.Errorf("incomplete JSON")
+		}
+		return a.positions[runeIndex(a.text, from[max(syntax.Offset-1, 0)])], err
+	}
+	return -1, nil
+}
+
+// runeIndex returns the index of the rune of s that the byte at offset is
+// part of, for errors that point into the middle of one.
+func runeIndex(s string, offset int) int {
+	for offset > 0 && offset < len(s) && !utf8.RuneStart(s[offset]) {
+		offset--
+	}
+	return utf8.RuneCountInString(s[:min(offset, len(s))])
+}
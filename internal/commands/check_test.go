@@ -0,0 +1,30 @@
+package commands
+
+import "testing"
+
+func TestCheckJSON(t *testing.T) {
+	tests := []struct {
+		line string
+		at   int // -1 when there is no mistake
+	}{
+		{`find '{"a":1}'`, -1},
+		{`find {"a": 1}`, -1},
+		{`find {name: 'bob', age: {$gt: 21}}`, -1},
+		{`find {name: 'bob' age: 1}`, 18},
+		{`find '{"a": 1}' '{"b": 1}'`, -1},
+		{`find '{"a": `, -1}, // Still being typed
+		{`find '{"a" 1}'`, 11},
+		{`find "{\"a\": x}"`, 14},
+		{`find '{"a":' '1}'`, 10}, // Split in two
+		{`cd shop/orders`, -1},
+		{`insert '[{"a": 1},, {"b": 2}]'`, 18},
+		{"\"[\xf40\"", 2}, // Not UTF-8
+		{"find {a: '\u00e9\u00e9' b}", 14},
+	}
+	for _, tt := range tests {
+		at, err := CheckJSON(tt.line)
+		if at != tt.at || (err != nil) != (tt.at >= 0) {
+			t.Errorf("%s: mistake at %d (%v), want %d", tt.line, at, err, tt.at)
+		}
+	}
+}
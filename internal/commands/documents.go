@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ParseDocument parses a filter, sort or other document argument written as
+// Extended JSON, or in mongosh's relaxed syntax.
+func ParseDocument(s string) (bson.D, error) {
+	strict, err := StrictJSON(s)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.D
+	if err := bson.UnmarshalExtJSON([]byte(strict), false, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// ParsePipeline parses an aggregation pipeline written as a JSON array of
+// stages, which may use mongosh's relaxed syntax.
+func ParsePipeline(s string) ([]bson.D, error) {
+	strict, err := StrictJSON(s)
+	if err != nil {
+		return nil, err
+	}
+	var wrapper struct {
+		Pipeline []bson.D `bson:"pipeline"`
+	}
+	if err := bson.UnmarshalExtJSON([]byte(`{"pipeline": `+strict+`}`), false, &wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper.Pipeline, nil
+}
+
+// ParseCollation parses a --collation argument: either a JSON collation
+// document such as '{"locale": "en", "strength": 2}' or just a locale.
+func ParseCollation(s string) (*options.Collation, error) {
+	if !strings.HasPrefix(strings.TrimSpace(s), "{") {
+		return &options.Collation{Locale: s}, nil
+	}
+
+	strict, err := StrictJSON(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid collation: %w", err)
+	}
+	var collation options.Collation
+	dec := json.NewDecoder(bytes.NewReader([]byte(strict)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&collation); err != nil {
+		return nil, fmt.Errorf("invalid collation: %w", err)
+	}
+	if collation.Locale == "" {
+		return nil, fmt.Errorf("invalid collation: locale is required")
+	}
+	return &collation, nil
+}
@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// now is the time now-24h and friends count from, replaced in tests.
+var now = time.Now
+
+// errIncomplete is a literal cut short by the end of the input.
+var errIncomplete = errors.New("incomplete literal")
+
+// constructors turn the argument of a mongosh constructor such as
+// ISODate("...") or ObjectId("...") into Extended JSON, as pasted from
+// mongosh and logs.
+var constructors = map[string]func(arg string) (string, error){
+	"ISODate":       isoDate,
+	"ObjectId":      objectID,
+	"UUID":          uuid,
+	"NumberLong":    numberLong,
+	"NumberInt":     numberInt,
+	"NumberDecimal": numberDecimal,
+}
+
+// literal turns the mongosh literal starting with the word s[i:j] into
+// Extended JSON, returning the JSON and where the literal ends, or "" if
+// the word does not start one.
+func literal(s string, i, j int) (string, int, error) {
+	word := s[i:j]
+	if word == "now" {
+		return relativeDate(s, j)
+	}
+	construct, ok := constructors[word]
+	if !ok {
+		return "", 0, nil
+	}
+	open := skipSpace(s, j)
+	if open == len(s) {
+		return "", 0, errIncomplete
+	}
+	if s[open] != '(' {
+		return "", 0, nil
+	}
+	var quote byte
+	for k := open + 1; k < len(s); k++ {
+		switch {
+		case quote != 0:
+			if s[k] == quote {
+				quote = 0
+			}
+		case s[k] == '\'' || s[k] == '"':
+			quote = s[k]
+		case s[k] == ')':
+			arg := strings.TrimSpace(s[open+1 : k])
+			if len(arg) >= 2 && (arg[0] == '"' || arg[0] == '\'') && arg[len(arg)-1] == arg[0] {
+				arg = arg[1 : len(arg)-1]
+			}
+			text, err := construct(arg)
+			if err != nil {
+				return "", 0, fmt.Errorf("%s: %w", word, err)
+			}
+			return text, k + 1, nil
+		}
+	}
+	return "", 0, errIncomplete
+}
+
+// dateLayouts are the ways ISODate takes dates, those without a zone being
+// UTC.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// isoDate is ISODate("2024-05-01T12:00:00Z"), or the time now with no
+// argument.
+func isoDate(arg string) (string, error) {
+	if arg == "" {
+		return dateJSON(now()), nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, arg); err == nil {
+			return dateJSON(t), nil
+		}
+	}
+	return "", fmt.Errorf("cannot read %q as a date, e.g. 2024-05-01 or 2024-05-01T12:00:00Z", arg)
+}
+
+func objectID(arg string) (string, error) {
+	if _, err := primitive.ObjectIDFromHex(arg); err != nil {
+		return "", fmt.Errorf("%q is not 24 hex digits", arg)
+	}
+	return fmt.Sprintf(`{"$oid": "%s"}`, arg), nil
+}
+
+// uuid is UUID("a1b2c3d4-..."), binary of subtype 4 like mongosh's.
+func uuid(arg string) (string, error) {
+	data, err := hex.DecodeString(strings.ReplaceAll(arg, "-", ""))
+	if err != nil || len(data) != 16 {
+		return "", fmt.Errorf("%q is not 32 hex digits", arg)
+	}
+	return fmt.Sprintf(`{"$binary": {"base64": "%s", "subType": "04"}}`, base64.StdEncoding.EncodeToString(data)), nil
+}
+
+func numberLong(arg string) (string, error) {
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%q is not a 64-bit integer", arg)
+	}
+	return fmt.Sprintf(`{"$numberLong": "%d"}`, n), nil
+}
+
+func numberInt(arg string) (string, error) {
+	n, err := strconv.ParseInt(arg, 10, 32)
+	if err != nil {
+		return "", fmt.Errorf("%q is not a 32-bit integer", arg)
+	}
+	return fmt.Sprintf(`{"$numberInt": "%d"}`, n), nil
+}
+
+func numberDecimal(arg string) (string, error) {
+	d, err := primitive.ParseDecimal128(arg)
+	if err != nil {
+		return "", fmt.Errorf("%q is not a decimal", arg)
+	}
+	return fmt.Sprintf(`{"$numberDecimal": "%s"}`, d), nil
+}
+
+// dateUnits are the units of a relative date.
+var dateUnits = map[byte]time.Duration{
+	's': time.Second,
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+}
+
+// relativeDate is now, or a time relative to it such as now-24h or
+// now+1d12h, whose offset starts at s[j].
+func relativeDate(s string, j int) (string, int, error) {
+	if j == len(s) || (s[j] != '-' && s[j] != '+') {
+		return dateJSON(now()), j, nil
+	}
+	sign := time.Duration(1)
+	if s[j] == '-' {
+		sign = -1
+	}
+	var offset time.Duration
+	k := j + 1
+	for {
+		start := k
+		for k < len(s) && s[k] >= '0' && s[k] <= '9' {
+			k++
+		}
+		switch {
+		case start == k && offset > 0:
+			return dateJSON(now().Add(sign * offset)), k, nil
+		case k == len(s):
+			return "", 0, errIncomplete
+		}
+		unit, ok := dateUnits[s[k]]
+		if start == k || !ok {
+			return "", 0, fmt.Errorf("now%s: expected an offset such as now-24h, in s, m, h, d or w", s[j:k+1])
+		}
+		n, err := strconv.Atoi(s[start:k])
+		if err != nil {
+			return "", 0, fmt.Errorf("now: %w", err)
+		}
+		offset += time.Duration(n) * unit
+		k++
+	}
+}
+
+func dateJSON(t time.Time) string {
+	return fmt.Sprintf(`{"$date": {"$numberLong": "%d"}}`, t.UnixMilli())
+}
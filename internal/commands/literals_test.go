@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDateLiterals(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = time.Now })
+
+	tests := []struct {
+		filter string
+		want   time.Time
+	}{
+		{`{at: now}`, at},
+		{`{at: {$gte: now-24h}}`, at.Add(-24 * time.Hour)},
+		{`{at: {$lt: now+1d12h}}`, at.Add(36 * time.Hour)},
+		{`{at: ISODate("2024-03-10")}`, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{`{at: ISODate('2024-03-10T08:30:00+02:00')}`, time.Date(2024, 3, 10, 6, 30, 0, 0, time.UTC)},
+		{`{at: ISODate()}`, at},
+	}
+	for _, tt := range tests {
+		doc, err := ParseDocument(tt.filter)
+		if err != nil {
+			t.Errorf("%s: %v", tt.filter, err)
+			continue
+		}
+		v := doc[0].Value
+		if d, ok := v.(bson.D); ok {
+			v = d[0].Value
+		}
+		if got, ok := v.(primitive.DateTime); !ok || !got.Time().Equal(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.filter, v, tt.want)
+		}
+	}
+
+	for _, bad := range []string{`{at: ISODate("May 1st")}`, `{at: now-3y}`} {
+		if _, err := ParseDocument(bad); err == nil {
+			t.Errorf("%s: no error", bad)
+		}
+	}
+	if at, err := CheckJSON(`find {at: {$gt: now-2`); err != nil {
+		t.Errorf("date still being typed: mistake at %d: %v", at, err)
+	}
+}
+
+func TestConstructors(t *testing.T) {
+	doc, err := ParseDocument(`{_id: ObjectId("65f1a2b3c4d5e6f708192a3b"), key: UUID('0e9f2c1a-4b7d-4c3e-9a8b-1c2d3e4f5a6b'), n: NumberLong(9007199254740993), i: NumberInt("7"), price: NumberDecimal("19.99")}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := doc.Map()
+	if id, ok := m["_id"].(primitive.ObjectID); !ok || id.Hex() != "65f1a2b3c4d5e6f708192a3b" {
+		t.Errorf("ObjectId: %#v", m["_id"])
+	}
+	if key, ok := m["key"].(primitive.Binary); !ok || key.Subtype != 4 || len(key.Data) != 16 || key.Data[0] != 0x0e {
+		t.Errorf("UUID: %#v", m["key"])
+	}
+	if n, ok := m["n"].(int64); !ok || n != 9007199254740993 {
+		t.Errorf("NumberLong: %#v", m["n"])
+	}
+	if i, ok := m["i"].(int32); !ok || i != 7 {
+		t.Errorf("NumberInt: %#v", m["i"])
+	}
+	if d, ok := m["price"].(primitive.Decimal128); !ok || d.String() != "19.99" {
+		t.Errorf("NumberDecimal: %#v", m["price"])
+	}
+
+	for _, bad := range []string{`{_id: ObjectId("65f1")}`, `{key: UUID("xyz")}`, `{n: NumberLong("1.5")}`} {
+		if _, err := ParseDocument(bad); err == nil {
+			t.Errorf("%s: no error", bad)
+		}
+	}
+}
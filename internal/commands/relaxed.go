@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+)
+
+// StrictJSON rewrites a document written in mongosh's relaxed syntax, such
+// as {name: 'bob', age: {$gt: 21},}, as the JSON the Extended JSON parser
+// expects: unquoted keys are quoted, single-quoted strings are
+// double-quoted, trailing commas are dropped and literals such as
+// ISODate("2024-05-01") or now-24h become Extended JSON. JSON comes out as
+// it went in, and anything else it cannot make sense of is left for the
+// parser to report.
+func StrictJSON(s string) (string, error) {
+	strict, _, err := relax(s)
+	if err != nil {
+		return "", err
+	}
+	return strict, nil
+}
+
+// literalError is a literal that could not be made sense of, at the index of
+// the byte it starts at.
+type literalError struct {
+	at  int
+	err error
+}
+
+func (e *literalError) Error() string {
+	return e.err.Error()
+}
+
+// relax does the work of StrictJSON, also returning for each byte of the
+// JSON the index of the byte of s it comes from. A literal cut short by the
+// end of s ends the JSON there, incomplete.
+func relax(s string) (string, []int, error) {
+	var out strings.Builder
+	var from []int
+	emit := func(text string, at int) {
+		out.WriteString(text)
+		for range len(text) {
+			from = append(from, at)
+		}
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(s))
+			for ; i < j; i++ {
+				emit(s[i:i+1], i)
+			}
+		case c == '\'':
+			emit(`"`, i)
+		quoted:
+			for i++; i < len(s); i++ {
+				switch {
+				case s[i] == '\\' && i+1 < len(s) && s[i+1] == '\'':
+					i++
+					emit(`'`, i)
+				case s[i] == '\\' && i+1 < len(s):
+					emit(s[i:i+2], i)
+					i++
+				case s[i] == '"':
+					emit(`\"`, i)
+				case s[i] == '\'':
+					emit(`"`, i)
+					i++
+					break quoted
+				default:
+					emit(s[i:i+1], i)
+				}
+			}
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(s) && (isIdentStart(s[j]) || s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			if k := skipSpace(s, j); k < len(s) && s[k] == ':' {
+				emit(`"`, i)
+				for k := i; k < j; k++ {
+					emit(s[k:k+1], k)
+				}
+				emit(`"`, j-1)
+				i = j
+				continue
+			}
+			text, end, err := literal(s, i, j)
+			switch {
+			case errors.Is(err, errIncomplete):
+				emit("{", i)
+				return out.String(), from, nil
+			case err != nil:
+				return "", nil, &literalError{at: i, err: err}
+			case text != "":
+				emit(text, i)
+				i = end
+				continue
+			}
+			if word := s[i:j]; j == len(s) && word != "true" && word != "false" && word != "null" {
+				return out.String(), from, nil // A word still being typed
+			}
+			for k := i; k < j; k++ {
+				emit(s[k:k+1], k)
+			}
+			i = j
+		case c == ',':
+			if k := skipSpace(s, i+1); k < len(s) && (s[k] == '}' || s[k] == ']') {
+				i++ // Trailing comma
+				continue
+			}
+			emit(",", i)
+			i++
+		default:
+			emit(s[i:i+1], i)
+			i++
+		}
+	}
+	return out.String(), from, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$'
+}
+
+func skipSpace(s string, i int) int {
+	for i < len(s) && strings.IndexByte(" \t\r\n", s[i]) >= 0 {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,20 @@
+package commands
+
+import "testing"
+
+func TestStrictJSON(t *testing.T) {
+	tests := []struct {
+		relaxed, want string
+	}{
+		{`{"a": 1, "b": [true, null]}`, `{"a": 1, "b": [true, null]}`},
+		{`{name: 'bob', age: {$gt: 21}}`, `{"name": "bob", "age": {"$gt": 21}}`},
+		{`{'it\'s': 'say "hi"'}`, `{"it's": "say \"hi\""}`},
+		{`{address.city: 'Oslo', tags: ['a', 'b',],}`, `{"address.city": "Oslo", "tags": ["a", "b"]}`},
+		{`{"url": "http://x", n: 1e5}`, `{"url": "http://x", "n": 1e5}`},
+	}
+	for _, tt := range tests {
+		if got, err := StrictJSON(tt.relaxed); err != nil || got != tt.want {
+			t.Errorf("%s: got %s (%v), want %s", tt.relaxed, got, err, tt.want)
+		}
+	}
+}
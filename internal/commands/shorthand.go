@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// comparisons are the operators of filter shorthand, two-character ones
+// first so >= is not read as >.
+var comparisons = []struct {
+	op, operator string
+}{
+	{">=", "$gte"},
+	{"<=", "$lte"},
+	{"!=", "$ne"},
+	{"=", ""},
+	{">", "$gt"},
+	{"<", "$lt"},
+}
+
+// ParseShorthand builds a filter from shorthand arguments, all of which must
+// match: name=bob, age>21, age<=65, status!=done, or name=/^bob/i for a
+// regular expression. Values are read like JSON values, so age=21 is a
+// number and active=true a boolean, falling back to a string; dates and
+// constructors such as now-24h or ObjectId("...") work too. Comparisons of
+// the same field are combined, e.g. age>21 age<65.
+func ParseShorthand(args []string) (bson.D, error) {
+	filter := bson.D{}
+	fields := map[string]int{} // Index in filter of each field
+	for _, arg := range args {
+		field, operator, raw, err := splitShorthand(arg)
+		if err != nil {
+			return nil, err
+		}
+		value, err := shorthandValue(raw, operator)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", arg, err)
+		}
+		if operator == "$ne" {
+			if _, regex := value.(primitive.Regex); regex {
+				operator = "$not"
+			}
+		}
+		condition := value
+		if operator != "" {
+			condition = bson.D{{Key: operator, Value: value}}
+		}
+		i, seen := fields[field]
+		if !seen {
+			fields[field] = len(filter)
+			filter = append(filter, bson.E{Key: field, Value: condition})
+			continue
+		}
+		previous, ok := filter[i].Value.(bson.D)
+		if operator == "" || !ok {
+			return nil, fmt.Errorf("%s: %s is already matched exactly", arg, field)
+		}
+		filter[i].Value = append(previous, condition.(bson.D)...)
+	}
+	return filter, nil
+}
+
+// splitShorthand splits age>21 into the field, the operator ("" for
+// equality) and the value as typed.
+func splitShorthand(arg string) (field, operator, value string, err error) {
+	at := strings.IndexAny(arg, "<>=!")
+	if at <= 0 || strings.ContainsAny(arg[:at], " \t{}[]") {
+		return "", "", "", fmt.Errorf("expected a filter such as '{\"age\": 21}' or age>21, got %s", arg)
+	}
+	for _, c := range comparisons {
+		if strings.HasPrefix(arg[at:], c.op) {
+			value := arg[at+len(c.op):]
+			if strings.ContainsAny(value[:min(len(value), 1)], "<>=") {
+				return "", "", "", fmt.Errorf("%s: unknown comparison %s%s", arg, c.op, value[:1])
+			}
+			return arg[:at], c.operator, value, nil
+		}
+	}
+	return "", "", "", fmt.Errorf("%s: expected =, !=, >, >=, < or <= after %s", arg, arg[:at])
+}
+
+// shorthandValue reads the value of a comparison: a regular expression such
+// as /^bob/i for = and !=, or a JSON value, or else the text itself.
+func shorthandValue(raw, operator string) (interface{}, error) {
+	if end := strings.LastIndexByte(raw, '/'); strings.HasPrefix(raw, "/") && end > 0 {
+		flags := raw[end+1:]
+		if strings.Trim(flags, "imsx") != "" {
+			return nil, fmt.Errorf("unknown regular expression flags %q, expected i, m, s or x", flags)
+		}
+		if operator != "" && operator != "$ne" {
+			return nil, fmt.Errorf("regular expressions only go with = and !=")
+		}
+		return primitive.Regex{Pattern: raw[1:end], Options: flags}, nil
+	}
+	strict, err := StrictJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	var wrapper bson.D
+	if strict != "" && bson.UnmarshalExtJSON([]byte(`{"v": `+strict+`}`), false, &wrapper) == nil {
+		return wrapper[0].Value, nil
+	}
+	return raw, nil
+}
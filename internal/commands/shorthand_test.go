@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseShorthand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bson.D
+	}{
+		{[]string{"name=/^bob/i"}, bson.D{{Key: "name", Value: primitive.Regex{Pattern: "^bob", Options: "i"}}}},
+		{[]string{"status=done", "qty>=5"}, bson.D{{Key: "status", Value: "done"}, {Key: "qty", Value: bson.D{{Key: "$gte", Value: int32(5)}}}}},
+		{[]string{"age>21", "age<65"}, bson.D{{Key: "age", Value: bson.D{{Key: "$gt", Value: int32(21)}, {Key: "$lt", Value: int32(65)}}}}},
+		{[]string{"active!=true", "name!=/test/"}, bson.D{
+			{Key: "active", Value: bson.D{{Key: "$ne", Value: true}}},
+			{Key: "name", Value: bson.D{{Key: "$not", Value: primitive.Regex{Pattern: "test"}}}},
+		}},
+		{[]string{"address.city=New York"}, bson.D{{Key: "address.city", Value: "New York"}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseShorthand(tt.args)
+		if err != nil {
+			t.Errorf("%q: %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.args, got, tt.want)
+		}
+	}
+
+	for _, bad := range [][]string{{"name"}, {"age=>3"}, {"name>/bob/"}, {"name=/bob/q"}, {"age=3", "age>2"}} {
+		if _, err := ParseShorthand(bad); err == nil {
+			t.Errorf("%q: no error", bad)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Package config reads mon-go's optional config file.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	SafeModeAuto = "auto"
+	SafeModeOff  = "off"
+
+	GovernorReject = "reject"
+	GovernorWarn   = "warn"
+
+	LinesWrap     = "wrap"
+	LinesTruncate = "truncate"
+)
+
+// Config is read from config.json in the mon-go user config directory. A
+// missing file is not an error; every setting has a usable default.
+type Config struct {
+	// SafeMode decides whether clusters that look like production start in
+	// read-only mode: "auto" (the default) or "off".
+	SafeMode string `json:"safeMode"`
+
+	// MaskFields lists fields that are redacted whenever documents are shown,
+	// the syntax is described in the README.
+	MaskFields []string `json:"maskFields"`
+
+	// Governor limits the cost of queries.
+	Governor Governor `json:"governor"`
+
+	// Log configures mon-go's own log.
+	Log Log `json:"log"`
+
+	// BatchSize is how many documents a cursor fetches per round trip. 0
+	// leaves it to the server.
+	BatchSize int32 `json:"batchSize"`
+
+	// Atlas holds the API key of the atlas commands.
+	Atlas Atlas `json:"atlas"`
+
+	// SlowOps is how long an operation may run before the status bar warns
+	// of it, e.g. "30s". Empty leaves the warning off.
+	SlowOps string `json:"slowOps"`
+
+	// Lines decides what happens to output lines wider than the terminal:
+	// "wrap" (the default) or "truncate", which cuts them off and lets the
+	// output be scrolled sideways.
+	Lines string `json:"lines"`
+
+	// Namespaces restricts which databases and collections are shown and
+	// written to.
+	Namespaces Namespaces `json:"namespaces"`
+
+	// AuditLog is a file every command is appended to, with the number of
+	// documents it wrote. Empty leaves auditing off.
+	AuditLog string `json:"auditLog"`
+
+	// RestoreWorkspace saves the open tabs, their paths and last commands
+	// on exit, and opens them again on the next start without a connection
+	// string or profile.
+	RestoreWorkspace bool `json:"restoreWorkspace"`
+
+	// Prompt is the text before the input line, with variables in braces
+	// such as "{profile}:{db}/{coll} ({count} docs) > ". Empty keeps
+	// "mon-go (<path>) > ".
+	Prompt string `json:"prompt"`
+}
+
+// PromptVariables are the variables Config.Prompt may use.
+var PromptVariables = []string{"profile", "env", "path", "db", "coll", "count", "session"}
+
+var promptVariable = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// Namespaces are rules on databases and collections, for teams sharing
+// profiles. Each entry is a database ("admin") or a namespace
+// ("billing.invoices"); both parts may be globs ("billing.*", "tmp_*"). A
+// database entry covers all of its collections. Empty lists leave
+// everything visible and writable.
+type Namespaces struct {
+	// Show, if not empty, lists the only namespaces shown.
+	Show []string `json:"show"`
+
+	// Hide lists namespaces that are not shown, and cannot be entered.
+	Hide []string `json:"hide"`
+
+	// Writable, if not empty, lists the only namespaces that may be
+	// written to.
+	Writable []string `json:"writable"`
+
+	// ReadOnly lists namespaces writes to are refused.
+	ReadOnly []string `json:"readOnly"`
+}
+
+// Governor protects shared clusters from accidental heavy queries. A zero
+// value leaves queries alone.
+type Governor struct {
+	// MaxTimeMS is set as maxTimeMS on queries, so the server aborts them.
+	MaxTimeMS int64 `json:"maxTimeMS"`
+
+	// MaxScannedDocs is the number of documents a query may be predicted to
+	// scan before Action applies.
+	MaxScannedDocs int64 `json:"maxScannedDocs"`
+
+	// Action is "reject" (the default) or "warn".
+	Action string `json:"action"`
+}
+
+// Log configures mon-go's own log, meant to be attached to bug reports.
+// Logging is off unless a level is set.
+type Log struct {
+	// Level is "debug", "info", "warn" or "error".
+	Level string `json:"level"`
+
+	// File is where the log is appended, mon-go.log in the user cache
+	// directory by default.
+	File string `json:"file"`
+}
+
+// Atlas configures access to the Atlas Administration API with a
+// programmatic API key. The atlas commands are unavailable without one.
+type Atlas struct {
+	PublicKey  string `json:"publicKey"`
+	PrivateKey string `json:"privateKey"`
+
+	// Project is the name or ID of the project used when a command names
+	// none.
+	Project string `json:"project"`
+
+	// BaseURL is the API to call, for Atlas for Government; the commercial
+	// Atlas API by default.
+	BaseURL string `json:"baseURL"`
+}
+
+// LogLevels maps the names accepted for Log.Level to slog levels.
+var LogLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// Default returns the settings used when there is no config file.
+func Default() Config {
+	return Config{SafeMode: SafeModeAuto, Lines: LinesWrap}
+}
+
+// Path returns the location of the config file.
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mon-go", "config.json"), nil
+}
+
+// Load reads the config file, falling back to defaults for anything it does
+// not set.
+func Load() (Config, error) {
+	cfg := Default()
+
+	path, err := Path()
+	if err != nil {
+		return cfg, nil // No config directory, nothing to load
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+
+	switch cfg.SafeMode {
+	case "":
+		cfg.SafeMode = SafeModeAuto
+	case SafeModeAuto, SafeModeOff:
+	default:
+		return cfg, fmt.Errorf("%s: safeMode must be %q or %q", path, SafeModeAuto, SafeModeOff)
+	}
+	switch cfg.Governor.Action {
+	case "":
+		cfg.Governor.Action = GovernorReject
+	case GovernorReject, GovernorWarn:
+	default:
+		return cfg, fmt.Errorf("%s: governor.action must be %q or %q", path, GovernorReject, GovernorWarn)
+	}
+	switch cfg.Lines {
+	case "":
+		cfg.Lines = LinesWrap
+	case LinesWrap, LinesTruncate:
+	default:
+		return cfg, fmt.Errorf("%s: lines must be %q or %q", path, LinesWrap, LinesTruncate)
+	}
+	if cfg.BatchSize < 0 {
+		return cfg, fmt.Errorf("%s: batchSize must not be negative", path)
+	}
+	if (cfg.Atlas.PublicKey == "") != (cfg.Atlas.PrivateKey == "") {
+		return cfg, fmt.Errorf("%s: atlas needs both publicKey and privateKey", path)
+	}
+	if d, err := time.ParseDuration(cfg.SlowOps); cfg.SlowOps != "" && (err != nil || d < time.Second) {
+		return cfg, fmt.Errorf("%s: slowOps must be a duration of at least 1s such as \"30s\"", path)
+	}
+	for _, match := range promptVariable.FindAllStringSubmatch(cfg.Prompt, -1) {
+		if !slices.Contains(PromptVariables, match[1]) {
+			return cfg, fmt.Errorf("%s: prompt: unknown variable {%s}, use {%s}", path, match[1], strings.Join(PromptVariables, "}, {"))
+		}
+	}
+	for _, rules := range [][]string{cfg.Namespaces.Show, cfg.Namespaces.Hide, cfg.Namespaces.Writable, cfg.Namespaces.ReadOnly} {
+		for _, rule := range rules {
+			db, coll, _ := strings.Cut(rule, ".")
+			if _, err := filepath.Match(db, ""); err != nil || db == "" {
+				return cfg, fmt.Errorf("%s: namespaces: invalid rule %q", path, rule)
+			}
+			if _, err := filepath.Match(coll, ""); err != nil {
+				return cfg, fmt.Errorf("%s: namespaces: invalid rule %q", path, rule)
+			}
+		}
+	}
+	if _, ok := LogLevels[cfg.Log.Level]; cfg.Log.Level != "" && !ok {
+		return cfg, fmt.Errorf("%s: log.level must be debug, info, warn or error", path)
+	}
+	return cfg, nil
+}
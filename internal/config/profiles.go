@@ -0,0 +1,88 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Environments a profile can be tagged with.
+const (
+	EnvDev     = "dev"
+	EnvStaging = "staging"
+	EnvProd    = "prod"
+)
+
+// Profile is a saved connection, used by starting mon-go with its name
+// instead of a connection string.
+type Profile struct {
+	URI      string `json:"uri"`
+	ReadOnly bool   `json:"readOnly,omitempty"` // Refuse writes in sessions opened with the profile
+	Env      string `json:"env,omitempty"`      // dev, staging or prod; colors the prompt, and prod confirms writes
+
+	// RememberPath goes back to the path a session with the profile was
+	// last at when connecting with it again.
+	RememberPath bool `json:"rememberPath,omitempty"`
+}
+
+// profilesPath returns the file profiles are kept in, next to the config
+// file.
+func profilesPath() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "profiles.json"), nil
+}
+
+// LoadProfiles reads the saved profiles by name. A missing file means there
+// are none.
+func LoadProfiles() (map[string]Profile, error) {
+	profiles := map[string]Profile{}
+	path, err := profilesPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for name, profile := range profiles {
+		switch profile.Env {
+		case "", EnvDev, EnvStaging, EnvProd:
+		default:
+			return nil, fmt.Errorf("%s: profile %s: env must be %q, %q or %q", path, name, EnvDev, EnvStaging, EnvProd)
+		}
+	}
+	return profiles, nil
+}
+
+// SaveProfile adds or replaces the profile called name. The file is only
+// readable by the user, since connection strings may hold passwords.
+func SaveProfile(name string, profile Profile) error {
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return err
+	}
+	profiles[name] = profile
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	path, err := profilesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State is what mon-go remembers between sessions, as opposed to settings
+// the user edits.
+type State struct {
+	SeenVersion string              `json:"seenVersion"`       // Version whose release notes were shown
+	Columns     map[string][]string `json:"columns,omitempty"` // Table columns chosen with the columns command, by namespace
+	Workspace   *Workspace          `json:"workspace,omitempty"`
+	LastPaths   map[string]string   `json:"lastPaths,omitempty"` // Path each profile with rememberPath was last at, by profile
+}
+
+// Workspace is the tabs open when mon-go last quit, kept when
+// RestoreWorkspace is on.
+type Workspace struct {
+	SavedAt time.Time      `json:"savedAt"`
+	Active  int            `json:"active"` // Index of the tab shown
+	Tabs    []WorkspaceTab `json:"tabs"`
+}
+
+// WorkspaceTab is what a tab was connected to, where it was, and the last
+// command it ran.
+type WorkspaceTab struct {
+	Profile     string `json:"profile,omitempty"`
+	URI         string `json:"uri,omitempty"` // Connection string of a tab not opened with a profile, without credentials
+	Path        string `json:"path"`          // e.g. /shop/orders
+	LastCommand string `json:"lastCommand,omitempty"`
+	LastResult  string `json:"lastResult,omitempty"` // Summary of its result, e.g. 20 documents
+}
+
+// statePath returns the file the state is kept in, next to the config file.
+func statePath() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "state.json"), nil
+}
+
+// LoadState reads the state. A missing file means a first start.
+func LoadState() (State, error) {
+	var state State
+	path, err := statePath()
+	if err != nil {
+		return state, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("%s: %w", path, err)
+	}
+	return state, nil
+}
+
+// SaveState writes the state.
+func SaveState(state State) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,562 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Fake is an in-memory Store for tests. Filters support equality, including
+// on dotted paths, array indexes and array elements, $eq, $ne, $gt, $gte,
+// $lt, $lte, $in, $nin, $exists, $type, $and and $or; Find supports sort,
+// skip and limit.
+// Writes are described in fake_writes.go, aggregations in
+// fake_aggregate.go, server commands in fake_commands.go.
+type Fake struct {
+	mu            sync.Mutex
+	dbs           map[string]map[string][]bson.D
+	namespaces    map[string]*fakeNamespace // By db.coll
+	params        []fakeParam               // By name
+	clusterParams []bson.D
+	activity      *fakeActivity
+}
+
+func NewFake() *Fake {
+	return &Fake{
+		dbs:           map[string]map[string][]bson.D{},
+		namespaces:    map[string]*fakeNamespace{},
+		params:        defaultParams(),
+		clusterParams: defaultClusterParams(),
+		activity:      newFakeActivity(),
+	}
+}
+
+// Seed adds docs to db.coll, creating the collection even if docs is empty.
+// Documents without an _id get an ObjectID.
+func (f *Fake) Seed(db, coll string, docs ...bson.D) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.add(db, coll, docs...)
+}
+
+// add is Seed with f.mu held.
+func (f *Fake) add(db, coll string, docs ...bson.D) {
+	if f.dbs[db] == nil {
+		f.dbs[db] = map[string][]bson.D{}
+	}
+	if f.dbs[db][coll] == nil {
+		f.dbs[db][coll] = []bson.D{}
+	}
+	for _, doc := range docs {
+		f.dbs[db][coll] = append(f.dbs[db][coll], withID(doc))
+	}
+}
+
+func withID(doc bson.D) bson.D {
+	for _, e := range doc {
+		if e.Key == "_id" {
+			return doc
+		}
+	}
+	return append(bson.D{{Key: "_id", Value: primitive.NewObjectID()}}, doc...)
+}
+
+func (f *Fake) ListDatabaseNames(ctx context.Context) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]string, 0, len(f.dbs))
+	for name := range f.dbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (f *Fake) ListNamespaces(ctx context.Context, db string) ([]Namespace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var namespaces []Namespace
+	for _, name := range f.collectionNames(db) {
+		namespaces = append(namespaces, Namespace{Name: name, Kind: f.namespace(db, name).kind})
+	}
+	return namespaces, nil
+}
+
+func (f *Fake) Find(ctx context.Context, db, coll string, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+	defer f.activity.op(db+"."+coll, "reads", time.Now())
+	f.activity.count("opcounters.query", 1)
+	docs, err := f.matching(db, coll, filter)
+	if err != nil {
+		return nil, err
+	}
+	o := options.MergeFindOptions(opts...)
+	if err := sortDocs(docs, o.Sort); err != nil {
+		return nil, err
+	}
+	if o.Skip != nil {
+		docs = docs[min(int(*o.Skip), len(docs)):]
+	}
+	if o.Limit != nil && *o.Limit > 0 && int(*o.Limit) < len(docs) {
+		docs = docs[:*o.Limit]
+	}
+	if o.Projection != nil { // Only for the text score it asks for
+		var projection bson.D
+		if err := convert(o.Projection, &projection); err != nil {
+			return nil, fmt.Errorf("fake: invalid projection: %w", err)
+		}
+		for _, p := range projection {
+			if !isTextScore(p.Value) {
+				continue
+			}
+			for i := range docs {
+				docs[i].doc = append(docs[i].doc[:len(docs[i].doc):len(docs[i].doc)], bson.E{Key: p.Key, Value: docs[i].score})
+			}
+		}
+	}
+	f.activity.count("metrics.document.returned", int64(len(docs)))
+	return &fakeCursor{docs: docs, pos: -1}, nil
+}
+
+// newFakeCursor returns a cursor over docs.
+func newFakeCursor(docs []bson.D) *fakeCursor {
+	c := &fakeCursor{pos: -1}
+	for i, doc := range docs {
+		c.docs = append(c.docs, storedDoc{doc: doc, index: i})
+	}
+	return c
+}
+
+func (f *Fake) FindOne(ctx context.Context, db, coll string, filter interface{}) (bson.M, error) {
+	defer f.activity.op(db+"."+coll, "reads", time.Now())
+	f.activity.count("opcounters.query", 1)
+	docs, err := f.matching(db, coll, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrNoDocuments
+	}
+	f.activity.count("metrics.document.returned", 1)
+	return docs[0].fields, nil
+}
+
+func (f *Fake) CountDocuments(ctx context.Context, db, coll string, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	defer f.activity.op(db+"."+coll, "commands", time.Now())
+	f.activity.count("opcounters.command", 1)
+	docs, err := f.matching(db, coll, filter)
+	return int64(len(docs)), err
+}
+
+// InsertOne inserts doc, refusing an _id that is already taken like the
+// unique index on _id would.
+func (f *Fake) InsertOne(ctx context.Context, db, coll string, doc interface{}) (interface{}, error) {
+	var d bson.D
+	if err := convert(doc, &d); err != nil {
+		return nil, err
+	}
+	d = withID(d)
+	id := idOf(d)
+	defer f.activity.op(db+"."+coll, "writes", time.Now())
+	f.activity.count("opcounters.insert", 1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.writable(db, coll); err != nil {
+		return nil, err
+	}
+	for _, other := range f.dbs[db][coll] {
+		if sameValue(idOf(other), id) {
+			return nil, duplicateKey(db, coll, id)
+		}
+	}
+	f.add(db, coll, d)
+	f.activity.count("metrics.document.inserted", 1)
+	return id, nil
+}
+
+func (f *Fake) InsertMany(ctx context.Context, db, coll string, docs []interface{}) ([]interface{}, error) {
+	ids := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		id, err := f.InsertOne(ctx, db, coll, doc)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// storedDoc is a stored document, in its field order and as a map for
+// matching, with its position in the collection.
+type storedDoc struct {
+	doc    bson.D
+	fields bson.M
+	index  int
+	score  float64 // Of a $text query
+}
+
+// matching returns the documents of db.coll matching filter.
+func (f *Fake) matching(db, coll string, filter interface{}) ([]storedDoc, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.match(db, coll, filter)
+}
+
+// match is matching with f.mu held.
+func (f *Fake) match(db, coll string, filter interface{}) ([]storedDoc, error) {
+	var query bson.D
+	if filter != nil {
+		if err := convert(filter, &query); err != nil {
+			return nil, fmt.Errorf("fake: invalid filter: %w", err)
+		}
+	}
+	var search string
+	var weights bson.D
+	for i, e := range query {
+		if e.Key != "$text" {
+			continue
+		}
+		spec, _ := e.Value.(bson.D)
+		search, _ = field(spec, "$search").(string)
+		var err error
+		if weights, err = f.textWeights(db, coll); err != nil {
+			return nil, err
+		}
+		query = append(query[:i:i], query[i+1:]...)
+		break
+	}
+	all, err := f.documents(db, coll)
+	if err != nil {
+		return nil, err
+	}
+	var docs []storedDoc
+	for i, d := range all {
+		var fields bson.M
+		if err := convert(d, &fields); err != nil {
+			return nil, err
+		}
+		ok, err := matches(fields, query)
+		if err != nil {
+			return nil, err
+		}
+		var score float64
+		if weights != nil {
+			score = textScore(fields, weights, search)
+			ok = ok && score > 0
+		}
+		if ok {
+			docs = append(docs, storedDoc{doc: d, fields: fields, index: i, score: score})
+		}
+	}
+	f.plannedAccess(db, coll, query, len(all), len(docs))
+	return docs, nil
+}
+
+// sortDocs sorts docs by spec, a sort document such as {"n": -1}, if any.
+func sortDocs(docs []storedDoc, spec interface{}) error {
+	if spec == nil {
+		return nil
+	}
+	var order bson.D
+	if err := convert(spec, &order); err != nil {
+		return fmt.Errorf("fake: invalid sort: %w", err)
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, key := range order {
+			if isTextScore(key.Value) {
+				if docs[i].score != docs[j].score {
+					return docs[i].score > docs[j].score
+				}
+				continue
+			}
+			c := compare(lookup(docs[i].fields, key.Key), lookup(docs[j].fields, key.Key))
+			if direction, _ := number(key.Value); direction < 0 { // -1 may come as an int32, int64 or double
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	return nil
+}
+
+// convert round-trips v through BSON into out, as the server would see it.
+func convert(v interface{}, out interface{}) error {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, out)
+}
+
+func matches(doc bson.M, query bson.D) (bool, error) {
+	for _, e := range query {
+		switch e.Key {
+		case "$and", "$or":
+			clauses, ok := e.Value.(bson.A)
+			if !ok {
+				return false, fmt.Errorf("fake: %s needs an array", e.Key)
+			}
+			matchedAny := false
+			for _, clause := range clauses {
+				var sub bson.D
+				if err := convert(clause, &sub); err != nil {
+					return false, err
+				}
+				ok, err := matches(doc, sub)
+				if err != nil {
+					return false, err
+				}
+				if e.Key == "$and" && !ok {
+					return false, nil
+				}
+				matchedAny = matchedAny || ok
+			}
+			if e.Key == "$or" && !matchedAny {
+				return false, nil
+			}
+		default:
+			ok, err := matchesField(lookup(doc, e.Key), e.Value)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+func matchesField(value, cond interface{}) (bool, error) {
+	ops, ok := cond.(bson.D)
+	if !ok || len(ops) == 0 || !strings.HasPrefix(ops[0].Key, "$") {
+		return equals(value, cond), nil
+	}
+	for _, op := range ops {
+		var ok bool
+		switch op.Key {
+		case "$eq":
+			ok = equals(value, op.Value)
+		case "$ne":
+			ok = !equals(value, op.Value)
+		case "$gt":
+			ok = value != nil && compare(value, op.Value) > 0
+		case "$gte":
+			ok = value != nil && compare(value, op.Value) >= 0
+		case "$lt":
+			ok = value != nil && compare(value, op.Value) < 0
+		case "$lte":
+			ok = value != nil && compare(value, op.Value) <= 0
+		case "$in", "$nin":
+			candidates, _ := op.Value.(bson.A)
+			for _, c := range candidates {
+				ok = ok || equals(value, c)
+			}
+			if op.Key == "$nin" {
+				ok = !ok
+			}
+		case "$exists":
+			want, _ := op.Value.(bool)
+			ok = (value != nil) == want
+		case "$type":
+			ok = hasType(value, op.Value)
+		default:
+			return false, fmt.Errorf("fake: unsupported operator %s", op.Key)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// hasType reports whether value is of the BSON type named by want, such as
+// "string" or the alias "number", or of one in an array of them.
+func hasType(value, want interface{}) bool {
+	if names, ok := want.(bson.A); ok {
+		for _, name := range names {
+			if hasType(value, name) {
+				return true
+			}
+		}
+		return false
+	}
+	var name string
+	switch value.(type) {
+	case nil:
+		name = "null"
+	case float64:
+		name = "double"
+	case string:
+		name = "string"
+	case bson.M, bson.D:
+		name = "object"
+	case bson.A:
+		name = "array"
+	case primitive.ObjectID:
+		name = "objectId"
+	case bool:
+		name = "bool"
+	case primitive.DateTime, time.Time:
+		name = "date"
+	case int32:
+		name = "int"
+	case int64:
+		name = "long"
+	case primitive.Decimal128:
+		name = "decimal"
+	}
+	switch want {
+	case name:
+		return true
+	case "number":
+		_, ok := number(value)
+		return ok
+	}
+	return false
+}
+
+// equals compares like the server: an array field matches a value equal to
+// any of its elements.
+func equals(value, want interface{}) bool {
+	if arr, ok := value.(bson.A); ok {
+		if _, wantArr := want.(bson.A); !wantArr {
+			for _, elem := range arr {
+				if compare(elem, want) == 0 {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return compare(value, want) == 0
+}
+
+// lookup returns the value at a dotted path of doc, nil if it is missing.
+func lookup(doc bson.M, path string) interface{} {
+	var v interface{} = doc
+	for _, key := range strings.Split(path, ".") {
+		switch container := v.(type) {
+		case bson.M:
+			v = container[key]
+		case bson.A:
+			i, err := strconv.Atoi(key) // Like items.0, the first element
+			if err != nil || i < 0 || i >= len(container) {
+				return nil
+			}
+			v = container[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// compare orders values of the same kind, after missing ones as on the
+// server; values of different kinds are ordered by their formatted text,
+// which is enough for tests.
+func compare(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	switch x := a.(type) {
+	case primitive.DateTime:
+		if y, ok := b.(primitive.DateTime); ok {
+			return compare(int64(x), int64(y))
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+	if reflect.DeepEqual(a, b) {
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// fakeCursor iterates over the documents of a Find on a Fake.
+type fakeCursor struct {
+	docs []storedDoc
+	pos  int
+}
+
+func (c *fakeCursor) Next(ctx context.Context) bool {
+	if c.pos+1 >= len(c.docs) {
+		c.pos = len(c.docs)
+		return false
+	}
+	c.pos++
+	return true
+}
+
+func (c *fakeCursor) Decode(v interface{}) error {
+	if c.pos < 0 || c.pos >= len(c.docs) {
+		return fmt.Errorf("fake: Decode called without a current document")
+	}
+	return convert(c.docs[c.pos].doc, v)
+}
+
+func (c *fakeCursor) All(ctx context.Context, results interface{}) error {
+	slice := reflect.ValueOf(results)
+	if slice.Kind() != reflect.Pointer || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("fake: All needs a pointer to a slice")
+	}
+	out := slice.Elem()
+	out.Set(out.Slice(0, 0)) // Like the driver, All replaces what the slice held
+	for c.Next(ctx) {
+		elem := reflect.New(out.Type().Elem())
+		if err := c.Decode(elem.Interface()); err != nil {
+			return err
+		}
+		out.Set(reflect.Append(out, elem.Elem()))
+	}
+	return nil
+}
+
+func (c *fakeCursor) Err() error {
+	return nil
+}
+
+func (c *fakeCursor) Close(ctx context.Context) error {
+	c.pos = len(c.docs)
+	return nil
+}
@@ -0,0 +1,244 @@
+package mongo
+
+import (
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// What the Fake counts of the operations run on it, for serverStatus, the
+// latencyStats of $collStats and $indexStats. Reads are finds and
+// aggregations, writes inserts, updates and deletes, and commands counts;
+// their latencies are the Fake's own, in microseconds. The Fake's own
+// operations finish before they could be seen, so currentOp reports those
+// tests start with StartOp, until killOp kills them.
+
+// fakeActivity has its own lock, so it can be updated with f.mu held or
+// not.
+type fakeActivity struct {
+	mu       sync.Mutex
+	counters map[string]int64                   // By serverStatus path, e.g. opcounters.query
+	latency  map[string]map[string]*fakeLatency // By namespace, then reads, writes or commands
+	accesses map[string]int64                   // By namespace and index name, db.coll/name
+	started  time.Time
+	inprog   []bson.D // As currentOp reports them
+	nextOpID int32
+}
+
+// fakeLatency is the latencyStats of one kind of operation.
+type fakeLatency struct {
+	ops, micros int64
+	histogram   map[int64]int64 // Counts by lower bound in microseconds
+}
+
+func newFakeActivity() *fakeActivity {
+	return &fakeActivity{
+		counters: map[string]int64{},
+		latency:  map[string]map[string]*fakeLatency{},
+		accesses: map[string]int64{},
+		started:  time.Now(),
+	}
+}
+
+// count adds n to the serverStatus counter at path.
+func (a *fakeActivity) count(path string, n int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.counters[path] += n
+}
+
+// op records an operation of kind on ns that began at started.
+func (a *fakeActivity) op(ns, kind string, started time.Time) {
+	micros := time.Since(started).Microseconds()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	kinds := a.latency[ns]
+	if kinds == nil {
+		kinds = map[string]*fakeLatency{}
+		a.latency[ns] = kinds
+	}
+	l := kinds[kind]
+	if l == nil {
+		l = &fakeLatency{histogram: map[int64]int64{}}
+		kinds[kind] = l
+	}
+	l.ops++
+	l.micros += micros
+	bucket := int64(0) // The server's buckets are powers of two at first
+	for b := int64(1); b <= micros; b *= 2 {
+		bucket = b
+	}
+	l.histogram[bucket]++
+}
+
+// access records a use of index name of ns.
+func (a *fakeActivity) access(ns, name string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.accesses[ns+"/"+name]++
+}
+
+// serverStatus answers {serverStatus: 1} with the counters.
+func (a *fakeActivity) serverStatus() (bson.D, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	status := bson.D{{Key: "uptime", Value: time.Since(a.started).Seconds()}}
+	for _, path := range []string{
+		"opcounters.insert", "opcounters.query", "opcounters.update", "opcounters.delete", "opcounters.getmore", "opcounters.command",
+		"metrics.document.returned", "metrics.document.inserted", "metrics.document.updated", "metrics.document.deleted",
+		"metrics.queryExecutor.scanned", "metrics.queryExecutor.scannedObjects",
+	} {
+		var err error
+		if status, err = setPath(status, path, a.counters[path]); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// latencyStats is the latencyStats of $collStats for ns.
+func (a *fakeActivity) latencyStats(ns string, histograms bool) bson.D {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	stats := bson.D{}
+	for _, kind := range []string{"reads", "writes", "commands", "transactions"} {
+		l := a.latency[ns][kind]
+		if l == nil {
+			l = &fakeLatency{}
+		}
+		doc := bson.D{{Key: "latency", Value: l.micros}, {Key: "ops", Value: l.ops}}
+		if histograms {
+			buckets := bson.A{}
+			for micros, count := range l.histogram {
+				buckets = append(buckets, bson.D{{Key: "micros", Value: micros}, {Key: "count", Value: count}})
+			}
+			doc = append(doc, bson.E{Key: "histogram", Value: buckets})
+		}
+		stats = append(stats, bson.E{Key: kind, Value: doc})
+	}
+	return stats
+}
+
+// collStatsStage is the document of a $collStats first stage on db.coll.
+func (f *Fake) collStatsStage(db, coll string, spec interface{}) (bson.D, error) {
+	ns, err := f.existing(db, coll)
+	if err != nil {
+		return nil, err
+	}
+	options, _ := spec.(bson.D)
+	doc := bson.D{{Key: "ns", Value: db + "." + coll}, {Key: "host", Value: "fake"}, {Key: "localTime", Value: time.Now()}}
+	for _, o := range options {
+		switch o.Key {
+		case "latencyStats":
+			l, _ := o.Value.(bson.D)
+			histograms, _ := field(l, "histograms").(bool)
+			doc = append(doc, bson.E{Key: "latencyStats", Value: f.activity.latencyStats(db+"."+coll, histograms)})
+		case "storageStats":
+			if ns.kind == "view" {
+				return nil, commandError(166, "CommandNotSupportedOnView", "Namespace %s.%s is a view, not a collection", db, coll)
+			}
+			stats, err := f.collStats(db, bson.D{{Key: "collStats", Value: coll}})
+			if err != nil {
+				return nil, err
+			}
+			doc = append(doc, bson.E{Key: "storageStats", Value: stats})
+		case "count":
+			doc = append(doc, bson.E{Key: "count", Value: int64(len(f.dbs[db][coll]))})
+		default:
+			return nil, commandError(40415, "Location40415", "BSON field '$collStats.%s' is an unknown field.", o.Key)
+		}
+	}
+	return doc, nil
+}
+
+// indexStats is the output of $indexStats on db.coll.
+func (f *Fake) indexStats(db, coll string, ns *fakeNamespace) []bson.D {
+	f.activity.mu.Lock()
+	defer f.activity.mu.Unlock()
+	var docs []bson.D
+	for _, ix := range ns.indexes {
+		name, _ := field(ix, "name").(string)
+		docs = append(docs, bson.D{
+			{Key: "name", Value: name},
+			{Key: "key", Value: field(ix, "key")},
+			{Key: "host", Value: "fake"},
+			{Key: "accesses", Value: bson.D{
+				{Key: "ops", Value: f.activity.accesses[db+"."+coll+"/"+name]},
+				{Key: "since", Value: f.activity.started},
+			}},
+			{Key: "spec", Value: ix},
+		})
+	}
+	return docs
+}
+
+// plannedAccess records the scan a query with query on db.coll makes: of
+// the index plannedIndex picks, or of the whole collection.
+func (f *Fake) plannedAccess(db, coll string, query bson.D, total, matched int) {
+	if index := f.plannedIndex(db, coll, query); index != "" {
+		f.activity.access(db+"."+coll, index)
+		f.activity.count("metrics.queryExecutor.scanned", int64(matched))
+		f.activity.count("metrics.queryExecutor.scannedObjects", int64(matched))
+		return
+	}
+	f.activity.count("metrics.queryExecutor.scannedObjects", int64(total))
+}
+
+// StartOp adds op, an inprog document of currentOp such as {op: "query",
+// ns: "shop.orders", active: true, microsecs_running: 5000000}, to the
+// operations in progress, and returns the opid it is given.
+func (f *Fake) StartOp(op bson.D) int32 {
+	a := f.activity
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nextOpID++
+	a.inprog = append(a.inprog, append(bson.D{{Key: "opid", Value: a.nextOpID}}, op...))
+	return a.nextOpID
+}
+
+// currentOp answers currentOp with the operations in progress matching the
+// filter fields of command. Every operation counts as the session's own.
+func (a *fakeActivity) currentOp(command bson.D) (bson.D, error) {
+	var filter bson.D
+	for _, e := range command[1:] {
+		if e.Key != "$ownOps" && e.Key != "$all" {
+			filter = append(filter, e)
+		}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	inprog := bson.A{}
+	for _, op := range a.inprog {
+		var fields bson.M
+		if err := convert(op, &fields); err != nil {
+			return nil, err
+		}
+		ok, err := matches(fields, filter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			inprog = append(inprog, op)
+		}
+	}
+	return bson.D{{Key: "inprog", Value: inprog}}, nil
+}
+
+// killOp answers killOp, removing the operation with the opid of command.
+// Like the server, it succeeds whether or not there is one.
+func (a *fakeActivity) killOp(command bson.D) (bson.D, error) {
+	opID := field(command, "op")
+	if opID == nil {
+		return nil, commandError(4, "NoSuchKey", "Did not provide \"op\" field")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i, op := range a.inprog {
+		if equals(field(op, "opid"), opID) {
+			a.inprog = append(a.inprog[:i:i], a.inprog[i+1:]...)
+			break
+		}
+	}
+	return bson.D{{Key: "info", Value: "attempting to kill op"}}, nil
+}
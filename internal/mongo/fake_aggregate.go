@@ -0,0 +1,735 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Aggregation in the Fake supports the stages mon-go builds: $match, $project,
+// $addFields and $set, $unset, $replaceRoot and $replaceWith, $sort, $skip,
+// $limit, $count, $group, $unwind, $sample, $facet and $sortByCount, and as
+// first stages $collStats and $indexStats. $group has $sum, $avg, $min,
+// $max, $first, $last, $push, $addToSet, $stdDevPop, $percentile and
+// $count. Expressions are field paths, literals, documents and arrays of
+// them, and $literal, $add, $subtract, $multiply, $divide, $concat, $ifNull
+// and $size. $search and $vectorSearch fail as on a server outside Atlas;
+// other stages fail as unsupported.
+
+func (f *Fake) Aggregate(ctx context.Context, db, coll string, pipeline interface{}, opts ...*options.AggregateOptions) (Cursor, error) {
+	stages, err := pipelineStages(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	f.activity.count("opcounters.command", 1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(stages) > 0 {
+		switch stages[0][0].Key {
+		case "$collStats":
+			first, err := f.collStatsStage(db, coll, stages[0][0].Value)
+			if err != nil {
+				return nil, err
+			}
+			docs, err := f.runStages(db, []bson.D{first}, stages[1:])
+			return newFakeCursor(docs), err
+		case "$indexStats":
+			ns, err := f.existing(db, coll)
+			if err != nil {
+				return nil, err
+			}
+			docs, err := f.runStages(db, f.indexStats(db, coll, ns), stages[1:])
+			return newFakeCursor(docs), err
+		}
+	}
+	defer f.activity.op(db+"."+coll, "reads", time.Now())
+	docs, err := f.documents(db, coll)
+	if err != nil {
+		return nil, err
+	}
+	if docs, err = f.runStages(db, docs, stages); err != nil {
+		return nil, err
+	}
+	f.activity.count("metrics.document.returned", int64(len(docs)))
+	return newFakeCursor(docs), nil
+}
+
+// pipelineStages converts pipeline, a slice of stage documents, to bson.D
+// stages of one field each.
+func pipelineStages(pipeline interface{}) ([]bson.D, error) {
+	var wrapped struct {
+		Stages []bson.D `bson:"stages"`
+	}
+	if err := convert(bson.D{{Key: "stages", Value: pipeline}}, &wrapped); err != nil {
+		return nil, fmt.Errorf("fake: invalid pipeline: %w", err)
+	}
+	for _, stage := range wrapped.Stages {
+		if len(stage) != 1 {
+			return nil, commandError(40323, "Location40323", "A pipeline stage specification object must contain exactly one field.")
+		}
+	}
+	return wrapped.Stages, nil
+}
+
+// documents returns the documents of db.coll, those its pipeline makes of
+// its source for a view, with f.mu held.
+func (f *Fake) documents(db, coll string) ([]bson.D, error) {
+	ns := f.namespace(db, coll)
+	if ns == nil || ns.kind != "view" {
+		return f.dbs[db][coll], nil
+	}
+	source, _ := field(ns.options, "viewOn").(string)
+	docs, err := f.documents(db, source)
+	if err != nil {
+		return nil, err
+	}
+	stages, err := pipelineStages(field(ns.options, "pipeline"))
+	if err != nil {
+		return nil, err
+	}
+	return f.runStages(db, docs, stages)
+}
+
+// runStages runs stages on docs, with f.mu held.
+func (f *Fake) runStages(db string, docs []bson.D, stages []bson.D) ([]bson.D, error) {
+	for _, stage := range stages {
+		var err error
+		if docs, err = f.runStage(db, docs, stage[0]); err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+func (f *Fake) runStage(db string, docs []bson.D, stage bson.E) ([]bson.D, error) {
+	switch stage.Key {
+	case "$match":
+		var query bson.D
+		if err := convert(stage.Value, &query); err != nil {
+			return nil, fmt.Errorf("fake: invalid $match: %w", err)
+		}
+		var out []bson.D
+		for _, d := range docs {
+			var fields bson.M
+			if err := convert(d, &fields); err != nil {
+				return nil, err
+			}
+			ok, err := matches(fields, query)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				out = append(out, d)
+			}
+		}
+		return out, nil
+	case "$project":
+		spec, ok := stage.Value.(bson.D)
+		if !ok {
+			return nil, commandError(15969, "Location15969", "$project specification must be an object")
+		}
+		return eachDocument(docs, func(d bson.D) (bson.D, error) { return projectStage(d, spec) })
+	case "$addFields", "$set":
+		spec, ok := stage.Value.(bson.D)
+		if !ok {
+			return nil, commandError(40272, "Location40272", "%s specification stage must be an object", stage.Key)
+		}
+		return eachDocument(docs, func(d bson.D) (bson.D, error) {
+			out := copyDocument(d)
+			for _, e := range spec {
+				v, err := eval(d, e.Value)
+				if err != nil {
+					return nil, err
+				}
+				if out, err = setPath(out, e.Key, v); err != nil {
+					return nil, err
+				}
+			}
+			return out, nil
+		})
+	case "$unset":
+		var paths []string
+		switch v := stage.Value.(type) {
+		case string:
+			paths = []string{v}
+		case bson.A:
+			for _, p := range v {
+				s, _ := p.(string)
+				paths = append(paths, s)
+			}
+		}
+		return eachDocument(docs, func(d bson.D) (bson.D, error) {
+			out := copyDocument(d)
+			for _, p := range paths {
+				out = unsetPath(out, p)
+			}
+			return out, nil
+		})
+	case "$replaceRoot", "$replaceWith":
+		expr := stage.Value
+		if stage.Key == "$replaceRoot" {
+			spec, _ := stage.Value.(bson.D)
+			expr = field(spec, "newRoot")
+		}
+		return eachDocument(docs, func(d bson.D) (bson.D, error) {
+			v, err := eval(d, expr)
+			if err != nil {
+				return nil, err
+			}
+			root, ok := v.(bson.D)
+			if !ok {
+				return nil, commandError(40228, "Location40228", "'newRoot' expression must evaluate to an object, but resulting value was: %v", v)
+			}
+			return root, nil
+		})
+	case "$sort":
+		sorted, err := storedDocs(docs)
+		if err != nil {
+			return nil, err
+		}
+		if err := sortDocs(sorted, stage.Value); err != nil {
+			return nil, err
+		}
+		return unstored(sorted), nil
+	case "$skip", "$limit":
+		n, ok := number(stage.Value)
+		if !ok || n < 0 || (stage.Key == "$limit" && n == 0) {
+			return nil, commandError(15958, "Location15958", "the %s must be a positive number", stage.Key)
+		}
+		if stage.Key == "$skip" {
+			return docs[min(int(n), len(docs)):], nil
+		}
+		return docs[:min(int(n), len(docs))], nil
+	case "$count":
+		name, _ := stage.Value.(string)
+		if name == "" || strings.HasPrefix(name, "$") || strings.Contains(name, ".") {
+			return nil, commandError(40156, "Location40156", "the count field must be a non-empty string without '$' or '.'")
+		}
+		if len(docs) == 0 {
+			return nil, nil
+		}
+		return []bson.D{{{Key: name, Value: int32(len(docs))}}}, nil
+	case "$group":
+		spec, ok := stage.Value.(bson.D)
+		if !ok || !hasKey(spec, "_id") {
+			return nil, commandError(15955, "Location15955", "a group specification must include an _id")
+		}
+		return group(docs, spec)
+	case "$sortByCount":
+		grouped, err := group(docs, bson.D{{Key: "_id", Value: stage.Value}, {Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}})
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(grouped, func(i, j int) bool {
+			return compare(field(grouped[i], "count"), field(grouped[j], "count")) > 0
+		})
+		return grouped, nil
+	case "$unwind":
+		return unwind(docs, stage.Value)
+	case "$sample":
+		spec, _ := stage.Value.(bson.D)
+		size, ok := number(field(spec, "size"))
+		if !ok || size < 0 {
+			return nil, commandError(28747, "Location28747", "size argument to $sample must be a non-negative number")
+		}
+		sampled := make([]bson.D, 0, len(docs))
+		for _, i := range rand.Perm(len(docs)) {
+			sampled = append(sampled, docs[i])
+		}
+		return sampled[:min(int(size), len(sampled))], nil
+	case "$facet":
+		spec, _ := stage.Value.(bson.D)
+		out := bson.D{}
+		for _, facet := range spec {
+			stages, err := pipelineStages(facet.Value)
+			if err != nil {
+				return nil, err
+			}
+			results, err := f.runStages(db, docs, stages)
+			if err != nil {
+				return nil, err
+			}
+			arr := bson.A{}
+			for _, r := range results {
+				arr = append(arr, r)
+			}
+			out = append(out, bson.E{Key: facet.Key, Value: arr})
+		}
+		return []bson.D{out}, nil
+	case "$collStats", "$indexStats":
+		return nil, commandError(40602, "Location40602", "%s is only valid as the first stage in a pipeline", stage.Key)
+	case "$search", "$vectorSearch", "$searchMeta":
+		return nil, commandError(6047401, "Location6047401", "%s stage is only allowed on MongoDB Atlas", stage.Key)
+	default:
+		return nil, fmt.Errorf("fake: unsupported stage %s", stage.Key)
+	}
+}
+
+// eachDocument returns what fn makes of each of docs.
+func eachDocument(docs []bson.D, fn func(bson.D) (bson.D, error)) ([]bson.D, error) {
+	out := make([]bson.D, 0, len(docs))
+	for _, d := range docs {
+		v, err := fn(d)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// storedDocs wraps docs for sortDocs.
+func storedDocs(docs []bson.D) ([]storedDoc, error) {
+	out := make([]storedDoc, len(docs))
+	for i, d := range docs {
+		out[i] = storedDoc{doc: d, index: i}
+		if err := convert(d, &out[i].fields); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func unstored(docs []storedDoc) []bson.D {
+	out := make([]bson.D, len(docs))
+	for i, d := range docs {
+		out[i] = d.doc
+	}
+	return out
+}
+
+// copyDocument returns a deep copy of d, which stages may change.
+func copyDocument(d bson.D) bson.D {
+	var out bson.D
+	if convert(d, &out) != nil {
+		return append(bson.D{}, d...)
+	}
+	return out
+}
+
+func hasKey(doc bson.D, key string) bool {
+	for _, e := range doc {
+		if e.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// projectStage applies a $project spec to d: with inclusions or computed
+// fields it keeps only those and _id, with exclusions it removes them.
+func projectStage(d bson.D, spec bson.D) (bson.D, error) {
+	inclusive := false
+	for _, e := range spec {
+		if e.Key == "_id" {
+			continue
+		}
+		if include, isFlag := projectionFlag(e.Value); !isFlag || include {
+			inclusive = true
+		}
+	}
+	if !inclusive {
+		out := copyDocument(d)
+		for _, e := range spec {
+			out = unsetPath(out, e.Key)
+		}
+		return out, nil
+	}
+	out := bson.D{}
+	if include, isFlag := projectionFlag(field(spec, "_id")); !hasKey(spec, "_id") || isFlag && include {
+		if id := idOf(d); id != nil {
+			out = append(out, bson.E{Key: "_id", Value: id})
+		}
+	}
+	for _, e := range spec {
+		include, isFlag := projectionFlag(e.Value)
+		var v interface{}
+		switch {
+		case isFlag && !include:
+			if e.Key != "_id" {
+				return nil, commandError(31254, "Location31254", "Cannot do exclusion on field %s in inclusion projection", e.Key)
+			}
+			continue
+		case isFlag:
+			if e.Key == "_id" {
+				continue
+			}
+			var found bool
+			if v, found = getPath(d, e.Key); !found {
+				continue
+			}
+		default:
+			var err error
+			if v, err = eval(d, e.Value); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		if out, err = setPath(out, e.Key, v); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// projectionFlag reports whether v includes or excludes a field in a
+// projection, and whether it is such a flag rather than an expression.
+func projectionFlag(v interface{}) (include, isFlag bool) {
+	if b, ok := v.(bool); ok {
+		return b, true
+	}
+	if n, ok := number(v); ok {
+		return n != 0, true
+	}
+	return false, false
+}
+
+// eval evaluates an aggregation expression on d.
+func eval(d bson.D, expr interface{}) (interface{}, error) {
+	switch e := expr.(type) {
+	case string:
+		if strings.HasPrefix(e, "$$") {
+			if e == "$$ROOT" || e == "$$CURRENT" {
+				return d, nil
+			}
+			return nil, fmt.Errorf("fake: unsupported variable %s", e)
+		}
+		if strings.HasPrefix(e, "$") {
+			v, _ := getPath(d, e[1:])
+			return v, nil
+		}
+		return e, nil
+	case bson.A:
+		out := make(bson.A, len(e))
+		for i, item := range e {
+			v, err := eval(d, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	case bson.D:
+		if len(e) == 1 && strings.HasPrefix(e[0].Key, "$") {
+			return evalOperator(d, e[0].Key, e[0].Value)
+		}
+		out := bson.D{}
+		for _, f := range e {
+			v, err := eval(d, f.Value)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, bson.E{Key: f.Key, Value: v})
+		}
+		return out, nil
+	}
+	return expr, nil
+}
+
+func evalOperator(d bson.D, op string, arg interface{}) (interface{}, error) {
+	if op == "$literal" {
+		return arg, nil
+	}
+	v, err := eval(d, arg)
+	if err != nil {
+		return nil, err
+	}
+	args, ok := v.(bson.A)
+	if !ok {
+		args = bson.A{v}
+	}
+	switch op {
+	case "$add", "$multiply":
+		var total interface{} = int32(0)
+		if op == "$multiply" {
+			total = int32(1)
+		}
+		for _, a := range args {
+			if a == nil {
+				return nil, nil
+			}
+			if _, ok := number(a); !ok {
+				return nil, commandError(16554, "Location16554", "%s only supports numeric types", op)
+			}
+			if op == "$add" {
+				total = arithmetic("$inc", total, a)
+			} else {
+				total = arithmetic("$mul", total, a)
+			}
+		}
+		return total, nil
+	case "$subtract", "$divide":
+		if len(args) != 2 {
+			return nil, commandError(16020, "Location16020", "Expression %s takes exactly 2 arguments", op)
+		}
+		if args[0] == nil || args[1] == nil {
+			return nil, nil
+		}
+		x, okX := number(args[0])
+		y, okY := number(args[1])
+		if !okX || !okY {
+			return nil, commandError(16556, "Location16556", "%s only supports numeric types", op)
+		}
+		if op == "$divide" {
+			if y == 0 {
+				return nil, commandError(2, "BadValue", "can't $divide by zero")
+			}
+			return x / y, nil
+		}
+		return arithmetic("$inc", args[0], arithmetic("$mul", args[1], int32(-1))), nil
+	case "$concat":
+		var b strings.Builder
+		for _, a := range args {
+			if a == nil {
+				return nil, nil
+			}
+			s, ok := a.(string)
+			if !ok {
+				return nil, commandError(16702, "Location16702", "$concat only supports strings")
+			}
+			b.WriteString(s)
+		}
+		return b.String(), nil
+	case "$ifNull":
+		for _, a := range args {
+			if a != nil {
+				return a, nil
+			}
+		}
+		return nil, nil
+	case "$size":
+		arr, ok := args[0].(bson.A)
+		if len(args) != 1 || !ok {
+			return nil, commandError(17124, "Location17124", "The argument to $size must be an array")
+		}
+		return int32(len(arr)), nil
+	}
+	return nil, fmt.Errorf("fake: unsupported expression operator %s", op)
+}
+
+// unwind outputs a document per element of the array at the path of spec.
+func unwind(docs []bson.D, spec interface{}) ([]bson.D, error) {
+	path, _ := spec.(string)
+	preserve := false
+	if d, ok := spec.(bson.D); ok {
+		path, _ = field(d, "path").(string)
+		preserve, _ = field(d, "preserveNullAndEmptyArrays").(bool)
+	}
+	if !strings.HasPrefix(path, "$") {
+		return nil, commandError(28818, "Location28818", "path option to $unwind stage should be prefixed with a '$'")
+	}
+	path = path[1:]
+	var out []bson.D
+	for _, d := range docs {
+		v, found := getPath(d, path)
+		arr, isArray := v.(bson.A)
+		switch {
+		case isArray && len(arr) > 0:
+			for _, elem := range arr {
+				unwound, err := setPath(copyDocument(d), path, elem)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, unwound)
+			}
+		case !found || v == nil || isArray:
+			if preserve {
+				out = append(out, d)
+			}
+		default:
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// accumulator gathers the values of a field of $group for one group.
+type accumulator struct {
+	op     string
+	arg    interface{}
+	values []interface{} // Evaluated arguments, in document order
+}
+
+// group runs a $group stage, with the groups in the order their first
+// documents come in.
+func group(docs []bson.D, spec bson.D) ([]bson.D, error) {
+	type groupState struct {
+		id   interface{}
+		accs []*accumulator
+	}
+	var groups []*groupState
+	byKey := map[string]*groupState{}
+	for _, d := range docs {
+		id, err := eval(d, field(spec, "_id"))
+		if err != nil {
+			return nil, err
+		}
+		key, err := groupKey(id)
+		if err != nil {
+			return nil, err
+		}
+		g := byKey[key]
+		if g == nil {
+			g = &groupState{id: id}
+			for _, e := range spec {
+				if e.Key == "_id" {
+					continue
+				}
+				acc, ok := e.Value.(bson.D)
+				if !ok || len(acc) != 1 {
+					return nil, commandError(40234, "Location40234", "The field '%s' must be an accumulator object", e.Key)
+				}
+				g.accs = append(g.accs, &accumulator{op: acc[0].Key, arg: acc[0].Value})
+			}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		for _, acc := range g.accs {
+			arg := acc.arg
+			if acc.op == "$percentile" {
+				spec, _ := arg.(bson.D)
+				arg = field(spec, "input")
+			}
+			v, err := eval(d, arg)
+			if err != nil {
+				return nil, err
+			}
+			acc.values = append(acc.values, v)
+		}
+	}
+	out := make([]bson.D, 0, len(groups))
+	for _, g := range groups {
+		doc := bson.D{{Key: "_id", Value: g.id}}
+		i := 0
+		for _, e := range spec {
+			if e.Key == "_id" {
+				continue
+			}
+			v, err := g.accs[i].result()
+			if err != nil {
+				return nil, err
+			}
+			doc = append(doc, bson.E{Key: e.Key, Value: v})
+			i++
+		}
+		out = append(out, doc)
+	}
+	return out, nil
+}
+
+// groupKey is the key of a group _id, equal for numbers of different types
+// like on the server.
+func groupKey(id interface{}) (string, error) {
+	if n, ok := number(id); ok {
+		id = n
+	}
+	data, err := bson.MarshalExtJSON(bson.D{{Key: "id", Value: id}}, true, false)
+	return string(data), err
+}
+
+// result is the value of the accumulator over its group.
+func (a *accumulator) result() (interface{}, error) {
+	var numbers []float64
+	integral := true
+	for _, v := range a.values {
+		if n, ok := number(v); ok {
+			numbers = append(numbers, n)
+			switch v.(type) {
+			case int32, int64, int:
+			default:
+				integral = false
+			}
+		}
+	}
+	switch a.op {
+	case "$sum", "$count":
+		var total float64
+		for _, n := range numbers {
+			total += n
+		}
+		if a.op == "$count" {
+			total = float64(len(a.values))
+		}
+		if !integral {
+			return total, nil
+		}
+		if total >= math.MinInt32 && total <= math.MaxInt32 {
+			return int32(total), nil
+		}
+		return int64(total), nil
+	case "$avg", "$stdDevPop":
+		if len(numbers) == 0 {
+			return nil, nil
+		}
+		var total float64
+		for _, n := range numbers {
+			total += n
+		}
+		mean := total / float64(len(numbers))
+		if a.op == "$avg" {
+			return mean, nil
+		}
+		var squares float64
+		for _, n := range numbers {
+			squares += (n - mean) * (n - mean)
+		}
+		return math.Sqrt(squares / float64(len(numbers))), nil
+	case "$min", "$max":
+		var best interface{}
+		for _, v := range a.values {
+			if v == nil {
+				continue
+			}
+			if c := compare(v, best); best == nil || a.op == "$min" && c < 0 || a.op == "$max" && c > 0 {
+				best = v
+			}
+		}
+		return best, nil
+	case "$first", "$last":
+		if len(a.values) == 0 {
+			return nil, nil
+		}
+		if a.op == "$first" {
+			return a.values[0], nil
+		}
+		return a.values[len(a.values)-1], nil
+	case "$push", "$addToSet":
+		out := bson.A{}
+		for _, v := range a.values {
+			if v == nil && a.op == "$push" {
+				continue // A missing field is not pushed
+			}
+			if a.op == "$addToSet" && containsValue(out, v) {
+				continue
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case "$percentile":
+		arg, _ := a.arg.(bson.D)
+		ps, ok := field(arg, "p").(bson.A)
+		if !ok {
+			return nil, commandError(7750301, "Location7750301", "$percentile requires p to be an array of numbers")
+		}
+		sort.Float64s(numbers)
+		out := bson.A{}
+		for _, p := range ps {
+			q, _ := number(p)
+			if len(numbers) == 0 {
+				out = append(out, nil)
+				continue
+			}
+			rank := int(math.Ceil(q*float64(len(numbers)))) - 1 // Nearest rank
+			out = append(out, numbers[max(0, min(rank, len(numbers)-1))])
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("fake: unsupported accumulator %s", a.op)
+}
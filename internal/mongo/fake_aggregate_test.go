@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFakeAggregate(t *testing.T) {
+	fake := NewFake()
+	fake.Seed("db", "c",
+		bson.D{{Key: "k", Value: "a"}, {Key: "n", Value: 1}, {Key: "tags", Value: bson.A{"x", "y"}}},
+		bson.D{{Key: "k", Value: "a"}, {Key: "n", Value: 2.5}, {Key: "tags", Value: bson.A{"x"}}},
+		bson.D{{Key: "k", Value: "b"}, {Key: "n", Value: 3}},
+	)
+	ctx := context.Background()
+	if err := fake.CreateView(ctx, "db", "big", "c", bson.A{bson.D{{Key: "$match", Value: bson.D{{Key: "n", Value: bson.D{{Key: "$gt", Value: 2}}}}}}}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		coll, pipeline, want string
+	}{
+		{"c", `[{"$group": {"_id": "$k", "sum": {"$sum": "$n"}, "count": {"$sum": 1}, "max": {"$max": "$n"}, "tags": {"$push": "$tags"}}}]`,
+			`[{"_id":"a","sum":3.5,"count":2,"max":2.5,"tags":[["x","y"],["x"]]},{"_id":"b","sum":3,"count":1,"max":3,"tags":[]}]`},
+		{"c", `[{"$unwind": "$tags"}, {"$sortByCount": "$tags"}]`, `[{"_id":"x","count":2},{"_id":"y","count":1}]`},
+		{"c", `[{"$match": {"k": "a"}}, {"$project": {"_id": 0, "k": 1, "twice": {"$multiply": ["$n", 2]}}}]`,
+			`[{"k":"a","twice":2},{"k":"a","twice":5.0}]`},
+		{"c", `[{"$sort": {"n": -1}}, {"$skip": 1}, {"$limit": 1}, {"$project": {"_id": 0, "n": 1}}]`, `[{"n":2.5}]`},
+		{"c", `[{"$facet": {"total": [{"$count": "n"}], "none": [{"$match": {"k": "z"}}, {"$count": "n"}]}}]`, `[{"total":[{"n":3}],"none":[]}]`},
+		{"c", `[{"$group": {"_id": null, "p": {"$percentile": {"input": "$n", "p": [0.5, 1], "method": "approximate"}}, "avg": {"$avg": "$n"}}}]`,
+			`[{"_id":null,"p":[2.5,3.0],"avg":2.1666666666666665}]`},
+		{"big", `[{"$project": {"_id": 0, "k": 1}}]`, `[{"k":"a"},{"k":"b"}]`},
+	}
+	for _, tt := range tests {
+		var pipeline bson.A
+		if err := bson.UnmarshalExtJSON([]byte(tt.pipeline), false, &pipeline); err != nil {
+			t.Fatal(err)
+		}
+		cur, err := fake.Aggregate(ctx, "db", tt.coll, pipeline)
+		if err != nil {
+			t.Errorf("%s: %v", tt.pipeline, err)
+			continue
+		}
+		var docs []bson.D
+		if err := cur.All(ctx, &docs); err != nil {
+			t.Fatal(err)
+		}
+		got, err := bson.MarshalExtJSON(bson.D{{Key: "docs", Value: docs}}, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"docs":` + tt.want + `}`; string(got) != want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.pipeline, got, want)
+		}
+	}
+
+	n, err := fake.CountDocuments(ctx, "db", "big", bson.D{{Key: "k", Value: "b"}})
+	if err != nil || n != 1 {
+		t.Errorf("counting in a view: %d, %v", n, err)
+	}
+}
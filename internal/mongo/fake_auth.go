@@ -0,0 +1,421 @@
+package mongo
+
+import (
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// The user and role management of the Fake. Users and custom roles are
+// documents of admin.system.users and admin.system.roles with the _id
+// "db.name", as on the server, so restoring users with a direct insert into
+// system.users works like there. Passwords are not kept: users get
+// placeholder SCRAM credentials. The builtin roles are a few of the
+// server's, with simplified privileges.
+
+// builtinRoles are the builtin roles of every database, by name, with the
+// actions they allow on it.
+var builtinRoles = map[string][]string{
+	"read":      {"collStats", "dbStats", "find", "listCollections", "listIndexes"},
+	"readWrite": {"collStats", "dbStats", "find", "insert", "listCollections", "listIndexes", "remove", "update"},
+	"dbAdmin":   {"collMod", "collStats", "createCollection", "createIndex", "dbStats", "dropCollection", "dropIndex", "listCollections", "listIndexes"},
+	"userAdmin": {"createRole", "createUser", "dropRole", "dropUser", "grantRole", "revokeRole", "viewRole", "viewUser"},
+}
+
+// builtinAdminRoles are the builtin roles only the admin database has.
+var builtinAdminRoles = []string{"readAnyDatabase", "root"}
+
+func isBuiltinRole(role, db string) bool {
+	if _, ok := builtinRoles[role]; ok {
+		return true
+	}
+	for _, name := range builtinAdminRoles {
+		if db == "admin" && name == role {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinRole returns the rolesInfo entry of a builtin role.
+func builtinRole(role, db string) bson.D {
+	resource := bson.D{{Key: "db", Value: db}, {Key: "collection", Value: ""}}
+	actions := builtinRoles[role]
+	switch role {
+	case "readAnyDatabase":
+		resource, actions = bson.D{{Key: "db", Value: ""}, {Key: "collection", Value: ""}}, builtinRoles["read"]
+	case "root":
+		resource, actions = bson.D{{Key: "anyResource", Value: true}}, []string{"anyAction"}
+	}
+	privilege := bson.D{{Key: "resource", Value: resource}, {Key: "actions", Value: actions}}
+	return bson.D{
+		{Key: "_id", Value: db + "." + role},
+		{Key: "role", Value: role},
+		{Key: "db", Value: db},
+		{Key: "roles", Value: bson.A{}},
+		{Key: "privileges", Value: bson.A{privilege}},
+	}
+}
+
+// roleRefs reads the roles of a command, given as names of roles of db or
+// as {role, db} documents.
+func roleRefs(v interface{}, db string) ([]bson.D, error) {
+	list, ok := v.(bson.A)
+	if !ok && v != nil {
+		return nil, commandError(14, "TypeMismatch", "roles must be an array")
+	}
+	var refs []bson.D
+	for _, r := range list {
+		switch r := r.(type) {
+		case string:
+			refs = append(refs, bson.D{{Key: "role", Value: r}, {Key: "db", Value: db}})
+		case bson.D:
+			role, _ := field(r, "role").(string)
+			roleDB, _ := field(r, "db").(string)
+			refs = append(refs, bson.D{{Key: "role", Value: role}, {Key: "db", Value: roleDB}})
+		default:
+			return nil, commandError(14, "TypeMismatch", "a role must be a name or a {role, db} document")
+		}
+	}
+	return refs, nil
+}
+
+func refName(ref bson.D) (string, string) {
+	role, _ := field(ref, "role").(string)
+	db, _ := field(ref, "db").(string)
+	return role, db
+}
+
+// principal returns the position of the document with _id id in
+// admin.<coll>, -1 if there is none.
+func (f *Fake) principal(coll, id string) int {
+	for i, doc := range f.dbs["admin"][coll] {
+		if idOf(doc) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkRoles fails with RoleNotFound if a role of refs does not exist.
+func (f *Fake) checkRoles(refs []bson.D) error {
+	for _, ref := range refs {
+		role, db := refName(ref)
+		if !isBuiltinRole(role, db) && f.principal("system.roles", db+"."+role) < 0 {
+			return commandError(31, "RoleNotFound", "Could not find role: %s@%s", role, db)
+		}
+	}
+	return nil
+}
+
+// usersInfo answers usersInfo: 1 for the users of db, {forAllDBs: true},
+// and a user name or {user, db} document for one user.
+func (f *Fake) usersInfo(db string, command bson.D) (bson.D, error) {
+	match := func(doc bson.D) bool { return field(doc, "db") == db }
+	switch spec := command[0].Value.(type) {
+	case string:
+		match = func(doc bson.D) bool { return idOf(doc) == db+"."+spec }
+	case bson.D:
+		if all, _ := field(spec, "forAllDBs").(bool); all {
+			match = func(bson.D) bool { return true }
+		} else {
+			user, _ := field(spec, "user").(string)
+			userDB, _ := field(spec, "db").(string)
+			match = func(doc bson.D) bool { return idOf(doc) == userDB+"."+user }
+		}
+	}
+	showCredentials, _ := field(command, "showCredentials").(bool)
+
+	users := bson.A{}
+	for _, doc := range f.sortedPrincipals("system.users") {
+		if !match(doc) {
+			continue
+		}
+		var user bson.D
+		for _, e := range doc {
+			if e.Key != "credentials" || showCredentials {
+				user = append(user, e)
+			}
+		}
+		users = append(users, user)
+	}
+	return bson.D{{Key: "users", Value: users}}, nil
+}
+
+// sortedPrincipals returns the documents of admin.<coll> by _id, as the
+// server lists them.
+func (f *Fake) sortedPrincipals(coll string) []bson.D {
+	docs := append([]bson.D(nil), f.dbs["admin"][coll]...)
+	sort.SliceStable(docs, func(i, j int) bool { return fmt.Sprint(idOf(docs[i])) < fmt.Sprint(idOf(docs[j])) })
+	return docs
+}
+
+func (f *Fake) createUser(db string, command bson.D) error {
+	name, _ := command[0].Value.(string)
+	if f.principal("system.users", db+"."+name) >= 0 {
+		return commandError(51003, "Location51003", "User \"%s@%s\" already exists", name, db)
+	}
+	refs, err := roleRefs(field(command, "roles"), db)
+	if err != nil {
+		return err
+	}
+	if err := f.checkRoles(refs); err != nil {
+		return err
+	}
+	mechanisms := field(command, "mechanisms")
+	if mechanisms == nil {
+		mechanisms = bson.A{"SCRAM-SHA-1", "SCRAM-SHA-256"}
+	}
+	credentials := bson.D{}
+	for _, mechanism := range mechanisms.(bson.A) {
+		credentials = append(credentials, bson.E{Key: fmt.Sprint(mechanism), Value: bson.D{{Key: "iterationCount", Value: int32(15000)}}})
+	}
+	roles := bson.A{}
+	for _, ref := range refs {
+		roles = append(roles, ref)
+	}
+	user := bson.D{
+		{Key: "_id", Value: db + "." + name},
+		{Key: "user", Value: name},
+		{Key: "db", Value: db},
+		{Key: "credentials", Value: credentials},
+		{Key: "roles", Value: roles},
+	}
+	for _, key := range []string{"customData", "authenticationRestrictions"} {
+		if v := field(command, key); v != nil {
+			user = append(user, bson.E{Key: key, Value: v})
+		}
+	}
+	user = append(user, bson.E{Key: "mechanisms", Value: mechanisms})
+	f.add("admin", "system.users", user)
+	return nil
+}
+
+func (f *Fake) dropUser(db string, command bson.D) error {
+	name, _ := command[0].Value.(string)
+	i := f.principal("system.users", db+"."+name)
+	if i < 0 {
+		return commandError(11, "UserNotFound", "User '%s@%s' not found", name, db)
+	}
+	f.remove("admin", "system.users", []storedDoc{{index: i}})
+	return nil
+}
+
+// changeUserRoles grants or revokes the roles of command to a user of db.
+// Revoking a role the user does not have does nothing.
+func (f *Fake) changeUserRoles(db string, command bson.D, grant bool) error {
+	name, _ := command[0].Value.(string)
+	i := f.principal("system.users", db+"."+name)
+	if i < 0 {
+		return commandError(11, "UserNotFound", "Could not find user \"%s\" for db \"%s\"", name, db)
+	}
+	refs, err := roleRefs(field(command, "roles"), db)
+	if err != nil {
+		return err
+	}
+	if grant {
+		if err := f.checkRoles(refs); err != nil {
+			return err
+		}
+	}
+	user := f.dbs["admin"]["system.users"][i]
+	current, _ := field(user, "roles").(bson.A)
+	roles := changedRoles(current, refs, grant)
+	updated, err := setPath(user, "roles", roles)
+	if err != nil {
+		return err
+	}
+	f.dbs["admin"]["system.users"][i] = updated
+	return nil
+}
+
+// changedRoles returns current with refs added, or removed if not grant.
+func changedRoles(current bson.A, refs []bson.D, grant bool) bson.A {
+	roles := bson.A{}
+	for _, r := range current {
+		revoked := false
+		for _, ref := range refs {
+			revoked = revoked || (!grant && sameRole(r, ref))
+		}
+		if !revoked {
+			roles = append(roles, r)
+		}
+	}
+	if grant {
+		for _, ref := range refs {
+			if !containsRole(roles, ref) {
+				roles = append(roles, ref)
+			}
+		}
+	}
+	return roles
+}
+
+func sameRole(r interface{}, ref bson.D) bool {
+	doc, ok := r.(bson.D)
+	if !ok {
+		return false
+	}
+	role, db := refName(doc)
+	refRole, refDB := refName(ref)
+	return role == refRole && db == refDB
+}
+
+func containsRole(roles bson.A, ref bson.D) bool {
+	for _, r := range roles {
+		if sameRole(r, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// rolesInfo answers rolesInfo: 1 for the custom roles of db, and the
+// builtin ones with showBuiltinRoles, and a role name or {role, db}
+// document for one role. showPrivileges adds the privileges each role
+// grants and inherits.
+func (f *Fake) rolesInfo(db string, command bson.D) (bson.D, error) {
+	showBuiltin, _ := field(command, "showBuiltinRoles").(bool)
+	showPrivileges, _ := field(command, "showPrivileges").(bool)
+
+	var roles []bson.D
+	switch spec := command[0].Value.(type) {
+	case string:
+		roles = f.lookupRole(spec, db)
+	case bson.D:
+		role, _ := field(spec, "role").(string)
+		roleDB, _ := field(spec, "db").(string)
+		roles = f.lookupRole(role, roleDB)
+	default:
+		for _, doc := range f.sortedPrincipals("system.roles") {
+			if field(doc, "db") == db {
+				roles = append(roles, doc)
+			}
+		}
+		if showBuiltin {
+			var names []string
+			for name := range builtinRoles {
+				names = append(names, name)
+			}
+			if db == "admin" {
+				names = append(names, builtinAdminRoles...)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				roles = append(roles, builtinRole(name, db))
+			}
+		}
+	}
+
+	list := bson.A{}
+	for _, doc := range roles {
+		role, roleDB := refName(doc)
+		entry := bson.D{
+			{Key: "_id", Value: idOf(doc)},
+			{Key: "role", Value: role},
+			{Key: "db", Value: roleDB},
+			{Key: "isBuiltin", Value: isBuiltinRole(role, roleDB)},
+			{Key: "roles", Value: field(doc, "roles")},
+		}
+		if showPrivileges {
+			entry = append(entry,
+				bson.E{Key: "privileges", Value: field(doc, "privileges")},
+				bson.E{Key: "inheritedPrivileges", Value: f.inheritedPrivileges(doc, map[string]bool{})})
+		}
+		if restrictions := field(doc, "authenticationRestrictions"); restrictions != nil {
+			entry = append(entry, bson.E{Key: "authenticationRestrictions", Value: restrictions})
+		}
+		list = append(list, entry)
+	}
+	return bson.D{{Key: "roles", Value: list}}, nil
+}
+
+// lookupRole returns the custom or builtin role name of db, if it exists.
+func (f *Fake) lookupRole(name, db string) []bson.D {
+	if isBuiltinRole(name, db) {
+		return []bson.D{builtinRole(name, db)}
+	}
+	if i := f.principal("system.roles", db+"."+name); i >= 0 {
+		return []bson.D{f.dbs["admin"]["system.roles"][i]}
+	}
+	return nil
+}
+
+// inheritedPrivileges returns the privileges of role and of the roles it
+// inherits, skipping those already seen.
+func (f *Fake) inheritedPrivileges(role bson.D, seen map[string]bool) bson.A {
+	id := fmt.Sprint(idOf(role))
+	if seen[id] {
+		return bson.A{}
+	}
+	seen[id] = true
+	privileges := bson.A{}
+	own, _ := field(role, "privileges").(bson.A)
+	privileges = append(privileges, own...)
+	inherited, _ := field(role, "roles").(bson.A)
+	for _, r := range inherited {
+		ref, _ := r.(bson.D)
+		for _, parent := range f.lookupRole(refName(ref)) {
+			privileges = append(privileges, f.inheritedPrivileges(parent, seen)...)
+		}
+	}
+	return privileges
+}
+
+func (f *Fake) createRole(db string, command bson.D) error {
+	name, _ := command[0].Value.(string)
+	if isBuiltinRole(name, db) || f.principal("system.roles", db+"."+name) >= 0 {
+		return commandError(51002, "Location51002", "Role \"%s@%s\" already exists", name, db)
+	}
+	refs, err := roleRefs(field(command, "roles"), db)
+	if err != nil {
+		return err
+	}
+	if err := f.checkRoles(refs); err != nil {
+		return err
+	}
+	privileges, _ := field(command, "privileges").(bson.A)
+	if privileges == nil {
+		privileges = bson.A{}
+	}
+	roles := bson.A{}
+	for _, ref := range refs {
+		roles = append(roles, ref)
+	}
+	role := bson.D{
+		{Key: "_id", Value: db + "." + name},
+		{Key: "role", Value: name},
+		{Key: "db", Value: db},
+		{Key: "privileges", Value: privileges},
+		{Key: "roles", Value: roles},
+	}
+	if restrictions := field(command, "authenticationRestrictions"); restrictions != nil {
+		role = append(role, bson.E{Key: "authenticationRestrictions", Value: restrictions})
+	}
+	f.add("admin", "system.roles", role)
+	return nil
+}
+
+func (f *Fake) grantRolesToRole(db string, command bson.D) error {
+	name, _ := command[0].Value.(string)
+	i := f.principal("system.roles", db+"."+name)
+	if i < 0 {
+		return commandError(31, "RoleNotFound", "Could not find role: %s@%s", name, db)
+	}
+	refs, err := roleRefs(field(command, "roles"), db)
+	if err != nil {
+		return err
+	}
+	if err := f.checkRoles(refs); err != nil {
+		return err
+	}
+	role := f.dbs["admin"]["system.roles"][i]
+	current, _ := field(role, "roles").(bson.A)
+	updated, err := setPath(role, "roles", changedRoles(current, refs, true))
+	if err != nil {
+		return err
+	}
+	f.dbs["admin"]["system.roles"][i] = updated
+	return nil
+}
@@ -0,0 +1,189 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// The server commands of the Fake, for the admin commands of mon-go: user
+// and role management (fake_auth.go), server parameters, collMod, explain,
+// collStats and dbStats (fake_namespaces.go), and serverStatus, currentOp
+// and killOp (fake_activity.go). Other commands fail with CommandNotFound,
+// as on a server that does not know them.
+
+// RunCommand runs cmd on db. Replies carry ok: 1 like the server's; errors
+// are mongo.CommandErrors with the server's codes.
+func (f *Fake) RunCommand(ctx context.Context, db string, cmd, result interface{}) error {
+	var command bson.D
+	if err := convert(cmd, &command); err != nil {
+		return fmt.Errorf("fake: invalid command: %w", err)
+	}
+	if len(command) == 0 {
+		return commandError(59, "CommandNotFound", "no command given")
+	}
+	f.activity.count("opcounters.command", 1)
+	f.mu.Lock()
+	reply, err := f.command(db, command)
+	f.mu.Unlock()
+	if err != nil || result == nil {
+		return err
+	}
+	return convert(append(reply, bson.E{Key: "ok", Value: 1.0}), result)
+}
+
+// command runs command on db with f.mu held.
+func (f *Fake) command(db string, command bson.D) (bson.D, error) {
+	switch name := command[0].Key; name {
+	case "usersInfo":
+		return f.usersInfo(db, command)
+	case "createUser":
+		return nil, f.createUser(db, command)
+	case "dropUser":
+		return nil, f.dropUser(db, command)
+	case "grantRolesToUser", "revokeRolesFromUser":
+		return nil, f.changeUserRoles(db, command, name == "grantRolesToUser")
+	case "rolesInfo":
+		return f.rolesInfo(db, command)
+	case "createRole":
+		return nil, f.createRole(db, command)
+	case "grantRolesToRole":
+		return nil, f.grantRolesToRole(db, command)
+	case "getParameter":
+		return f.getParameter(command)
+	case "getClusterParameter":
+		return f.getClusterParameter()
+	case "setParameter":
+		return f.setParameter(command)
+	case "setClusterParameter":
+		return nil, f.setClusterParameter(command)
+	case "collMod":
+		return f.collMod(db, command)
+	case "explain":
+		return f.explain(db, command)
+	case "collStats":
+		return f.collStats(db, command)
+	case "dbStats":
+		return f.dbStats(db)
+	case "serverStatus":
+		return f.activity.serverStatus()
+	case "currentOp":
+		return f.activity.currentOp(command)
+	case "killOp":
+		return f.activity.killOp(command)
+	default:
+		return nil, commandError(59, "CommandNotFound", "no such command: '%s'", name)
+	}
+}
+
+// commandError is the error the server replies with.
+func commandError(code int32, name, format string, args ...interface{}) error {
+	return mongo.CommandError{Code: code, Name: name, Message: fmt.Sprintf(format, args...)}
+}
+
+// field returns the value of key in doc, nil if it is missing.
+func field(doc bson.D, key string) interface{} {
+	for _, e := range doc {
+		if e.Key == key {
+			return e.Value
+		}
+	}
+	return nil
+}
+
+// fakeParam is a server parameter of the Fake.
+type fakeParam struct {
+	name    string
+	value   interface{}
+	runtime bool // Settable with setParameter, not only at startup
+}
+
+// defaultParams are the server parameters a Fake starts with: a few of a
+// real server's, settable and startup only.
+func defaultParams() []fakeParam {
+	return []fakeParam{
+		{name: "cursorTimeoutMillis", value: int64(600000), runtime: true},
+		{name: "enableLocalhostAuthBypass", value: true},
+		{name: "logLevel", value: int32(0), runtime: true},
+		{name: "notablescan", value: false, runtime: true},
+	}
+}
+
+// defaultClusterParams are the cluster parameters a Fake starts with.
+func defaultClusterParams() []bson.D {
+	return []bson.D{
+		{{Key: "_id", Value: "changeStreamOptions"}, {Key: "preAndPostImages", Value: bson.D{{Key: "expireAfterSeconds", Value: "off"}}}},
+	}
+}
+
+// getParameter answers {getParameter: "*"} with the values, and
+// {getParameter: {allParameters: true, showDetails: true}} with details.
+func (f *Fake) getParameter(command bson.D) (bson.D, error) {
+	var details bool
+	switch spec := command[0].Value.(type) {
+	case string:
+		if spec != "*" {
+			return nil, commandError(2, "BadValue", "fake: getParameter only supports \"*\"")
+		}
+	case bson.D:
+		details, _ = field(spec, "showDetails").(bool)
+	}
+	var reply bson.D
+	for _, p := range f.params {
+		value := p.value
+		if details {
+			value = bson.D{{Key: "value", Value: p.value}, {Key: "settableAtRuntime", Value: p.runtime}, {Key: "settableAtStartup", Value: true}}
+		}
+		reply = append(reply, bson.E{Key: p.name, Value: value})
+	}
+	return reply, nil
+}
+
+func (f *Fake) getClusterParameter() (bson.D, error) {
+	params := bson.A{}
+	for _, p := range f.clusterParams {
+		params = append(params, p)
+	}
+	return bson.D{{Key: "clusterParameters", Value: params}}, nil
+}
+
+// setParameter sets the parameters of {setParameter: 1, name: value} and
+// replies with the previous value, as was.
+func (f *Fake) setParameter(command bson.D) (bson.D, error) {
+	var reply bson.D
+	for _, e := range command[1:] {
+		i := sort.Search(len(f.params), func(i int) bool { return f.params[i].name >= e.Key })
+		if i == len(f.params) || f.params[i].name != e.Key {
+			return nil, commandError(72, "InvalidOptions", "attempted to set unrecognized parameter [%s], use help:true to see options", e.Key)
+		}
+		if !f.params[i].runtime {
+			return nil, commandError(72, "InvalidOptions", "not allowed to change [%s] at runtime", e.Key)
+		}
+		reply = append(reply, bson.E{Key: "was", Value: f.params[i].value})
+		f.params[i].value = e.Value
+	}
+	return reply, nil
+}
+
+// setClusterParameter replaces the fields of the cluster parameter of
+// {setClusterParameter: {name: {...}}}.
+func (f *Fake) setClusterParameter(command bson.D) error {
+	spec, _ := command[0].Value.(bson.D)
+	if len(spec) != 1 {
+		return commandError(2, "BadValue", "setClusterParameter needs exactly one parameter")
+	}
+	fields, ok := spec[0].Value.(bson.D)
+	if !ok {
+		return commandError(14, "TypeMismatch", "the value of cluster parameter %s must be a document", spec[0].Key)
+	}
+	for i, p := range f.clusterParams {
+		if idOf(p) == spec[0].Key {
+			f.clusterParams[i] = append(bson.D{{Key: "_id", Value: spec[0].Key}}, fields...)
+			return nil
+		}
+	}
+	return commandError(2, "BadValue", "unknown cluster parameter %s", spec[0].Key)
+}
@@ -0,0 +1,37 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestFakeCommandErrors(t *testing.T) {
+	fake := NewFake()
+	ctx := context.Background()
+	createUser := bson.D{{Key: "createUser", Value: "u"}, {Key: "pwd", Value: "p"}, {Key: "roles", Value: bson.A{"read"}}}
+	if err := fake.RunCommand(ctx, "db", createUser, nil); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		cmd  bson.D
+		code int32
+	}{
+		{bson.D{{Key: "shutdown", Value: 1}}, 59},
+		{createUser, 51003},
+		{bson.D{{Key: "dropUser", Value: "nobody"}}, 11},
+		{bson.D{{Key: "grantRolesToUser", Value: "u"}, {Key: "roles", Value: bson.A{"missing"}}}, 31},
+		{bson.D{{Key: "createRole", Value: "read"}, {Key: "privileges", Value: bson.A{}}, {Key: "roles", Value: bson.A{}}}, 51002},
+		{bson.D{{Key: "setParameter", Value: 1}, {Key: "enableLocalhostAuthBypass", Value: false}}, 72},
+	}
+	for _, tt := range tests {
+		err := fake.RunCommand(ctx, "db", tt.cmd, nil)
+		var cmdErr mongo.CommandError
+		if !errors.As(err, &cmdErr) || cmdErr.Code != tt.code {
+			t.Errorf("%v: got error %v, want code %d", tt.cmd, err, tt.code)
+		}
+	}
+}
@@ -0,0 +1,636 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The namespaces of the Fake: collection options, views, indexes and search
+// indexes, with the commands that read and change them, collMod, explain,
+// collStats and dbStats. Indexes are only described: apart from the one on
+// _id, unique indexes do not refuse duplicates, and explain picks an index
+// when the filter has an equality or range on its first field. Sizes are
+// those of the documents' BSON; every index and empty collection takes one
+// 4KB page, as an empty WiredTiger table does.
+
+// emptyTableSize is the size the Fake gives an index or an empty
+// collection.
+const emptyTableSize = 4096
+
+// fakeNamespace is what the Fake knows of a collection or view besides its
+// documents.
+type fakeNamespace struct {
+	kind          string   // "collection", "view" or "timeseries"
+	options       bson.D   // As listCollections reports them
+	indexes       []bson.D // As listIndexes reports them, _id_ first
+	searchIndexes []bson.D // As $listSearchIndexes reports them
+}
+
+func idIndex() bson.D {
+	return bson.D{{Key: "v", Value: int32(2)}, {Key: "key", Value: bson.D{{Key: "_id", Value: int32(1)}}}, {Key: "name", Value: "_id_"}}
+}
+
+// namespace returns what f knows of db.coll, nil if it does not exist.
+func (f *Fake) namespace(db, coll string) *fakeNamespace {
+	if _, ok := f.dbs[db][coll]; !ok {
+		return nil
+	}
+	ns := f.namespaces[db+"."+coll]
+	if ns == nil {
+		ns = &fakeNamespace{kind: "collection", options: bson.D{}, indexes: []bson.D{idIndex()}}
+		f.namespaces[db+"."+coll] = ns
+	}
+	return ns
+}
+
+// writable fails like the server on writes to a view.
+func (f *Fake) writable(db, coll string) error {
+	if ns := f.namespace(db, coll); ns != nil && ns.kind == "view" {
+		return commandError(166, "CommandNotSupportedOnView", "Namespace %s.%s is a view, not a collection", db, coll)
+	}
+	return nil
+}
+
+// existing returns db.coll, failing with NamespaceNotFound if it does not
+// exist.
+func (f *Fake) existing(db, coll string) (*fakeNamespace, error) {
+	ns := f.namespace(db, coll)
+	if ns == nil {
+		return nil, commandError(26, "NamespaceNotFound", "ns does not exist: %s.%s", db, coll)
+	}
+	return ns, nil
+}
+
+// collectionNames returns the names of the namespaces of db, sorted.
+func (f *Fake) collectionNames(db string) []string {
+	names := make([]string, 0, len(f.dbs[db]))
+	for name := range f.dbs[db] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ListDatabases returns the databases of f with their size on disk, that of
+// their collections and indexes as dbStats reports it.
+func (f *Fake) ListDatabases(ctx context.Context) ([]mongo.DatabaseSpecification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]string, 0, len(f.dbs))
+	for name := range f.dbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	specs := make([]mongo.DatabaseSpecification, len(names))
+	for i, name := range names {
+		stats, err := f.dbStats(name)
+		if err != nil {
+			return nil, err
+		}
+		specs[i] = mongo.DatabaseSpecification{
+			Name:       name,
+			SizeOnDisk: field(stats, "storageSize").(int64) + field(stats, "indexSize").(int64),
+			Empty:      len(f.dbs[name]) == 0,
+		}
+	}
+	return specs, nil
+}
+
+// ListCollections returns the specifications of the namespaces of db that
+// match filter: name, type, options and info.
+func (f *Fake) ListCollections(ctx context.Context, db string, filter interface{}) (Cursor, error) {
+	var query bson.D
+	if filter != nil {
+		if err := convert(filter, &query); err != nil {
+			return nil, fmt.Errorf("fake: invalid filter: %w", err)
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var specs []bson.D
+	for _, name := range f.collectionNames(db) {
+		ns := f.namespace(db, name)
+		spec := bson.D{
+			{Key: "name", Value: name},
+			{Key: "type", Value: ns.kind},
+			{Key: "options", Value: ns.options},
+			{Key: "info", Value: bson.D{{Key: "readOnly", Value: ns.kind == "view"}}},
+		}
+		var fields bson.M
+		if err := convert(spec, &fields); err != nil {
+			return nil, err
+		}
+		ok, err := matches(fields, query)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			specs = append(specs, spec)
+		}
+	}
+	return newFakeCursor(specs), nil
+}
+
+// CreateCollection creates db.coll with the options listCollections then
+// reports. Time series collections get the bucket span of their
+// granularity.
+func (f *Fake) CreateCollection(ctx context.Context, db, coll string, opts ...*options.CreateCollectionOptions) error {
+	o := options.MergeCreateCollectionOptions(opts...)
+	kind, collOptions, err := collectionOptions(o)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.namespace(db, coll) != nil {
+		return commandError(48, "NamespaceExists", "Collection %s.%s already exists.", db, coll)
+	}
+	f.add(db, coll)
+	ns := f.namespace(db, coll)
+	ns.kind, ns.options = kind, collOptions
+	return nil
+}
+
+// collectionOptions returns the kind and options of a collection created
+// with o.
+func collectionOptions(o *options.CreateCollectionOptions) (string, bson.D, error) {
+	kind, doc := "collection", bson.D{}
+	if o.Capped != nil && *o.Capped {
+		doc = append(doc, bson.E{Key: "capped", Value: true})
+		if o.SizeInBytes != nil {
+			doc = append(doc, bson.E{Key: "size", Value: *o.SizeInBytes})
+		}
+		if o.MaxDocuments != nil {
+			doc = append(doc, bson.E{Key: "max", Value: *o.MaxDocuments})
+		}
+	}
+	if o.ClusteredIndex != nil {
+		doc = append(doc, bson.E{Key: "clusteredIndex", Value: o.ClusteredIndex})
+	}
+	if ts := o.TimeSeriesOptions; ts != nil {
+		kind = "timeseries"
+		granularity := "seconds"
+		if ts.Granularity != nil {
+			granularity = *ts.Granularity
+		}
+		span := map[string]int32{"seconds": 3600, "minutes": 86400, "hours": 2592000}[granularity]
+		spec := bson.D{{Key: "timeField", Value: ts.TimeField}}
+		if ts.MetaField != nil {
+			spec = append(spec, bson.E{Key: "metaField", Value: *ts.MetaField})
+		}
+		spec = append(spec, bson.E{Key: "granularity", Value: granularity}, bson.E{Key: "bucketMaxSpanSeconds", Value: span})
+		doc = append(doc, bson.E{Key: "timeseries", Value: spec})
+	}
+	if o.ExpireAfterSeconds != nil {
+		doc = append(doc, bson.E{Key: "expireAfterSeconds", Value: *o.ExpireAfterSeconds})
+	}
+	for _, option := range []struct {
+		key   string
+		value *string
+	}{{"validationLevel", o.ValidationLevel}, {"validationAction", o.ValidationAction}} {
+		if option.value != nil {
+			doc = append(doc, bson.E{Key: option.key, Value: *option.value})
+		}
+	}
+	for _, option := range []struct {
+		key   string
+		value interface{}
+	}{{"validator", o.Validator}, {"storageEngine", o.StorageEngine}, {"changeStreamPreAndPostImages", o.ChangeStreamPreAndPostImages}} {
+		if option.value == nil {
+			continue
+		}
+		var v bson.D
+		if err := convert(option.value, &v); err != nil {
+			return "", nil, fmt.Errorf("fake: invalid %s: %w", option.key, err)
+		}
+		doc = append(doc, bson.E{Key: option.key, Value: v})
+	}
+	if o.Collation != nil {
+		var collation bson.D
+		if err := bson.Unmarshal(o.Collation.ToDocument(), &collation); err != nil {
+			return "", nil, err
+		}
+		doc = append(doc, bson.E{Key: "collation", Value: collation})
+	}
+	return kind, doc, nil
+}
+
+// CreateView creates view, whose documents are those of source through
+// pipeline.
+func (f *Fake) CreateView(ctx context.Context, db, view, source string, pipeline interface{}) error {
+	var spec struct {
+		Pipeline bson.A `bson:"pipeline"`
+	}
+	if err := convert(bson.D{{Key: "pipeline", Value: pipeline}}, &spec); err != nil {
+		return fmt.Errorf("fake: invalid pipeline: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.namespace(db, view) != nil {
+		return commandError(48, "NamespaceExists", "Namespace %s.%s already exists", db, view)
+	}
+	f.add(db, view)
+	ns := f.namespace(db, view)
+	ns.kind = "view"
+	ns.options = bson.D{{Key: "viewOn", Value: source}, {Key: "pipeline", Value: spec.Pipeline}}
+	ns.indexes = nil
+	return nil
+}
+
+func (f *Fake) EstimatedDocumentCount(ctx context.Context, db, coll string) (int64, error) {
+	defer f.activity.op(db+"."+coll, "commands", time.Now())
+	f.activity.count("opcounters.command", 1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.writable(db, coll); err != nil {
+		return 0, err // Views have no count of their own
+	}
+	return int64(len(f.dbs[db][coll])), nil
+}
+
+func (f *Fake) ListIndexes(ctx context.Context, db, coll string) (Cursor, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ns, err := f.existing(db, coll)
+	if err != nil {
+		return nil, err
+	}
+	return newFakeCursor(ns.indexes), nil
+}
+
+// CreateIndex adds the description of index, creating db.coll if needed.
+// Creating an index that exists with the same keys and name does nothing,
+// as on the server.
+func (f *Fake) CreateIndex(ctx context.Context, db, coll string, index mongo.IndexModel) (string, error) {
+	spec, err := indexSpec(index)
+	if err != nil {
+		return "", err
+	}
+	name, _ := field(spec, "name").(string)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.writable(db, coll); err != nil {
+		return "", err
+	}
+	if f.namespace(db, coll) == nil {
+		f.add(db, coll)
+	}
+	ns := f.namespace(db, coll)
+	for _, existing := range ns.indexes {
+		sameKey := sameDocument(field(existing, "key").(bson.D), field(spec, "key").(bson.D))
+		switch sameName := field(existing, "name") == name; {
+		case sameName && sameKey:
+			return name, nil
+		case sameName:
+			return "", commandError(86, "IndexKeySpecsConflict", "An existing index has the same name as the requested index but different keys: %s", name)
+		case sameKey:
+			return "", commandError(85, "IndexOptionsConflict", "Index already exists with a different name: %v", field(existing, "name"))
+		}
+	}
+	ns.indexes = append(ns.indexes, spec)
+	return name, nil
+}
+
+// indexSpec returns index as listIndexes reports it. Text indexes keep
+// their fields as weights, like the server's.
+func indexSpec(index mongo.IndexModel) (bson.D, error) {
+	var keys bson.D
+	if err := convert(index.Keys, &keys); err != nil {
+		return nil, fmt.Errorf("fake: invalid index keys: %w", err)
+	}
+	o := options.MergeIndexOptions(index.Options)
+	var parts []string
+	var weights bson.D
+	key := bson.D{}
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", k.Key, k.Value))
+		if k.Value == "text" {
+			weights = append(weights, bson.E{Key: k.Key, Value: int32(1)})
+			continue
+		}
+		key = append(key, k)
+	}
+	if weights != nil {
+		key = append(bson.D{{Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}}, key...)
+	}
+	name := strings.Join(parts, "_")
+	if o.Name != nil {
+		name = *o.Name
+	}
+	spec := bson.D{{Key: "v", Value: int32(2)}, {Key: "key", Value: key}, {Key: "name", Value: name}}
+	if o.Unique != nil && *o.Unique {
+		spec = append(spec, bson.E{Key: "unique", Value: true})
+	}
+	if o.Sparse != nil && *o.Sparse {
+		spec = append(spec, bson.E{Key: "sparse", Value: true})
+	}
+	if o.Hidden != nil && *o.Hidden {
+		spec = append(spec, bson.E{Key: "hidden", Value: true})
+	}
+	if o.ExpireAfterSeconds != nil {
+		spec = append(spec, bson.E{Key: "expireAfterSeconds", Value: *o.ExpireAfterSeconds})
+	}
+	for _, option := range []struct {
+		key   string
+		value interface{}
+	}{{"partialFilterExpression", o.PartialFilterExpression}, {"wildcardProjection", o.WildcardProjection}} {
+		if option.value == nil {
+			continue
+		}
+		var v bson.D
+		if err := convert(option.value, &v); err != nil {
+			return nil, fmt.Errorf("fake: invalid %s: %w", option.key, err)
+		}
+		spec = append(spec, bson.E{Key: option.key, Value: v})
+	}
+	if weights != nil {
+		spec = append(spec, bson.E{Key: "weights", Value: weights}, bson.E{Key: "default_language", Value: "english"})
+	}
+	return spec, nil
+}
+
+func (f *Fake) DropIndex(ctx context.Context, db, coll, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ns, err := f.existing(db, coll)
+	if err != nil {
+		return err
+	}
+	if name == "_id_" {
+		return commandError(72, "InvalidOptions", "cannot drop _id index")
+	}
+	for i, ix := range ns.indexes {
+		if field(ix, "name") == name {
+			ns.indexes = append(ns.indexes[:i:i], ns.indexes[i+1:]...)
+			return nil
+		}
+	}
+	return commandError(27, "IndexNotFound", "index not found with name [%s]", name)
+}
+
+// ListSearchIndexes lists the search indexes of db.coll, which the Fake
+// builds at once, as READY and queryable.
+func (f *Fake) ListSearchIndexes(ctx context.Context, db, coll, name string) (Cursor, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ns, err := f.existing(db, coll)
+	if err != nil {
+		return nil, err
+	}
+	var indexes []bson.D
+	for _, ix := range ns.searchIndexes {
+		if name == "" || field(ix, "name") == name {
+			indexes = append(indexes, ix)
+		}
+	}
+	return newFakeCursor(indexes), nil
+}
+
+func (f *Fake) CreateSearchIndex(ctx context.Context, db, coll string, index mongo.SearchIndexModel) (string, error) {
+	var definition bson.D
+	if err := convert(index.Definition, &definition); err != nil {
+		return "", fmt.Errorf("fake: invalid search index definition: %w", err)
+	}
+	name, kind := "default", "search"
+	if o := index.Options; o != nil {
+		if o.Name != nil {
+			name = *o.Name
+		}
+		if o.Type != nil {
+			kind = *o.Type
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ns, err := f.existing(db, coll)
+	if err != nil {
+		return "", err
+	}
+	for _, ix := range ns.searchIndexes {
+		if field(ix, "name") == name {
+			return "", commandError(68, "IndexAlreadyExists", "Index %s already exists.", name)
+		}
+	}
+	ns.searchIndexes = append(ns.searchIndexes, bson.D{
+		{Key: "id", Value: fmt.Sprintf("%024x", len(ns.searchIndexes)+1)},
+		{Key: "name", Value: name},
+		{Key: "type", Value: kind},
+		{Key: "status", Value: "READY"},
+		{Key: "queryable", Value: true},
+		{Key: "latestDefinition", Value: definition},
+	})
+	return name, nil
+}
+
+func (f *Fake) DropSearchIndex(ctx context.Context, db, coll, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ns, err := f.existing(db, coll)
+	if err != nil {
+		return err
+	}
+	for i, ix := range ns.searchIndexes {
+		if field(ix, "name") == name {
+			ns.searchIndexes = append(ns.searchIndexes[:i:i], ns.searchIndexes[i+1:]...)
+			return nil
+		}
+	}
+	return commandError(27, "IndexNotFound", "Index %s not found.", name)
+}
+
+// collMod changes the validation, expiry and pre- and post-images of a
+// collection, and the expiry and visibility of one of its indexes, which
+// the reply reports as <option>_old and <option>_new.
+func (f *Fake) collMod(db string, command bson.D) (bson.D, error) {
+	coll, _ := command[0].Value.(string)
+	ns, err := f.existing(db, coll)
+	if err != nil {
+		return nil, err
+	}
+	var reply bson.D
+	for _, e := range command[1:] {
+		switch e.Key {
+		case "validator", "validationLevel", "validationAction", "expireAfterSeconds", "changeStreamPreAndPostImages":
+			if ns.options, err = setPath(ns.options, e.Key, e.Value); err != nil {
+				return nil, err
+			}
+		case "index":
+			if reply, err = ns.modifyIndex(db, coll, e.Value); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, commandError(72, "InvalidOptions", "unknown option to collMod: %s", e.Key)
+		}
+	}
+	return reply, nil
+}
+
+// modifyIndex applies the index option of collMod, an index named or
+// given by its key pattern with the options to change.
+func (ns *fakeNamespace) modifyIndex(db, coll string, v interface{}) (bson.D, error) {
+	spec, _ := v.(bson.D)
+	name, byName := field(spec, "name").(string)
+	pattern, _ := field(spec, "keyPattern").(bson.D)
+	for i, ix := range ns.indexes {
+		if byName && field(ix, "name") != name || !byName && !sameDocument(field(ix, "key").(bson.D), pattern) {
+			continue
+		}
+		var reply bson.D
+		for _, option := range []string{"expireAfterSeconds", "hidden"} {
+			value := field(spec, option)
+			if value == nil {
+				continue
+			}
+			if old := field(ix, option); old != nil {
+				reply = append(reply, bson.E{Key: option + "_old", Value: old})
+			} else if option == "hidden" {
+				reply = append(reply, bson.E{Key: option + "_old", Value: false})
+			}
+			reply = append(reply, bson.E{Key: option + "_new", Value: value})
+			updated, err := setPath(ix, option, value)
+			if err != nil {
+				return nil, err
+			}
+			ns.indexes[i], ix = updated, updated
+		}
+		return reply, nil
+	}
+	return nil, commandError(27, "IndexNotFound", "cannot find index %v for ns %s.%s", field(spec, "name"), db, coll)
+}
+
+// explain answers explain of a find or count with the plan of its filter:
+// an IXSCAN of the first visible index whose first field the filter
+// restricts, or a COLLSCAN.
+func (f *Fake) explain(db string, command bson.D) (bson.D, error) {
+	explained, _ := command[0].Value.(bson.D)
+	if len(explained) == 0 {
+		return nil, commandError(2, "BadValue", "explain needs a command")
+	}
+	coll, _ := explained[0].Value.(string)
+	filter := field(explained, "filter")
+	if explained[0].Key == "count" {
+		filter = field(explained, "query")
+	}
+	var query bson.D
+	if filter != nil {
+		if err := convert(filter, &query); err != nil {
+			return nil, err
+		}
+	}
+	plan := bson.D{{Key: "stage", Value: "COLLSCAN"}}
+	if index := f.plannedIndex(db, coll, query); index != "" {
+		plan = bson.D{{Key: "stage", Value: "FETCH"}, {Key: "inputStage", Value: bson.D{{Key: "stage", Value: "IXSCAN"}, {Key: "indexName", Value: index}}}}
+	}
+	return bson.D{{Key: "queryPlanner", Value: bson.D{
+		{Key: "namespace", Value: db + "." + coll},
+		{Key: "winningPlan", Value: plan},
+	}}}, nil
+}
+
+// plannedIndex returns the name of the index a query with filter would
+// use, "" for a collection scan.
+func (f *Fake) plannedIndex(db, coll string, query bson.D) string {
+	ns := f.namespace(db, coll)
+	if ns == nil {
+		return ""
+	}
+	for _, ix := range ns.indexes {
+		key, _ := field(ix, "key").(bson.D)
+		if hidden, _ := field(ix, "hidden").(bool); hidden || len(key) == 0 || key[0].Key == "_fts" {
+			continue
+		}
+		for _, e := range query {
+			if e.Key == key[0].Key {
+				return field(ix, "name").(string)
+			}
+		}
+	}
+	return ""
+}
+
+// collStats answers collStats with the count and sizes of a collection;
+// those of a missing one are zero, as on the server.
+func (f *Fake) collStats(db string, command bson.D) (bson.D, error) {
+	coll, _ := command[0].Value.(string)
+	docs := f.dbs[db][coll]
+	var size int64
+	for _, doc := range docs {
+		data, err := bson.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		size += int64(len(data))
+	}
+	reply := bson.D{{Key: "ns", Value: db + "." + coll}, {Key: "count", Value: int64(len(docs))}, {Key: "size", Value: size}}
+	if len(docs) > 0 {
+		reply = append(reply, bson.E{Key: "avgObjSize", Value: size / int64(len(docs))})
+	}
+	ns := f.namespace(db, coll)
+	if ns == nil {
+		return append(reply, bson.E{Key: "storageSize", Value: int64(0)}, bson.E{Key: "nindexes", Value: int32(0)}, bson.E{Key: "totalIndexSize", Value: int64(0)}), nil
+	}
+	if ns.kind == "view" {
+		return nil, commandError(166, "CommandNotSupportedOnView", "Namespace %s.%s is a view, not a collection", db, coll)
+	}
+	indexSizes := bson.D{}
+	for _, ix := range ns.indexes {
+		indexSizes = append(indexSizes, bson.E{Key: field(ix, "name").(string), Value: int64(emptyTableSize)})
+	}
+	reply = append(reply,
+		bson.E{Key: "storageSize", Value: max(size, emptyTableSize)},
+		bson.E{Key: "nindexes", Value: int32(len(ns.indexes))},
+		bson.E{Key: "totalIndexSize", Value: int64(emptyTableSize * len(ns.indexes))},
+		bson.E{Key: "indexSizes", Value: indexSizes})
+	capped, _ := field(ns.options, "capped").(bool)
+	reply = append(reply, bson.E{Key: "capped", Value: capped})
+	if capped {
+		reply = append(reply, bson.E{Key: "maxSize", Value: field(ns.options, "size")})
+	}
+	if ns.kind == "timeseries" {
+		reply = append(reply, bson.E{Key: "timeseries", Value: bson.D{
+			{Key: "bucketsNs", Value: db + ".system.buckets." + coll},
+			{Key: "bucketCount", Value: int64(len(docs))},
+			{Key: "numMeasurementsCommitted", Value: int64(len(docs))},
+		}})
+	}
+	return reply, nil
+}
+
+// dbStats answers dbStats with the totals of the collections of db.
+func (f *Fake) dbStats(db string) (bson.D, error) {
+	var collections, views, indexes int32
+	var objects, dataSize, storageSize, indexSize int64
+	for _, name := range f.collectionNames(db) {
+		if f.namespace(db, name).kind == "view" {
+			views++
+			continue
+		}
+		stats, err := f.collStats(db, bson.D{{Key: "collStats", Value: name}})
+		if err != nil {
+			return nil, err
+		}
+		collections++
+		objects += field(stats, "count").(int64)
+		dataSize += field(stats, "size").(int64)
+		storageSize += field(stats, "storageSize").(int64)
+		indexes += field(stats, "nindexes").(int32)
+		indexSize += field(stats, "totalIndexSize").(int64)
+	}
+	return bson.D{
+		{Key: "db", Value: db},
+		{Key: "collections", Value: collections},
+		{Key: "views", Value: views},
+		{Key: "objects", Value: objects},
+		{Key: "dataSize", Value: dataSize},
+		{Key: "storageSize", Value: storageSize},
+		{Key: "indexes", Value: indexes},
+		{Key: "indexSize", Value: indexSize},
+	}, nil
+}
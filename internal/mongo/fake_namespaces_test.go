@@ -0,0 +1,47 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestFakeNamespaceErrors(t *testing.T) {
+	fake := NewFake()
+	fake.Seed("db", "c", bson.D{{Key: "n", Value: 1}})
+	ctx := context.Background()
+	if err := fake.CreateView(ctx, "db", "v", "c", bson.A{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fake.CreateIndex(ctx, "db", "c", mongo.IndexModel{Keys: bson.D{{Key: "n", Value: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, textErr := fake.Find(ctx, "db", "c", bson.D{{Key: "$text", Value: bson.D{{Key: "$search", Value: "x"}}}})
+	_, viewErr := fake.InsertOne(ctx, "db", "v", bson.D{})
+
+	tests := []struct {
+		name string
+		err  error
+		code int32
+	}{
+		{"creating an existing collection", fake.CreateCollection(ctx, "db", "c"), 48},
+		{"dropping the _id index", fake.DropIndex(ctx, "db", "c", "_id_"), 72},
+		{"dropping a missing index", fake.DropIndex(ctx, "db", "c", "missing"), 27},
+		{"dropping an index of a missing collection", fake.DropIndex(ctx, "db", "missing", "n_1"), 26},
+		{"text search without a text index", textErr, 27},
+		{"writing to a view", viewErr, 166},
+	}
+	for _, tt := range tests {
+		var cmdErr mongo.CommandError
+		if !errors.As(tt.err, &cmdErr) || cmdErr.Code != tt.code {
+			t.Errorf("%s: got error %v, want code %d", tt.name, tt.err, tt.code)
+		}
+	}
+	if _, err := fake.CreateIndex(ctx, "db", "c", mongo.IndexModel{Keys: bson.D{{Key: "n", Value: -1}}}); err != nil {
+		t.Errorf("an index on the same field in another direction: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestFakeFilters(t *testing.T) {
+	fake := NewFake()
+	fake.Seed("db", "c",
+		bson.D{{Key: "n", Value: 1}, {Key: "tags", Value: bson.A{"a", "b"}}, {Key: "addr", Value: bson.D{{Key: "city", Value: "Oslo"}}}},
+		bson.D{{Key: "n", Value: 2}, {Key: "tags", Value: bson.A{"b"}}},
+		bson.D{{Key: "n", Value: 3}},
+	)
+	tests := []struct {
+		filter string
+		want   int64
+	}{
+		{`{}`, 3},
+		{`{"n": 2}`, 1},
+		{`{"n": {"$gte": 2}}`, 2},
+		{`{"n": {"$gt": 1, "$lt": 3}}`, 1},
+		{`{"n": {"$ne": 1}}`, 2},
+		{`{"n": {"$in": [1, 3]}}`, 2},
+		{`{"n": {"$nin": [1, 3]}}`, 1},
+		{`{"tags": "a"}`, 1},
+		{`{"tags": "b"}`, 2},
+		{`{"tags": {"$exists": false}}`, 1},
+		{`{"addr.city": "Oslo"}`, 1},
+		{`{"tags.0": "b"}`, 1},
+		{`{"tags.1": {"$exists": true}}`, 1},
+		{`{"$or": [{"n": 1}, {"n": 3}]}`, 2},
+		{`{"$and": [{"tags": "b"}, {"n": {"$gt": 1}}]}`, 1},
+		{`{"n": {"$type": "number"}}`, 3},
+		{`{"tags": {"$type": "array"}}`, 2},
+		{`{"addr": {"$type": ["object", "string"]}}`, 1},
+	}
+	for _, tt := range tests {
+		var filter bson.D
+		if err := bson.UnmarshalExtJSON([]byte(tt.filter), false, &filter); err != nil {
+			t.Fatal(err)
+		}
+		got, err := fake.CountDocuments(context.Background(), "db", "c", filter)
+		if err != nil {
+			t.Errorf("%s: %v", tt.filter, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: matched %d documents, want %d", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestFakeFindOptions(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	for _, n := range []int{3, 1, 4, 1, 5} {
+		fake.Seed("db", "c", bson.D{{Key: "n", Value: n}})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "n", Value: -1}}).SetSkip(1).SetLimit(2)
+	cur, err := fake.Find(ctx, "db", "c", bson.D{}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var docs []struct {
+		N int `bson:"n"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[0].N != 4 || docs[1].N != 3 {
+		t.Errorf("sorted, skipped and limited: got %+v", docs)
+	}
+	for _, direction := range []interface{}{int64(-1), -1.0} { // What relaxed JSON parses -1 into
+		cur, err := fake.Find(ctx, "db", "c", bson.D{}, options.Find().SetSort(bson.D{{Key: "n", Value: direction}}).SetLimit(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cur.All(ctx, &docs); err != nil {
+			t.Fatal(err)
+		}
+		if len(docs) != 1 || docs[0].N != 5 {
+			t.Errorf("sorted by %T -1: got %+v", direction, docs)
+		}
+	}
+}
+
+func TestFakeInsertAndFindOne(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	id, err := fake.InsertOne(ctx, "db", "new", bson.M{"name": "x"})
+	if err != nil || id == nil {
+		t.Fatalf("InsertOne: %v, %v", id, err)
+	}
+	doc, err := fake.FindOne(ctx, "db", "new", bson.D{{Key: "_id", Value: id}})
+	if err != nil || doc["name"] != "x" {
+		t.Errorf("FindOne by the inserted _id: %v, %v", doc, err)
+	}
+	if _, err := fake.FindOne(ctx, "db", "new", bson.D{{Key: "name", Value: "y"}}); err != ErrNoDocuments {
+		t.Errorf("FindOne without a match: got %v, want ErrNoDocuments", err)
+	}
+	names, _ := fake.ListDatabaseNames(ctx)
+	if len(names) != 1 || names[0] != "db" {
+		t.Errorf("databases after inserting: %v", names)
+	}
+}
+
+// countingCatalog counts how often the cache asks it.
+type countingCatalog struct {
+	Catalog
+	dbCalls, nsCalls int
+}
+
+func (c *countingCatalog) ListDatabaseNames(ctx context.Context) ([]string, error) {
+	c.dbCalls++
+	return c.Catalog.ListDatabaseNames(ctx)
+}
+
+func (c *countingCatalog) ListNamespaces(ctx context.Context, db string) ([]Namespace, error) {
+	c.nsCalls++
+	return c.Catalog.ListNamespaces(ctx, db)
+}
+
+func TestNamespaceCache(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.Seed("a", "x")
+	catalog := &countingCatalog{Catalog: fake}
+	cache := NewNamespaceCache(catalog)
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.Databases(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cache.Collections(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if catalog.dbCalls != 1 || catalog.nsCalls != 1 {
+		t.Errorf("listed databases %d and namespaces %d times, want once each", catalog.dbCalls, catalog.nsCalls)
+	}
+
+	fake.Seed("a", "y")
+	cache.InvalidateDB("a")
+	colls, _ := cache.Collections(ctx, "a")
+	if len(colls) != 2 {
+		t.Errorf("after InvalidateDB: got %v", colls)
+	}
+	cache.Invalidate()
+	cache.Databases(ctx)
+	if catalog.dbCalls != 2 {
+		t.Errorf("database list not refetched after invalidation: %d calls", catalog.dbCalls)
+	}
+}
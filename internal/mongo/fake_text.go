@@ -0,0 +1,58 @@
+package mongo
+
+import (
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Text search in the Fake: {$text: {$search: "..."}} matches the documents
+// with any of the terms in a field of the collection's text index, scored
+// by the weighted number of occurrences. There is no stemming and there are
+// no stop words, phrases or negations.
+
+// textWeights returns the fields and weights of the text index of db.coll.
+func (f *Fake) textWeights(db, coll string) (bson.D, error) {
+	if ns := f.namespace(db, coll); ns != nil {
+		for _, ix := range ns.indexes {
+			if weights, ok := field(ix, "weights").(bson.D); ok {
+				return weights, nil
+			}
+		}
+	}
+	return nil, commandError(27, "IndexNotFound", "text index required for $text query")
+}
+
+// textScore is the score of doc for the terms of search, 0 if none of them
+// is in a field of weights.
+func textScore(doc bson.M, weights bson.D, search string) float64 {
+	terms := words(search)
+	var score float64
+	for _, w := range weights {
+		weight, _ := number(w.Value)
+		text, _ := lookup(doc, w.Key).(string)
+		for _, word := range words(text) {
+			for _, term := range terms {
+				if word == term {
+					score += weight
+				}
+			}
+		}
+	}
+	return score
+}
+
+// words splits s into lower-case words.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// isTextScore reports whether v is {$meta: "textScore"}, in a converted
+// sort or projection.
+func isTextScore(v interface{}) bool {
+	meta, ok := v.(bson.D)
+	return ok && len(meta) == 1 && meta[0].Key == "$meta" && meta[0].Value == "textScore"
+}
@@ -0,0 +1,796 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The writes of the Fake. Updates support $set, $setOnInsert, $unset, $inc,
+// $mul, $min, $max, $rename, $push and $addToSet (with $each), $pull and
+// $currentDate, on dotted paths, but not pipelines. An upsert starts from
+// the equalities of the filter, as on the server. FindOneAndUpdate and
+// FindOneAndDelete support sort, projections of top-level fields and, for
+// updates, returnDocument. Collations, hints and array filters are ignored.
+
+func (f *Fake) UpdateOne(ctx context.Context, db, coll string, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	o := options.MergeUpdateOptions(opts...)
+	return f.update(db, coll, filter, update, false, o.Upsert != nil && *o.Upsert)
+}
+
+func (f *Fake) UpdateMany(ctx context.Context, db, coll string, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	o := options.MergeUpdateOptions(opts...)
+	return f.update(db, coll, filter, update, true, o.Upsert != nil && *o.Upsert)
+}
+
+// update applies update to the first document of db.coll matching filter,
+// or with many to all of them, and inserts one if none matches and upsert
+// is set.
+func (f *Fake) update(db, coll string, filter, update interface{}, many, upsert bool) (*mongo.UpdateResult, error) {
+	ops, err := updateOperators(update)
+	if err != nil {
+		return nil, err
+	}
+	defer f.activity.op(db+"."+coll, "writes", time.Now())
+	f.activity.count("opcounters.update", 1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.writable(db, coll); err != nil {
+		return nil, err
+	}
+	docs, err := f.match(db, coll, filter)
+	if err != nil {
+		return nil, err
+	}
+	res := &mongo.UpdateResult{}
+	defer func() { f.activity.count("metrics.document.updated", res.ModifiedCount) }()
+	for _, d := range docs {
+		updated, err := applyUpdate(d.doc, ops, false)
+		if err != nil {
+			return res, err
+		}
+		res.MatchedCount++
+		if !sameDocument(d.doc, updated) {
+			f.dbs[db][coll][d.index] = updated
+			res.ModifiedCount++
+		}
+		if !many {
+			break
+		}
+	}
+	if len(docs) == 0 && upsert {
+		doc, err := upsertDocument(filter)
+		if err != nil {
+			return nil, err
+		}
+		if doc, err = applyUpdate(doc, ops, true); err != nil {
+			return nil, err
+		}
+		doc = withID(doc)
+		f.add(db, coll, doc)
+		f.activity.count("metrics.document.inserted", 1)
+		res.UpsertedCount, res.UpsertedID = 1, idOf(doc)
+	}
+	return res, nil
+}
+
+// ReplaceOne replaces the first document matching filter, keeping its _id.
+// An upsert inserts the replacement with the _id the filter requires, if
+// any.
+func (f *Fake) ReplaceOne(ctx context.Context, db, coll string, filter, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	o := options.MergeReplaceOptions(opts...)
+	var doc bson.D
+	if err := convert(replacement, &doc); err != nil {
+		return nil, fmt.Errorf("fake: invalid replacement: %w", err)
+	}
+	for _, e := range doc {
+		if strings.HasPrefix(e.Key, "$") {
+			return nil, fmt.Errorf("fake: the replacement has operator %s", e.Key)
+		}
+	}
+	defer f.activity.op(db+"."+coll, "writes", time.Now())
+	f.activity.count("opcounters.update", 1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.writable(db, coll); err != nil {
+		return nil, err
+	}
+	docs, err := f.match(db, coll, filter)
+	if err != nil {
+		return nil, err
+	}
+	res := &mongo.UpdateResult{}
+	defer func() { f.activity.count("metrics.document.updated", res.ModifiedCount) }()
+	if len(docs) > 0 {
+		current := docs[0].doc
+		id := idOf(current)
+		if newID := idOf(doc); newID != nil && !sameValue(newID, id) {
+			return nil, fmt.Errorf("fake: the replacement would change the immutable field '_id'")
+		}
+		replaced := append(bson.D{{Key: "_id", Value: id}}, withoutID(doc)...)
+		res.MatchedCount = 1
+		if !sameDocument(current, replaced) {
+			f.dbs[db][coll][docs[0].index] = replaced
+			res.ModifiedCount = 1
+		}
+		return res, nil
+	}
+	if o.Upsert == nil || !*o.Upsert {
+		return res, nil
+	}
+	if idOf(doc) == nil {
+		start, err := upsertDocument(filter)
+		if err != nil {
+			return nil, err
+		}
+		if id := idOf(start); id != nil {
+			doc = append(bson.D{{Key: "_id", Value: id}}, doc...)
+		}
+	}
+	doc = withID(doc)
+	f.add(db, coll, doc)
+	f.activity.count("metrics.document.inserted", 1)
+	res.UpsertedCount, res.UpsertedID = 1, idOf(doc)
+	return res, nil
+}
+
+func (f *Fake) DeleteMany(ctx context.Context, db, coll string, filter interface{}) (int64, error) {
+	return f.delete(db, coll, filter, true)
+}
+
+// delete deletes the first document of db.coll matching filter, or with
+// many all of them, and returns how many it deleted.
+func (f *Fake) delete(db, coll string, filter interface{}, many bool) (int64, error) {
+	defer f.activity.op(db+"."+coll, "writes", time.Now())
+	f.activity.count("opcounters.delete", 1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.writable(db, coll); err != nil {
+		return 0, err
+	}
+	docs, err := f.match(db, coll, filter)
+	if err != nil {
+		return 0, err
+	}
+	if !many && len(docs) > 1 {
+		docs = docs[:1]
+	}
+	f.remove(db, coll, docs)
+	f.activity.count("metrics.document.deleted", int64(len(docs)))
+	return int64(len(docs)), nil
+}
+
+// remove takes docs out of db.coll, with f.mu held.
+func (f *Fake) remove(db, coll string, docs []storedDoc) {
+	if len(docs) == 0 {
+		return
+	}
+	removed := map[int]bool{}
+	for _, d := range docs {
+		removed[d.index] = true
+	}
+	kept := []bson.D{}
+	for i, d := range f.dbs[db][coll] {
+		if !removed[i] {
+			kept = append(kept, d)
+		}
+	}
+	f.dbs[db][coll] = kept
+}
+
+// FindOneAndUpdate updates the first document matching filter in sort
+// order and returns it as it was before, or after with
+// SetReturnDocument(options.After).
+func (f *Fake) FindOneAndUpdate(ctx context.Context, db, coll string, filter, update interface{}, opts ...*options.FindOneAndUpdateOptions) (bson.D, error) {
+	o := options.MergeFindOneAndUpdateOptions(opts...)
+	ops, err := updateOperators(update)
+	if err != nil {
+		return nil, err
+	}
+	defer f.activity.op(db+"."+coll, "writes", time.Now())
+	f.activity.count("opcounters.command", 1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.writable(db, coll); err != nil {
+		return nil, err
+	}
+	docs, err := f.match(db, coll, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := sortDocs(docs, o.Sort); err != nil {
+		return nil, err
+	}
+	var before, after bson.D
+	switch {
+	case len(docs) > 0:
+		before = docs[0].doc
+		if after, err = applyUpdate(before, ops, false); err != nil {
+			return nil, err
+		}
+		f.dbs[db][coll][docs[0].index] = after
+		f.activity.count("metrics.document.updated", 1)
+	case o.Upsert != nil && *o.Upsert:
+		start, err := upsertDocument(filter)
+		if err != nil {
+			return nil, err
+		}
+		if after, err = applyUpdate(start, ops, true); err != nil {
+			return nil, err
+		}
+		after = withID(after)
+		f.add(db, coll, after)
+		f.activity.count("metrics.document.inserted", 1)
+	}
+	doc := before
+	if o.ReturnDocument != nil && *o.ReturnDocument == options.After {
+		doc = after
+	}
+	if doc == nil {
+		return nil, ErrNoDocuments
+	}
+	return project(doc, o.Projection)
+}
+
+// FindOneAndDelete deletes the first document matching filter in sort
+// order and returns it.
+func (f *Fake) FindOneAndDelete(ctx context.Context, db, coll string, filter interface{}, opts ...*options.FindOneAndDeleteOptions) (bson.D, error) {
+	o := options.MergeFindOneAndDeleteOptions(opts...)
+	defer f.activity.op(db+"."+coll, "writes", time.Now())
+	f.activity.count("opcounters.command", 1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.writable(db, coll); err != nil {
+		return nil, err
+	}
+	docs, err := f.match(db, coll, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := sortDocs(docs, o.Sort); err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrNoDocuments
+	}
+	f.remove(db, coll, docs[:1])
+	f.activity.count("metrics.document.deleted", 1)
+	return project(docs[0].doc, o.Projection)
+}
+
+// BulkWrite runs models in order. An ordered bulk write stops at the first
+// operation that fails, an unordered one runs them all; either returns the
+// failures as a mongo.BulkWriteException, with the result of the others.
+func (f *Fake) BulkWrite(ctx context.Context, db, coll string, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	o := options.MergeBulkWriteOptions(opts...)
+	ordered := o.Ordered == nil || *o.Ordered
+	res := &mongo.BulkWriteResult{UpsertedIDs: map[int64]interface{}{}}
+	var failed mongo.BulkWriteException
+	for i, model := range models {
+		err := f.write(ctx, db, coll, model, int64(i), res)
+		if err == nil {
+			continue
+		}
+		we := mongo.WriteError{Index: i, Message: err.Error()}
+		var exception mongo.WriteException
+		if errors.As(err, &exception) && len(exception.WriteErrors) > 0 {
+			we.Code, we.Message = exception.WriteErrors[0].Code, exception.WriteErrors[0].Message
+		}
+		failed.WriteErrors = append(failed.WriteErrors, mongo.BulkWriteError{WriteError: we, Request: model})
+		if ordered {
+			break
+		}
+	}
+	if len(failed.WriteErrors) > 0 {
+		return res, failed
+	}
+	return res, nil
+}
+
+// write runs model, operation i of a bulk write, adding what it did to res.
+func (f *Fake) write(ctx context.Context, db, coll string, model mongo.WriteModel, i int64, res *mongo.BulkWriteResult) error {
+	var updated *mongo.UpdateResult
+	var err error
+	switch m := model.(type) {
+	case *mongo.InsertOneModel:
+		if _, err := f.InsertOne(ctx, db, coll, m.Document); err != nil {
+			return err
+		}
+		res.InsertedCount++
+		return nil
+	case *mongo.UpdateOneModel:
+		updated, err = f.update(db, coll, m.Filter, m.Update, false, m.Upsert != nil && *m.Upsert)
+	case *mongo.UpdateManyModel:
+		updated, err = f.update(db, coll, m.Filter, m.Update, true, m.Upsert != nil && *m.Upsert)
+	case *mongo.ReplaceOneModel:
+		updated, err = f.ReplaceOne(ctx, db, coll, m.Filter, m.Replacement, options.Replace().SetUpsert(m.Upsert != nil && *m.Upsert))
+	case *mongo.DeleteOneModel:
+		n, err := f.delete(db, coll, m.Filter, false)
+		res.DeletedCount += n
+		return err
+	case *mongo.DeleteManyModel:
+		n, err := f.delete(db, coll, m.Filter, true)
+		res.DeletedCount += n
+		return err
+	default:
+		return fmt.Errorf("fake: unsupported write model %T", model)
+	}
+	if err != nil {
+		return err
+	}
+	res.MatchedCount += updated.MatchedCount
+	res.ModifiedCount += updated.ModifiedCount
+	res.UpsertedCount += updated.UpsertedCount
+	if updated.UpsertedID != nil {
+		res.UpsertedIDs[i] = updated.UpsertedID
+	}
+	return nil
+}
+
+// updateOperators checks that update is a document of update operators.
+func updateOperators(update interface{}) (bson.D, error) {
+	if v := reflect.ValueOf(update); v.Kind() == reflect.Slice && v.Type() != reflect.TypeOf(bson.D{}) {
+		return nil, fmt.Errorf("fake: update pipelines are not supported")
+	}
+	var ops bson.D
+	if err := convert(update, &ops); err != nil {
+		return nil, fmt.Errorf("fake: invalid update: %w", err)
+	}
+	for _, op := range ops {
+		if !strings.HasPrefix(op.Key, "$") {
+			return nil, fmt.Errorf("fake: the update has field %s instead of an operator", op.Key)
+		}
+		if _, ok := op.Value.(bson.D); !ok {
+			return nil, fmt.Errorf("fake: %s takes a document", op.Key)
+		}
+	}
+	return ops, nil
+}
+
+// applyUpdate returns a copy of doc with the update operators ops applied.
+// inserting is set for the document of an upsert, which $setOnInsert
+// applies to.
+func applyUpdate(doc bson.D, ops bson.D, inserting bool) (bson.D, error) {
+	var out bson.D
+	if err := convert(doc, &out); err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		for _, e := range op.Value.(bson.D) {
+			current, exists := getPath(out, e.Key)
+			var err error
+			switch op.Key {
+			case "$set":
+				out, err = setPath(out, e.Key, e.Value)
+			case "$setOnInsert":
+				if inserting {
+					out, err = setPath(out, e.Key, e.Value)
+				}
+			case "$unset":
+				out = unsetPath(out, e.Key)
+			case "$inc", "$mul":
+				if _, ok := number(e.Value); !ok {
+					return nil, fmt.Errorf("fake: %s of %s needs a number", op.Key, e.Key)
+				}
+				if !exists {
+					initial := zeroOf(e.Value) // A missing field multiplies to 0
+					if op.Key == "$inc" {
+						initial = e.Value
+					}
+					out, err = setPath(out, e.Key, initial)
+					break
+				}
+				if _, ok := number(current); !ok {
+					return nil, fmt.Errorf("fake: cannot apply %s to %s, which is not a number", op.Key, e.Key)
+				}
+				out, err = setPath(out, e.Key, arithmetic(op.Key, current, e.Value))
+			case "$min", "$max":
+				c := compare(e.Value, current)
+				if !exists || (op.Key == "$min" && c < 0) || (op.Key == "$max" && c > 0) {
+					out, err = setPath(out, e.Key, e.Value)
+				}
+			case "$rename":
+				to, ok := e.Value.(string)
+				if !ok {
+					return nil, fmt.Errorf("fake: $rename of %s needs a field name", e.Key)
+				}
+				if exists {
+					out, err = setPath(unsetPath(out, e.Key), to, current)
+				}
+			case "$push", "$addToSet":
+				var arr bson.A
+				if exists {
+					a, ok := current.(bson.A)
+					if !ok {
+						return nil, fmt.Errorf("fake: %s needs an array at %s", op.Key, e.Key)
+					}
+					arr = append(arr, a...)
+				}
+				values := bson.A{e.Value}
+				if each, ok := e.Value.(bson.D); ok && len(each) > 0 && each[0].Key == "$each" {
+					if len(each) > 1 {
+						return nil, fmt.Errorf("fake: %s only supports $each, not %s", op.Key, each[1].Key)
+					}
+					values, _ = each[0].Value.(bson.A)
+				}
+				for _, v := range values {
+					if op.Key == "$addToSet" && containsValue(arr, v) {
+						continue
+					}
+					arr = append(arr, v)
+				}
+				out, err = setPath(out, e.Key, arr)
+			case "$pull":
+				if !exists {
+					break
+				}
+				a, ok := current.(bson.A)
+				if !ok {
+					return nil, fmt.Errorf("fake: $pull needs an array at %s", e.Key)
+				}
+				kept := bson.A{}
+				for _, v := range a {
+					pulled, err := pulls(v, e.Value)
+					if err != nil {
+						return nil, err
+					}
+					if !pulled {
+						kept = append(kept, v)
+					}
+				}
+				out, err = setPath(out, e.Key, kept)
+			case "$currentDate":
+				out, err = setPath(out, e.Key, primitive.NewDateTimeFromTime(time.Now()))
+			default:
+				return nil, fmt.Errorf("fake: unsupported update operator %s", op.Key)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !inserting && !sameValue(idOf(out), idOf(doc)) {
+		return nil, fmt.Errorf("fake: the update would change the immutable field '_id'")
+	}
+	return out, nil
+}
+
+// pulls reports whether $pull with cond removes the array element v:
+// elements equal to cond, or matching it as a query.
+func pulls(v, cond interface{}) (bool, error) {
+	query, ok := cond.(bson.D)
+	if !ok || len(query) == 0 || strings.HasPrefix(query[0].Key, "$") {
+		return matchesField(v, cond)
+	}
+	var fields bson.M
+	if err := convert(v, &fields); err != nil {
+		return false, nil // Not a document, so it does not match
+	}
+	return matches(fields, query)
+}
+
+func containsValue(arr bson.A, v interface{}) bool {
+	for _, elem := range arr {
+		if compare(elem, v) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// arithmetic adds or, for $mul, multiplies a and b, keeping integers
+// integers: int32 unless either is an int64 or the result does not fit.
+func arithmetic(op string, a, b interface{}) interface{} {
+	_, aFloat := a.(float64)
+	_, bFloat := b.(float64)
+	if aFloat || bFloat {
+		x, _ := number(a)
+		y, _ := number(b)
+		if op == "$mul" {
+			return x * y
+		}
+		return x + y
+	}
+	x, y := integer(a), integer(b)
+	r := x + y
+	if op == "$mul" {
+		r = x * y
+	}
+	_, aLong := a.(int64)
+	_, bLong := b.(int64)
+	if aLong || bLong || r != int64(int32(r)) {
+		return r
+	}
+	return int32(r)
+}
+
+func integer(v interface{}) int64 {
+	switch n := v.(type) {
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	}
+	return 0
+}
+
+// zeroOf returns 0 of the numeric type of v.
+func zeroOf(v interface{}) interface{} {
+	switch v.(type) {
+	case float64:
+		return 0.0
+	case int64:
+		return int64(0)
+	}
+	return int32(0)
+}
+
+// upsertDocument returns the document an upsert starts from: the fields
+// the filter requires to equal a value.
+func upsertDocument(filter interface{}) (bson.D, error) {
+	var query bson.D
+	if filter != nil {
+		if err := convert(filter, &query); err != nil {
+			return nil, fmt.Errorf("fake: invalid filter: %w", err)
+		}
+	}
+	doc := bson.D{}
+	for _, e := range query {
+		if e.Key == "$and" {
+			clauses, _ := e.Value.(bson.A)
+			for _, clause := range clauses {
+				sub, err := upsertDocument(clause)
+				if err != nil {
+					return nil, err
+				}
+				doc = append(doc, sub...)
+			}
+			continue
+		}
+		if strings.HasPrefix(e.Key, "$") {
+			continue
+		}
+		value := e.Value
+		if ops, ok := value.(bson.D); ok && len(ops) > 0 && strings.HasPrefix(ops[0].Key, "$") {
+			if len(ops) != 1 || ops[0].Key != "$eq" {
+				continue
+			}
+			value = ops[0].Value
+		}
+		var err error
+		if doc, err = setPath(doc, e.Key, value); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// project applies a projection of top-level fields to doc: inclusions keep
+// the fields named, exclusions drop them, and _id is kept unless excluded.
+func project(doc bson.D, projection interface{}) (bson.D, error) {
+	if projection == nil {
+		return doc, nil
+	}
+	var spec bson.D
+	if err := convert(projection, &spec); err != nil {
+		return nil, fmt.Errorf("fake: invalid projection: %w", err)
+	}
+	fields := map[string]bool{}
+	showID, including, excluding := true, false, false
+	for _, e := range spec {
+		n, isNumber := number(e.Value)
+		on := (isNumber && n != 0) || e.Value == true
+		if e.Key == "_id" {
+			showID = on
+			continue
+		}
+		fields[e.Key] = on
+		including, excluding = including || on, excluding || !on
+	}
+	if including && excluding {
+		return nil, fmt.Errorf("fake: a projection cannot both include and exclude fields")
+	}
+	var out bson.D
+	for _, e := range doc {
+		switch {
+		case e.Key == "_id":
+			if showID {
+				out = append(out, e)
+			}
+		case including:
+			if fields[e.Key] {
+				out = append(out, e)
+			}
+		default:
+			if _, excluded := fields[e.Key]; !excluded {
+				out = append(out, e)
+			}
+		}
+	}
+	return out, nil
+}
+
+// getPath returns the value at a dotted path of doc, whose parts name
+// fields of documents or indexes of arrays.
+func getPath(doc bson.D, path string) (interface{}, bool) {
+	var v interface{} = doc
+	for _, key := range strings.Split(path, ".") {
+		switch c := v.(type) {
+		case bson.D:
+			found := false
+			for _, e := range c {
+				if e.Key == key {
+					v, found = e.Value, true
+					break
+				}
+			}
+			if !found {
+				return nil, false
+			}
+		case bson.A:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			v = c[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// setPath sets the value at a dotted path of doc, creating the documents
+// on the way.
+func setPath(doc bson.D, path string, value interface{}) (bson.D, error) {
+	v, err := setIn(doc, path, value)
+	if err != nil {
+		return nil, err
+	}
+	return v.(bson.D), nil
+}
+
+// setIn sets the value at path inside v, a document or an array.
+func setIn(v interface{}, path string, value interface{}) (interface{}, error) {
+	key, rest, nested := strings.Cut(path, ".")
+	switch c := v.(type) {
+	case bson.D:
+		for i, e := range c {
+			if e.Key != key {
+				continue
+			}
+			if !nested {
+				c[i].Value = value
+				return c, nil
+			}
+			child, err := setIn(e.Value, rest, value)
+			if err != nil {
+				return nil, err
+			}
+			c[i].Value = child
+			return c, nil
+		}
+		if !nested {
+			return append(c, bson.E{Key: key, Value: value}), nil
+		}
+		child, err := setIn(bson.D{}, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		return append(c, bson.E{Key: key, Value: child}), nil
+	case bson.A:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("fake: cannot set field %s of an array", key)
+		}
+		for len(c) <= i {
+			c = append(c, nil)
+		}
+		if !nested {
+			c[i] = value
+			return c, nil
+		}
+		elem := c[i]
+		if elem == nil {
+			elem = bson.D{}
+		}
+		if c[i], err = setIn(elem, rest, value); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("fake: cannot set %s in %v, which is not a document", path, v)
+}
+
+// unsetPath removes the field at a dotted path of doc; an array element is
+// set to null instead, as on the server.
+func unsetPath(doc bson.D, path string) bson.D {
+	key, rest, nested := strings.Cut(path, ".")
+	for i, e := range doc {
+		if e.Key != key {
+			continue
+		}
+		if !nested {
+			return append(doc[:i:i], doc[i+1:]...)
+		}
+		switch child := e.Value.(type) {
+		case bson.D:
+			doc[i].Value = unsetPath(child, rest)
+		case bson.A:
+			index, indexRest, deeper := strings.Cut(rest, ".")
+			j, err := strconv.Atoi(index)
+			if err != nil || j < 0 || j >= len(child) {
+				break
+			}
+			if !deeper {
+				child[j] = nil
+			} else if elem, ok := child[j].(bson.D); ok {
+				child[j] = unsetPath(elem, indexRest)
+			}
+		}
+		return doc
+	}
+	return doc
+}
+
+// idOf returns the _id of doc, nil if it has none.
+func idOf(doc bson.D) interface{} {
+	for _, e := range doc {
+		if e.Key == "_id" {
+			return e.Value
+		}
+	}
+	return nil
+}
+
+func withoutID(doc bson.D) bson.D {
+	var out bson.D
+	for _, e := range doc {
+		if e.Key != "_id" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// sameValue reports whether a and b are the same value, numbers of
+// different types included, unlike compare which may order values of
+// different kinds as equal.
+func sameValue(a, b interface{}) bool {
+	if _, ok := number(a); ok {
+		if _, ok := number(b); ok {
+			return compare(a, b) == 0
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// sameDocument reports whether a and b encode to the same BSON.
+func sameDocument(a, b bson.D) bool {
+	x, err := bson.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := bson.Marshal(b)
+	return err == nil && bytes.Equal(x, y)
+}
+
+// duplicateKey is the error of an insert whose _id is taken.
+func duplicateKey(db, coll string, id interface{}) error {
+	return mongo.WriteException{WriteErrors: mongo.WriteErrors{{
+		Code:    11000,
+		Message: fmt.Sprintf("E11000 duplicate key error collection: %s.%s index: _id_ dup key: { _id: %v }", db, coll, id),
+	}}}
+}
@@ -0,0 +1,207 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// doc parses relaxed Extended JSON for the tests.
+func doc(t *testing.T, s string) bson.D {
+	t.Helper()
+	var d bson.D
+	if err := bson.UnmarshalExtJSON([]byte(s), false, &d); err != nil {
+		t.Fatalf("%s: %v", s, err)
+	}
+	return d
+}
+
+// contents returns the documents of db.coll as relaxed Extended JSON,
+// without their _id unless it was chosen, in stored order.
+func contents(t *testing.T, fake *Fake, db, coll string) []string {
+	t.Helper()
+	cur, err := fake.Find(context.Background(), db, coll, bson.D{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for cur.Next(context.Background()) {
+		var d bson.D
+		if err := cur.Decode(&d); err != nil {
+			t.Fatal(err)
+		}
+		if _, generated := idOf(d).(interface{ Hex() string }); generated {
+			d = withoutID(d)
+		}
+		data, err := bson.MarshalExtJSON(d, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, string(data))
+	}
+	return out
+}
+
+func TestFakeUpdateOperators(t *testing.T) {
+	tests := []struct {
+		doc, update, want string
+	}{
+		{`{"a": 1}`, `{"$set": {"b.c": "x"}}`, `{"a":1,"b":{"c":"x"}}`},
+		{`{"a": 1, "b": 2}`, `{"$unset": {"a": ""}}`, `{"b":2}`},
+		{`{"n": 1}`, `{"$inc": {"n": 2, "m": 5}}`, `{"n":3,"m":5}`},
+		{`{"n": 2}`, `{"$inc": {"n": 0.5}}`, `{"n":2.5}`},
+		{`{"n": 3}`, `{"$mul": {"n": 4, "m": 2}}`, `{"n":12,"m":0}`},
+		{`{"n": 3}`, `{"$min": {"n": 1}, "$max": {"m": 7}}`, `{"n":1,"m":7}`},
+		{`{"old": 1}`, `{"$rename": {"old": "new"}}`, `{"new":1}`},
+		{`{"tags": ["a"]}`, `{"$push": {"tags": "b"}}`, `{"tags":["a","b"]}`},
+		{`{"tags": ["a"]}`, `{"$addToSet": {"tags": {"$each": ["a", "c"]}}}`, `{"tags":["a","c"]}`},
+		{`{"items": [{"sku": "x"}, {"sku": "y"}]}`, `{"$pull": {"items": {"sku": "x"}}}`, `{"items":[{"sku":"y"}]}`},
+		{`{"n": [1, 5, 9]}`, `{"$pull": {"n": {"$gte": 5}}}`, `{"n":[1]}`},
+		{`{"a": [{"b": 1}]}`, `{"$set": {"a.0.b": 2}}`, `{"a":[{"b":2}]}`},
+		{`{"a": 1}`, `{"$setOnInsert": {"b": 1}}`, `{"a":1}`},
+	}
+	for _, tt := range tests {
+		fake := NewFake()
+		fake.Seed("db", "c", doc(t, tt.doc))
+		if _, err := fake.UpdateOne(context.Background(), "db", "c", bson.D{}, doc(t, tt.update)); err != nil {
+			t.Errorf("%s on %s: %v", tt.update, tt.doc, err)
+			continue
+		}
+		if got := contents(t, fake, "db", "c"); len(got) != 1 || got[0] != tt.want {
+			t.Errorf("%s on %s: got %v, want %s", tt.update, tt.doc, got, tt.want)
+		}
+	}
+
+	fake := NewFake()
+	fake.Seed("db", "c", doc(t, `{"_id": 1, "s": "x"}`))
+	for _, update := range []string{`{"$inc": {"s": 1}}`, `{"$set": {"_id": 2}}`, `{"$bit": {"s": 1}}`, `{"s": 1}`} {
+		if _, err := fake.UpdateOne(context.Background(), "db", "c", bson.D{}, doc(t, update)); err == nil {
+			t.Errorf("%s: no error", update)
+		}
+	}
+}
+
+func TestFakeUpdateAndUpsert(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.Seed("db", "c", doc(t, `{"_id": 1, "n": 1}`), doc(t, `{"_id": 2, "n": 1}`), doc(t, `{"_id": 3, "n": 2}`))
+
+	res, err := fake.UpdateOne(ctx, "db", "c", doc(t, `{"n": 1}`), doc(t, `{"$set": {"done": true}}`))
+	if err != nil || res.MatchedCount != 1 || res.ModifiedCount != 1 {
+		t.Fatalf("UpdateOne: %+v, %v", res, err)
+	}
+	res, err = fake.UpdateMany(ctx, "db", "c", doc(t, `{"n": 1}`), doc(t, `{"$set": {"done": true}}`))
+	if err != nil || res.MatchedCount != 2 || res.ModifiedCount != 1 {
+		t.Fatalf("UpdateMany, one already done: %+v, %v", res, err)
+	}
+	res, err = fake.UpdateOne(ctx, "db", "c", doc(t, `{"n": 7, "k": {"$eq": "z"}, "m": {"$gt": 1}}`), doc(t, `{"$inc": {"hits": 1}, "$setOnInsert": {"new": true}}`), options.Update().SetUpsert(true))
+	if err != nil || res.MatchedCount != 0 || res.UpsertedCount != 1 || res.UpsertedID == nil {
+		t.Fatalf("upsert: %+v, %v", res, err)
+	}
+	want := []string{`{"_id":1,"n":1,"done":true}`, `{"_id":2,"n":1,"done":true}`, `{"_id":3,"n":2}`, `{"n":7,"k":"z","hits":1,"new":true}`}
+	if got := contents(t, fake, "db", "c"); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("after the updates: %v", got)
+	}
+
+	res, err = fake.UpdateOne(ctx, "db", "other", doc(t, `{"n": 7}`), doc(t, `{"$set": {"a": 1}}`))
+	if err != nil || res.MatchedCount != 0 || res.UpsertedID != nil {
+		t.Errorf("no match without upsert: %+v, %v", res, err)
+	}
+}
+
+func TestFakeReplaceAndDelete(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.Seed("db", "c", doc(t, `{"_id": 1, "n": 1}`), doc(t, `{"_id": 2, "n": 2}`), doc(t, `{"_id": 3, "n": 3}`))
+
+	res, err := fake.ReplaceOne(ctx, "db", "c", doc(t, `{"n": 2}`), doc(t, `{"m": 20}`))
+	if err != nil || res.MatchedCount != 1 || res.ModifiedCount != 1 {
+		t.Fatalf("ReplaceOne: %+v, %v", res, err)
+	}
+	if _, err := fake.ReplaceOne(ctx, "db", "c", doc(t, `{"n": 1}`), doc(t, `{"_id": 9}`)); err == nil {
+		t.Error("ReplaceOne changing _id: no error")
+	}
+	res, err = fake.ReplaceOne(ctx, "db", "c", doc(t, `{"_id": 4}`), doc(t, `{"m": 40}`), options.Replace().SetUpsert(true))
+	if err != nil || res.UpsertedID != int32(4) {
+		t.Fatalf("ReplaceOne upserting: %+v, %v", res, err)
+	}
+
+	n, err := fake.DeleteMany(ctx, "db", "c", doc(t, `{"n": {"$lte": 3}}`))
+	if err != nil || n != 2 {
+		t.Fatalf("DeleteMany: %d, %v", n, err)
+	}
+	if got := contents(t, fake, "db", "c"); len(got) != 2 || got[0] != `{"_id":2,"m":20}` || got[1] != `{"_id":4,"m":40}` {
+		t.Errorf("after replacing and deleting: %v", got)
+	}
+}
+
+func TestFakeFindAndModify(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.Seed("db", "q", doc(t, `{"_id": 1, "at": 3, "status": "ready"}`), doc(t, `{"_id": 2, "at": 1, "status": "ready"}`), doc(t, `{"_id": 3, "at": 2, "status": "done"}`))
+	oldest := bson.D{{Key: "at", Value: 1}}
+	claim := doc(t, `{"$set": {"status": "claimed"}}`)
+
+	before, err := fake.FindOneAndUpdate(ctx, "db", "q", doc(t, `{"status": "ready"}`), claim, options.FindOneAndUpdate().SetSort(oldest))
+	if err != nil || idOf(before) != int32(2) || before.Map()["status"] != "ready" {
+		t.Fatalf("FindOneAndUpdate, before: %v, %v", before, err)
+	}
+	after, err := fake.FindOneAndUpdate(ctx, "db", "q", doc(t, `{"status": "ready"}`), claim,
+		options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.D{{Key: "status", Value: 1}}))
+	if err != nil || len(after) != 2 || idOf(after) != int32(1) || after.Map()["status"] != "claimed" {
+		t.Fatalf("FindOneAndUpdate, after with a projection: %v, %v", after, err)
+	}
+	if _, err := fake.FindOneAndUpdate(ctx, "db", "q", doc(t, `{"status": "ready"}`), claim); err != ErrNoDocuments {
+		t.Errorf("FindOneAndUpdate without a match: %v", err)
+	}
+	upserted, err := fake.FindOneAndUpdate(ctx, "db", "q", doc(t, `{"_id": 4}`), claim, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After))
+	if err != nil || idOf(upserted) != int32(4) || upserted.Map()["status"] != "claimed" {
+		t.Errorf("FindOneAndUpdate upserting: %v, %v", upserted, err)
+	}
+
+	deleted, err := fake.FindOneAndDelete(ctx, "db", "q", bson.D{}, options.FindOneAndDelete().SetSort(bson.D{{Key: "at", Value: -1}}).SetProjection(bson.D{{Key: "at", Value: 0}}))
+	if err != nil || idOf(deleted) != int32(1) || len(deleted) != 2 {
+		t.Fatalf("FindOneAndDelete: %v, %v", deleted, err)
+	}
+	if n, _ := fake.CountDocuments(ctx, "db", "q", bson.D{}); n != 3 {
+		t.Errorf("%d documents left, want 3", n)
+	}
+}
+
+func TestFakeBulkWrite(t *testing.T) {
+	ctx := context.Background()
+	models := []mongo.WriteModel{
+		mongo.NewInsertOneModel().SetDocument(doc(t, `{"_id": 1, "n": 1}`)),
+		mongo.NewInsertOneModel().SetDocument(doc(t, `{"_id": 1, "n": 2}`)), // Duplicate _id
+		mongo.NewUpdateOneModel().SetFilter(doc(t, `{"_id": 1}`)).SetUpdate(doc(t, `{"$inc": {"n": 10}}`)),
+		mongo.NewUpdateManyModel().SetFilter(doc(t, `{"_id": 5}`)).SetUpdate(doc(t, `{"$set": {"n": 5}}`)).SetUpsert(true),
+		mongo.NewDeleteOneModel().SetFilter(doc(t, `{"_id": 5}`)),
+	}
+
+	ordered := NewFake()
+	res, err := ordered.BulkWrite(ctx, "db", "c", models)
+	var bwe mongo.BulkWriteException
+	if !errors.As(err, &bwe) || len(bwe.WriteErrors) != 1 || bwe.WriteErrors[0].Index != 1 || !mongo.IsDuplicateKeyError(err) {
+		t.Fatalf("ordered: %v", err)
+	}
+	if res.InsertedCount != 1 || res.ModifiedCount != 0 {
+		t.Errorf("ordered went on after the failure: %+v", res)
+	}
+
+	unordered := NewFake()
+	res, err = unordered.BulkWrite(ctx, "db", "c", models, options.BulkWrite().SetOrdered(false))
+	if !errors.As(err, &bwe) || len(bwe.WriteErrors) != 1 {
+		t.Fatalf("unordered: %v", err)
+	}
+	if res.InsertedCount != 1 || res.ModifiedCount != 1 || res.UpsertedCount != 1 || res.UpsertedIDs[3] != int32(5) || res.DeletedCount != 1 {
+		t.Errorf("unordered: %+v", res)
+	}
+	if got := contents(t, unordered, "db", "c"); len(got) != 1 || got[0] != `{"_id":1,"n":11}` {
+		t.Errorf("after the unordered bulk write: %v", got)
+	}
+}
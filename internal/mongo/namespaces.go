@@ -0,0 +1,91 @@
+// Package mongo holds mon-go's access to the server that does not depend on
+// the terminal UI. It reaches the server through small interfaces, so the
+// logic built on them can be tested against fakes.
+package mongo
+
+import (
+	"context"
+	"sync"
+)
+
+// Namespace is a collection, view or other namespace of a database.
+type Namespace struct {
+	Name string
+	Kind string // "collection", "view", "timeseries", ...
+}
+
+// Catalog lists the databases and namespaces of a deployment.
+type Catalog interface {
+	ListDatabaseNames(ctx context.Context) ([]string, error)
+	ListNamespaces(ctx context.Context, db string) ([]Namespace, error)
+}
+
+// NamespaceCache keeps database and collection names for the session, so
+// navigating does not list them on the server at every step. It is filled
+// lazily, cleared by `refresh`, and dropped for a database when mon-go itself
+// creates something in it. Operations use it concurrently.
+type NamespaceCache struct {
+	catalog Catalog
+	mu      sync.Mutex
+	dbs     []string
+	colls   map[string][]Namespace
+}
+
+func NewNamespaceCache(catalog Catalog) *NamespaceCache {
+	return &NamespaceCache{catalog: catalog, colls: map[string][]Namespace{}}
+}
+
+// Databases returns the database names, listing them on first use.
+func (c *NamespaceCache) Databases(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	dbs := c.dbs
+	c.mu.Unlock()
+	if dbs != nil {
+		return dbs, nil
+	}
+
+	dbs, err := c.catalog.ListDatabaseNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.dbs = dbs
+	c.mu.Unlock()
+	return dbs, nil
+}
+
+// Collections returns the namespaces of db, listing them on first use.
+func (c *NamespaceCache) Collections(ctx context.Context, db string) ([]Namespace, error) {
+	c.mu.Lock()
+	colls, ok := c.colls[db]
+	c.mu.Unlock()
+	if ok {
+		return colls, nil
+	}
+
+	colls, err := c.catalog.ListNamespaces(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.colls[db] = colls
+	c.mu.Unlock()
+	return colls, nil
+}
+
+// Invalidate forgets everything.
+func (c *NamespaceCache) Invalidate() {
+	c.mu.Lock()
+	c.dbs = nil
+	c.colls = map[string][]Namespace{}
+	c.mu.Unlock()
+}
+
+// InvalidateDB forgets the namespaces of db, and the database list since db
+// may be new.
+func (c *NamespaceCache) InvalidateDB(db string) {
+	c.mu.Lock()
+	c.dbs = nil
+	delete(c.colls, db)
+	c.mu.Unlock()
+}
@@ -0,0 +1,257 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNoDocuments is returned by FindOne when nothing matches.
+var ErrNoDocuments = mongo.ErrNoDocuments
+
+// Store is the server access the navigation, query and write commands
+// need: cd, ls, find, count, insert, update, replace, deletemany, findupdate,
+// finddelete and bulk, the collection, view and index commands (mkdir, view,
+// collmod, schema, stats, ttl, index, search and searchindex), the
+// aggregations of analyze, sample, fieldstats, groupby, pipeline, vsearch,
+// synthesize, latency and measure, and RunCommand for user, role, params,
+// ls -l, track, currentop, slowops and cancelling. Client implements it with
+// the driver and Fake in memory, for tests.
+//
+// What needs a real deployment stays on the driver client: change streams
+// (watchboard), causally consistent sessions and the staleness of
+// secondaries (set causal, served-by annotations), inspecting the
+// deployment at connect time, disconnecting, and compare, which connects to
+// a second deployment.
+type Store interface {
+	Catalog
+	ListDatabases(ctx context.Context) ([]mongo.DatabaseSpecification, error)
+	Find(ctx context.Context, db, coll string, filter interface{}, opts ...*options.FindOptions) (Cursor, error)
+	Aggregate(ctx context.Context, db, coll string, pipeline interface{}, opts ...*options.AggregateOptions) (Cursor, error)
+	FindOne(ctx context.Context, db, coll string, filter interface{}) (bson.M, error)
+	CountDocuments(ctx context.Context, db, coll string, filter interface{}, opts ...*options.CountOptions) (int64, error)
+	InsertOne(ctx context.Context, db, coll string, doc interface{}) (interface{}, error)
+	InsertMany(ctx context.Context, db, coll string, docs []interface{}) ([]interface{}, error)
+	UpdateOne(ctx context.Context, db, coll string, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	UpdateMany(ctx context.Context, db, coll string, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	ReplaceOne(ctx context.Context, db, coll string, filter, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error)
+	DeleteMany(ctx context.Context, db, coll string, filter interface{}) (int64, error)
+	FindOneAndUpdate(ctx context.Context, db, coll string, filter, update interface{}, opts ...*options.FindOneAndUpdateOptions) (bson.D, error)
+	FindOneAndDelete(ctx context.Context, db, coll string, filter interface{}, opts ...*options.FindOneAndDeleteOptions) (bson.D, error)
+	BulkWrite(ctx context.Context, db, coll string, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error)
+	RunCommand(ctx context.Context, db string, cmd, result interface{}) error
+	ListCollections(ctx context.Context, db string, filter interface{}) (Cursor, error)
+	CreateCollection(ctx context.Context, db, coll string, opts ...*options.CreateCollectionOptions) error
+	CreateView(ctx context.Context, db, view, source string, pipeline interface{}) error
+	EstimatedDocumentCount(ctx context.Context, db, coll string) (int64, error)
+	ListIndexes(ctx context.Context, db, coll string) (Cursor, error)
+	CreateIndex(ctx context.Context, db, coll string, index mongo.IndexModel) (string, error)
+	DropIndex(ctx context.Context, db, coll, name string) error
+	ListSearchIndexes(ctx context.Context, db, coll, name string) (Cursor, error)
+	CreateSearchIndex(ctx context.Context, db, coll string, index mongo.SearchIndexModel) (string, error)
+	DropSearchIndex(ctx context.Context, db, coll, name string) error
+}
+
+// Cursor iterates over the documents of a Find or an Aggregate. *mongo.Cursor implements it.
+type Cursor interface {
+	Next(ctx context.Context) bool
+	Decode(v interface{}) error
+	All(ctx context.Context, results interface{}) error
+	Err() error
+	Close(ctx context.Context) error
+}
+
+// Client is the Store of a connected driver client.
+type Client struct {
+	*mongo.Client
+}
+
+func (c Client) ListDatabaseNames(ctx context.Context) ([]string, error) {
+	return c.Client.ListDatabaseNames(ctx, bson.M{})
+}
+
+func (c Client) ListDatabases(ctx context.Context) ([]mongo.DatabaseSpecification, error) {
+	res, err := c.Client.ListDatabases(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Databases, nil
+}
+
+func (c Client) ListNamespaces(ctx context.Context, db string) ([]Namespace, error) {
+	specs, err := c.Database(db).ListCollectionSpecifications(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make([]Namespace, len(specs))
+	for i, spec := range specs {
+		namespaces[i] = Namespace{Name: spec.Name, Kind: spec.Type}
+	}
+	return namespaces, nil
+}
+
+func (c Client) Find(ctx context.Context, db, coll string, filter interface{}, opts ...*options.FindOptions) (Cursor, error) {
+	cur, err := c.Database(db).Collection(coll).Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err // Not a typed nil Cursor
+	}
+	return cur, nil
+}
+
+func (c Client) Aggregate(ctx context.Context, db, coll string, pipeline interface{}, opts ...*options.AggregateOptions) (Cursor, error) {
+	cur, err := c.Database(db).Collection(coll).Aggregate(ctx, pipeline, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return cur, nil
+}
+
+func (c Client) FindOne(ctx context.Context, db, coll string, filter interface{}) (bson.M, error) {
+	var doc bson.M
+	if err := c.Database(db).Collection(coll).FindOne(ctx, filter).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (c Client) CountDocuments(ctx context.Context, db, coll string, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	return c.Database(db).Collection(coll).CountDocuments(ctx, filter, opts...)
+}
+
+func (c Client) InsertOne(ctx context.Context, db, coll string, doc interface{}) (interface{}, error) {
+	res, err := c.Database(db).Collection(coll).InsertOne(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	return res.InsertedID, nil
+}
+
+// InsertMany inserts docs unordered, so one failing does not stop the
+// others, and returns the _ids of those inserted.
+func (c Client) InsertMany(ctx context.Context, db, coll string, docs []interface{}) ([]interface{}, error) {
+	res, err := c.Database(db).Collection(coll).InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if res == nil {
+		return nil, err
+	}
+	return res.InsertedIDs, err
+}
+
+func (c Client) UpdateOne(ctx context.Context, db, coll string, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	return c.Database(db).Collection(coll).UpdateOne(ctx, filter, update, opts...)
+}
+
+func (c Client) UpdateMany(ctx context.Context, db, coll string, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	return c.Database(db).Collection(coll).UpdateMany(ctx, filter, update, opts...)
+}
+
+func (c Client) ReplaceOne(ctx context.Context, db, coll string, filter, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	return c.Database(db).Collection(coll).ReplaceOne(ctx, filter, replacement, opts...)
+}
+
+// DeleteMany deletes the documents matching filter and returns how many.
+func (c Client) DeleteMany(ctx context.Context, db, coll string, filter interface{}) (int64, error) {
+	res, err := c.Database(db).Collection(coll).DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// FindOneAndUpdate returns the document updated, keeping its field order,
+// or ErrNoDocuments if none matched.
+func (c Client) FindOneAndUpdate(ctx context.Context, db, coll string, filter, update interface{}, opts ...*options.FindOneAndUpdateOptions) (bson.D, error) {
+	var doc bson.D
+	if err := c.Database(db).Collection(coll).FindOneAndUpdate(ctx, filter, update, opts...).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// FindOneAndDelete returns the document deleted, keeping its field order,
+// or ErrNoDocuments if none matched.
+func (c Client) FindOneAndDelete(ctx context.Context, db, coll string, filter interface{}, opts ...*options.FindOneAndDeleteOptions) (bson.D, error) {
+	var doc bson.D
+	if err := c.Database(db).Collection(coll).FindOneAndDelete(ctx, filter, opts...).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (c Client) BulkWrite(ctx context.Context, db, coll string, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	return c.Database(db).Collection(coll).BulkWrite(ctx, models, opts...)
+}
+
+// RunCommand runs cmd on db and decodes the reply into result, or only
+// checks that it succeeded if result is nil.
+func (c Client) RunCommand(ctx context.Context, db string, cmd, result interface{}) error {
+	res := c.Database(db).RunCommand(ctx, cmd)
+	if result == nil {
+		return res.Err()
+	}
+	return res.Decode(result)
+}
+
+// ListCollections returns the specifications of the namespaces of db
+// matching filter, as listCollections reports them.
+func (c Client) ListCollections(ctx context.Context, db string, filter interface{}) (Cursor, error) {
+	cur, err := c.Database(db).ListCollections(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return cur, nil
+}
+
+func (c Client) CreateCollection(ctx context.Context, db, coll string, opts ...*options.CreateCollectionOptions) error {
+	return c.Database(db).CreateCollection(ctx, coll, opts...)
+}
+
+func (c Client) CreateView(ctx context.Context, db, view, source string, pipeline interface{}) error {
+	return c.Database(db).CreateView(ctx, view, source, pipeline)
+}
+
+func (c Client) EstimatedDocumentCount(ctx context.Context, db, coll string) (int64, error) {
+	return c.Database(db).Collection(coll).EstimatedDocumentCount(ctx)
+}
+
+func (c Client) ListIndexes(ctx context.Context, db, coll string) (Cursor, error) {
+	cur, err := c.Database(db).Collection(coll).Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cur, nil
+}
+
+// CreateIndex creates index and returns its name.
+func (c Client) CreateIndex(ctx context.Context, db, coll string, index mongo.IndexModel) (string, error) {
+	return c.Database(db).Collection(coll).Indexes().CreateOne(ctx, index)
+}
+
+func (c Client) DropIndex(ctx context.Context, db, coll, name string) error {
+	_, err := c.Database(db).Collection(coll).Indexes().DropOne(ctx, name)
+	return err
+}
+
+// ListSearchIndexes returns the search indexes of db.coll, or the one named
+// name if it is not empty.
+func (c Client) ListSearchIndexes(ctx context.Context, db, coll, name string) (Cursor, error) {
+	opts := options.SearchIndexes()
+	if name != "" {
+		opts.SetName(name)
+	}
+	cur, err := c.Database(db).Collection(coll).SearchIndexes().List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return cur, nil
+}
+
+// CreateSearchIndex starts building index and returns its name.
+func (c Client) CreateSearchIndex(ctx context.Context, db, coll string, index mongo.SearchIndexModel) (string, error) {
+	return c.Database(db).Collection(coll).SearchIndexes().CreateOne(ctx, index)
+}
+
+func (c Client) DropSearchIndex(ctx context.Context, db, coll, name string) error {
+	return c.Database(db).Collection(coll).SearchIndexes().DropOne(ctx, name)
+}
@@ -0,0 +1,85 @@
+// Package release holds the release notes of mon-go, embedded in the binary
+// so the shell can point out what is new after an upgrade.
+package release
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+//go:embed releases.json
+var releasesJSON []byte
+
+// Feature is a command, flag or key binding and what it does.
+type Feature struct {
+	Name    string `json:"name"`
+	Summary string `json:"summary"`
+}
+
+// Release is what a version added.
+type Release struct {
+	Version  string    `json:"version"`
+	Commands []Feature `json:"commands"`
+	Keys     []Feature `json:"keys"`
+	Notes    []string  `json:"notes"`
+}
+
+var releases []Release
+
+func init() {
+	if err := json.Unmarshal(releasesJSON, &releases); err != nil {
+		panic("release: invalid releases.json: " + err.Error())
+	}
+	if len(releases) == 0 {
+		panic("release: releases.json lists no releases")
+	}
+}
+
+// All returns every release, newest first.
+func All() []Release {
+	return releases
+}
+
+// Current returns the version of this build.
+func Current() string {
+	return releases[0].Version
+}
+
+// Since returns the releases newer than seen, newest first. Without a seen
+// version, on first start, only the current release is returned.
+func Since(seen string) []Release {
+	if seen == "" {
+		return releases[:1]
+	}
+	var newer []Release
+	for _, r := range releases {
+		if compare(r.Version, seen) <= 0 {
+			break
+		}
+		newer = append(newer, r)
+	}
+	return newer
+}
+
+// compare orders dotted versions such as 0.9.0 and 0.10.1.
+func compare(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,30 @@
+package release
+
+import "testing"
+
+func TestReleasesAreNewestFirst(t *testing.T) {
+	all := All()
+	for i := 1; i < len(all); i++ {
+		if compare(all[i-1].Version, all[i].Version) <= 0 {
+			t.Errorf("%s is listed before %s", all[i-1].Version, all[i].Version)
+		}
+	}
+}
+
+func TestSince(t *testing.T) {
+	if got := Since(""); len(got) != 1 || got[0].Version != Current() {
+		t.Errorf("first start: got %v", got)
+	}
+	if got := Since(Current()); len(got) != 0 {
+		t.Errorf("current version seen: got %v", got)
+	}
+	if got := Since("0.0.1"); len(got) != len(All()) {
+		t.Errorf("upgrade from an old version: got %d releases, want %d", len(got), len(All()))
+	}
+}
+
+func TestCompare(t *testing.T) {
+	if compare("0.10.0", "0.9.3") != 1 || compare("1.0", "1.0.0") != 0 || compare("0.8.0", "0.9.0") != -1 {
+		t.Error("compare orders versions wrongly")
+	}
+}
@@ -0,0 +1,259 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+const (
+	defaultAnalyzeSample = 1000
+	maxFieldExamples     = 3
+	maxExampleLength     = 30
+)
+
+// bsonTypeNames maps BSON types to the aliases used by $type queries.
+var bsonTypeNames = map[bsontype.Type]string{
+	bsontype.Double:           "double",
+	bsontype.String:           "string",
+	bsontype.EmbeddedDocument: "object",
+	bsontype.Array:            "array",
+	bsontype.Binary:           "binData",
+	bsontype.Undefined:        "undefined",
+	bsontype.ObjectID:         "objectId",
+	bsontype.Boolean:          "bool",
+	bsontype.DateTime:         "date",
+	bsontype.Null:             "null",
+	bsontype.Regex:            "regex",
+	bsontype.DBPointer:        "dbPointer",
+	bsontype.JavaScript:       "javascript",
+	bsontype.Symbol:           "symbol",
+	bsontype.CodeWithScope:    "javascriptWithScope",
+	bsontype.Int32:            "int",
+	bsontype.Timestamp:        "timestamp",
+	bsontype.Int64:            "long",
+	bsontype.Decimal128:       "decimal",
+	bsontype.MinKey:           "minKey",
+	bsontype.MaxKey:           "maxKey",
+}
+
+// fieldStats describes one field path across a sample of documents.
+type fieldStats struct {
+	path     string
+	present  int            // Documents the field appears in
+	types    map[string]int // BSON type alias -> occurrences
+	examples []string
+}
+
+// typeNames returns the observed types, most frequent first.
+func (f *fieldStats) typeNames() []string {
+	names := make([]string, 0, len(f.types))
+	for name := range f.types {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if f.types[names[i]] != f.types[names[j]] {
+			return f.types[names[i]] > f.types[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// schemaAnalyzer infers the shape of a collection from sampled documents.
+// Embedded documents, including those inside arrays, are descended into
+// using dot notation, as in queries.
+type schemaAnalyzer struct {
+	docs   int
+	fields map[string]*fieldStats
+}
+
+func newSchemaAnalyzer() *schemaAnalyzer {
+	return &schemaAnalyzer{fields: map[string]*fieldStats{}}
+}
+
+// add records a sampled document.
+func (a *schemaAnalyzer) add(doc bson.Raw) error {
+	a.docs++
+	seen := map[string]bool{}
+	return a.addDocument(doc, "", seen)
+}
+
+func (a *schemaAnalyzer) addDocument(doc bson.Raw, prefix string, seen map[string]bool) error {
+	elems, err := doc.Elements()
+	if err != nil {
+		return err
+	}
+	for _, elem := range elems {
+		path := prefix + elem.Key()
+		if err := a.addValue(path, elem.Value(), seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *schemaAnalyzer) addValue(path string, value bson.RawValue, seen map[string]bool) error {
+	f := a.fields[path]
+	if f == nil {
+		f = &fieldStats{path: path, types: map[string]int{}}
+		a.fields[path] = f
+	}
+	if !seen[path] {
+		seen[path] = true
+		f.present++
+	}
+	f.types[bsonTypeNames[value.Type]]++
+
+	switch value.Type {
+	case bsontype.EmbeddedDocument:
+		return a.addDocument(value.Document(), path+".", seen)
+	case bsontype.Array:
+		values, err := value.Array().Values()
+		if err != nil {
+			return err
+		}
+		for _, v := range values {
+			if v.Type == bsontype.EmbeddedDocument {
+				if err := a.addDocument(v.Document(), path+".", seen); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		if len(f.examples) < maxFieldExamples {
+			example := value.String()
+			if len(example) > maxExampleLength {
+				example = example[:maxExampleLength] + "…"
+			}
+			for _, e := range f.examples {
+				if e == example {
+					return nil
+				}
+			}
+			f.examples = append(f.examples, example)
+		}
+	}
+	return nil
+}
+
+// result returns the analysis, fields ordered by path with _id first.
+func (a *schemaAnalyzer) result() schemaAnalysis {
+	fields := make([]*fieldStats, 0, len(a.fields))
+	for _, f := range a.fields {
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if (fields[i].path == "_id") != (fields[j].path == "_id") {
+			return fields[i].path == "_id"
+		}
+		return fields[i].path < fields[j].path
+	})
+	return schemaAnalysis{sampled: a.docs, fields: fields}
+}
+
+// schemaAnalysis is the result of `analyze`.
+type schemaAnalysis struct {
+	sampled int
+	fields  []*fieldStats
+}
+
+func (s schemaAnalysis) String() string {
+	if s.sampled == 0 {
+		return "collection is empty\n"
+	}
+
+	width := len("field")
+	for _, f := range s.fields {
+		if len(f.path) > width {
+			width = len(f.path)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d documents sampled\n\n", s.sampled))
+	b.WriteString(fmt.Sprintf("%-*s  %8s  %-24s  %s\n", width, "field", "present", "types", "examples"))
+	for _, f := range s.fields {
+		presence := fmt.Sprintf("%.1f%%", 100*float64(f.present)/float64(s.sampled))
+		b.WriteString(fmt.Sprintf("%-*s  %8s  %-24s  %s\n", width, f.path, presence,
+			strings.Join(f.typeNames(), ", "), strings.Join(f.examples, ", ")))
+	}
+	return b.String()
+}
+
+// sampleCollection analyzes up to size randomly sampled documents of
+// db.coll.
+func sampleCollection(ctx context.Context, st store.Store, db, coll string, size int) (schemaAnalysis, error) {
+	pipeline := mongo.Pipeline{{{Key: "$sample", Value: bson.D{{Key: "size", Value: size}}}}}
+	cur, err := st.Aggregate(ctx, db, coll, pipeline)
+	if err != nil {
+		return schemaAnalysis{}, err
+	}
+	defer cur.Close(ctx)
+
+	analyzer := newSchemaAnalyzer()
+	for cur.Next(ctx) {
+		var doc bson.Raw
+		if err := cur.Decode(&doc); err != nil {
+			return schemaAnalysis{}, err
+		}
+		if err := analyzer.add(doc); err != nil {
+			return schemaAnalysis{}, err
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return schemaAnalysis{}, err
+	}
+	return analyzer.result(), nil
+}
+
+func (m *model) analyze(args []string) (tea.Model, tea.Cmd) {
+	fs := commands.NewFlagSet("analyze")
+	sample := fs.Int("sample", defaultAnalyzeSample, "number of documents to sample")
+	if positional, err := commands.ParseFlags(fs, args); err != nil || len(positional) > 0 || *sample <= 0 {
+		m.err = fmt.Errorf("usage: analyze [--sample N]")
+		return m, nil
+	}
+
+	db, coll, err := m.collectionPath("analyze")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	size := *sample
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		analysis, err := sampleCollection(ctx, m.store, db, coll, size)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		return mongoMsg{result: analysis}
+	})
+}
+
+// masked hides the examples of masked fields and of fields nested in them.
+func (s schemaAnalysis) masked(rules maskRules) result {
+	fields := make([]*fieldStats, len(s.fields))
+	for i, f := range s.fields {
+		fields[i] = f
+		segments := strings.Split(f.path, ".")
+		for n := 1; n <= len(segments); n++ {
+			if rules.matches(segments[:n]) {
+				redacted := *f
+				redacted.examples = []string{maskedValue}
+				fields[i] = &redacted
+				break
+			}
+		}
+	}
+	return schemaAnalysis{sampled: s.sampled, fields: fields}
+}
@@ -0,0 +1,24 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	m := newTestModel(seededFake())
+	expectError(t, m, "analyze", "cd into a collection first")
+	run(t, m, "cd shop/orders")
+
+	run(t, m, "analyze")
+	got := output(t, m)
+	for _, want := range []string{"3 documents sampled", "item     100.0%  string", "qty      100.0%  int"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("analyze lacks %q:\n%s", want, got)
+		}
+	}
+	run(t, m, "analyze --sample 2")
+	if got := output(t, m); !strings.Contains(got, "2 documents sampled") {
+		t.Errorf("analyze --sample 2:\n%s", got)
+	}
+}
@@ -0,0 +1,245 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nick-popovic/mon-go/internal/atlas"
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+// atlasProjectID matches project IDs, which are ObjectIds, as opposed to
+// project names.
+var atlasProjectID = regexp.MustCompile(`^[0-9a-f]{24}$`)
+
+// atlasClient returns a client of the Atlas Administration API with the
+// configured key.
+func (m *model) atlasClient() (*atlas.Client, error) {
+	if m.atlas.PublicKey == "" {
+		return nil, fmt.Errorf("atlas: no API key, set atlas.publicKey and atlas.privateKey in the config file")
+	}
+	return &atlas.Client{PublicKey: m.atlas.PublicKey, PrivateKey: m.atlas.PrivateKey, BaseURL: m.atlas.BaseURL}, nil
+}
+
+// resolveProject returns the ID of a project given by name or ID.
+func resolveProject(ctx context.Context, client *atlas.Client, project string) (string, error) {
+	if atlasProjectID.MatchString(project) {
+		return project, nil
+	}
+	projects, err := client.Projects(ctx)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, len(projects))
+	for i, p := range projects {
+		if p.Name == project {
+			return p.ID, nil
+		}
+		names[i] = p.Name
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("atlas: no project named %s; the API key sees %s", project, strings.Join(names, ", "))
+}
+
+// atlasProjects is the result of `atlas projects`.
+type atlasProjects []atlas.Project
+
+func (ps atlasProjects) String() string {
+	if len(ps) == 0 {
+		return "the API key sees no projects\n"
+	}
+	width := len("name")
+	for _, p := range ps {
+		if len(p.Name) > width {
+			width = len(p.Name)
+		}
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%-*s  %-24s  %s\n", width, "name", "id", "organization"))
+	for _, p := range ps {
+		b.WriteString(fmt.Sprintf("%-*s  %-24s  %s\n", width, p.Name, p.ID, p.OrgID))
+	}
+	return b.String()
+}
+
+// atlasClusters is the result of `atlas clusters`.
+type atlasClusters []atlas.Cluster
+
+func clusterState(c atlas.Cluster) string {
+	if c.Paused {
+		return "PAUSED"
+	}
+	return c.StateName
+}
+
+func (cs atlasClusters) String() string {
+	if len(cs) == 0 {
+		return "the project has no clusters\n"
+	}
+	width := len("name")
+	for _, c := range cs {
+		if len(c.Name) > width {
+			width = len(c.Name)
+		}
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%-*s  %-10s  %-10s  %s\n", width, "name", "state", "type", "version"))
+	for _, c := range cs {
+		b.WriteString(fmt.Sprintf("%-*s  %-10s  %-10s  %s\n", width, c.Name, clusterState(c), c.ClusterType, c.MongoDBVersion))
+	}
+	return b.String()
+}
+
+// connectionStrings lists the connection strings of a cluster, SRV first.
+func connectionStrings(c atlas.Cluster) string {
+	var b strings.Builder
+	for _, cs := range []struct{ name, uri string }{
+		{"standard (SRV)", c.ConnectionStrings.StandardSrv},
+		{"standard", c.ConnectionStrings.Standard},
+		{"private endpoint (SRV)", c.ConnectionStrings.PrivateSrv},
+		{"private endpoint", c.ConnectionStrings.Private},
+	} {
+		if cs.uri != "" {
+			b.WriteString(fmt.Sprintf("%-22s  %s\n", cs.name, cs.uri))
+		}
+	}
+	if b.Len() == 0 {
+		return fmt.Sprintf("%s has no connection strings yet (state %s)\n", c.Name, clusterState(c))
+	}
+	b.WriteString("\nconnect with: mon-go '<connection string>', adding the user, or save it with connstr\n")
+	return b.String()
+}
+
+// atlasCommand runs the atlas commands, which manage Atlas projects and
+// clusters through the Administration API.
+func (m *model) atlasCommand(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: atlas projects | clusters | connstr <cluster> | pause <cluster> | resume <cluster> [--project <name|id>]"
+	if len(args) == 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	client, err := m.atlasClient()
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	fs := commands.NewFlagSet("atlas " + args[0])
+	project := fs.String("project", m.atlas.Project, "name or ID of the project")
+	positional, err := commands.ParseFlags(fs, args[1:])
+	if err != nil {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	sub := args[0]
+	switch sub {
+	case "projects":
+		if len(positional) != 0 {
+			m.err = fmt.Errorf(usage)
+			return m, nil
+		}
+		return m, m.run(func(ctx context.Context) tea.Msg {
+			projects, err := client.Projects(ctx)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			return mongoMsg{result: atlasProjects(projects)}
+		})
+	case "clusters", "connstr", "pause", "resume":
+	default:
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	want := 1
+	if sub == "clusters" {
+		want = 0
+	}
+	if len(positional) != want {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	if *project == "" {
+		m.err = fmt.Errorf("atlas %s: name the project with --project, or set atlas.project in the config file", sub)
+		return m, nil
+	}
+	projectName := *project
+
+	switch sub {
+	case "clusters":
+		return m, m.run(func(ctx context.Context) tea.Msg {
+			id, err := resolveProject(ctx, client, projectName)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			clusters, err := client.Clusters(ctx, id)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			return mongoMsg{result: atlasClusters(clusters)}
+		})
+
+	case "connstr":
+		name := positional[0]
+		return m, m.run(func(ctx context.Context) tea.Msg {
+			id, err := resolveProject(ctx, client, projectName)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			cluster, err := client.Cluster(ctx, id, name)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			return mongoMsg{result: message(connectionStrings(cluster))}
+		})
+
+	case "resume":
+		name := positional[0]
+		return m, m.run(func(ctx context.Context) tea.Msg {
+			id, err := resolveProject(ctx, client, projectName)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			cluster, err := client.SetPaused(ctx, id, name, false)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			return mongoMsg{result: message(fmt.Sprintf("resuming %s (state %s); it takes a few minutes to accept connections", cluster.Name, cluster.StateName))}
+		})
+
+	default: // pause
+		name := positional[0]
+		return m, m.run(func(ctx context.Context) tea.Msg {
+			id, err := resolveProject(ctx, client, projectName)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			cluster, err := client.Cluster(ctx, id, name)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			if cluster.Paused {
+				return mongoMsg{result: message(fmt.Sprintf("%s is already paused", name))}
+			}
+			return confirmMsg{
+				result:   atlasClusters{cluster},
+				question: fmt.Sprintf("pause %s of project %s? Its applications lose their connections [y/N] ", name, projectName),
+				onYes: func() tea.Cmd {
+					return m.run(func(ctx context.Context) tea.Msg {
+						cluster, err := client.SetPaused(ctx, id, name, true)
+						if err != nil {
+							return mongoMsg{err: err}
+						}
+						return mongoMsg{result: message(fmt.Sprintf("pausing %s (state %s)", cluster.Name, cluster.StateName))}
+					})
+				},
+				declined: "cluster not paused",
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+)
+
+func TestAtlasCommands(t *testing.T) {
+	var patched bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/groups":
+			w.Write([]byte(`{"results": [{"id": "5f1a2b3c4d5e6f7a8b9c0d1e", "name": "shop", "orgId": "o1"}]}`))
+		case "/groups/5f1a2b3c4d5e6f7a8b9c0d1e/clusters":
+			w.Write([]byte(`{"results": [{"name": "main", "stateName": "IDLE", "clusterType": "REPLICASET", "mongoDBVersion": "7.0.12"}, {"name": "dev", "paused": true, "clusterType": "REPLICASET"}]}`))
+		case "/groups/5f1a2b3c4d5e6f7a8b9c0d1e/clusters/main":
+			patched = patched || r.Method == http.MethodPatch
+			w.Write([]byte(`{"name": "main", "stateName": "IDLE", "connectionStrings": {"standardSrv": "mongodb+srv://main.abc.mongodb.net"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	m := newTestModel(seededFake())
+	expectError(t, m, "atlas projects", "no API key")
+	m.atlas = config.Atlas{PublicKey: "public", PrivateKey: "private", BaseURL: srv.URL}
+
+	run(t, m, "atlas projects")
+	if got := output(t, m); !strings.Contains(got, "shop  5f1a2b3c4d5e6f7a8b9c0d1e  o1") {
+		t.Errorf("projects: %q", got)
+	}
+	expectError(t, m, "atlas clusters", "name the project with --project")
+	expectError(t, m, "atlas clusters --project nope", "no project named nope; the API key sees shop")
+
+	run(t, m, "atlas clusters --project shop")
+	got := output(t, m)
+	for _, want := range []string{"main  IDLE        REPLICASET  7.0.12", "dev   PAUSED"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("clusters lack %q:\n%s", want, got)
+		}
+	}
+
+	m.atlas.Project = "5f1a2b3c4d5e6f7a8b9c0d1e"
+	run(t, m, "atlas connstr main")
+	if got := output(t, m); !strings.Contains(got, "standard (SRV)          mongodb+srv://main.abc.mongodb.net") {
+		t.Errorf("connstr: %q", got)
+	}
+
+	run(t, m, "atlas pause main")
+	if m.prompt == nil || !strings.Contains(m.prompt.label, "pause main") {
+		t.Fatalf("pause asked no question: %+v", m.prompt)
+	}
+	answer(t, m, "n")
+	if patched || !strings.Contains(output(t, m), "cluster not paused") {
+		t.Errorf("declined pause: patched = %v, output %q", patched, output(t, m))
+	}
+	m.readOnly = true
+	expectError(t, m, "atlas resume main", "read-only")
+}
@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// errCancelled is recorded in the audit log for operations cancelled with
+// Esc, whose writes may have been done in part.
+var errCancelled = errors.New("cancelled")
+
+// auditEntry is a line of the audit log.
+type auditEntry struct {
+	Time      time.Time `json:"time"`
+	Profile   string    `json:"profile"`             // Profile connected with, or the connection string without its password
+	Namespace string    `json:"namespace"`           // Path the command ran in, "/" at the root
+	Command   string    `json:"command"`             // As typed
+	Documents *int64    `json:"documents,omitempty"` // Documents written, for commands that write
+	Error     string    `json:"error,omitempty"`
+}
+
+// audit appends a command to the audit log, if one is configured. written
+// is the number of documents the command wrote and is only recorded for
+// commands that write and succeeded, since a failed or cancelled write may
+// have been done in part. A log that cannot be written to is reported below
+// the result rather than failing the command, which has run already.
+func (m *model) audit(command string, write bool, written int64, err error) {
+	if m.auditLog == "" || command == "" {
+		return
+	}
+	entry := auditEntry{
+		Time:      time.Now().UTC(),
+		Profile:   m.profile,
+		Namespace: "/" + strings.Join(m.currentPath, "/"),
+		Command:   command,
+	}
+	if entry.Profile == "" {
+		entry.Profile = redactURI(m.connectionString)
+	}
+	if write && err == nil {
+		entry.Documents = &written
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if err := appendAudit(m.auditLog, entry); err != nil {
+		slog.Error("audit log failed", "error", err)
+		m.warnings = append(m.warnings, fmt.Sprintf("audit log: %v", err))
+	}
+}
+
+// appendAudit appends entry to the file at path as a line of JSON. The file
+// is only ever appended to, and only readable by the user.
+func appendAudit(path string, entry auditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
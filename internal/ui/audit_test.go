@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	m := newTestModel(seededFake())
+	m.auditLog = filepath.Join(t.TempDir(), "audit.log")
+	m.profile = "shop-dev"
+
+	run(t, m, "cd shop/orders")
+	run(t, m, `insert '{"item": "fig"}'`)
+	run(t, m, "count")
+	run(t, m, "set table on")
+	run(t, m, "frobnicate")
+
+	data, err := os.ReadFile(m.auditLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("%d entries, want 5:\n%s", len(lines), data)
+	}
+	var entries []auditEntry
+	for _, line := range lines {
+		var e auditEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+	insert := entries[1]
+	if insert.Command != `insert '{"item": "fig"}'` || insert.Namespace != "/shop/orders" || insert.Profile != "shop-dev" || insert.Documents == nil || *insert.Documents != 1 {
+		t.Errorf("insert entry: %s", lines[1])
+	}
+	if entries[2].Documents != nil {
+		t.Errorf("count recorded documents written: %s", lines[2])
+	}
+	if entries[3].Command != "set table on" {
+		t.Errorf("setting entry: %s", lines[3])
+	}
+	if !strings.Contains(entries[4].Error, "unknown command") {
+		t.Errorf("unknown command entry: %s", lines[4])
+	}
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// minAutorefresh keeps auto-refresh from hammering the server.
+const minAutorefresh = time.Second
+
+// refreshableCommands are the read-only commands auto-refresh may re-run.
+// For command families only the listed subcommands qualify; nil means every
+// use of the command does.
+var refreshableCommands = map[string][]string{
+	"ls":     nil,
+	"find":   nil,
+	"count":  nil,
+	"stats":  nil,
+	"ttl":    {"ls"},
+	"user":   {"ls"},
+	"users":  {"ls"},
+	"role":   {"ls", "show"},
+	"schema": {"show"},
+}
+
+func isRefreshable(command string, args []string) bool {
+	subcommands, ok := refreshableCommands[command]
+	if !ok {
+		return false
+	}
+	if subcommands == nil {
+		return true
+	}
+	for _, sub := range subcommands {
+		if len(args) > 0 && args[0] == sub {
+			return true
+		}
+	}
+	return false
+}
+
+// autorefreshMsg re-runs the last listing. gen tells ticks of an earlier
+// `set autorefresh` apart from the current one.
+type autorefreshMsg struct {
+	gen int
+}
+
+// setAutorefresh changes the auto-refresh interval; "off" or 0 disables it.
+func (m *model) setAutorefresh(value string) (tea.Model, tea.Cmd) {
+	interval := time.Duration(0)
+	if value != "off" {
+		d, err := time.ParseDuration(value)
+		if err != nil || (d != 0 && d < minAutorefresh) {
+			m.err = fmt.Errorf("set autorefresh: expected a duration of at least %s such as 5s, or off", minAutorefresh)
+			return m, nil
+		}
+		interval = d
+	}
+
+	m.autorefresh = interval
+	m.autorefreshGen++
+	m.err = nil
+	if interval == 0 {
+		m.result = message("auto-refresh off")
+		return m, nil
+	}
+	if m.lastListing == "" {
+		m.result = message(fmt.Sprintf("auto-refresh every %s, starting with the next listing such as ls or find", interval))
+	} else {
+		m.result = message(fmt.Sprintf("auto-refresh of '%s' every %s", m.lastListing, interval))
+	}
+	return m, m.scheduleAutorefresh()
+}
+
+func (m *model) scheduleAutorefresh() tea.Cmd {
+	gen := m.autorefreshGen
+	return tea.Tick(m.autorefresh, func(time.Time) tea.Msg {
+		return autorefreshMsg{gen: gen}
+	})
+}
+
+// runAutorefresh re-runs the last listing unless the user is busy with
+// something else, then schedules the next refresh.
+func (m *model) runAutorefresh() (tea.Model, tea.Cmd) {
+	next := m.scheduleAutorefresh()
+	if m.lastListing == "" || m.running != nil || m.prompt != nil {
+		return m, next
+	}
+	_, cmd := m.processCommand(m.lastListing)
+	return m, tea.Batch(cmd, next)
+}
+
+func (m *model) autorefreshSetting() string {
+	if m.autorefresh == 0 {
+		return "off"
+	}
+	return m.autorefresh.String()
+}
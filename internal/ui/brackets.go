@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var matchingBracketStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6")).Underline(true)
+
+// closers maps the brackets that are closed for you to their closing one.
+var closers = map[rune]rune{'{': '}', '[': ']', '(': ')'}
+
+// bracketKey closes brackets as they are opened, since nearly every argument
+// is nested JSON: typing { gives {} with the cursor inside, typing the } in
+// front of the cursor steps over it, and Backspace in an empty pair removes
+// both. A bracket is only closed in front of the end of the line, a space or
+// another closing bracket, so brackets added to what is already typed are
+// left alone. Answers to questions are typed as they are.
+func (m *model) bracketKey(msg tea.KeyMsg) bool {
+	if m.prompt != nil || msg.Paste {
+		return false
+	}
+	value := []rune(m.textInput.Value())
+	pos := m.textInput.Position()
+	var next rune
+	if pos < len(value) {
+		next = value[pos]
+	}
+	switch {
+	case msg.Type == tea.KeyRunes && len(msg.Runes) == 1:
+		r := msg.Runes[0]
+		if closer, ok := closers[r]; ok && (next == 0 || next == ' ' || isCloser(next)) {
+			m.editInput(string(value[:pos])+string(r)+string(closer)+string(value[pos:]), pos+1)
+			return true
+		}
+		if isCloser(r) && r == next {
+			m.textInput.SetCursor(pos + 1)
+			return true
+		}
+	case msg.Type == tea.KeyBackspace && pos > 0:
+		if closer, ok := closers[value[pos-1]]; ok && closer == next {
+			m.editInput(string(value[:pos-1])+string(value[pos+1:]), pos-1)
+			return true
+		}
+	}
+	return false
+}
+
+func isCloser(r rune) bool {
+	return r == '}' || r == ']' || r == ')'
+}
+
+// editInput replaces what is typed, keeping the suggestions in step with it.
+func (m *model) editInput(value string, pos int) {
+	m.textInput.SetValue(value)
+	m.textInput.SetCursor(pos)
+	m.textInput.SetSuggestions(m.history)
+}
+
+// matchingBracket returns the bracket just before the cursor, or else the
+// one under it, and the bracket it pairs with. ok is false when there is no
+// bracket there or it is not paired.
+func matchingBracket(value []rune, pos int) (at, match int, ok bool) {
+	for _, at := range []int{pos - 1, pos} {
+		if at < 0 || at >= len(value) {
+			continue
+		}
+		if match := pairOf(value, at); match >= 0 {
+			return at, match, true
+		}
+	}
+	return 0, 0, false
+}
+
+// pairOf returns the index of the bracket pairing with the one at i, or -1.
+func pairOf(value []rune, i int) int {
+	r := value[i]
+	if closer, ok := closers[r]; ok {
+		depth := 0
+		for j := i; j < len(value); j++ {
+			switch value[j] {
+			case r:
+				depth++
+			case closer:
+				if depth--; depth == 0 {
+					return j
+				}
+			}
+		}
+		return -1
+	}
+	for opener, closer := range closers {
+		if r != closer {
+			continue
+		}
+		depth := 0
+		for j := i; j >= 0; j-- {
+			switch value[j] {
+			case r:
+				depth++
+			case opener:
+				if depth--; depth == 0 {
+					return j
+				}
+			}
+		}
+	}
+	return -1
+}
+
+// bracketView renders the input like its View does, with the bracket next to
+// the cursor and the one it pairs with highlighted. It returns false when
+// there is nothing to highlight or the input scrolls sideways, which its
+// own View takes care of.
+func bracketView(input textinput.Model) (string, bool) {
+	value := []rune(input.Value())
+	pos := input.Position()
+	at, match, ok := matchingBracket(value, pos)
+	if !ok || (input.Width > 0 && len(value) > input.Width) {
+		return "", false
+	}
+	text := func(from, to int) string {
+		var b strings.Builder
+		style := input.TextStyle.Inline(true)
+		for i := from; i < to; {
+			if i == at || i == match {
+				b.WriteString(matchingBracketStyle.Render(string(value[i])))
+				i++
+				continue
+			}
+			end := i
+			for end < to && end != at && end != match {
+				end++
+			}
+			b.WriteString(style.Render(string(value[i:end])))
+			i = end
+		}
+		return b.String()
+	}
+	suggestion := []rune(input.CurrentSuggestion())
+	completion := input.PlaceholderStyle.Inline(true).Render
+
+	var b strings.Builder
+	b.WriteString(input.PromptStyle.Render(input.Prompt))
+	b.WriteString(text(0, pos))
+	cursor := input.Cursor
+	if pos < len(value) {
+		if pos == at || pos == match {
+			cursor.TextStyle = matchingBracketStyle
+		}
+		cursor.SetChar(string(value[pos]))
+		b.WriteString(cursor.View())
+		b.WriteString(text(pos+1, len(value)))
+		if len(suggestion) > len(value) {
+			b.WriteString(completion(string(suggestion[len(value):])))
+		}
+		return b.String(), true
+	}
+	if len(suggestion) > len(value) {
+		cursor.TextStyle = input.CompletionStyle
+		cursor.SetChar(string(suggestion[pos]))
+		b.WriteString(cursor.View())
+		b.WriteString(completion(string(suggestion[pos+1:])))
+	} else {
+		cursor.SetChar(" ")
+		b.WriteString(cursor.View())
+	}
+	return b.String(), true
+}
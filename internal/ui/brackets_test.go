@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestBrackets(t *testing.T) {
+	m := newTestModel(seededFake())
+	m.textInput.Focus()
+	typed := func(s string) {
+		for _, r := range s {
+			m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	typed(`find '{"tags": [1`)
+	if got := m.textInput.Value(); got != `find '{"tags": [1]}` {
+		t.Errorf("opening brackets: %q", got)
+	}
+	typed("]}'")
+	if got := m.textInput.Value(); got != `find '{"tags": [1]}'` {
+		t.Errorf("closing brackets step over the closed ones: %q", got)
+	}
+	if at, match, ok := matchingBracket([]rune(m.textInput.Value()), 19); !ok || at != 18 || match != 6 {
+		t.Errorf("match of }: %d and %d, %v", at, match, ok)
+	}
+	if view := m.View(); !strings.Contains(view, `find '{"tags": [1]}'`) {
+		t.Errorf("input with the brackets highlighted: %q", strings.SplitN(view, "\n", 2)[0])
+	}
+
+	m.textInput.SetValue("count ")
+	typed("{")
+	m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if got := m.textInput.Value(); got != "count " {
+		t.Errorf("Backspace in an empty pair: %q", got)
+	}
+
+	m.textInput.SetValue("find a")
+	m.textInput.SetCursor(5)
+	typed("[")
+	if got := m.textInput.Value(); got != "find [a" {
+		t.Errorf("bracket before text: %q", got)
+	}
+}
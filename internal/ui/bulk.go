@@ -0,0 +1,304 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+// bulkOpFields lists the fields each kind of operation of a bulk file
+// takes, in the form of mongosh's bulkWrite, and whether they are required.
+var bulkOpFields = map[string]map[string]bool{
+	"insertOne":  {"document": true},
+	"updateOne":  {"filter": true, "update": true, "upsert": false, "arrayFilters": false, "collation": false, "hint": false},
+	"updateMany": {"filter": true, "update": true, "upsert": false, "arrayFilters": false, "collation": false, "hint": false},
+	"replaceOne": {"filter": true, "replacement": true, "upsert": false, "collation": false, "hint": false},
+	"deleteOne":  {"filter": true, "collation": false, "hint": false},
+	"deleteMany": {"filter": true, "collation": false, "hint": false},
+}
+
+// bulkOp is an operation read from a bulk file.
+type bulkOp struct {
+	kind  string
+	model mongo.WriteModel
+}
+
+// parseBulkOps reads a JSON array of operations such as
+// {"updateOne": {"filter": {...}, "update": {...}, "upsert": true}}, which
+// may use mongosh's relaxed syntax like the documents typed in commands.
+func parseBulkOps(data []byte) ([]bulkOp, error) {
+	strict, err := commands.StrictJSON(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("expected a JSON array of operations: %w", err)
+	}
+	var wrapper struct {
+		Ops []bson.D `bson:"ops"`
+	}
+	if err := bson.UnmarshalExtJSON([]byte(`{"ops": `+strict+`}`), false, &wrapper); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of operations: %w", err)
+	}
+	if len(wrapper.Ops) == 0 {
+		return nil, fmt.Errorf("there are no operations")
+	}
+
+	ops := make([]bulkOp, len(wrapper.Ops))
+	for i, spec := range wrapper.Ops {
+		op, err := parseBulkOp(spec)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i+1, err)
+		}
+		ops[i] = op
+	}
+	return ops, nil
+}
+
+func parseBulkOp(spec bson.D) (bulkOp, error) {
+	if len(spec) != 1 {
+		return bulkOp{}, fmt.Errorf("expected one of insertOne, updateOne, updateMany, replaceOne, deleteOne or deleteMany")
+	}
+	kind := spec[0].Key
+	allowed, ok := bulkOpFields[kind]
+	if !ok {
+		return bulkOp{}, fmt.Errorf("unknown operation %s", kind)
+	}
+	args, ok := spec[0].Value.(bson.D)
+	if !ok {
+		return bulkOp{}, fmt.Errorf("%s takes a document", kind)
+	}
+	fields := args.Map()
+	for name := range fields {
+		if _, ok := allowed[name]; !ok {
+			return bulkOp{}, fmt.Errorf("%s does not take %s", kind, name)
+		}
+	}
+	for name, required := range allowed {
+		if _, ok := fields[name]; required && !ok {
+			return bulkOp{}, fmt.Errorf("%s needs %s", kind, name)
+		}
+	}
+	upsert, _ := fields["upsert"].(bool)
+	var collation *options.Collation
+	if c, ok := fields["collation"].(bson.D); ok {
+		data, err := bson.Marshal(c)
+		if err != nil {
+			return bulkOp{}, err
+		}
+		collation = &options.Collation{}
+		if err := bson.Unmarshal(data, collation); err != nil {
+			return bulkOp{}, fmt.Errorf("invalid collation: %w", err)
+		}
+	}
+	var arrayFilters *options.ArrayFilters
+	if af, ok := fields["arrayFilters"].(bson.A); ok {
+		arrayFilters = &options.ArrayFilters{Filters: af}
+	}
+	hint := fields["hint"]
+
+	var model mongo.WriteModel
+	switch kind {
+	case "insertOne":
+		model = mongo.NewInsertOneModel().SetDocument(fields["document"])
+	case "updateOne":
+		m := mongo.NewUpdateOneModel().SetFilter(fields["filter"]).SetUpdate(fields["update"]).SetUpsert(upsert)
+		if arrayFilters != nil {
+			m.SetArrayFilters(*arrayFilters)
+		}
+		if collation != nil {
+			m.SetCollation(collation)
+		}
+		if hint != nil {
+			m.SetHint(hint)
+		}
+		model = m
+	case "updateMany":
+		m := mongo.NewUpdateManyModel().SetFilter(fields["filter"]).SetUpdate(fields["update"]).SetUpsert(upsert)
+		if arrayFilters != nil {
+			m.SetArrayFilters(*arrayFilters)
+		}
+		if collation != nil {
+			m.SetCollation(collation)
+		}
+		if hint != nil {
+			m.SetHint(hint)
+		}
+		model = m
+	case "replaceOne":
+		m := mongo.NewReplaceOneModel().SetFilter(fields["filter"]).SetReplacement(fields["replacement"]).SetUpsert(upsert)
+		if collation != nil {
+			m.SetCollation(collation)
+		}
+		if hint != nil {
+			m.SetHint(hint)
+		}
+		model = m
+	case "deleteOne":
+		m := mongo.NewDeleteOneModel().SetFilter(fields["filter"])
+		if collation != nil {
+			m.SetCollation(collation)
+		}
+		if hint != nil {
+			m.SetHint(hint)
+		}
+		model = m
+	case "deleteMany":
+		m := mongo.NewDeleteManyModel().SetFilter(fields["filter"])
+		if collation != nil {
+			m.SetCollation(collation)
+		}
+		if hint != nil {
+			m.SetHint(hint)
+		}
+		model = m
+	}
+	return bulkOp{kind: kind, model: model}, nil
+}
+
+// bulkResult is the result of `bulk`: what happened to each operation, and
+// the totals.
+type bulkResult struct {
+	ops      []bulkOp
+	ordered  bool
+	res      *mongo.BulkWriteResult
+	failed   map[int]string // Index -> error of operations that failed
+	upserted map[int]interface{}
+}
+
+// written returns how many documents the operations inserted, updated or
+// deleted.
+func (r bulkResult) written() int64 {
+	if r.res == nil {
+		return 0
+	}
+	return r.res.InsertedCount + r.res.ModifiedCount + r.res.DeletedCount + r.res.UpsertedCount
+}
+
+func newBulkResult(ops []bulkOp, ordered bool, res *mongo.BulkWriteResult, err error) (bulkResult, error) {
+	r := bulkResult{ops: ops, ordered: ordered, res: res, failed: map[int]string{}, upserted: map[int]interface{}{}}
+	var bwe mongo.BulkWriteException
+	if err != nil && !errors.As(err, &bwe) {
+		return r, err // Nothing is known about the operations
+	}
+	for _, we := range bwe.WriteErrors {
+		r.failed[we.Index] = we.Message
+	}
+	if res != nil {
+		for i, id := range res.UpsertedIDs {
+			r.upserted[int(i)] = id
+		}
+	}
+	if bwe.WriteConcernError != nil {
+		return r, fmt.Errorf("bulk: write concern error: %s", bwe.WriteConcernError.Message)
+	}
+	return r, nil
+}
+
+func (r bulkResult) String() string {
+	var b strings.Builder
+	stoppedAt := -1
+	if r.ordered && len(r.failed) > 0 {
+		indexes := make([]int, 0, len(r.failed))
+		for i := range r.failed {
+			indexes = append(indexes, i)
+		}
+		sort.Ints(indexes)
+		stoppedAt = indexes[0]
+	}
+	for i, op := range r.ops {
+		var status string
+		switch {
+		case r.failed[i] != "":
+			status = "failed: " + r.failed[i]
+		case stoppedAt >= 0 && i > stoppedAt:
+			status = fmt.Sprintf("not run, the ordered bulk write stopped at %d", stoppedAt+1)
+		case r.upserted[i] != nil:
+			status = "upserted _id " + valueJSON(r.upserted[i])
+		default:
+			status = "done"
+		}
+		b.WriteString(fmt.Sprintf("%4d  %-10s  %s\n", i+1, op.kind, status))
+	}
+	if r.res != nil {
+		b.WriteString(fmt.Sprintf("\ninserted %d, matched %d, modified %d, upserted %d, deleted %d\n",
+			r.res.InsertedCount, r.res.MatchedCount, r.res.ModifiedCount, r.res.UpsertedCount, r.res.DeletedCount))
+	}
+	if len(r.failed) > 0 {
+		b.WriteString(fmt.Sprintf("%d of %d operations failed\n", len(r.failed), len(r.ops)))
+	}
+	return b.String()
+}
+
+// bulk runs the operations of a file against the current collection in one
+// BulkWrite.
+func (m *model) bulk(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: bulk <file> [--unordered]"
+
+	db, coll, err := m.collectionPath("bulk")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	fs := commands.NewFlagSet("bulk")
+	unordered := fs.Bool("unordered", false, "run every operation even if some fail, in any order")
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) != 1 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	data, err := os.ReadFile(positional[0])
+	if err != nil {
+		m.err = fmt.Errorf("bulk: %w", err)
+		return m, nil
+	}
+	ops, err := parseBulkOps(data)
+	if err != nil {
+		m.err = fmt.Errorf("bulk: %s: %w", positional[0], err)
+		return m, nil
+	}
+	ordered := !*unordered
+
+	if m.dryRun {
+		counts := map[string]int{}
+		var kinds []string
+		for _, op := range ops {
+			if counts[op.kind] == 0 {
+				kinds = append(kinds, op.kind)
+			}
+			counts[op.kind]++
+		}
+		parts := make([]string, len(kinds))
+		for i, kind := range kinds {
+			parts[i] = fmt.Sprintf("%d %s", counts[kind], kind)
+		}
+		mode := "ordered, stopping at the first error"
+		if !ordered {
+			mode = "unordered"
+		}
+		m.err = nil
+		m.result = message(fmt.Sprintf("dry run: would run %d operations on %s.%s, %s: %s", len(ops), db, coll, mode, strings.Join(parts, ", ")))
+		return m, nil
+	}
+
+	models := make([]mongo.WriteModel, len(ops))
+	for i, op := range ops {
+		models[i] = op.model
+	}
+	return m, m.runWithTimeout(0, func(ctx context.Context) tea.Msg { // Big files take a while
+		res, err := m.store.BulkWrite(ctx, db, coll, models, options.BulkWrite().SetOrdered(ordered))
+		result, err := newBulkResult(ops, ordered, res, err)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		m.names.InvalidateDB(db) // Inserts and upserts may create the collection
+		return mongoMsg{result: result, written: result.written()}
+	})
+}
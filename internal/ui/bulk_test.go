@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+const bulkFile = `[
+	{"insertOne": {"document": {"_id": 1, "status": "new"}}},
+	{"updateMany": {"filter": {"status": "new"}, "update": {"$set": {"status": "open"}}}},
+	{"updateOne": {"filter": {"_id": 7}, "update": [{"$set": {"n": 1}}], "upsert": true}},
+	{"replaceOne": {"filter": {"_id": 2}, "replacement": {"status": "closed"}}},
+	{"deleteOne": {"filter": {"_id": 3}}}
+]`
+
+func TestParseBulkOps(t *testing.T) {
+	ops, err := parseBulkOps([]byte(bulkFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, op := range ops {
+		kinds = append(kinds, op.kind)
+	}
+	if got := strings.Join(kinds, " "); got != "insertOne updateMany updateOne replaceOne deleteOne" {
+		t.Errorf("kinds = %s", got)
+	}
+	if upsert := ops[2].model.(*mongo.UpdateOneModel).Upsert; upsert == nil || !*upsert {
+		t.Error("upsert was not set")
+	}
+
+	ops, err = parseBulkOps([]byte(`[{insertOne: {document: {_id: 'a', at: ISODate("2024-05-01")}}},]`))
+	if err != nil || len(ops) != 1 {
+		t.Errorf("relaxed syntax: %d operations, %v", len(ops), err)
+	}
+
+	for spec, want := range map[string]string{
+		`{}`:                   "JSON array",
+		`[]`:                   "no operations",
+		`[{"insertMany": {}}]`: "operation 1: unknown operation insertMany",
+		`[{"deleteOne": {}}]`:  "deleteOne needs filter",
+		`[{"deleteOne": {"filter": {}, "x": 1}}]`:            "deleteOne does not take x",
+		`[{"insertOne": {"document": {}}, "deleteOne": {}}]`: "expected one of",
+	} {
+		if _, err := parseBulkOps([]byte(spec)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", spec, err, want)
+		}
+	}
+}
+
+func TestBulkResult(t *testing.T) {
+	ops, _ := parseBulkOps([]byte(bulkFile))
+	res := &mongo.BulkWriteResult{InsertedCount: 1, MatchedCount: 1, ModifiedCount: 1, UpsertedCount: 1, UpsertedIDs: map[int64]interface{}{2: 7}}
+	err := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Index: 3, Code: 11000, Message: "E11000 duplicate key"}}}}
+
+	r, rerr := newBulkResult(ops, true, res, err)
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	got := r.String()
+	for _, want := range []string{"upserted _id 7", "4  replaceOne  failed: E11000", "5  deleteOne   not run, the ordered bulk write stopped at 4", "1 of 5 operations failed"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("result lacks %q:\n%s", want, got)
+		}
+	}
+
+	r, _ = newBulkResult(ops, false, res, err)
+	if got := r.String(); strings.Contains(got, "not run") {
+		t.Errorf("unordered result has operations not run:\n%s", got)
+	}
+}
+
+func TestBulkDryRun(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ops.json")
+	if err := os.WriteFile(file, []byte(bulkFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fake := store.NewFake()
+	fake.Seed("shop", "orders")
+	m := newTestModel(fake)
+	run(t, m, "cd shop/orders")
+
+	run(t, m, "bulk "+file+" --unordered --dry-run")
+	if got := output(t, m); !strings.Contains(got, "would run 5 operations on shop.orders, unordered: 1 insertOne, 1 updateMany, 1 updateOne, 1 replaceOne, 1 deleteOne") {
+		t.Errorf("dry run: %q", got)
+	}
+	m.readOnly = true
+	expectError(t, m, "bulk "+file, "read-only")
+}
+
+func TestBulk(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ops.json")
+	ops := `[
+		{insertOne: {document: {_id: 10, item: 'fig', qty: 1}}},
+		{insertOne: {document: {_id: 10, item: 'fig again'}}},
+		{updateMany: {filter: {qty: {$gt: 2}}, update: {$inc: {qty: 1}}}},
+		{updateOne: {filter: {item: 'kiwi'}, update: {$set: {qty: 3}}, upsert: true}},
+		{deleteOne: {filter: {item: 'pear'}}},
+	]`
+	if err := os.WriteFile(file, []byte(ops), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+	run(t, m, "bulk "+file)
+	got := output(t, m)
+	for _, want := range []string{"1  insertOne   done", "2  insertOne   failed: E11000", "3  updateMany  not run, the ordered bulk write stopped at 2",
+		"inserted 1, matched 0, modified 0, upserted 0, deleted 0", "1 of 5 operations failed"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ordered result lacks %q:\n%s", want, got)
+		}
+	}
+	if matching(t, m, `{}`) != 4 || matching(t, m, `{"item": "apple", "qty": 5}`) != 1 {
+		t.Error("the ordered bulk write went on after the failure")
+	}
+
+	m = newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+	run(t, m, "bulk "+file+" --unordered")
+	got = output(t, m)
+	for _, want := range []string{"2  insertOne   failed: E11000", "3  updateMany  done", "4  updateOne   upserted _id",
+		"inserted 1, matched 2, modified 2, upserted 1, deleted 1", "1 of 5 operations failed"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("unordered result lacks %q:\n%s", want, got)
+		}
+	}
+	if matching(t, m, `{"qty": {"$in": [6, 13]}}`) != 2 || matching(t, m, `{"item": "kiwi", "qty": 3}`) != 1 || matching(t, m, `{"item": "pear"}`) != 0 {
+		t.Error("the unordered bulk write did not run every other operation")
+	}
+}
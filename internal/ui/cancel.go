@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// killOpTimeout bounds the server-side cleanup after a cancel.
+const killOpTimeout = 2 * time.Second
+
+// sessions counts the sessions connected, for their appNames.
+var sessions atomic.Int32
+
+// sessionAppName returns the appName a session connects with unless the
+// connection string sets one. It is unique to the session, so the operations
+// a tab leaves behind on the server can be found again without touching
+// those of the other tabs.
+func sessionAppName(clientOpts *options.ClientOptions) string {
+	if clientOpts.AppName != nil {
+		return *clientOpts.AppName
+	}
+	name := fmt.Sprintf("mon-go/%d/%d", os.Getpid(), sessions.Add(1))
+	clientOpts.SetAppName(name)
+	return name
+}
+
+// cancelRunning cancels the running operation. Cancelling the context only
+// abandons the operation on the client, so the returned command also kills
+// what is left of it on the server.
+func (m *model) cancelRunning() tea.Cmd {
+	slog.Info("command cancelled", "command", loggedCommand(m.running.label), "elapsed", time.Since(m.running.started))
+	m.audit(m.running.label, m.running.write, 0, errCancelled)
+	m.recordCommand(m.running.label, m.running.write, 0, nil, errCancelled)
+	m.running.cancel()
+	m.running = nil
+	m.result = message("cancelled")
+	m.err = nil
+	return m.killOwnOps
+}
+
+// killOwnOps kills the active operations of this session, except change
+// streams, which belong to a running watchboard. Failures are only logged:
+// the client side is already cancelled and the server also gives up on
+// operations whose connection is gone.
+func (m *model) killOwnOps() tea.Msg {
+	ctx, cancel := context.WithTimeout(context.Background(), killOpTimeout)
+	defer cancel()
+
+	cmd := bson.D{
+		{Key: "currentOp", Value: 1},
+		{Key: "$ownOps", Value: true},
+		{Key: "active", Value: true},
+		{Key: "appName", Value: m.appName},
+		{Key: "command.currentOp", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "command.pipeline.0.$changeStream", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "cursor.originatingCommand.pipeline.0.$changeStream", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	var res struct {
+		InProg []struct {
+			OpID interface{} `bson:"opid"`
+		} `bson:"inprog"`
+	}
+	if err := m.store.RunCommand(ctx, "admin", cmd, &res); err != nil {
+		slog.Warn("currentOp after cancel failed", "error", err)
+		return nil
+	}
+	for _, op := range res.InProg {
+		err := m.store.RunCommand(ctx, "admin", bson.D{{Key: "killOp", Value: 1}, {Key: "op", Value: op.OpID}}, nil)
+		if err != nil {
+			slog.Warn("killOp failed", "opid", op.OpID, "error", err)
+			continue
+		}
+		slog.Info("killed operation", "opid", op.OpID)
+	}
+	return nil
+}
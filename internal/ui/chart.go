@@ -0,0 +1,194 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	chartBarWidth    = 40
+	chartHeight      = 10
+	chartLabelLength = 20
+)
+
+// chartKinds are the values of --chart.
+var chartKinds = map[string]bool{"bar": true, "line": true}
+
+// eighthBlocks draw bars to an eighth of a character, horizontally and
+// vertically.
+var (
+	horizontalEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+	verticalEighths   = []string{" ", "▁", "▂", "▃", "▄", "▅", "▆", "▇"}
+)
+
+// chartable is a result that --chart can draw: labelled numbers.
+type chartable interface {
+	series() (labels []string, values []float64, err error)
+}
+
+// series reads documents such as those of a $group, {_id: label, n: 3}: a
+// label, the _id or else the one non-numeric field, and one number.
+func (l documentList) series() ([]string, []float64, error) {
+	const shape = `--chart needs documents of a label and one number, such as {"_id": "shipped", "count": 3}`
+	if len(l.docs) == 0 {
+		return nil, nil, fmt.Errorf("--chart: there are no documents to chart")
+	}
+	labels := make([]string, len(l.docs))
+	values := make([]float64, len(l.docs))
+	for i, doc := range l.docs {
+		var numbers, others []string
+		for key, v := range doc {
+			if key == "_id" {
+				continue
+			}
+			if _, ok := asFloat(v); ok {
+				numbers = append(numbers, key)
+			} else {
+				others = append(others, key)
+			}
+		}
+		if len(numbers) != 1 {
+			return nil, nil, fmt.Errorf("%s; document %d has %d numbers", shape, i+1, len(numbers))
+		}
+		values[i], _ = asFloat(doc[numbers[0]])
+
+		label, hasID := doc["_id"]
+		switch {
+		case hasID:
+		case len(others) == 1:
+			label = doc[others[0]]
+		default:
+			return nil, nil, fmt.Errorf("%s; document %d has no _id to use as its label", shape, i+1)
+		}
+		if s, ok := label.(string); ok {
+			labels[i] = s
+		} else {
+			labels[i] = valueJSON(label)
+		}
+	}
+	return labels, values, nil
+}
+
+func (d valueDistribution) series() ([]string, []float64, error) {
+	labels := make([]string, len(d.groups))
+	values := make([]float64, len(d.groups))
+	for i, g := range d.groups {
+		switch {
+		case d.hidden:
+			labels[i] = maskedValue
+		case g.value == nil:
+			labels[i] = "(null or missing)"
+		default:
+			labels[i] = valueJSON(g.value)
+		}
+		values[i] = float64(g.count)
+	}
+	return labels, values, nil
+}
+
+// chart draws labelled numbers as horizontal bars or as columns over time.
+type chart struct {
+	kind   string
+	labels []string
+	values []float64
+}
+
+// newChart draws a result with --chart, or explains why it cannot.
+func newChart(kind string, r result) (result, error) {
+	c, ok := r.(chartable)
+	if !ok {
+		return nil, fmt.Errorf("--chart: this command's result is not a list of labelled numbers")
+	}
+	labels, values, err := c.series()
+	if err != nil {
+		return nil, err
+	}
+	return chart{kind: kind, labels: labels, values: values}, nil
+}
+
+func truncateLabel(s string) string {
+	if r := []rune(s); len(r) > chartLabelLength {
+		return string(r[:chartLabelLength-1]) + "…"
+	}
+	return s
+}
+
+func (c chart) String() string {
+	if c.kind == "line" {
+		return c.columns()
+	}
+	return c.bars()
+}
+
+// bars draws a bar per label, scaled to the largest absolute value.
+func (c chart) bars() string {
+	width, largest := 0, 0.0
+	for i, label := range c.labels {
+		width = max(width, len([]rune(truncateLabel(label))))
+		largest = math.Max(largest, math.Abs(c.values[i]))
+	}
+	var b strings.Builder
+	for i, label := range c.labels {
+		eighths := 0
+		if largest > 0 {
+			eighths = int(math.Abs(c.values[i])/largest*chartBarWidth*8 + 0.5)
+		}
+		bar := strings.Repeat("█", eighths/8) + horizontalEighths[eighths%8]
+		label = truncateLabel(label)
+		b.WriteString(fmt.Sprintf("%s%s  %-*s  %s\n", label, strings.Repeat(" ", width-len([]rune(label))), chartBarWidth, bar, formatStat(c.values[i])))
+	}
+	return b.String()
+}
+
+// columns draws the values left to right, in the order of the result, as a
+// column per value between the lowest (or zero) and the highest, for series
+// such as counts per day.
+func (c chart) columns() string {
+	low, high := math.Min(0, c.values[0]), c.values[0]
+	for _, v := range c.values {
+		low, high = math.Min(low, v), math.Max(high, v)
+	}
+	span := high - low
+	heights := make([]int, len(c.values)) // In eighths of a row
+	for i, v := range c.values {
+		if span > 0 {
+			heights[i] = int((v-low)/span*chartHeight*8 + 0.5)
+		}
+	}
+
+	axis := []string{formatStat(high), formatStat(low)}
+	axisWidth := max(len(axis[0]), len(axis[1]))
+	var b strings.Builder
+	for row := chartHeight - 1; row >= 0; row-- {
+		label := ""
+		switch row {
+		case chartHeight - 1:
+			label = axis[0]
+		case 0:
+			label = axis[1]
+		}
+		b.WriteString(fmt.Sprintf("%*s │", axisWidth, label))
+		for _, h := range heights {
+			fill := h - row*8
+			switch {
+			case fill >= 8:
+				b.WriteString("█")
+			case fill <= 0:
+				b.WriteString(" ")
+			default:
+				b.WriteString(verticalEighths[fill])
+			}
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(strings.Repeat(" ", axisWidth) + " └" + strings.Repeat("─", len(heights)) + "\n")
+	first, last := truncateLabel(c.labels[0]), truncateLabel(c.labels[len(c.labels)-1])
+	gap := max(1, len(heights)-len([]rune(first))-len([]rune(last)))
+	b.WriteString(strings.Repeat(" ", axisWidth+2) + first)
+	if len(c.labels) > 1 {
+		b.WriteString(strings.Repeat(" ", gap) + last)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+func TestDocumentSeries(t *testing.T) {
+	docs := documentList{docs: []bson.M{
+		{"_id": "shipped", "count": int32(6)},
+		{"_id": bson.M{"y": 2024}, "count": 2.5},
+	}}
+	labels, values, err := docs.series()
+	if err != nil || labels[0] != "shipped" || labels[1] != `{"y":2024}` || values[0] != 6 || values[1] != 2.5 {
+		t.Errorf("series = %q, %v, %v", labels, values, err)
+	}
+
+	labels, _, err = documentList{docs: []bson.M{{"day": "mon", "n": int64(1)}}}.series()
+	if err != nil || labels[0] != "mon" {
+		t.Errorf("series without _id = %q, %v", labels, err)
+	}
+	for _, doc := range []bson.M{{"_id": 1, "a": 1, "b": 2}, {"_id": 1}, {"a": "x", "b": "y", "n": 1}} {
+		if _, _, err := (documentList{docs: []bson.M{doc}}).series(); err == nil || !strings.Contains(err.Error(), "--chart needs documents of a label and one number") {
+			t.Errorf("%v: err = %v", doc, err)
+		}
+	}
+}
+
+func TestBarChart(t *testing.T) {
+	c := chart{kind: "bar", labels: []string{"shipped", "a very long label that is cut"}, values: []float64{8, 1}}
+	got := c.String()
+	want := "shipped               " + strings.Repeat("█", 40) + "  8\n" +
+		"a very long label t…  █████" + strings.Repeat(" ", 35) + "  1\n"
+	if got != want {
+		t.Errorf("bars:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLineChart(t *testing.T) {
+	c := chart{kind: "line", labels: []string{"mon", "tue", "wed"}, values: []float64{0, 5, 10}}
+	lines := strings.Split(c.String(), "\n")
+	if len(lines) != chartHeight+3 {
+		t.Fatalf("chart has %d lines:\n%s", len(lines), c.String())
+	}
+	if lines[0] != "10 │  █" || lines[chartHeight-1] != " 0 │ ██" {
+		t.Errorf("top and bottom rows: %q, %q", lines[0], lines[chartHeight-1])
+	}
+	if lines[chartHeight+1] != "    mon wed" {
+		t.Errorf("labels: %q", lines[chartHeight+1])
+	}
+}
+
+func TestChartFlag(t *testing.T) {
+	fake := store.NewFake()
+	fake.Seed("shop", "daily",
+		bson.D{{Key: "_id", Value: "mon"}, {Key: "orders", Value: 3}},
+		bson.D{{Key: "_id", Value: "tue"}, {Key: "orders", Value: 6}},
+	)
+	m := newTestModel(fake)
+	run(t, m, "cd shop/daily")
+
+	run(t, m, "find --chart bar")
+	if got := output(t, m); !strings.Contains(got, "tue  "+strings.Repeat("█", 40)+"  6") {
+		t.Errorf("find --chart bar:\n%s", got)
+	}
+	expectError(t, m, "find --chart pie", "--chart takes bar or line")
+	expectError(t, m, "count --chart bar", "not a list of labelled numbers")
+}
@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+// mkdirFlags are the options of mkdir.
+type mkdirFlags struct {
+	capped, clustered                                                 *bool
+	size, timeField, metaField, granularity, storageEngine, collation *string
+	maxDocs, expireAfter                                              *int64
+}
+
+// mkdirFlagSet returns the flags of mkdir, for parsing its arguments.
+func mkdirFlagSet() (*flag.FlagSet, mkdirFlags) {
+	fs := commands.NewFlagSet("mkdir")
+	f := mkdirFlags{
+		capped:        fs.Bool("capped", false, "create a capped collection"),
+		size:          fs.String("size", "", "maximum size of a capped collection, e.g. 64MB"),
+		maxDocs:       fs.Int64("max", 0, "maximum number of documents in a capped collection"),
+		clustered:     fs.Bool("clustered", false, "cluster the collection by _id"),
+		timeField:     fs.String("time-field", "", "create a time series collection with this time field"),
+		metaField:     fs.String("meta-field", "", "field identifying the series of a time series collection"),
+		granularity:   fs.String("granularity", "", "time series granularity: seconds, minutes or hours"),
+		expireAfter:   fs.Int64("expire-after", 0, "seconds after which time series measurements are deleted"),
+		storageEngine: fs.String("storage-engine", "", "storage engine options as JSON"),
+		collation:     fs.String("collation", "", "default collation as JSON, or just a locale"),
+	}
+	return fs, f
+}
+
+// mkdir creates a collection. The target is resolved like a cd path, so
+// `mkdir logs` works inside a database and `mkdir shop/logs` at the root.
+func (m *model) mkdir(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: mkdir <[db/]collection> [--capped --size <bytes> [--max <docs>]] [--clustered] [--time-field <field> [--meta-field <field>] [--granularity <unit>] [--expire-after <seconds>]] [--storage-engine <json>] [--collation <json|locale>]"
+
+	fs, f := mkdirFlagSet()
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	if len(positional) != 1 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	path := m.resolvePath(positional[0])
+	if len(path) != 2 {
+		m.err = fmt.Errorf("mkdir: '%s' does not name a collection", positional[0])
+		return m, nil
+	}
+
+	opts := options.CreateCollection()
+	if *f.capped {
+		if *f.size == "" {
+			m.err = fmt.Errorf("mkdir: --capped requires --size")
+			return m, nil
+		}
+		sizeInBytes, err := commands.ParseByteSize(*f.size)
+		if err != nil {
+			m.err = fmt.Errorf("mkdir: %w", err)
+			return m, nil
+		}
+		opts.SetCapped(true).SetSizeInBytes(sizeInBytes)
+		if *f.maxDocs > 0 {
+			opts.SetMaxDocuments(*f.maxDocs)
+		}
+	} else if *f.size != "" || *f.maxDocs != 0 {
+		m.err = fmt.Errorf("mkdir: --size and --max only apply to --capped collections")
+		return m, nil
+	}
+	if *f.clustered {
+		if *f.capped {
+			m.err = fmt.Errorf("mkdir: a clustered collection cannot be capped")
+			return m, nil
+		}
+		opts.SetClusteredIndex(bson.D{
+			{Key: "key", Value: bson.D{{Key: "_id", Value: 1}}},
+			{Key: "unique", Value: true},
+		})
+	}
+	if *f.timeField != "" {
+		if *f.capped || *f.clustered {
+			m.err = fmt.Errorf("mkdir: a time series collection cannot be capped or clustered")
+			return m, nil
+		}
+		timeSeries := options.TimeSeries().SetTimeField(*f.timeField)
+		if *f.metaField != "" {
+			timeSeries.SetMetaField(*f.metaField)
+		}
+		switch *f.granularity {
+		case "":
+		case "seconds", "minutes", "hours":
+			timeSeries.SetGranularity(*f.granularity)
+		default:
+			m.err = fmt.Errorf("mkdir: --granularity must be seconds, minutes or hours")
+			return m, nil
+		}
+		opts.SetTimeSeriesOptions(timeSeries)
+		if *f.expireAfter > 0 {
+			opts.SetExpireAfterSeconds(*f.expireAfter)
+		}
+	} else if *f.metaField != "" || *f.granularity != "" || *f.expireAfter != 0 {
+		m.err = fmt.Errorf("mkdir: --meta-field, --granularity and --expire-after only apply to time series collections (--time-field)")
+		return m, nil
+	}
+	if *f.storageEngine != "" {
+		var engine bson.D
+		if err := bson.UnmarshalExtJSON([]byte(*f.storageEngine), false, &engine); err != nil {
+			m.err = fmt.Errorf("mkdir: invalid --storage-engine: %w", err)
+			return m, nil
+		}
+		opts.SetStorageEngine(engine)
+	}
+	if *f.collation != "" {
+		c, err := commands.ParseCollation(*f.collation)
+		if err != nil {
+			m.err = fmt.Errorf("mkdir: %w", err)
+			return m, nil
+		}
+		opts.SetCollation(c)
+	}
+
+	db, coll := path[0], path[1]
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		if err := m.store.CreateCollection(ctx, db, coll, opts); err != nil {
+			return mongoMsg{err: err}
+		}
+		m.names.InvalidateDB(db)
+		return mongoMsg{result: message(fmt.Sprintf("collection '%s' created in database '%s'", coll, db))}
+	})
+}
+
+// collectionPath returns the database and collection of the current path, or
+// an error naming command if the path is not inside a collection.
+func (m *model) collectionPath(command string) (string, string, error) {
+	if len(m.currentPath) < 2 {
+		return "", "", fmt.Errorf("%s: cd into a collection first", command)
+	}
+	return m.currentPath[0], m.currentPath[1], nil
+}
+
+// asInt64 converts the numeric types the server returns for counts and
+// sizes to int64.
+func asInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float64:
+		return int64(n), true
+	case int:
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
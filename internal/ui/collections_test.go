@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMkdir(t *testing.T) {
+	m := newTestModel(seededFake())
+
+	run(t, m, "mkdir shop/metrics --time-field ts --meta-field host --granularity minutes --expire-after 3600")
+	if got := output(t, m); got != "collection 'metrics' created in database 'shop'\n" {
+		t.Errorf("mkdir of a time series collection: %q", got)
+	}
+	run(t, m, "cd shop")
+	run(t, m, "mkdir log --capped --size 1MB --max 100")
+	expectError(t, m, "mkdir log", "Collection shop.log already exists")
+	expectError(t, m, "mkdir log2 --size 1MB", "--size and --max only apply to --capped collections")
+	expectError(t, m, "mkdir log2 --clustered --capped --size 1MB", "a clustered collection cannot be capped")
+
+	run(t, m, "stats metrics")
+	got := output(t, m)
+	for _, want := range []string{"type                              timeseries", "timeField                         ts", "granularity                       minutes", "expireAfterSeconds                3600"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("stats of the time series collection: %q, want %q", got, want)
+		}
+	}
+	run(t, m, "stats log")
+	got = output(t, m)
+	for _, want := range []string{"capped          true", "maxSize         1.0MB"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("stats of the capped collection: %q, want %q", got, want)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	m := newTestModel(seededFake())
+	expectError(t, m, "stats", "usage: stats")
+	run(t, m, "cd shop/orders")
+	run(t, m, "stats")
+	got := output(t, m)
+	for _, want := range []string{"ns              shop.orders", "count           3", "nindexes        1"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("stats: %q, want %q", got, want)
+		}
+	}
+	run(t, m, "index create '{\"qty\": 1}' --yes")
+	run(t, m, "stats")
+	if got := output(t, m); !strings.Contains(got, "nindexes        2") {
+		t.Errorf("stats after creating an index: %q", got)
+	}
+}
+
+func TestCollmod(t *testing.T) {
+	m := newTestModel(seededFake())
+	expectError(t, m, "collmod --validation-level strict", "cd into a collection first")
+	run(t, m, "cd shop/orders")
+
+	run(t, m, `collmod --validator '{"qty": {"$gte": 0}}' --validation-level moderate`)
+	if got := output(t, m); got != "collection 'shop.orders' modified\n" {
+		t.Errorf("collmod --validator: %q", got)
+	}
+	run(t, m, "schema show")
+	got := output(t, m)
+	for _, want := range []string{"validationLevel: moderate", "validationAction: error", `"$gte": 0`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("schema show after collmod: %q, want %q", got, want)
+		}
+	}
+
+	run(t, m, "index create '{\"qty\": 1}' --yes")
+	run(t, m, "collmod --index qty_1 --hide")
+	if got := output(t, m); !strings.Contains(got, "hidden_old") || !strings.Contains(got, "hidden_new") {
+		t.Errorf("collmod --index --hide: %q", got)
+	}
+	expectError(t, m, "collmod --index nosuchindex --hide", "nosuchindex")
+}
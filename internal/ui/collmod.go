@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+// collmod changes options of the current collection in place with the
+// collMod command.
+func (m *model) collmod(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: collmod [--validator <json>] [--validation-level <level>] [--validation-action <action>] " +
+		"[--index <name> [--hide | --unhide] [--expire-after <seconds>]] [--expire-after <seconds>] [--pre-post-images on|off]"
+
+	db, coll, err := m.collectionPath("collmod")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	fs := commands.NewFlagSet("collmod")
+	validator := fs.String("validator", "", "validator document, e.g. a $jsonSchema")
+	level := fs.String("validation-level", "", "off, moderate or strict")
+	action := fs.String("validation-action", "", "error or warn")
+	index := fs.String("index", "", "name of the index to change")
+	hide := fs.Bool("hide", false, "hide the index from the query planner")
+	unhide := fs.Bool("unhide", false, "make a hidden index visible again")
+	expireAfter := fs.Int64("expire-after", -1, "TTL of the index, or of a time series collection without --index")
+	images := fs.String("pre-post-images", "", "record pre- and post-images for change streams: on or off")
+
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	if len(positional) != 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	cmd := bson.D{{Key: "collMod", Value: coll}}
+	if *validator != "" {
+		doc, err := commands.ParseDocument(*validator)
+		if err != nil {
+			m.err = fmt.Errorf("collmod: invalid validator: %w", err)
+			return m, nil
+		}
+		cmd = append(cmd, bson.E{Key: "validator", Value: doc})
+	}
+	if *level != "" {
+		cmd = append(cmd, bson.E{Key: "validationLevel", Value: *level})
+	}
+	if *action != "" {
+		cmd = append(cmd, bson.E{Key: "validationAction", Value: *action})
+	}
+
+	if *index != "" {
+		spec := bson.D{{Key: "name", Value: *index}}
+		if *hide && *unhide {
+			m.err = fmt.Errorf("collmod: --hide and --unhide are mutually exclusive")
+			return m, nil
+		}
+		if *hide || *unhide {
+			spec = append(spec, bson.E{Key: "hidden", Value: *hide})
+		}
+		if *expireAfter >= 0 {
+			spec = append(spec, bson.E{Key: "expireAfterSeconds", Value: *expireAfter})
+		}
+		if len(spec) == 1 {
+			m.err = fmt.Errorf("collmod: --index needs --hide, --unhide or --expire-after")
+			return m, nil
+		}
+		cmd = append(cmd, bson.E{Key: "index", Value: spec})
+	} else {
+		if *hide || *unhide {
+			m.err = fmt.Errorf("collmod: --hide and --unhide need --index")
+			return m, nil
+		}
+		if *expireAfter >= 0 {
+			cmd = append(cmd, bson.E{Key: "expireAfterSeconds", Value: *expireAfter})
+		}
+	}
+
+	switch *images {
+	case "":
+	case "on", "off":
+		cmd = append(cmd, bson.E{Key: "changeStreamPreAndPostImages", Value: bson.D{{Key: "enabled", Value: *images == "on"}}})
+	default:
+		m.err = fmt.Errorf("collmod: --pre-post-images must be on or off")
+		return m, nil
+	}
+
+	if len(cmd) == 1 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		var reply bson.D
+		if err := m.store.RunCommand(ctx, db, cmd, &reply); err != nil {
+			return mongoMsg{err: err}
+		}
+		// The reply reports old and new values of changed index options.
+		var res statsResult
+		for _, e := range reply {
+			if e.Key == "ok" || strings.HasPrefix(e.Key, "$") || e.Key == "operationTime" {
+				continue
+			}
+			res.fields = append(res.fields, statField{name: e.Key, value: e.Value})
+		}
+		if len(res.fields) == 0 {
+			return mongoMsg{result: message(fmt.Sprintf("collection '%s.%s' modified", db, coll))}
+		}
+		return mongoMsg{result: res}
+	})
+}
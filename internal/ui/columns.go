@@ -0,0 +1,407 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// computedColumn is a table column calculated from each document on the
+// client, such as `total = price*qty`. The data itself is never modified.
+type computedColumn struct {
+	name string
+	expr string
+	eval exprNode
+}
+
+// exprNode evaluates an expression against a document. A nil result means
+// the value is missing, e.g. because a field is absent or not a number.
+type exprNode func(doc bson.M) interface{}
+
+// exprFunctions are the functions available in computed column expressions.
+var exprFunctions = map[string]func(v interface{}) interface{}{
+	"daysSince": func(v interface{}) interface{} {
+		if t, ok := asTime(v); ok {
+			return time.Since(t).Hours() / 24
+		}
+		return nil
+	},
+	"hoursSince": func(v interface{}) interface{} {
+		if t, ok := asTime(v); ok {
+			return time.Since(t).Hours()
+		}
+		return nil
+	},
+	"round": numberFunc(math.Round),
+	"abs":   numberFunc(math.Abs),
+	"len": func(v interface{}) interface{} {
+		switch v := v.(type) {
+		case string:
+			return float64(len(v))
+		case bson.A:
+			return float64(len(v))
+		}
+		return nil
+	},
+}
+
+func numberFunc(f func(float64) float64) func(v interface{}) interface{} {
+	return func(v interface{}) interface{} {
+		if n, ok := asFloat(v); ok {
+			return f(n)
+		}
+		return nil
+	}
+}
+
+// parseColumn parses a column definition of the form `name = expression`.
+func parseColumn(def string) (computedColumn, error) {
+	name, expr, ok := strings.Cut(def, "=")
+	name, expr = strings.TrimSpace(name), strings.TrimSpace(expr)
+	if !ok || name == "" || expr == "" {
+		return computedColumn{}, fmt.Errorf("expected name = expression, got '%s'", def)
+	}
+	p := &exprParser{input: expr}
+	eval, err := p.parse()
+	if err != nil {
+		return computedColumn{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return computedColumn{name: name, expr: expr, eval: eval}, nil
+}
+
+// exprParser parses arithmetic on numbers, field paths and function calls,
+// e.g. `round(price * qty * (1 - discount))` or `daysSince(createdAt)`.
+type exprParser struct {
+	input string
+	pos   int
+}
+
+func (p *exprParser) parse() (exprNode, error) {
+	node, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected '%s' at position %d", p.input[p.pos:], p.pos+1)
+	}
+	return node, nil
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// accept consumes c if it is the next character.
+func (p *exprParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) sum() (exprNode, error) {
+	left, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept('+'):
+			right, err := p.product()
+			if err != nil {
+				return nil, err
+			}
+			left = arithmetic(left, right, func(a, b float64) float64 { return a + b })
+		case p.accept('-'):
+			right, err := p.product()
+			if err != nil {
+				return nil, err
+			}
+			left = arithmetic(left, right, func(a, b float64) float64 { return a - b })
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *exprParser) product() (exprNode, error) {
+	left, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept('*'):
+			right, err := p.factor()
+			if err != nil {
+				return nil, err
+			}
+			left = arithmetic(left, right, func(a, b float64) float64 { return a * b })
+		case p.accept('/'):
+			right, err := p.factor()
+			if err != nil {
+				return nil, err
+			}
+			left = arithmetic(left, right, func(a, b float64) float64 { return a / b })
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *exprParser) factor() (exprNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	switch c := p.input[p.pos]; {
+	case c == '(':
+		p.pos++
+		node, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(')') {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		return node, nil
+
+	case c == '-':
+		p.pos++
+		operand, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return arithmetic(func(bson.M) interface{} { return 0.0 }, operand, func(a, b float64) float64 { return a - b }), nil
+
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] >= '0' && p.input[p.pos] <= '9' || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", p.input[start:p.pos])
+		}
+		return func(bson.M) interface{} { return n }, nil
+
+	case c == '_' || c == '$' || unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.input) {
+			c := rune(p.input[p.pos])
+			if c != '_' && c != '$' && c != '.' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+				break
+			}
+			p.pos++
+		}
+		name := p.input[start:p.pos]
+		if !p.accept('(') {
+			path := strings.Split(name, ".")
+			return func(doc bson.M) interface{} { return lookupPath(doc, path) }, nil
+		}
+		fn, ok := exprFunctions[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown function '%s'", name)
+		}
+		arg, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(')') {
+			return nil, fmt.Errorf("missing ')' after argument of %s", name)
+		}
+		return func(doc bson.M) interface{} { return fn(arg(doc)) }, nil
+
+	default:
+		return nil, fmt.Errorf("unexpected '%c' at position %d", c, p.pos+1)
+	}
+}
+
+// arithmetic combines two numeric operands; a missing or non-numeric operand
+// makes the result missing.
+func arithmetic(left, right exprNode, op func(a, b float64) float64) exprNode {
+	return func(doc bson.M) interface{} {
+		a, okA := asFloat(left(doc))
+		b, okB := asFloat(right(doc))
+		if !okA || !okB {
+			return nil
+		}
+		return op(a, b)
+	}
+}
+
+// lookupPath returns the value at a dotted field path, or nil if it is absent.
+func lookupPath(doc bson.M, path []string) interface{} {
+	var v interface{} = doc
+	for _, field := range path {
+		sub, ok := v.(bson.M)
+		if !ok {
+			return nil
+		}
+		v = sub[field]
+	}
+	return v
+}
+
+func asFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case primitive.Decimal128:
+		f, err := strconv.ParseFloat(n.String(), 64)
+		return f, err == nil
+	}
+	if n, ok := asInt64(v); ok {
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func asTime(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case primitive.DateTime:
+		return t.Time(), true
+	case time.Time:
+		return t, true
+	case primitive.ObjectID:
+		return t.Timestamp(), true
+	}
+	return time.Time{}, false
+}
+
+// column manages the computed columns of the current collection, which are
+// shown in table view and can be sorted on with `find --sort-by`.
+func (m *model) column(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: column ls | add <name> = <expression> | rm <name>"
+
+	db, coll, err := m.collectionPath("column")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	ns := db + "." + coll
+	if len(args) == 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	switch args[0] {
+	case "ls":
+		var res statsResult
+		for _, c := range m.columns[ns] {
+			res.fields = append(res.fields, statField{name: c.name, value: c.expr})
+		}
+		if len(res.fields) == 0 {
+			m.result = message(fmt.Sprintf("no computed columns for %s", ns))
+		} else {
+			m.result = res
+		}
+		m.err = nil
+		return m, nil
+
+	case "add":
+		c, err := parseColumn(strings.Join(args[1:], " "))
+		if err != nil {
+			m.err = fmt.Errorf("column add: %w", err)
+			return m, nil
+		}
+		columns := m.columns[ns][:0:0]
+		for _, existing := range m.columns[ns] {
+			if existing.name != c.name {
+				columns = append(columns, existing)
+			}
+		}
+		m.columns[ns] = append(columns, c)
+		m.err = nil
+		m.result = message(fmt.Sprintf("column '%s' added, shown in table view (`set table on`)", c.name))
+		return m, nil
+
+	case "rm":
+		if len(args) != 2 {
+			m.err = fmt.Errorf(usage)
+			return m, nil
+		}
+		columns := m.columns[ns][:0:0]
+		for _, c := range m.columns[ns] {
+			if c.name != args[1] {
+				columns = append(columns, c)
+			}
+		}
+		if len(columns) == len(m.columns[ns]) {
+			m.err = fmt.Errorf("column rm: no column '%s'", args[1])
+			return m, nil
+		}
+		m.columns[ns] = columns
+		m.err = nil
+		m.result = message(fmt.Sprintf("column '%s' removed", args[1]))
+		return m, nil
+
+	default:
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+}
+
+// columnValue returns the value of a field or computed column of doc.
+func columnValue(doc bson.M, name string, computed []computedColumn) interface{} {
+	for _, c := range computed {
+		if c.name == name {
+			return c.eval(doc)
+		}
+	}
+	return lookupPath(doc, strings.Split(name, "."))
+}
+
+// sortDocuments sorts docs on the client by a field or computed column.
+// Numbers sort numerically and before other values; missing values sort last.
+func sortDocuments(docs []bson.M, key string, descending bool, computed []computedColumn) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		a, b := columnValue(docs[i], key, computed), columnValue(docs[j], key, computed)
+		if a == nil || b == nil {
+			return a != nil
+		}
+		less := compareValues(a, b) < 0
+		if descending {
+			less = compareValues(a, b) > 0
+		}
+		return less
+	})
+}
+
+func compareValues(a, b interface{}) int {
+	if x, ok := asFloat(a); ok {
+		if y, ok := asFloat(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+		return -1
+	}
+	if _, ok := asFloat(b); ok {
+		return 1
+	}
+	if x, ok := asTime(a); ok {
+		if y, ok := asTime(b); ok {
+			return x.Compare(y)
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
@@ -0,0 +1,275 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	"github.com/nick-popovic/mon-go/internal/config"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+const (
+	defaultCompareSample = 100
+	compareExamples      = 5 // _ids shown of the documents on one side only
+)
+
+// compareSide is one of the collections `compare` reads.
+type compareSide struct {
+	store    store.Store
+	db, coll string
+}
+
+func (s compareSide) String() string {
+	return s.db + "." + s.coll
+}
+
+// collectionComparison is the result of `compare`.
+type collectionComparison struct {
+	left, right           string
+	leftCount, rightCount int
+	onlyLeft, onlyRight   int
+	leftExamples          []interface{} // _ids of some documents only on the left
+	rightExamples         []interface{}
+	sampled, mismatched   int
+	fieldMismatches       map[string]int // Field path -> sampled documents it differs in
+}
+
+// compareCollections compares the _ids of two collections and the contents
+// of up to sample documents they share, picked at random. The _ids of the
+// left collection are held in memory.
+func compareCollections(ctx context.Context, left, right compareSide, sample int) (*collectionComparison, error) {
+	c := &collectionComparison{left: left.String(), right: right.String(), fieldMismatches: map[string]int{}}
+	idsOnly := options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}})
+
+	leftIDs := map[string]interface{}{} // Canonical JSON of the _id -> _id
+	cur, err := left.store.Find(ctx, left.db, left.coll, bson.D{}, idsOnly)
+	if err != nil {
+		return nil, err
+	}
+	for cur.Next(ctx) {
+		var doc struct {
+			ID interface{} `bson:"_id"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		leftIDs[canonicalJSON(doc.ID)] = doc.ID
+	}
+	cur.Close(ctx)
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	c.leftCount = len(leftIDs)
+
+	var shared []interface{}
+	seen := map[string]bool{}
+	cur, err = right.store.Find(ctx, right.db, right.coll, bson.D{}, idsOnly)
+	if err != nil {
+		return nil, err
+	}
+	for cur.Next(ctx) {
+		var doc struct {
+			ID interface{} `bson:"_id"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		c.rightCount++
+		key := canonicalJSON(doc.ID)
+		if _, ok := leftIDs[key]; ok {
+			seen[key] = true
+			shared = append(shared, doc.ID)
+			continue
+		}
+		c.onlyRight++
+		if len(c.rightExamples) < compareExamples {
+			c.rightExamples = append(c.rightExamples, doc.ID)
+		}
+	}
+	cur.Close(ctx)
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	var onlyLeft []string
+	for key := range leftIDs {
+		if !seen[key] {
+			onlyLeft = append(onlyLeft, key)
+		}
+	}
+	sort.Strings(onlyLeft) // Examples the same from one run to the next
+	c.onlyLeft = len(onlyLeft)
+	for _, key := range onlyLeft {
+		if len(c.leftExamples) == compareExamples {
+			break
+		}
+		c.leftExamples = append(c.leftExamples, leftIDs[key])
+	}
+
+	rand.Shuffle(len(shared), func(i, j int) { shared[i], shared[j] = shared[j], shared[i] })
+	if len(shared) > sample {
+		shared = shared[:sample]
+	}
+	for _, id := range shared {
+		a, err := findDocument(ctx, left.store, left.db, left.coll, id)
+		if err == errDocumentNotFound {
+			continue // Deleted since its _id was read
+		}
+		if err != nil {
+			return nil, err
+		}
+		b, err := findDocument(ctx, right.store, right.db, right.coll, id)
+		if err == errDocumentNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.sampled++
+		var lines []diffLine
+		diffDocuments("", a, b, &lines)
+		if len(lines) == 0 {
+			continue
+		}
+		c.mismatched++
+		fields := map[string]bool{}
+		for _, l := range lines {
+			fields[l.path] = true
+		}
+		for field := range fields {
+			c.fieldMismatches[field]++
+		}
+	}
+	return c, nil
+}
+
+func (c *collectionComparison) String() string {
+	var b strings.Builder
+	width := len(c.left)
+	if len(c.right) > width {
+		width = len(c.right)
+	}
+
+	b.WriteString(fmt.Sprintf("%-*s  %d documents\n", width, c.left, c.leftCount))
+	b.WriteString(fmt.Sprintf("%-*s  %d documents\n\n", width, c.right, c.rightCount))
+
+	only := func(name string, n int, examples []interface{}) {
+		if n == 0 {
+			return
+		}
+		ids := make([]string, len(examples))
+		for i, id := range examples {
+			ids[i] = valueJSON(id)
+		}
+		more := ""
+		if n > len(examples) {
+			more = ", ..."
+		}
+		b.WriteString(fmt.Sprintf("only in %s: %d (%s%s)\n", name, n, strings.Join(ids, ", "), more))
+	}
+	only(c.left, c.onlyLeft, c.leftExamples)
+	only(c.right, c.onlyRight, c.rightExamples)
+	if c.onlyLeft == 0 && c.onlyRight == 0 {
+		b.WriteString("both have the same _ids\n")
+	}
+
+	switch {
+	case c.sampled == 0:
+		return b.String()
+	case c.mismatched == 0:
+		b.WriteString(fmt.Sprintf("\n%d sampled documents on both sides are identical\n", c.sampled))
+		return b.String()
+	}
+	b.WriteString(fmt.Sprintf("\n%d of %d sampled documents on both sides differ\n\n", c.mismatched, c.sampled))
+
+	fields := make([]string, 0, len(c.fieldMismatches))
+	fieldWidth := len("field")
+	for field := range c.fieldMismatches {
+		fields = append(fields, field)
+		if len(field) > fieldWidth {
+			fieldWidth = len(field)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if c.fieldMismatches[fields[i]] != c.fieldMismatches[fields[j]] {
+			return c.fieldMismatches[fields[i]] > c.fieldMismatches[fields[j]]
+		}
+		return fields[i] < fields[j]
+	})
+	b.WriteString(fmt.Sprintf("%-*s  %s\n", fieldWidth, "field", "documents"))
+	for _, field := range fields {
+		b.WriteString(fmt.Sprintf("%-*s  %d\n", fieldWidth, field, c.fieldMismatches[field]))
+	}
+	return b.String()
+}
+
+// compare compares two collections, of this deployment or, with --uri, the
+// second of another one.
+func (m *model) compare(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: compare <[db/]collection> <[db/]collection> [--sample N] [--uri <connection string|profile>]"
+
+	fs := commands.NewFlagSet("compare")
+	sample := fs.Int("sample", defaultCompareSample, "documents on both sides to compare field by field")
+	uri := fs.String("uri", "", "connection string or profile of the second collection's deployment")
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) != 2 || *sample < 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	var sides [2]compareSide
+	for i, arg := range positional {
+		path := m.resolvePath(arg)
+		if len(path) != 2 {
+			m.err = fmt.Errorf("compare: '%s' does not name a collection", arg)
+			return m, nil
+		}
+		sides[i] = compareSide{store: m.store, db: path[0], coll: path[1]}
+	}
+
+	other := *uri
+	if other != "" && !strings.Contains(other, "://") {
+		profiles, err := config.LoadProfiles()
+		if err != nil {
+			m.err = fmt.Errorf("compare: %w", err)
+			return m, nil
+		}
+		profile, ok := profiles[other]
+		if !ok {
+			m.err = fmt.Errorf("compare: no profile named %s", other)
+			return m, nil
+		}
+		other = profile.URI
+	}
+
+	size := *sample
+	return m, m.runWithTimeout(0, func(ctx context.Context) tea.Msg { // Reads every _id of both sides
+		if other != "" {
+			client, err := mongo.Connect(ctx, options.Client().ApplyURI(other).SetMonitor(commandMonitor))
+			if err != nil {
+				return mongoMsg{err: fmt.Errorf("compare: connecting: %w", err)}
+			}
+			defer client.Disconnect(context.Background())
+			sides[1].store = store.Client{Client: client}
+		}
+		c, err := compareCollections(ctx, sides[0], sides[1], size)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		if other != "" {
+			c.right = redactURI(other) + " " + c.right
+		}
+		return mongoMsg{result: c}
+	})
+}
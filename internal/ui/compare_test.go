@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+func TestCompare(t *testing.T) {
+	fake := store.NewFake()
+	for i := 1; i <= 5; i++ {
+		fake.Seed("shop", "orders", bson.D{{Key: "_id", Value: i}, {Key: "status", Value: "open"}, {Key: "qty", Value: i}})
+	}
+	fake.Seed("backup", "orders",
+		bson.D{{Key: "_id", Value: 2}, {Key: "status", Value: "open"}, {Key: "qty", Value: 2}},
+		bson.D{{Key: "_id", Value: 3}, {Key: "status", Value: "shipped"}, {Key: "qty", Value: 3}},
+		bson.D{{Key: "_id", Value: 4}, {Key: "status", Value: "open"}, {Key: "qty", Value: 40}, {Key: "note", Value: "fixed"}},
+		bson.D{{Key: "_id", Value: 5}, {Key: "status", Value: "open"}, {Key: "qty", Value: 5}},
+		bson.D{{Key: "_id", Value: 9}, {Key: "status", Value: "open"}, {Key: "qty", Value: 9}},
+	)
+	m := newTestModel(fake)
+	run(t, m, "cd shop")
+
+	run(t, m, "compare orders /backup/orders")
+	if m.err != nil {
+		t.Fatal(m.err)
+	}
+	c := m.result.(*collectionComparison)
+	if c.leftCount != 5 || c.rightCount != 5 || c.onlyLeft != 1 || c.onlyRight != 1 {
+		t.Errorf("counts: %d vs %d, only left %d, only right %d", c.leftCount, c.rightCount, c.onlyLeft, c.onlyRight)
+	}
+	if c.sampled != 4 || c.mismatched != 2 || c.fieldMismatches["qty"] != 1 || c.fieldMismatches["status"] != 1 || c.fieldMismatches["note"] != 1 {
+		t.Errorf("sample: %d of %d differ, fields %v", c.mismatched, c.sampled, c.fieldMismatches)
+	}
+	got := output(t, m)
+	if !strings.Contains(got, "only in shop.orders: 1 (1)") || !strings.Contains(got, "only in backup.orders: 1 (9)") || !strings.Contains(got, "2 of 4 sampled documents") {
+		t.Errorf("output:\n%s", got)
+	}
+
+	run(t, m, "compare orders /backup/orders --sample 1")
+	if c := m.result.(*collectionComparison); c.sampled != 1 {
+		t.Errorf("--sample 1 compared %d documents", c.sampled)
+	}
+
+	expectError(t, m, "compare orders", "usage")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	expectError(t, m, "compare orders --uri nosuchprofile /backup/orders", "no profile")
+}
@@ -0,0 +1,320 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+)
+
+// authMechanisms maps the answers `connstr` accepts to authMechanism values.
+var authMechanisms = map[string]string{
+	"none":     "",
+	"scram":    "SCRAM-SHA-256",
+	"scram1":   "SCRAM-SHA-1",
+	"x509":     "MONGODB-X509",
+	"aws":      "MONGODB-AWS",
+	"ldap":     "PLAIN",
+	"kerberos": "GSSAPI",
+}
+
+// connAnswers are the answers to the `connstr` questions.
+type connAnswers struct {
+	srv        bool
+	hosts      []string
+	replicaSet string
+	mechanism  string
+	username   string
+	password   string
+	authSource string
+	tls        bool
+	caFile     string
+	certFile   string // Client certificate and key, for X.509
+	extra      string // Further options as a query string
+}
+
+// buildConnString assembles a connection string from the answers, escaping
+// what needs escaping, and checks it the way the driver will.
+func buildConnString(a connAnswers) (string, error) {
+	u := url.URL{Scheme: "mongodb", Host: strings.Join(a.hosts, ","), Path: "/"}
+	if a.srv {
+		u.Scheme = "mongodb+srv"
+	}
+	if a.username != "" {
+		if a.password != "" {
+			u.User = url.UserPassword(a.username, a.password)
+		} else {
+			u.User = url.User(a.username)
+		}
+	}
+
+	query := url.Values{}
+	if a.replicaSet != "" {
+		query.Set("replicaSet", a.replicaSet)
+	}
+	if a.mechanism != "" {
+		query.Set("authMechanism", a.mechanism)
+	}
+	if a.authSource != "" {
+		query.Set("authSource", a.authSource)
+	}
+	if a.tls && !a.srv { // SRV connections use TLS unless told otherwise
+		query.Set("tls", "true")
+	}
+	if a.caFile != "" {
+		query.Set("tlsCAFile", a.caFile)
+	}
+	if a.certFile != "" {
+		query.Set("tlsCertificateKeyFile", a.certFile)
+	}
+	if a.extra != "" {
+		extra, err := url.ParseQuery(a.extra)
+		if err != nil {
+			return "", fmt.Errorf("invalid options: %w", err)
+		}
+		for key, values := range extra {
+			for _, v := range values {
+				query.Add(key, v)
+			}
+		}
+	}
+	u.RawQuery = query.Encode()
+	uri := u.String()
+
+	if a.srv {
+		// Parsing an SRV string looks up its DNS records, so only its shape
+		// is checked here
+		if len(a.hosts) != 1 || strings.Contains(a.hosts[0], ":") {
+			return "", fmt.Errorf("mongodb+srv takes a single host name without a port")
+		}
+		return uri, nil
+	}
+	opts := options.Client().ApplyURI(uri)
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+	return uri, nil
+}
+
+// hidePassword replaces password in uri, so it is not shown on screen after
+// being typed masked.
+func hidePassword(uri, password string) string {
+	if password == "" {
+		return uri
+	}
+	escaped := url.UserPassword("", password).String() // ":" followed by the escaped password
+	return strings.Replace(uri, escaped+"@", ":****@", 1)
+}
+
+// parseHosts splits a comma-separated host list, adding the default port to
+// hosts without one.
+func parseHosts(s string, withPort bool) ([]string, error) {
+	var hosts []string
+	for _, h := range strings.Split(s, ",") {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+		if withPort {
+			if _, _, err := net.SplitHostPort(h); err != nil {
+				h = net.JoinHostPort(strings.Trim(h, "[]"), "27017")
+			}
+		}
+		hosts = append(hosts, h)
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("at least one host is needed")
+	}
+	return hosts, nil
+}
+
+// wizardStep is a question of an interactive builder. apply records the
+// answer or says what is wrong with it; skip leaves out questions that do
+// not apply given the earlier answers.
+type wizardStep struct {
+	question string
+	masked   bool
+	skip     func() bool
+	apply    func(answer string) error
+}
+
+// wizard asks steps one after the other on the input line, asking again
+// when an answer is rejected, and calls done after the last one. Esc
+// abandons it.
+func (m *model) wizard(steps []wizardStep, done func() tea.Cmd) tea.Cmd {
+	for len(steps) > 0 && steps[0].skip != nil && steps[0].skip() {
+		steps = steps[1:]
+	}
+	if len(steps) == 0 {
+		return done()
+	}
+	step := steps[0]
+	m.ask(step.question, step.masked, func(answer string) tea.Cmd {
+		if err := step.apply(strings.TrimSpace(answer)); err != nil {
+			cmd := m.wizard(steps, done)
+			m.err = err
+			return cmd
+		}
+		return m.wizard(steps[1:], done)
+	})
+	return nil
+}
+
+// yesNo reads a y/n answer, def when empty.
+func yesNo(answer string, def bool) (bool, error) {
+	switch strings.ToLower(answer) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("answer y or n")
+}
+
+// connstr builds a connection string by asking about the deployment, and
+// can save it as a profile.
+func (m *model) connstr(args []string) (tea.Model, tea.Cmd) {
+	if len(args) > 0 {
+		m.err = fmt.Errorf("usage: connstr")
+		return m, nil
+	}
+	a := &connAnswers{}
+	external := func() bool {
+		return a.mechanism == "MONGODB-X509" || a.mechanism == "MONGODB-AWS" || a.mechanism == "PLAIN" || a.mechanism == "GSSAPI"
+	}
+	steps := []wizardStep{
+		{question: "use a DNS seed list (mongodb+srv, e.g. Atlas)? [y/N] ", apply: func(s string) (err error) {
+			a.srv, err = yesNo(s, false)
+			return err
+		}},
+		{question: "cluster host name, e.g. cluster0.abcde.mongodb.net: ", skip: func() bool { return !a.srv }, apply: func(s string) (err error) {
+			a.hosts, err = parseHosts(s, false)
+			return err
+		}},
+		{question: "hosts, comma-separated host[:port] [localhost:27017]: ", skip: func() bool { return a.srv }, apply: func(s string) (err error) {
+			if s == "" {
+				s = "localhost:27017"
+			}
+			a.hosts, err = parseHosts(s, true)
+			return err
+		}},
+		{question: "replica set name (empty for none): ", skip: func() bool { return a.srv }, apply: func(s string) error {
+			a.replicaSet = s
+			return nil
+		}},
+		{question: "authentication: none, scram, scram1, x509, aws, ldap or kerberos? [none] ", apply: func(s string) error {
+			if s == "" {
+				s = "none"
+			}
+			mechanism, ok := authMechanisms[strings.ToLower(s)]
+			if !ok {
+				return fmt.Errorf("unknown authentication: %s", s)
+			}
+			a.mechanism = mechanism
+			return nil
+		}},
+		{question: "user name: ", skip: func() bool { return a.mechanism == "" || a.mechanism == "MONGODB-X509" || a.mechanism == "MONGODB-AWS" }, apply: func(s string) error {
+			if s == "" {
+				return fmt.Errorf("a user name is needed")
+			}
+			a.username = s
+			return nil
+		}},
+		{question: "password (empty to leave it out): ", masked: true, skip: func() bool { return a.username == "" || a.mechanism == "GSSAPI" }, apply: func(s string) error {
+			a.password = s
+			return nil
+		}},
+		{question: "authentication database [admin]: ", skip: func() bool { return a.mechanism == "" || external() }, apply: func(s string) error {
+			if s != "" && s != "admin" {
+				a.authSource = s
+			}
+			return nil
+		}},
+		{question: "use TLS? [y/N] ", skip: func() bool { return a.srv || a.mechanism == "MONGODB-X509" }, apply: func(s string) (err error) {
+			a.tls, err = yesNo(s, false)
+			return err
+		}},
+		{question: "CA file (empty for the system's CAs): ", skip: func() bool { return !a.tls && !a.srv && a.mechanism != "MONGODB-X509" }, apply: func(s string) error {
+			a.caFile = s
+			return nil
+		}},
+		{question: "client certificate and key file (PEM): ", skip: func() bool { return a.mechanism != "MONGODB-X509" }, apply: func(s string) error {
+			if s == "" {
+				return fmt.Errorf("X.509 authentication needs a client certificate")
+			}
+			a.certFile = s
+			a.tls = true
+			return nil
+		}},
+		{question: "other options, e.g. readPreference=secondaryPreferred&w=majority (empty for none): ", apply: func(s string) error {
+			a.extra = s
+			return nil
+		}},
+	}
+
+	var uri, shown, name string
+	var readOnly bool
+	var env string
+	saveSteps := []wizardStep{
+		{question: "save as profile (name, empty to skip): ", apply: func(s string) error {
+			if strings.Contains(s, "://") || strings.ContainsAny(s, " /") {
+				return fmt.Errorf("profile names cannot contain spaces, slashes or ://")
+			}
+			name = s
+			return nil
+		}},
+		{question: "refuse writes in sessions opened with this profile? [y/N] ", skip: func() bool { return name == "" }, apply: func(s string) (err error) {
+			readOnly, err = yesNo(s, false)
+			return err
+		}},
+		{question: "environment, colors the prompt and prod confirms writes (dev, staging, prod, empty for none): ", skip: func() bool { return name == "" }, apply: func(s string) error {
+			switch s {
+			case "", config.EnvDev, config.EnvStaging, config.EnvProd:
+				env = s
+				return nil
+			}
+			return fmt.Errorf("answer dev, staging or prod")
+		}},
+	}
+	save := func() tea.Cmd {
+		if name == "" {
+			return nil
+		}
+		if err := config.SaveProfile(name, config.Profile{URI: uri, ReadOnly: readOnly, Env: env}); err != nil {
+			m.err = fmt.Errorf("connstr: %w", err)
+			return nil
+		}
+		var tags []string
+		if readOnly {
+			tags = append(tags, "read-only")
+		}
+		if env != "" {
+			tags = append(tags, env)
+		}
+		mode := ""
+		if len(tags) > 0 {
+			mode = " (" + strings.Join(tags, ", ") + ")"
+		}
+		m.result = message(fmt.Sprintf("%s\nsaved as profile '%s'%s, connect with: mon-go %s", shown, name, mode, name))
+		return nil
+	}
+	return m, m.wizard(steps, func() tea.Cmd {
+		var err error
+		uri, err = buildConnString(*a)
+		if err != nil {
+			m.err = fmt.Errorf("connstr: %w", err)
+			return nil
+		}
+		shown = hidePassword(uri, a.password)
+		cmd := m.wizard(saveSteps, save)
+		m.result = message(shown)
+		return cmd
+	})
+}
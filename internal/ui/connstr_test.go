@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildConnString(t *testing.T) {
+	tests := []struct {
+		name string
+		a    connAnswers
+		want string
+	}{
+		{"plain", connAnswers{hosts: []string{"localhost:27017"}}, "mongodb://localhost:27017/"},
+		{
+			"replica set with auth",
+			connAnswers{hosts: []string{"a:27017", "b:27018"}, replicaSet: "rs0", mechanism: "SCRAM-SHA-256", username: "app", password: "p@ss:w/rd", authSource: "users"},
+			"mongodb://app:p%40ss%3Aw%2Frd@a:27017,b:27018/?authMechanism=SCRAM-SHA-256&authSource=users&replicaSet=rs0",
+		},
+		{
+			"tls", // Certificate files are read when the string is checked, so none are given here
+			connAnswers{hosts: []string{"db:27017"}, mechanism: "MONGODB-AWS", tls: true},
+			"mongodb://db:27017/?authMechanism=MONGODB-AWS&tls=true",
+		},
+		{"srv", connAnswers{srv: true, hosts: []string{"cluster0.example.net"}, extra: "w=majority"}, "mongodb+srv://cluster0.example.net/?w=majority"},
+	}
+	for _, tt := range tests {
+		got, err := buildConnString(tt.a)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildConnStringInvalid(t *testing.T) {
+	invalid := []connAnswers{
+		{srv: true, hosts: []string{"cluster0.example.net:27017"}},
+		{srv: true, hosts: []string{"a.example.net", "b.example.net"}},
+		{hosts: []string{"localhost:27017"}, extra: "readPreference=sometimes"},
+		{hosts: []string{"localhost:27017"}, tls: true, caFile: "/nonexistent/ca.pem"},
+	}
+	for _, a := range invalid {
+		if uri, err := buildConnString(a); err == nil {
+			t.Errorf("%+v: got %s, want an error", a, uri)
+		}
+	}
+}
+
+func TestHidePassword(t *testing.T) {
+	uri, err := buildConnString(connAnswers{hosts: []string{"h:1"}, mechanism: "SCRAM-SHA-256", username: "u", password: "s3cr@t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hidePassword(uri, "s3cr@t"); strings.Contains(got, "s3cr") || !strings.Contains(got, "u:****@h:1") {
+		t.Errorf("hidePassword: got %s", got)
+	}
+}
+
+func TestParseHosts(t *testing.T) {
+	hosts, err := parseHosts("a, b:27018,[::1]", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(hosts, ","); got != "a:27017,b:27018,[::1]:27017" {
+		t.Errorf("got %s", got)
+	}
+	if _, err := parseHosts(" , ", true); err == nil {
+		t.Error("an empty host list was accepted")
+	}
+}
@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+// currentOpCommandWidth is how much of an operation's command `currentop`
+// shows.
+const currentOpCommandWidth = 60
+
+// activeOp is an operation in progress on the server.
+type activeOp struct {
+	opID    interface{}
+	running time.Duration
+	op      string // query, update, getmore, command...
+	ns      string
+	client  string // appName, or the client's address
+	command bson.M
+}
+
+// currentOpFilter selects the active operations that have run for at least
+// min, leaving out currentOp itself and change streams, which run for as
+// long as someone watches.
+func currentOpFilter(min time.Duration) bson.D {
+	cmd := bson.D{
+		{Key: "currentOp", Value: 1},
+		{Key: "active", Value: true},
+		{Key: "command.currentOp", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "command.pipeline.0.$changeStream", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "cursor.originatingCommand.pipeline.0.$changeStream", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	if min > 0 {
+		cmd = append(cmd, bson.E{Key: "microsecs_running", Value: bson.D{{Key: "$gte", Value: min.Microseconds()}}})
+	}
+	return cmd
+}
+
+// parseCurrentOps reads the inprog documents of currentOp, longest running
+// first.
+func parseCurrentOps(inprog []bson.M) []activeOp {
+	ops := make([]activeOp, 0, len(inprog))
+	for _, doc := range inprog {
+		op := activeOp{opID: doc["opid"]}
+		if us, ok := asInt64(doc["microsecs_running"]); ok {
+			op.running = time.Duration(us) * time.Microsecond
+		} else if secs, ok := asInt64(doc["secs_running"]); ok {
+			op.running = time.Duration(secs) * time.Second
+		}
+		op.op, _ = doc["op"].(string)
+		op.ns, _ = doc["ns"].(string)
+		if op.client, _ = doc["appName"].(string); op.client == "" {
+			op.client, _ = doc["client"].(string)
+		}
+		op.command, _ = doc["command"].(bson.M)
+		ops = append(ops, op)
+	}
+	sort.SliceStable(ops, func(i, j int) bool { return ops[i].running > ops[j].running })
+	return ops
+}
+
+// currentOps runs currentOp with the filter.
+func (m *model) currentOps(ctx context.Context, filter bson.D) ([]activeOp, error) {
+	var res struct {
+		InProg []bson.M `bson:"inprog"`
+	}
+	if err := m.store.RunCommand(ctx, "admin", filter, &res); err != nil {
+		return nil, err
+	}
+	return parseCurrentOps(res.InProg), nil
+}
+
+// opList is the result of `currentop`.
+type opList struct {
+	ops []activeOp
+	min time.Duration
+}
+
+func (l opList) String() string {
+	if len(l.ops) == 0 {
+		if l.min > 0 {
+			return fmt.Sprintf("no operation has been running for %s or longer\n", l.min)
+		}
+		return "no active operations\n"
+	}
+	rows := make([][]string, len(l.ops))
+	widths := []int{len("opid"), len("running"), len("op"), len("ns"), len("client")}
+	for i, op := range l.ops {
+		command := "-"
+		if op.command != nil {
+			command = valueJSON(op.command)
+			if r := []rune(command); len(r) > currentOpCommandWidth {
+				command = string(r[:currentOpCommandWidth-1]) + "…"
+			}
+		}
+		rows[i] = []string{valueJSON(op.opID), formatElapsed(op.running), op.op, op.ns, op.client, command}
+		for j := range widths {
+			widths[j] = max(widths[j], len(rows[i][j]))
+		}
+	}
+	var b strings.Builder
+	line := func(cells []string) {
+		b.WriteString(fmt.Sprintf("%-*s  %*s  %-*s  %-*s  %-*s  %s\n",
+			widths[0], cells[0], widths[1], cells[1], widths[2], cells[2], widths[3], cells[3], widths[4], cells[4], cells[5]))
+	}
+	line([]string{"opid", "running", "op", "ns", "client", "command"})
+	for _, row := range rows {
+		line(row)
+	}
+	return b.String()
+}
+
+// currentop lists the operations in progress on the server, longest running
+// first.
+func (m *model) currentop(args []string) (tea.Model, tea.Cmd) {
+	fs := commands.NewFlagSet("currentop")
+	min := fs.Duration("min", 0, "only show operations running for at least this long, e.g. 5s")
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) != 0 || *min < 0 {
+		m.err = fmt.Errorf("usage: currentop [--min <duration>]")
+		return m, nil
+	}
+	threshold := *min
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		ops, err := m.currentOps(ctx, currentOpFilter(threshold))
+		if err != nil {
+			return mongoMsg{err: fmt.Errorf("currentop: %w", err)}
+		}
+		return mongoMsg{result: opList{ops: ops, min: threshold}}
+	})
+}
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseCurrentOps(t *testing.T) {
+	ops := parseCurrentOps([]bson.M{
+		{"opid": int32(7), "secs_running": int64(2), "op": "query", "ns": "shop.orders", "client": "10.0.0.5:51234"},
+		{"opid": int32(9), "microsecs_running": int64(95_000_000), "op": "update", "ns": "shop.customers", "appName": "billing",
+			"command": bson.M{"update": "customers"}},
+	})
+	if len(ops) != 2 || ops[0].opID != int32(9) || ops[0].running != 95*time.Second || ops[1].running != 2*time.Second {
+		t.Fatalf("ops = %+v", ops)
+	}
+	if ops[0].client != "billing" || ops[1].client != "10.0.0.5:51234" {
+		t.Fatalf("clients = %q, %q", ops[0].client, ops[1].client)
+	}
+
+	got := opList{ops: ops}.String()
+	for _, want := range []string{"opid", "1m35s  update  shop.customers  billing", `{"update":"customers"}`, "2s  query   shop.orders"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+	if got := (opList{min: 10 * time.Second}).String(); got != "no operation has been running for 10s or longer\n" {
+		t.Errorf("empty list = %q", got)
+	}
+}
+
+func TestCurrentOpFilter(t *testing.T) {
+	filter := currentOpFilter(1500 * time.Millisecond).Map()
+	if filter["microsecs_running"].(bson.D)[0].Value != int64(1_500_000) {
+		t.Fatalf("filter = %v", filter)
+	}
+	if _, ok := currentOpFilter(0).Map()["microsecs_running"]; ok {
+		t.Fatalf("a zero minimum filters on running time")
+	}
+}
+
+func TestCurrentOpArguments(t *testing.T) {
+	m := newTestModel(seededFake())
+	expectError(t, m, "currentop extra", "usage: currentop")
+	expectError(t, m, "currentop --min soon", "usage: currentop")
+}
+
+func TestCurrentOp(t *testing.T) {
+	fake := seededFake()
+	m := newTestModel(fake)
+	run(t, m, "currentop")
+	if got := output(t, m); got != "no active operations\n" {
+		t.Fatalf("currentop with nothing running: %q", got)
+	}
+
+	fake.StartOp(bson.D{{Key: "active", Value: true}, {Key: "op", Value: "query"}, {Key: "ns", Value: "shop.orders"},
+		{Key: "microsecs_running", Value: int64(2_000_000)}, {Key: "appName", Value: "billing"}})
+	fake.StartOp(bson.D{{Key: "active", Value: true}, {Key: "op", Value: "update"}, {Key: "ns", Value: "shop.customers"},
+		{Key: "microsecs_running", Value: int64(30_000_000)}, {Key: "command", Value: bson.D{{Key: "update", Value: "customers"}}}})
+	fake.StartOp(bson.D{{Key: "active", Value: true}, {Key: "op", Value: "getmore"}, {Key: "ns", Value: "shop.orders"},
+		{Key: "microsecs_running", Value: int64(90_000_000)},
+		{Key: "command", Value: bson.D{{Key: "pipeline", Value: bson.A{bson.D{{Key: "$changeStream", Value: bson.D{}}}}}}}})
+	fake.StartOp(bson.D{{Key: "active", Value: false}, {Key: "op", Value: "none"}})
+
+	run(t, m, "currentop")
+	got := output(t, m)
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "30s  update  shop.customers") || !strings.Contains(lines[2], "2s  query   shop.orders     billing") {
+		t.Fatalf("currentop leaves out change streams and idle operations, longest first:\n%s", got)
+	}
+	run(t, m, "currentop --min 10s")
+	if got := output(t, m); strings.Contains(got, "billing") || !strings.Contains(got, "shop.customers") {
+		t.Errorf("currentop --min 10s:\n%s", got)
+	}
+}
+
+func TestCancelKillsOwnOps(t *testing.T) {
+	fake := seededFake()
+	m := newTestModel(fake)
+	m.appName = "mon-go/1/1"
+	fake.StartOp(bson.D{{Key: "active", Value: true}, {Key: "op", Value: "query"}, {Key: "ns", Value: "shop.orders"},
+		{Key: "microsecs_running", Value: int64(5_000_000)}, {Key: "appName", Value: m.appName}})
+	fake.StartOp(bson.D{{Key: "active", Value: true}, {Key: "op", Value: "query"}, {Key: "ns", Value: "shop.orders"},
+		{Key: "microsecs_running", Value: int64(5_000_000)}, {Key: "appName", Value: "mon-go/1/2"}})
+
+	run(t, m, "cd shop/orders")
+	_, cmd := m.processCommand("count")
+	_, kill := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	drain(m, cmd)
+	drain(m, kill)
+	if got := output(t, m); got != "cancelled\n" {
+		t.Fatalf("Esc: %q", got)
+	}
+
+	run(t, m, "currentop")
+	got := output(t, m)
+	if strings.Contains(got, "mon-go/1/1") || !strings.Contains(got, "mon-go/1/2") {
+		t.Errorf("the operations of another session were killed, or not those of this one:\n%s", got)
+	}
+}
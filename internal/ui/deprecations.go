@@ -0,0 +1,145 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// operatorChange is an operator that was deprecated or removed in some server
+// version, and what to use instead.
+type operatorChange struct {
+	deprecated string // Version it was deprecated in, if it was
+	removed    string // Version it was removed in, if it was
+	instead    string
+}
+
+// operatorChanges lists the query and aggregation operators that filters and
+// pipelines are checked for.
+var operatorChanges = map[string]operatorChange{
+	"$where":       {deprecated: "8.0", instead: "$expr with aggregation operators, which run without server-side JavaScript"},
+	"$function":    {deprecated: "8.0", instead: "aggregation expressions, which run without server-side JavaScript"},
+	"$accumulator": {deprecated: "8.0", instead: "built-in accumulators such as $push combined with $reduce"},
+	"$isolated":    {removed: "4.0", instead: "a transaction"},
+	"$snapshot":    {removed: "4.0", instead: "a hint on {_id: 1}"},
+	"$maxScan":     {deprecated: "4.0", removed: "4.2", instead: "maxTimeMS, or the governor's maxTimeMS setting"},
+	"$uniqueDocs":  {removed: "2.6", instead: "nothing: geo queries always return each document once"},
+	"$query":       {deprecated: "3.2", removed: "3.6", instead: "a plain filter"},
+	"$orderby":     {deprecated: "3.2", removed: "3.6", instead: "--sort"},
+}
+
+// serverVersion returns the version of the connected server, or "" if it
+// cannot be told.
+func serverVersion(ctx context.Context, client *mongo.Client) string {
+	var info struct {
+		Version string `bson:"version"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&info); err != nil {
+		return ""
+	}
+	return info.Version
+}
+
+// checkOperators looks through filters and pipelines for operators that are
+// deprecated in version, returned as warnings with their replacement, or
+// removed in it, returned as an error before the server rejects them (or
+// ignores them). With an unknown version every known change is a warning.
+func checkOperators(version string, docs ...interface{}) ([]string, error) {
+	found := map[string]bool{}
+	withScope := false
+	for _, doc := range docs {
+		walkOperators(doc, func(op string, value interface{}) {
+			if _, ok := operatorChanges[op]; ok {
+				found[op] = true
+			}
+			if _, ok := value.(primitive.CodeWithScope); ok && op == "$where" {
+				withScope = true
+			}
+		})
+	}
+
+	if withScope && version != "" && versionAtLeast(version, "4.4") {
+		return nil, fmt.Errorf("$where no longer accepts code with scope since 4.4 (connected to %s); put the values in the function or use $expr", version)
+	}
+	ops := make([]string, 0, len(found))
+	for op := range found {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	var warnings []string
+	for _, op := range ops {
+		change := operatorChanges[op]
+		switch {
+		case change.removed != "" && version != "" && versionAtLeast(version, change.removed):
+			return nil, fmt.Errorf("%s was removed in MongoDB %s (connected to %s); use %s", op, change.removed, version, change.instead)
+		case change.deprecated != "" && (version == "" || versionAtLeast(version, change.deprecated)):
+			warnings = append(warnings, fmt.Sprintf("%s is deprecated since MongoDB %s; use %s", op, change.deprecated, change.instead))
+		case change.removed != "" && version == "":
+			warnings = append(warnings, fmt.Sprintf("%s was removed in MongoDB %s; use %s", op, change.removed, change.instead))
+		}
+	}
+	return warnings, nil
+}
+
+// walkOperators calls fn with every $-prefixed key of v and its value.
+func walkOperators(v interface{}, fn func(op string, value interface{})) {
+	switch v := v.(type) {
+	case bson.D:
+		for _, e := range v {
+			if strings.HasPrefix(e.Key, "$") {
+				fn(e.Key, e.Value)
+			}
+			walkOperators(e.Value, fn)
+		}
+	case bson.M:
+		for key, value := range v {
+			if strings.HasPrefix(key, "$") {
+				fn(key, value)
+			}
+			walkOperators(value, fn)
+		}
+	case bson.A:
+		for _, elem := range v {
+			walkOperators(elem, fn)
+		}
+	case []bson.D:
+		for _, stage := range v {
+			walkOperators(stage, fn)
+		}
+	case mongo.Pipeline:
+		for _, stage := range v {
+			walkOperators(stage, fn)
+		}
+	}
+}
+
+// versionAtLeast compares dotted server versions such as 7.0.5 and 4.4.
+// Suffixes like -rc0 are ignored.
+func versionAtLeast(version, min string) bool {
+	parse := func(s string) []int {
+		var parts []int
+		for _, p := range strings.Split(strings.SplitN(s, "-", 2)[0], ".") {
+			n, _ := strconv.Atoi(p)
+			parts = append(parts, n)
+		}
+		return parts
+	}
+	v, m := parse(version), parse(min)
+	for i := range m {
+		got := 0
+		if i < len(v) {
+			got = v[i]
+		}
+		if got != m[i] {
+			return got > m[i]
+		}
+	}
+	return true
+}
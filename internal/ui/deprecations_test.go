@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+func TestCheckOperators(t *testing.T) {
+	where, _ := commands.ParseDocument(`{"$or": [{"a": 1}, {"$where": "this.a > this.b"}]}`)
+	warnings, err := checkOperators("8.0.1", where)
+	if err != nil || len(warnings) != 1 || !strings.Contains(warnings[0], "$where is deprecated since MongoDB 8.0") {
+		t.Errorf("$where on 8.0: got %v, %v", warnings, err)
+	}
+	if warnings, err := checkOperators("7.0.5", where); err != nil || len(warnings) != 0 {
+		t.Errorf("$where on 7.0: got %v, %v", warnings, err)
+	}
+
+	maxScan, _ := commands.ParseDocument(`{"a": 1, "$maxScan": 10}`)
+	if _, err := checkOperators("6.0.0", maxScan); err == nil || !strings.Contains(err.Error(), "removed in MongoDB 4.2") {
+		t.Errorf("$maxScan on 6.0: got %v", err)
+	}
+	if warnings, err := checkOperators("4.0.3", maxScan); err != nil || len(warnings) != 1 {
+		t.Errorf("$maxScan on 4.0: got %v, %v", warnings, err)
+	}
+	if warnings, err := checkOperators("", maxScan); err != nil || len(warnings) != 1 {
+		t.Errorf("$maxScan on an unknown version: got %v, %v", warnings, err)
+	}
+
+	pipeline, _ := commands.ParsePipeline(`[{"$group": {"_id": null, "all": {"$accumulator": {"init": "function() {}"}}}}]`)
+	if warnings, _ := checkOperators("8.0.0", pipeline); len(warnings) != 1 || !strings.Contains(warnings[0], "$accumulator") {
+		t.Errorf("$accumulator in a pipeline: got %v", warnings)
+	}
+
+	scope, _ := commands.ParseDocument(`{"$where": {"$code": "this.a == x", "$scope": {"x": 1}}}`)
+	if _, err := checkOperators("5.0.0", scope); err == nil || !strings.Contains(err.Error(), "code with scope") {
+		t.Errorf("$where with scope on 5.0: got %v", err)
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		version, min string
+		want         bool
+	}{
+		{"7.0.5", "4.4", true},
+		{"4.4.0", "4.4", true},
+		{"4.2.24", "4.4", false},
+		{"8.0.0-rc3", "8.0", true},
+		{"10.0.1", "8.0", true},
+	}
+	for _, tt := range tests {
+		if got := versionAtLeast(tt.version, tt.min); got != tt.want {
+			t.Errorf("versionAtLeast(%s, %s) = %v", tt.version, tt.min, got)
+		}
+	}
+}
+
+func TestDryRunWarnsOfDeprecatedOperators(t *testing.T) {
+	m := newTestModel(seededFake())
+	m.serverVersion = "8.0.1"
+	run(t, m, "cd shop/orders")
+	filter := `'{"$or": [{"item": "pear"}, {"$where": "this.qty > 100"}]}'`
+	for _, input := range []string{
+		"update " + filter + ` '{"$set": {"qty": 2}}' --dry-run`,
+		"update " + filter + ` '{"$set": {"qty": 2}}' --upsert --dry-run`,
+		"replace " + filter + ` '{"item": "pear", "qty": 2}' --dry-run`,
+		`replace '{"$where": "this.qty > 100"}' '{"item": "fig"}' --dry-run`,
+		"deletemany " + filter + " --dry-run",
+	} {
+		run(t, m, input)
+		if got := output(t, m); !strings.Contains(got, "dry run") && !strings.Contains(got, "replacement (dry run)") {
+			t.Errorf("%s: %q", input, got)
+		}
+		if len(m.warnings) != 1 || !strings.Contains(m.warnings[0], "$where is deprecated") {
+			t.Errorf("%s: warnings %q", input, m.warnings)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+var (
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	diffHeaderStyle  = lipgloss.NewStyle().Bold(true)
+)
+
+// errDocumentNotFound is returned by findDocument when no document has the
+// _id.
+var errDocumentNotFound = errors.New("document not found")
+
+// findDocument reads the document with _id id, keeping its field order.
+func findDocument(ctx context.Context, s store.Store, db, coll string, id interface{}) (bson.D, error) {
+	cur, err := s.Find(ctx, db, coll, bson.M{"_id": id}) // Unlike FindOne, keeps the field order
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	if !cur.Next(ctx) {
+		if err := cur.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errDocumentNotFound
+	}
+	var doc bson.D
+	if err := cur.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// parseDocumentID reads an _id typed on the command line: an ObjectId in
+// hex, a JSON value such as 42 or "abc", or else a plain string.
+func parseDocumentID(s string) interface{} {
+	if id, err := primitive.ObjectIDFromHex(s); err == nil {
+		return id
+	}
+	if doc, err := commands.ParseDocument(`{"v": ` + s + `}`); err == nil {
+		return doc[0].Value
+	}
+	return s
+}
+
+// diffLine is a difference between two documents: a field only on the
+// left ('-'), only on the right ('+'), or a changed value, which is both.
+type diffLine struct {
+	op    byte
+	path  string
+	value interface{}
+}
+
+// diffValues compares the values at path, descending into sub-documents and
+// arrays so only the fields that differ are reported.
+func diffValues(path string, a, b interface{}, out *[]diffLine) {
+	da, aDoc := a.(bson.D)
+	db, bDoc := b.(bson.D)
+	if aDoc && bDoc {
+		diffDocuments(path+".", da, db, out)
+		return
+	}
+	aa, aArr := a.(bson.A)
+	ba, bArr := b.(bson.A)
+	if aArr && bArr {
+		for i := 0; i < len(aa) || i < len(ba); i++ {
+			elem := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(ba):
+				*out = append(*out, diffLine{'-', elem, aa[i]})
+			case i >= len(aa):
+				*out = append(*out, diffLine{'+', elem, ba[i]})
+			default:
+				diffValues(elem, aa[i], ba[i], out)
+			}
+		}
+		return
+	}
+	if canonicalJSON(a) != canonicalJSON(b) { // Tells 5 from 5.0 or NumberLong(5)
+		*out = append(*out, diffLine{'-', path, a}, diffLine{'+', path, b})
+	}
+}
+
+// diffDocuments compares the fields of two documents, in the order of a
+// followed by those only in b.
+func diffDocuments(prefix string, a, b bson.D, out *[]diffLine) {
+	inB := map[string]interface{}{}
+	for _, e := range b {
+		inB[e.Key] = e.Value
+	}
+	inA := map[string]bool{}
+	for _, e := range a {
+		inA[e.Key] = true
+		path := prefix + e.Key
+		if v, ok := inB[e.Key]; ok {
+			diffValues(path, e.Value, v, out)
+		} else {
+			*out = append(*out, diffLine{'-', path, e.Value})
+		}
+	}
+	for _, e := range b {
+		if !inA[e.Key] {
+			*out = append(*out, diffLine{'+', prefix + e.Key, e.Value})
+		}
+	}
+}
+
+func canonicalJSON(v interface{}) string {
+	out, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: v}}, true, false)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return string(out)
+}
+
+// documentDiff is the result of `diff`.
+type documentDiff struct {
+	leftName, rightName string
+	left, right         bson.D
+}
+
+// lines returns the differences, leaving out _id, which always differs
+// within a collection.
+func (d documentDiff) lines() []diffLine {
+	strip := func(doc bson.D) bson.D {
+		var out bson.D
+		for _, e := range doc {
+			if e.Key != "_id" {
+				out = append(out, e)
+			}
+		}
+		return out
+	}
+	var lines []diffLine
+	diffDocuments("", strip(d.left), strip(d.right), &lines)
+	return lines
+}
+
+func (d documentDiff) String() string {
+	var b strings.Builder
+	b.WriteString(diffHeaderStyle.Render("--- " + d.leftName))
+	b.WriteString("\n")
+	b.WriteString(diffHeaderStyle.Render("+++ " + d.rightName))
+	b.WriteString("\n")
+	lines := d.lines()
+	if len(lines) == 0 {
+		b.WriteString("the documents are identical apart from _id\n")
+		return b.String()
+	}
+	fields := map[string]bool{}
+	for _, l := range lines {
+		text := fmt.Sprintf("%c %s: %s", l.op, l.path, valueJSON(l.value))
+		if l.op == '-' {
+			b.WriteString(diffRemovedStyle.Render(text))
+		} else {
+			b.WriteString(diffAddedStyle.Render(text))
+		}
+		b.WriteString("\n")
+		fields[l.path] = true
+	}
+	if len(fields) == 1 {
+		b.WriteString("\n1 field differs\n")
+	} else {
+		b.WriteString(fmt.Sprintf("\n%d fields differ\n", len(fields)))
+	}
+	return b.String()
+}
+
+func (d documentDiff) masked(rules maskRules) result {
+	d.left = rules.value(d.left, nil).(bson.D)
+	d.right = rules.value(d.right, nil).(bson.D)
+	return d
+}
+
+// diff compares two documents given by _id in the current collection, or as
+// paths such as /shop/orders/<id> or ../archive/<id>.
+func (m *model) diff(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: diff <id|[db/]collection/id> <id|[db/]collection/id>"
+	if len(args) != 2 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	type target struct {
+		db, coll, id string
+	}
+	var targets [2]target
+	for i, arg := range args {
+		if !strings.Contains(arg, "/") {
+			db, coll, err := m.collectionPath("diff")
+			if err != nil {
+				m.err = fmt.Errorf("diff: '%s' is an _id, which needs a collection: %w", arg, err)
+				return m, nil
+			}
+			targets[i] = target{db, coll, arg}
+			continue
+		}
+		path := m.resolvePath(arg)
+		if len(path) != 3 {
+			m.err = fmt.Errorf("diff: '%s' does not name a document", arg)
+			return m, nil
+		}
+		targets[i] = target{path[0], path[1], path[2]}
+	}
+
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		var docs [2]bson.D
+		var names [2]string
+		for i, t := range targets {
+			names[i] = fmt.Sprintf("%s.%s/%s", t.db, t.coll, t.id)
+			doc, err := findDocument(ctx, m.store, t.db, t.coll, parseDocumentID(t.id))
+			if err == errDocumentNotFound {
+				return mongoMsg{err: fmt.Errorf("diff: %s not found", names[i])}
+			}
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			docs[i] = doc
+		}
+		return mongoMsg{result: documentDiff{leftName: names[0], rightName: names[1], left: docs[0], right: docs[1]}}
+	})
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDiffDocuments(t *testing.T) {
+	a := bson.D{
+		{Key: "name", Value: "fig"},
+		{Key: "qty", Value: int32(5)},
+		{Key: "address", Value: bson.D{{Key: "city", Value: "Paris"}, {Key: "zip", Value: "75001"}}},
+		{Key: "tags", Value: bson.A{"a", "b"}},
+		{Key: "legacy", Value: true},
+	}
+	b := bson.D{
+		{Key: "name", Value: "fig"},
+		{Key: "qty", Value: int64(5)},
+		{Key: "address", Value: bson.D{{Key: "city", Value: "Lyon"}, {Key: "zip", Value: "75001"}}},
+		{Key: "tags", Value: bson.A{"a", "b", "c"}},
+		{Key: "vip", Value: true},
+	}
+	var lines []diffLine
+	diffDocuments("", a, b, &lines)
+
+	var got []string
+	for _, l := range lines {
+		got = append(got, string(l.op)+" "+l.path)
+	}
+	want := "- qty,+ qty,- address.city,+ address.city,+ tags[2],- legacy,+ vip"
+	if strings.Join(got, ",") != want {
+		t.Errorf("diff = %s\nwant %s", strings.Join(got, ","), want)
+	}
+}
+
+func TestDiffCommand(t *testing.T) {
+	fake := seededFake()
+	id1, id2 := primitive.NewObjectID(), primitive.NewObjectID()
+	fake.Seed("shop", "orders",
+		bson.D{{Key: "_id", Value: id1}, {Key: "item", Value: "fig"}, {Key: "status", Value: "open"}},
+		bson.D{{Key: "_id", Value: id2}, {Key: "item", Value: "fig"}, {Key: "status", Value: "stuck"}},
+	)
+	fake.Seed("shop", "archive", bson.D{{Key: "_id", Value: 7}, {Key: "item", Value: "fig"}, {Key: "status", Value: "open"}})
+	m := newTestModel(fake)
+	run(t, m, "cd shop/orders")
+
+	run(t, m, "diff "+id1.Hex()+" "+id2.Hex())
+	got := output(t, m)
+	if !strings.Contains(got, `- status: "open"`) || !strings.Contains(got, `+ status: "stuck"`) || !strings.Contains(got, "1 field differs") {
+		t.Errorf("diff:\n%s", got)
+	}
+
+	run(t, m, "diff "+id1.Hex()+" ../archive/7")
+	if got := output(t, m); !strings.Contains(got, "+++ shop.archive/7") || !strings.Contains(got, "identical apart from _id") {
+		t.Errorf("diff across collections:\n%s", got)
+	}
+
+	expectError(t, m, "diff "+id1.Hex()+" "+primitive.NewObjectID().Hex(), "not found")
+}
@@ -0,0 +1,197 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	treeCursorStyle = lipgloss.NewStyle().Reverse(true)
+	treeHelpStyle   = lipgloss.NewStyle().Faint(true)
+)
+
+// treeNode is a field or array element of a document shown as a tree.
+// Sub-documents and arrays have children and start folded.
+type treeNode struct {
+	key       string // Field name, or the index of an array element
+	value     interface{}
+	children  []*treeNode
+	container string // "{" for sub-documents, "[" for arrays, empty for scalars
+	open      bool
+	depth     int
+	parent    *treeNode
+}
+
+// newTreeNodes makes nodes of the fields of a document or the elements of
+// an array.
+func newTreeNodes(v interface{}, depth int, parent *treeNode) []*treeNode {
+	var nodes []*treeNode
+	add := func(key string, value interface{}) {
+		n := &treeNode{key: key, depth: depth, parent: parent}
+		switch value.(type) {
+		case bson.D, bson.M:
+			n.container = "{"
+		case bson.A:
+			n.container = "["
+		default:
+			n.value = value
+		}
+		if n.container != "" {
+			n.children = newTreeNodes(value, depth+1, n)
+		}
+		nodes = append(nodes, n)
+	}
+
+	switch v := v.(type) {
+	case bson.D:
+		for _, e := range v {
+			add(e.Key, e.Value)
+		}
+	case bson.M:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if (keys[i] == "_id") != (keys[j] == "_id") {
+				return keys[i] == "_id"
+			}
+			return keys[i] < keys[j]
+		})
+		for _, key := range keys {
+			add(key, v[key])
+		}
+	case bson.A:
+		for i, e := range v {
+			add(fmt.Sprint(i), e)
+		}
+	}
+	return nodes
+}
+
+// summary describes a container without its contents, e.g. "{3 fields}".
+func (n *treeNode) summary() string {
+	count := len(n.children)
+	if n.container == "[" {
+		if count == 1 {
+			return "[1 element]"
+		}
+		return fmt.Sprintf("[%d elements]", count)
+	}
+	if count == 1 {
+		return "{1 field}"
+	}
+	return fmt.Sprintf("{%d fields}", count)
+}
+
+// documentTree is a single document shown as a tree whose sub-documents and
+// arrays fold, navigated with the arrow keys.
+type documentTree struct {
+	doc    interface{}
+	roots  []*treeNode
+	cursor int // Index of the selected row
+}
+
+func newDocumentTree(doc interface{}) *documentTree {
+	return &documentTree{doc: doc, roots: newTreeNodes(doc, 0, nil)}
+}
+
+// rows returns the nodes shown, in order: every node whose ancestors are
+// all open.
+func (t *documentTree) rows() []*treeNode {
+	var rows []*treeNode
+	var walk func(nodes []*treeNode)
+	walk = func(nodes []*treeNode) {
+		for _, n := range nodes {
+			rows = append(rows, n)
+			if n.open {
+				walk(n.children)
+			}
+		}
+	}
+	walk(t.roots)
+	return rows
+}
+
+// key moves the cursor with ↑/↓, unfolds the selected node or steps into it
+// with →, and folds it or steps out to its parent with ←. It reports whether
+// it used the key.
+func (t *documentTree) key(msg tea.KeyMsg) bool {
+	rows := t.rows()
+	if len(rows) == 0 {
+		return false
+	}
+	n := rows[t.cursor]
+	switch msg.Type {
+	case tea.KeyUp:
+		if t.cursor > 0 {
+			t.cursor--
+		}
+	case tea.KeyDown:
+		if t.cursor < len(rows)-1 {
+			t.cursor++
+		}
+	case tea.KeyRight:
+		switch {
+		case n.container == "":
+		case !n.open:
+			n.open = true
+		case len(n.children) > 0:
+			t.cursor++
+		}
+	case tea.KeyLeft:
+		switch {
+		case n.open:
+			n.open = false
+		case n.parent != nil:
+			for i, row := range rows {
+				if row == n.parent {
+					t.cursor = i
+				}
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func (t *documentTree) String() string {
+	var b strings.Builder
+	for i, n := range t.rows() {
+		var line string
+		switch {
+		case n.container == "":
+			line = fmt.Sprintf("  %s: %s", n.key, valueJSON(n.value))
+		case n.open:
+			line = fmt.Sprintf("▾ %s: %s", n.key, n.summary())
+		default:
+			line = fmt.Sprintf("▸ %s: %s", n.key, n.summary())
+		}
+		line = strings.Repeat("  ", n.depth) + line
+		if i == t.cursor {
+			line = treeCursorStyle.Render(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	b.WriteString(treeHelpStyle.Render("↑/↓ move, → unfold, ← fold"))
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (t *documentTree) masked(rules maskRules) result {
+	return newDocumentTree(rules.value(t.doc, nil))
+}
+
+// treeKeys reports whether the arrow keys go to a document tree: when
+// nothing else uses them and the input line is empty, so ← and → are not
+// needed to edit it.
+func (m *model) treeKeys() bool {
+	return m.prompt == nil && m.running == nil && m.err == nil && m.textInput.Value() == ""
+}
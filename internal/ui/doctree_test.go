@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDocumentTree(t *testing.T) {
+	fake := seededFake()
+	id := primitive.NewObjectID()
+	fake.Seed("shop", "orders", bson.D{
+		{Key: "_id", Value: id},
+		{Key: "item", Value: "fig"},
+		{Key: "address", Value: bson.D{{Key: "city", Value: "Paris"}, {Key: "zip", Value: "75001"}}},
+		{Key: "tags", Value: bson.A{"fresh", "dried", "bulk"}},
+	})
+	m := newTestModel(fake)
+	run(t, m, "cd shop/orders/"+id.Hex())
+	run(t, m, "ls")
+
+	tree, ok := m.result.(*documentTree)
+	if !ok {
+		t.Fatalf("result is %T, want *documentTree", m.result)
+	}
+	got := output(t, m)
+	if !strings.Contains(got, "▸ address: {2 fields}") || !strings.Contains(got, "▸ tags: [3 elements]") || strings.Contains(got, "Paris") {
+		t.Fatalf("folded tree:\n%s", got)
+	}
+	if strings.Index(got, "item") > strings.Index(got, "address") {
+		t.Errorf("fields are out of order:\n%s", got)
+	}
+
+	press := func(keys ...tea.KeyType) {
+		for _, k := range keys {
+			m.Update(tea.KeyMsg{Type: k})
+		}
+	}
+	press(tea.KeyDown, tea.KeyDown, tea.KeyRight) // Unfold address
+	if got := output(t, m); !strings.Contains(got, `city: "Paris"`) {
+		t.Errorf("unfolded address:\n%s", got)
+	}
+	press(tea.KeyRight, tea.KeyLeft) // Into city, back out to address
+	if rows := tree.rows(); rows[tree.cursor].key != "address" {
+		t.Errorf("← from a field selected %s, want its parent", rows[tree.cursor].key)
+	}
+	press(tea.KeyLeft) // Fold address
+	if got := output(t, m); strings.Contains(got, "Paris") {
+		t.Errorf("folded address still shows its fields:\n%s", got)
+	}
+
+	m.textInput.SetValue("ls")
+	press(tea.KeyUp)
+	if tree.cursor != 2 {
+		t.Errorf("arrow keys moved the tree while typing")
+	}
+}
+
+func TestDocumentTreeMasked(t *testing.T) {
+	tree := newDocumentTree(bson.D{{Key: "user", Value: bson.D{{Key: "password", Value: "hunter2"}}}})
+	masked := maskResult(tree, maskRules{"password"}).(*documentTree)
+	masked.roots[0].open = true
+	if got := masked.String(); strings.Contains(got, "hunter2") || !strings.Contains(got, maskedValue) {
+		t.Errorf("masked tree:\n%s", got)
+	}
+}
@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+// dryRunCommands lists the write commands that take --dry-run, in the form
+// of mutatingCommands. --dry-run on any other command is refused rather than
+// ignored, so it never writes by accident.
+var dryRunCommands = map[string][]string{
+	"insert":     nil,
+	"update":     nil,
+	"replace":    nil,
+	"deletemany": nil,
+	"seed":       nil,
+	"bulk":       nil,
+	"findupdate": nil,
+	"finddelete": nil,
+	"ttl":        {"set"},
+	"user":       {"import"},
+	"users":      {"import"},
+}
+
+// supportsDryRun reports whether command with args can be dry-run.
+func supportsDryRun(command string, args []string) bool {
+	subcommands, ok := dryRunCommands[command]
+	if !ok {
+		return false
+	}
+	if subcommands == nil {
+		return true
+	}
+	for _, sub := range subcommands {
+		if len(args) > 0 && args[0] == sub {
+			return true
+		}
+	}
+	return false
+}
+
+// dryRunDocument is a document a dry run would write or delete, under a
+// line saying what would be done with it. It is masked like the documents
+// of reads.
+type dryRunDocument struct {
+	heading string
+	doc     bson.D
+}
+
+func (d dryRunDocument) String() string {
+	return fmt.Sprintf("%s:\n%s", d.heading, extJSON(d.doc))
+}
+
+func (d dryRunDocument) masked(rules maskRules) result {
+	return dryRunDocument{heading: d.heading, doc: rules.value(d.doc, nil).(bson.D)}
+}
+
+// dryRunInsert reports what inserting doc into db.coll would do.
+func (m *model) dryRunInsert(db, coll string, doc bson.D) tea.Cmd {
+	return m.run(func(ctx context.Context) tea.Msg {
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("dry run: would insert 1 document into %s.%s:\n%s\n", db, coll, extJSON(doc)))
+		collections, err := m.names.Collections(ctx, db)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		exists := false
+		for _, c := range collections {
+			exists = exists || c.Name == coll
+		}
+		if !exists {
+			b.WriteString("the collection does not exist and would be created\n")
+		}
+		for _, e := range doc {
+			if e.Key != "_id" {
+				continue
+			}
+			_, err := m.store.FindOne(ctx, db, coll, bson.D{{Key: "_id", Value: e.Value}})
+			switch {
+			case err == nil:
+				b.WriteString("a document with this _id exists, so the insert would fail with a duplicate key error\n")
+			case !errors.Is(err, store.ErrNoDocuments):
+				return mongoMsg{err: err}
+			}
+		}
+		return mongoMsg{result: message(b.String())}
+	})
+}
+
+// dryRunTTL counts the documents of db.coll a TTL of seconds on field would
+// delete on the TTL monitor's next pass. For arrays of dates the earliest
+// date counts, which is what $lt on an array matches.
+func (m *model) dryRunTTL(db, coll, field string, seconds int32) tea.Cmd {
+	return m.run(func(ctx context.Context) tea.Msg {
+		cutoff := time.Now().Add(-time.Duration(seconds) * time.Second)
+		expired, err := m.store.CountDocuments(ctx, db, coll, bson.D{{Key: field, Value: bson.D{{Key: "$lt", Value: cutoff}}}})
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		total, err := m.store.CountDocuments(ctx, db, coll, bson.D{})
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		return mongoMsg{result: message(fmt.Sprintf("dry run: a TTL of %ds on '%s' would delete %d of %d documents of %s.%s on the next pass, those dated before %s",
+			seconds, field, expired, total, db, coll, cutoff.UTC().Format(time.RFC3339)))}
+	})
+}
+
+// dryRunUserImport reports which roles and users of export would be created
+// and which already exist.
+func (m *model) dryRunUserImport(export authExport) tea.Cmd {
+	return m.run(func(ctx context.Context) tea.Msg {
+		var create, skip []string
+		for _, role := range export.Roles {
+			db, _ := role["db"].(string)
+			var res struct {
+				Roles []bson.M `bson:"roles"`
+			}
+			cmd := bson.D{{Key: "rolesInfo", Value: bson.D{{Key: "role", Value: role["role"]}, {Key: "db", Value: db}}}}
+			if err := m.store.RunCommand(ctx, db, cmd, &res); err != nil {
+				return mongoMsg{err: err}
+			}
+			name := fmt.Sprintf("role %v@%s", role["role"], db)
+			if len(res.Roles) > 0 {
+				skip = append(skip, name)
+			} else {
+				create = append(create, name)
+			}
+		}
+		for _, user := range export.Users {
+			db, _ := user["db"].(string)
+			var res struct {
+				Users []bson.M `bson:"users"`
+			}
+			cmd := bson.D{{Key: "usersInfo", Value: bson.D{{Key: "user", Value: user["user"]}, {Key: "db", Value: db}}}}
+			if err := m.store.RunCommand(ctx, db, cmd, &res); err != nil {
+				return mongoMsg{err: err}
+			}
+			name := fmt.Sprintf("user %v@%s", user["user"], db)
+			if len(res.Users) > 0 {
+				skip = append(skip, name)
+			} else {
+				create = append(create, name)
+			}
+		}
+
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("dry run: would create %d and skip %d existing roles and users\n", len(create), len(skip)))
+		for _, name := range create {
+			b.WriteString("  create " + name + "\n")
+		}
+		for _, name := range skip {
+			b.WriteString("  skip   " + name + " (exists)\n")
+		}
+		return mongoMsg{result: message(b.String())}
+	})
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editRequestMsg asks Update to open content in the user's editor. It lets an
+// operation that first has to fetch something from the server hand over to
+// the editor once it is done.
+type editRequestMsg struct {
+	content []byte
+	onDone  func(edited []byte) tea.Cmd
+}
+
+// editDoneMsg is sent when the editor started by edit exits.
+type editDoneMsg struct {
+	edited []byte
+	err    error
+	onDone func(edited []byte) tea.Cmd
+}
+
+// editorCommand returns the user's editor from $VISUAL or $EDITOR, falling
+// back to vi. The variables may contain arguments, e.g. "code --wait".
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// edit suspends the UI and opens content in the user's editor. onDone
+// receives the edited text once the editor exits.
+func (m *model) edit(content []byte, onDone func(edited []byte) tea.Cmd) tea.Cmd {
+	f, err := os.CreateTemp("", "mon-go-*.json")
+	if err != nil {
+		return func() tea.Msg { return editDoneMsg{err: err} }
+	}
+	path := f.Name()
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return func() tea.Msg { return editDoneMsg{err: err} }
+	}
+
+	editor := editorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return editDoneMsg{err: fmt.Errorf("editor: %w", err)}
+		}
+		edited, err := os.ReadFile(path)
+		return editDoneMsg{edited: edited, err: err, onDone: onDone}
+	})
+}
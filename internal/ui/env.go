@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+)
+
+// envStyles color the prompt by the environment of the profile, so a
+// production terminal cannot be mistaken for a development one.
+var envStyles = map[string]lipgloss.Style{
+	config.EnvDev:     lipgloss.NewStyle().Foreground(lipgloss.Color("2")),
+	config.EnvStaging: lipgloss.NewStyle().Foreground(lipgloss.Color("3")),
+	config.EnvProd:    lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15")).Background(lipgloss.Color("1")),
+}
+
+// envPrompt renders the prompt before the input line, tagged and colored
+// with the environment if the profile has one.
+func (m *model) envPrompt(prompt string) string {
+	style, ok := envStyles[m.env]
+	if !ok {
+		return prompt
+	}
+	return style.Render("["+m.env+"] "+strings.TrimSuffix(prompt, " ")) + " "
+}
+
+// confirmProd asks before a write runs against production, on top of any
+// question the command asks itself: the answer must be the word prod.
+func (m *model) confirmProd(input string) (tea.Model, tea.Cmd) {
+	m.ask(fmt.Sprintf("PRODUCTION: type prod to run '%s': ", input), false, func(answer string) tea.Cmd {
+		if strings.TrimSpace(answer) != config.EnvProd {
+			m.result = message("not run")
+			return nil
+		}
+		m.envConfirmed = true
+		defer func() { m.envConfirmed = false }()
+		_, cmd := m.processCommand(input)
+		return cmd
+	})
+	return m, nil
+}
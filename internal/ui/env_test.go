@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+)
+
+func TestProdConfirmsWrites(t *testing.T) {
+	m := newTestModel(seededFake())
+	m.env = config.EnvProd
+	if !strings.Contains(m.View(), "[prod] mon-go (/)") {
+		t.Fatalf("prompt not tagged with the environment:\n%s", m.View())
+	}
+	run(t, m, "cd shop/customers")
+
+	run(t, m, `insert '{"a": 1}'`)
+	answer(t, m, "y")
+	if got := output(t, m); got != "not run\n" {
+		t.Fatalf("write confirmed with y: %q", got)
+	}
+	run(t, m, "count")
+	if got := output(t, m); !strings.Contains(got, "0") {
+		t.Fatalf("count after a refused write: %q", got)
+	}
+
+	run(t, m, `insert '{"a": 1}'`)
+	answer(t, m, "prod")
+	if m.prompt != nil || m.envConfirmed {
+		t.Fatalf("still asking %v, confirmed %v", m.prompt, m.envConfirmed)
+	}
+	run(t, m, "count")
+	if got := output(t, m); !strings.Contains(got, "1") {
+		t.Fatalf("count after a confirmed write: %q", got)
+	}
+
+	run(t, m, "count") // Reads are not asked about
+	if m.prompt != nil {
+		t.Fatalf("read asked %q", m.prompt.label)
+	}
+}
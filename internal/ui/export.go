@@ -0,0 +1,593 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+const defaultExportChunk = 1000
+
+// exportFormat writes exported documents to a file: begin and end frame the
+// file's content, write adds a document.
+type exportFormat interface {
+	begin(w io.Writer) error
+	write(w io.Writer, doc bson.D) error
+	end(w io.Writer) error
+}
+
+// ndjsonFormat writes one document per line as canonical Extended JSON, which
+// mongoimport and most tools read back without losing types.
+type ndjsonFormat struct{}
+
+func (ndjsonFormat) begin(io.Writer) error { return nil }
+
+func (ndjsonFormat) write(w io.Writer, doc bson.D) error {
+	data, err := bson.MarshalExtJSON(doc, true, false)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func (ndjsonFormat) end(io.Writer) error { return nil }
+
+// mongoshFormat writes a script that inserts the documents with insertMany
+// calls of at most chunk documents, runnable with `mongosh <uri> <file>` or
+// load().
+type mongoshFormat struct {
+	db, coll string
+	chunk    int
+	inChunk  int // Documents written in the open insertMany call
+}
+
+func (f *mongoshFormat) begin(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "// %s.%s, exported by mon-go on %s\n", f.db, f.coll, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+func (f *mongoshFormat) write(w io.Writer, doc bson.D) error {
+	if f.inChunk == 0 {
+		if _, err := fmt.Fprintf(w, "db.getSiblingDB(%s).getCollection(%s).insertMany([\n", jsString(f.db), jsString(f.coll)); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "  %s,\n", mongoshValue(doc)); err != nil {
+		return err
+	}
+	f.inChunk++
+	if f.inChunk == f.chunk {
+		return f.end(w)
+	}
+	return nil
+}
+
+func (f *mongoshFormat) end(w io.Writer) error {
+	if f.inChunk == 0 {
+		return nil
+	}
+	f.inChunk = 0
+	_, err := io.WriteString(w, "]);\n")
+	return err
+}
+
+// jsString quotes s as a JavaScript string literal.
+func jsString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s) // Encoding a string cannot fail
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// mongoshValue formats v as a mongosh expression that evaluates to the same
+// BSON value, using the shell's type helpers so types survive the round
+// trip: an int64 stays a NumberLong and a whole double stays a Double.
+func mongoshValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bson.D:
+		if len(v) == 0 {
+			return "{}"
+		}
+		fields := make([]string, len(v))
+		for i, e := range v {
+			fields[i] = jsString(e.Key) + ": " + mongoshValue(e.Value)
+		}
+		return "{ " + strings.Join(fields, ", ") + " }"
+	case bson.A:
+		elems := make([]string, len(v))
+		for i, elem := range v {
+			elems[i] = mongoshValue(elem)
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case string:
+		return jsString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int32:
+		return fmt.Sprintf("NumberInt(%d)", v)
+	case int64:
+		return fmt.Sprintf("NumberLong(%q)", strconv.FormatInt(v, 10))
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NaN"
+		case math.IsInf(v, 1):
+			return "Infinity"
+		case math.IsInf(v, -1):
+			return "-Infinity"
+		case v == math.Trunc(v):
+			return fmt.Sprintf("Double(%s)", strconv.FormatFloat(v, 'g', -1, 64)) // A plain whole number would be stored as an int
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case primitive.ObjectID:
+		return fmt.Sprintf("ObjectId(%q)", v.Hex())
+	case primitive.DateTime:
+		t := v.Time().UTC()
+		if t.Year() < 0 || t.Year() > 9999 {
+			return fmt.Sprintf("new Date(%d)", int64(v))
+		}
+		return fmt.Sprintf("ISODate(%q)", t.Format("2006-01-02T15:04:05.000Z"))
+	case primitive.Decimal128:
+		return fmt.Sprintf("NumberDecimal(%q)", v.String())
+	case primitive.Timestamp:
+		return fmt.Sprintf("Timestamp({ t: %d, i: %d })", v.T, v.I)
+	case primitive.Binary:
+		if v.Subtype == 4 && len(v.Data) == 16 {
+			h := hex.EncodeToString(v.Data)
+			return fmt.Sprintf("UUID(\"%s-%s-%s-%s-%s\")", h[:8], h[8:12], h[12:16], h[16:20], h[20:])
+		}
+		return fmt.Sprintf("BinData(%d, %q)", v.Subtype, base64.StdEncoding.EncodeToString(v.Data))
+	case primitive.Regex:
+		return fmt.Sprintf("BSONRegExp(%s, %s)", jsString(v.Pattern), jsString(v.Options))
+	case primitive.JavaScript:
+		return fmt.Sprintf("Code(%s)", jsString(string(v)))
+	case primitive.CodeWithScope:
+		return fmt.Sprintf("Code(%s, %s)", jsString(string(v.Code)), mongoshValue(v.Scope))
+	case primitive.MinKey:
+		return "MinKey()"
+	case primitive.MaxKey:
+		return "MaxKey()"
+	case primitive.Undefined:
+		return "undefined"
+	case primitive.Null:
+		return "null"
+	}
+	// Rare types, such as symbols and DB pointers, go through EJSON
+	data, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: v}}, true, false)
+	if err != nil {
+		return "null"
+	}
+	return fmt.Sprintf("EJSON.parse(%s).v", jsString(string(data)))
+}
+
+// exportFiles writes an export to one file, or when split limits are given
+// to numbered files next to it, each complete in itself, and a manifest
+// listing them.
+type exportFiles struct {
+	path     string
+	format   exportFormat
+	maxBytes int64 // Bytes per file before starting the next one, 0 for no limit
+	maxDocs  int   // Documents per file, 0 for no limit
+	files    []exportedFile
+	f        *os.File
+	buf      *bufio.Writer
+	w        *countingWriter
+}
+
+// exportedFile is an entry of the manifest of a split export.
+type exportedFile struct {
+	File      string `json:"file"`
+	Documents int    `json:"documents"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// exportManifest describes a split export, so it can be loaded back in order
+// and checked for completeness.
+type exportManifest struct {
+	Namespace string         `json:"namespace"`
+	Filter    string         `json:"filter"`
+	Format    string         `json:"format"`
+	Created   time.Time      `json:"created"`
+	Documents int            `json:"documents"`
+	Files     []exportedFile `json:"files"`
+}
+
+// exportCheckpoint is saved when an export is detached on quit, for
+// `export --resume` to carry on after the last document written. Only
+// exports in _id order have one, so the rest is the documents past After.
+type exportCheckpoint struct {
+	Args      []string        `json:"args"` // Arguments of the export
+	Unmask    bool            `json:"unmask"`
+	Namespace string          `json:"namespace"`
+	Created   time.Time       `json:"created"`
+	After     json.RawMessage `json:"after,omitempty"` // {"_id": ...} of the last document written, in canonical Extended JSON
+	Files     []exportedFile  `json:"files"`
+}
+
+// checkpointName returns the name of the checkpoint of a detached export,
+// e.g. orders.checkpoint.json for orders.ndjson.
+func checkpointName(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".checkpoint.json"
+}
+
+func loadExportCheckpoint(path string) (*exportCheckpoint, error) {
+	data, err := os.ReadFile(checkpointName(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("export: no checkpoint %s to resume from", checkpointName(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp exportCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("export: invalid checkpoint %s: %w", checkpointName(path), err)
+	}
+	return &cp, nil
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (e *exportFiles) split() bool {
+	return e.maxBytes > 0 || e.maxDocs > 0
+}
+
+// splitName returns the name of the n-th file of a split export, e.g.
+// orders.003.ndjson for orders.ndjson.
+func splitName(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.%03d%s", strings.TrimSuffix(path, ext), n, ext)
+}
+
+// manifestName returns the name of the manifest of a split export, e.g.
+// orders.manifest.json for orders.ndjson.
+func manifestName(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".manifest.json"
+}
+
+func (e *exportFiles) write(doc bson.D) error {
+	if e.f == nil || e.full() {
+		if err := e.next(); err != nil {
+			return err
+		}
+	}
+	if err := e.format.write(e.w, doc); err != nil {
+		return err
+	}
+	e.files[len(e.files)-1].Documents++
+	return nil
+}
+
+// full reports whether the current file reached a split limit. A file always
+// gets at least one document, even one bigger than the size limit.
+func (e *exportFiles) full() bool {
+	current := e.files[len(e.files)-1]
+	return (e.maxDocs > 0 && current.Documents >= e.maxDocs) || (e.maxBytes > 0 && e.w.n >= e.maxBytes)
+}
+
+// next closes the current file and starts the next one.
+func (e *exportFiles) next() error {
+	if err := e.closeFile(); err != nil {
+		return err
+	}
+	name := e.path
+	if e.split() {
+		name = splitName(e.path, len(e.files)+1)
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	e.f, e.buf = f, bufio.NewWriter(f)
+	e.w = &countingWriter{w: e.buf}
+	e.files = append(e.files, exportedFile{File: filepath.Base(name)})
+	return e.format.begin(e.w)
+}
+
+func (e *exportFiles) closeFile() error {
+	if e.f == nil {
+		return nil
+	}
+	f := e.f
+	e.f = nil
+	if err := e.format.end(e.w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := e.buf.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	e.files[len(e.files)-1].Bytes = e.w.n
+	return f.Close()
+}
+
+// finish closes the last file, creating an empty one if nothing was
+// exported, and writes the manifest of a split export.
+func (e *exportFiles) finish(manifest exportManifest) error {
+	if len(e.files) == 0 {
+		if err := e.next(); err != nil {
+			return err
+		}
+	}
+	if err := e.closeFile(); err != nil {
+		return err
+	}
+	if !e.split() {
+		return nil
+	}
+	manifest.Files = e.files
+	for _, f := range e.files {
+		manifest.Documents += f.Documents
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestName(e.path), append(data, '\n'), 0o644)
+}
+
+// reopen continues an export detached with files written, appending to
+// the last of them.
+func (e *exportFiles) reopen(files []exportedFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+	e.files = files
+	last := files[len(files)-1]
+	f, err := os.OpenFile(filepath.Join(filepath.Dir(e.path), last.File), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	e.f, e.buf = f, bufio.NewWriter(f)
+	e.w = &countingWriter{w: e.buf, n: last.Bytes}
+	return nil
+}
+
+// detach closes the current file, complete in itself, and saves cp with the
+// files written, for the export to be resumed.
+func (e *exportFiles) detach(cp exportCheckpoint) error {
+	if err := e.closeFile(); err != nil {
+		return err
+	}
+	cp.Files = e.files
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(checkpointName(e.path), append(data, '\n'), 0o644)
+}
+
+// abort closes the current file after a failure.
+func (e *exportFiles) abort() {
+	if e.f != nil {
+		e.f.Close()
+	}
+}
+
+// export writes the documents of the current collection matching a filter to
+// a file. `export --resume <file>` carries on with an export detached on
+// quit, from its checkpoint.
+func (m *model) export(args []string) (tea.Model, tea.Cmd) {
+	if len(args) > 0 && args[0] == "--resume" {
+		if len(args) != 2 {
+			m.err = fmt.Errorf("usage: export --resume <file>")
+			return m, nil
+		}
+		cp, err := loadExportCheckpoint(args[1])
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.unmask = cp.Unmask
+		return m.startExport(cp.Args, cp)
+	}
+	return m.startExport(args, nil)
+}
+
+// startExport runs the export of args, continuing after cp if it is set.
+func (m *model) startExport(args []string, cp *exportCheckpoint) (tea.Model, tea.Cmd) {
+	const usage = "usage: export <file> ['<filter>'] [--format ndjson|mongosh] [--sort '<sort>'] [--limit N] [--chunk N] [--split-size <size>] [--split-docs N] | export --resume <file>"
+	fs := commands.NewFlagSet("export")
+	format := fs.String("format", "ndjson", "ndjson (canonical Extended JSON, one document per line) or mongosh (an insertMany script)")
+	sortFlag := fs.String("sort", "", "sort document")
+	limit := fs.Int64("limit", 0, "maximum number of documents, 0 for all")
+	chunk := fs.Int("chunk", defaultExportChunk, "documents per insertMany call of a mongosh script")
+	splitSize := fs.String("split-size", "", "start a new file after this many bytes, e.g. 100MB")
+	splitDocs := fs.Int("split-docs", 0, "start a new file after this many documents")
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) < 1 || len(positional) > 2 || *chunk <= 0 || *limit < 0 || *splitDocs < 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	var maxBytes int64
+	if *splitSize != "" {
+		if maxBytes, err = commands.ParseByteSize(*splitSize); err != nil || maxBytes <= 0 {
+			m.err = fmt.Errorf("export: invalid --split-size %s", *splitSize)
+			return m, nil
+		}
+	}
+	var db, coll string
+	if cp != nil {
+		db, coll, _ = strings.Cut(cp.Namespace, ".")
+	} else if db, coll, err = m.collectionPath("export"); err != nil {
+		m.err = err
+		return m, nil
+	}
+	path := positional[0]
+
+	filter := bson.D{}
+	if len(positional) == 2 {
+		if filter, err = commands.ParseDocument(positional[1]); err != nil {
+			m.err = fmt.Errorf("export: invalid filter: %w", err)
+			return m, nil
+		}
+	}
+	deprecated, err := checkOperators(m.serverVersion, filter)
+	if err != nil {
+		m.err = fmt.Errorf("export: %w", err)
+		return m, nil
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}) // Lets a detached export resume after the last _id
+	resumable := *sortFlag == ""
+	if *sortFlag != "" {
+		sort, err := commands.ParseDocument(*sortFlag)
+		if err != nil {
+			m.err = fmt.Errorf("export: invalid sort: %w", err)
+			return m, nil
+		}
+		findOptions.SetSort(sort)
+	}
+	exported := filter
+	if cp != nil && len(cp.After) > 0 { // Without After it was detached before writing anything
+		var after bson.D
+		if err := bson.UnmarshalExtJSON(cp.After, true, &after); err != nil || len(after) != 1 || !resumable {
+			m.err = fmt.Errorf("export: invalid checkpoint %s", checkpointName(path))
+			return m, nil
+		}
+		filter = bson.D{{Key: "$and", Value: bson.A{filter, bson.D{{Key: "_id", Value: bson.D{{Key: "$gt", Value: after[0].Value}}}}}}}
+		if *limit > 0 {
+			*limit -= int64(exportedDocuments(cp.Files)) // A detached export has documents left
+		}
+	}
+	if *limit > 0 {
+		findOptions.SetLimit(*limit)
+	}
+	if m.batchSize > 0 {
+		findOptions.SetBatchSize(m.batchSize)
+	}
+
+	var out exportFormat
+	switch *format {
+	case "ndjson":
+		out = ndjsonFormat{}
+	case "mongosh":
+		out = &mongoshFormat{db: db, coll: coll, chunk: *chunk}
+	default:
+		m.err = fmt.Errorf("export: unknown format %s, use ndjson or mongosh", *format)
+		return m, nil
+	}
+	masks := m.masks
+	if m.unmask {
+		masks = nil
+	}
+	files := &exportFiles{path: path, format: out, maxBytes: maxBytes, maxDocs: *splitDocs}
+	manifest := exportManifest{Namespace: db + "." + coll, Filter: extJSON(exported), Format: *format}
+	checkpoint := exportCheckpoint{Args: args, Unmask: m.unmask, Namespace: manifest.Namespace}
+	n := 0
+	if cp != nil {
+		checkpoint.Created, checkpoint.After, n = cp.Created, cp.After, exportedDocuments(cp.Files)
+	}
+	detached := make(chan struct{})
+
+	cmd := m.runWithTimeout(0, func(ctx context.Context) tea.Msg { // Big collections take a while
+		cur, err := m.store.Find(ctx, db, coll, filter, findOptions)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		defer cur.Close(ctx)
+		defer files.abort()
+		if cp != nil {
+			if err := files.reopen(cp.Files); err != nil {
+				return mongoMsg{err: err}
+			}
+		} else {
+			checkpoint.Created = time.Now().UTC()
+		}
+
+		for cur.Next(ctx) {
+			select {
+			case <-detached:
+				if err := files.detach(checkpoint); err != nil {
+					return mongoMsg{err: err}
+				}
+				return mongoMsg{result: message(fmt.Sprintf("export detached after %d documents, continue it with export --resume %s", n, path))}
+			default:
+			}
+			var doc bson.D
+			if err := cur.Decode(&doc); err != nil {
+				return mongoMsg{err: err}
+			}
+			var after []byte
+			for _, e := range doc {
+				if e.Key == "_id" {
+					if after, err = bson.MarshalExtJSON(bson.D{e}, true, false); err != nil {
+						return mongoMsg{err: err}
+					}
+				}
+			}
+			if len(masks) > 0 {
+				doc = masks.value(doc, nil).(bson.D)
+			}
+			if err := files.write(doc); err != nil {
+				return mongoMsg{err: err}
+			}
+			checkpoint.After = after
+			n++
+		}
+		if err := cur.Err(); err != nil {
+			return mongoMsg{err: err}
+		}
+		manifest.Created = checkpoint.Created
+		if err := files.finish(manifest); err != nil {
+			return mongoMsg{err: err}
+		}
+		os.Remove(checkpointName(path)) // Of an earlier export detached, if any
+		var warnings []string
+		if len(masks) > 0 {
+			warnings = append(warnings, "masked fields were exported as "+maskedValue+"; add --unmask to export their values")
+		}
+		done := fmt.Sprintf("exported %d documents from %s.%s to %s", n, db, coll, path)
+		if files.split() {
+			done = fmt.Sprintf("exported %d documents from %s.%s to %d files %s to %s, listed in %s",
+				n, db, coll, len(files.files), files.files[0].File, files.files[len(files.files)-1].File, manifestName(path))
+		}
+		return mongoMsg{result: message(done), warnings: append(deprecated, warnings...)}
+	})
+	if resumable {
+		m.running.detach = sync.OnceFunc(func() { close(detached) })
+	}
+	return m, cmd
+}
+
+// exportedDocuments counts the documents of files.
+func exportedDocuments(files []exportedFile) int {
+	n := 0
+	for _, f := range files {
+		n += f.Documents
+	}
+	return n
+}
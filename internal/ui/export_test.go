@@ -0,0 +1,211 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+func TestMongoshValue(t *testing.T) {
+	id, _ := primitive.ObjectIDFromHex("65a1b2c3d4e5f60718293a4b")
+	date := primitive.NewDateTimeFromTime(time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC))
+	dec, _ := primitive.ParseDecimal128("12.50")
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{bson.D{{Key: "_id", Value: id}, {Key: "n", Value: int32(3)}}, `{ "_id": ObjectId("65a1b2c3d4e5f60718293a4b"), "n": NumberInt(3) }`},
+		{int64(1) << 40, `NumberLong("1099511627776")`},
+		{2.0, "Double(2)"},
+		{2.5, "2.5"},
+		{math.Inf(-1), "-Infinity"},
+		{date, `ISODate("2024-03-01T12:30:00.000Z")`},
+		{dec, `NumberDecimal("12.50")`},
+		{bson.A{"a<b", true, nil}, `["a<b", true, null]`},
+		{primitive.Binary{Subtype: 4, Data: []byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0, 0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0}}, `UUID("12345678-9abc-def0-1234-56789abcdef0")`},
+		{primitive.Binary{Subtype: 0, Data: []byte("hi")}, `BinData(0, "aGk=")`},
+		{primitive.Timestamp{T: 10, I: 2}, "Timestamp({ t: 10, i: 2 })"},
+		{primitive.Regex{Pattern: "^a/b", Options: "i"}, `BSONRegExp("^a/b", "i")`},
+		{primitive.Symbol("s"), `EJSON.parse("{\"v\":{\"$symbol\":\"s\"}}").v`},
+	}
+	for _, tt := range tests {
+		if got := mongoshValue(tt.v); got != tt.want {
+			t.Errorf("mongoshValue(%#v)\n got  %s\n want %s", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	m := newTestModel(seededFake())
+	m.masks = maskRules{"price"}
+	run(t, m, "cd shop/orders")
+	dir := t.TempDir()
+
+	script := filepath.Join(dir, "orders.js")
+	run(t, m, "export "+script+` '{"qty": {"$gt": 2}}' --format mongosh --chunk 1 --sort '{"qty": 1}'`)
+	if got := output(t, m); !strings.Contains(got, "exported 2 documents") {
+		t.Errorf("export: %q", got)
+	}
+	data, err := os.ReadFile(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(data)
+	if strings.Count(text, `db.getSiblingDB("shop").getCollection("orders").insertMany([`) != 2 || strings.Count(text, "]);") != 2 {
+		t.Errorf("expected two insertMany calls:\n%s", text)
+	}
+	if !strings.Contains(text, `"item": "apple", "qty": NumberInt(5), "price": "***"`) {
+		t.Errorf("document not exported masked:\n%s", text)
+	}
+
+	ndjson := filepath.Join(dir, "orders.ndjson")
+	run(t, m, "export "+ndjson+" --unmask")
+	output(t, m)
+	data, err = os.ReadFile(ndjson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], `"price":{"$numberDouble":"2.0"}`) {
+		t.Errorf("ndjson export:\n%s", data)
+	}
+
+	expectError(t, m, "export "+ndjson+" --format csv", "unknown format csv")
+}
+
+func TestExportSplit(t *testing.T) {
+	fake := store.NewFake()
+	for i := 0; i < 25; i++ {
+		fake.Seed("db", "items", bson.D{{Key: "n", Value: i}})
+	}
+	m := newTestModel(fake)
+	run(t, m, "cd db/items")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "items.js")
+
+	run(t, m, "export "+path+" --format mongosh --chunk 4 --split-docs 10")
+	if got := output(t, m); !strings.Contains(got, "to 3 files items.001.js to items.003.js") {
+		t.Errorf("split export: %q", got)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "items.manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest exportManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Documents != 25 || len(manifest.Files) != 3 || manifest.Files[2].Documents != 5 || manifest.Namespace != "db.items" {
+		t.Errorf("manifest: %+v", manifest)
+	}
+	last, err := os.ReadFile(filepath.Join(dir, "items.003.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(last)) != manifest.Files[2].Bytes || strings.Count(string(last), "insertMany") != 2 || !strings.HasSuffix(string(last), "]);\n") {
+		t.Errorf("last file is not a complete script:\n%s", last)
+	}
+
+	sized := filepath.Join(dir, "sized.ndjson")
+	run(t, m, "export "+sized+" --split-size 200")
+	output(t, m)
+	data, _ = os.ReadFile(filepath.Join(dir, "sized.manifest.json"))
+	json.Unmarshal(data, &manifest)
+	for _, f := range manifest.Files[:len(manifest.Files)-1] {
+		if f.Bytes < 200 || f.Bytes > 300 {
+			t.Errorf("%s has %d bytes, want just over 200", f.File, f.Bytes)
+		}
+	}
+	if manifest.Documents != 25 {
+		t.Errorf("size-split export has %d documents", manifest.Documents)
+	}
+
+	expectError(t, m, "export "+sized+" --split-size lots", "invalid --split-size")
+}
+
+// detachingStore detaches the running export of m when its cursor is asked
+// for document n+1, once n documents are written.
+type detachingStore struct {
+	store.Store
+	m *model
+	n int
+}
+
+func (s detachingStore) Find(ctx context.Context, db, coll string, filter interface{}, opts ...*options.FindOptions) (store.Cursor, error) {
+	cur, err := s.Store.Find(ctx, db, coll, filter, opts...)
+	return &detachingCursor{Cursor: cur, m: s.m, n: s.n}, err
+}
+
+type detachingCursor struct {
+	store.Cursor
+	m    *model
+	n    int
+	next int
+}
+
+func (c *detachingCursor) Next(ctx context.Context) bool {
+	c.next++
+	if c.next == c.n+1 {
+		c.m.running.detach()
+	}
+	return c.Cursor.Next(ctx)
+}
+
+func TestExportDetachAndResume(t *testing.T) {
+	fake := store.NewFake()
+	for i := 0; i < 5; i++ {
+		fake.Seed("db", "items", bson.D{{Key: "n", Value: i}})
+	}
+	m := newTestModel(fake)
+	run(t, m, "cd db/items")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "items.js")
+
+	m.store = detachingStore{Store: fake, m: m, n: 3}
+	run(t, m, "export "+path+" --format mongosh --chunk 10 --split-docs 2")
+	if got := output(t, m); !strings.Contains(got, "export detached after 3 documents") {
+		t.Fatalf("detached export: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "items.checkpoint.json")); err != nil {
+		t.Fatalf("no checkpoint: %v", err)
+	}
+
+	m.store = fake
+	run(t, m, "cd /")
+	run(t, m, "export --resume "+path)
+	if got := output(t, m); !strings.Contains(got, "exported 5 documents from db.items to 3 files") {
+		t.Errorf("resumed export: %q", got)
+	}
+	var all string
+	for _, name := range []string{"items.001.js", "items.002.js", "items.003.js"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		all += string(data)
+	}
+	for i := 0; i < 5; i++ {
+		if n := strings.Count(all, fmt.Sprintf(`"n": NumberInt(%d)`, i)); n != 1 {
+			t.Errorf("document %d exported %d times:\n%s", i, n, all)
+		}
+	}
+	if strings.Count(all, "insertMany([") != strings.Count(all, "]);") {
+		t.Errorf("unclosed insertMany:\n%s", all)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "items.checkpoint.json")); !os.IsNotExist(err) {
+		t.Errorf("checkpoint left after the export finished: %v", err)
+	}
+	expectError(t, m, "export --resume "+path, "no checkpoint")
+}
@@ -0,0 +1,197 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+// statPercentiles are the percentiles fieldstats computes.
+var statPercentiles = []float64{0.5, 0.9, 0.95, 0.99}
+
+// numericFilter restricts filter to documents where field is a number.
+func numericFilter(field string, filter bson.D) bson.D {
+	numeric := bson.D{{Key: field, Value: bson.D{{Key: "$type", Value: "number"}}}}
+	if len(filter) == 0 {
+		return numeric
+	}
+	return bson.D{{Key: "$and", Value: bson.A{filter, numeric}}}
+}
+
+// statsPipeline computes the statistics of field over the documents matching
+// filter, and counts those documents. $percentile needs MongoDB 7.0; on older
+// servers, percentiles are left to percentileAt.
+func statsPipeline(field string, filter bson.D, withPercentiles bool) []bson.D {
+	group := bson.D{
+		{Key: "_id", Value: nil},
+		{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		{Key: "min", Value: bson.D{{Key: "$min", Value: "$" + field}}},
+		{Key: "max", Value: bson.D{{Key: "$max", Value: "$" + field}}},
+		{Key: "avg", Value: bson.D{{Key: "$avg", Value: "$" + field}}},
+		{Key: "sum", Value: bson.D{{Key: "$sum", Value: "$" + field}}},
+		{Key: "stddev", Value: bson.D{{Key: "$stdDevPop", Value: "$" + field}}},
+	}
+	if withPercentiles {
+		group = append(group, bson.E{Key: "percentiles", Value: bson.D{{Key: "$percentile", Value: bson.D{
+			{Key: "input", Value: "$" + field},
+			{Key: "p", Value: statPercentiles},
+			{Key: "method", Value: "approximate"},
+		}}}})
+	}
+	var pipeline []bson.D
+	if len(filter) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: filter}})
+	}
+	return append(pipeline, bson.D{{Key: "$facet", Value: bson.D{
+		{Key: "stats", Value: bson.A{
+			bson.D{{Key: "$match", Value: numericFilter(field, nil)}},
+			bson.D{{Key: "$group", Value: group}},
+		}},
+		{Key: "total", Value: bson.A{bson.D{{Key: "$count", Value: "n"}}}},
+	}}})
+}
+
+// numericStats is the result of `fieldstats`.
+type numericStats struct {
+	field                      string
+	documents, count           int64 // Documents matching, and those of them with a numeric value
+	min, max, avg, sum, stddev float64
+	percentiles                []float64 // Of statPercentiles
+}
+
+// formatStat writes a statistic with at most four decimals.
+func formatStat(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 4, 64)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+func (s numericStats) String() string {
+	if s.count == 0 {
+		return fmt.Sprintf("%s has no numeric value in the %s matching documents\n", s.field, groupDigits(s.documents))
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s: %s numeric values", s.field, groupDigits(s.count)))
+	if other := s.documents - s.count; other > 0 {
+		b.WriteString(fmt.Sprintf(", %s documents without one", groupDigits(other)))
+	}
+	b.WriteString("\n\n")
+	rows := [][2]string{
+		{"min", formatStat(s.min)},
+		{"max", formatStat(s.max)},
+		{"avg", formatStat(s.avg)},
+		{"sum", formatStat(s.sum)},
+		{"stddev", formatStat(s.stddev)},
+	}
+	for i, p := range s.percentiles {
+		rows = append(rows, [2]string{fmt.Sprintf("p%g", 100*statPercentiles[i]), formatStat(p)})
+	}
+	for _, row := range rows {
+		b.WriteString(fmt.Sprintf("%-6s  %s\n", row[0], row[1]))
+	}
+	return b.String()
+}
+
+// percentileAt reads the value at percentile p of the count numeric values
+// of field, by sorting, for servers without $percentile.
+func percentileAt(ctx context.Context, st store.Store, db, coll, field string, filter bson.D, count int64, p float64) (float64, error) {
+	skip := int64(p*float64(count-1) + 0.5)
+	opts := options.Find().SetSort(bson.D{{Key: field, Value: 1}}).SetSkip(skip).SetLimit(1).SetProjection(bson.D{{Key: field, Value: 1}})
+	cur, err := st.Find(ctx, db, coll, numericFilter(field, filter), opts)
+	if err != nil {
+		return 0, err
+	}
+	var docs []bson.M
+	if err := cur.All(ctx, &docs); err != nil {
+		return 0, err
+	}
+	if len(docs) == 0 {
+		return 0, store.ErrNoDocuments
+	}
+	v, _ := asFloat(lookupPath(docs[0], strings.Split(field, ".")))
+	return v, nil
+}
+
+// fieldstats computes statistics of a numeric field of the current
+// collection.
+func (m *model) fieldstats(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: fieldstats <field> ['<filter>']"
+
+	db, coll, err := m.collectionPath("fieldstats")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	if len(args) < 1 || len(args) > 2 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	field := strings.TrimPrefix(args[0], "$")
+	var filter bson.D
+	if len(args) == 2 {
+		if filter, err = commands.ParseDocument(args[1]); err != nil {
+			m.err = fmt.Errorf("fieldstats: invalid filter: %w", err)
+			return m, nil
+		}
+	}
+	deprecated, err := checkOperators(m.serverVersion, filter)
+	if err != nil {
+		m.err = fmt.Errorf("fieldstats: %w", err)
+		return m, nil
+	}
+
+	native := versionAtLeast(m.serverVersion, "7.0")
+	pipeline := statsPipeline(field, filter, native)
+	return m, m.runWithTimeout(0, func(ctx context.Context) tea.Msg { // Reads every matching document
+		cur, err := m.store.Aggregate(ctx, db, coll, pipeline)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		defer cur.Close(ctx)
+		var facets []struct {
+			Stats []struct {
+				Count                      int64
+				Min, Max, Avg, Sum, Stddev interface{}
+				Percentiles                []interface{}
+			}
+			Total []struct{ N int64 }
+		}
+		if err := cur.All(ctx, &facets); err != nil {
+			return mongoMsg{err: err}
+		}
+
+		s := numericStats{field: field}
+		if len(facets) != 1 || len(facets[0].Total) == 0 {
+			return mongoMsg{result: s, warnings: deprecated}
+		}
+		s.documents = facets[0].Total[0].N
+		if len(facets[0].Stats) == 0 {
+			return mongoMsg{result: s, warnings: deprecated}
+		}
+		st := facets[0].Stats[0]
+		s.count = st.Count
+		s.min, _ = asFloat(st.Min)
+		s.max, _ = asFloat(st.Max)
+		s.avg, _ = asFloat(st.Avg)
+		s.sum, _ = asFloat(st.Sum)
+		s.stddev, _ = asFloat(st.Stddev)
+		for i, p := range statPercentiles {
+			var v float64
+			if native && i < len(st.Percentiles) {
+				v, _ = asFloat(st.Percentiles[i])
+			} else if v, err = percentileAt(ctx, m.store, db, coll, field, filter, s.count, p); err != nil {
+				return mongoMsg{err: err}
+			}
+			s.percentiles = append(s.percentiles, v)
+		}
+		return mongoMsg{result: s, warnings: deprecated}
+	})
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestStatsPipeline(t *testing.T) {
+	got := extJSON(bson.D{{Key: "p", Value: statsPipeline("price", bson.D{{Key: "item", Value: "apple"}}, true)}})
+	for _, want := range []string{`{"$match":{"item":"apple"}}`, `{"price":{"$type":"number"}}`, `"$stdDevPop":"$price"`, `"$percentile":{"input":"$price","p":[0.5,0.9,0.95,0.99],"method":"approximate"}`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("pipeline lacks %s:\n%s", want, got)
+		}
+	}
+	if got := extJSON(bson.D{{Key: "p", Value: statsPipeline("price", nil, false)}}); strings.Contains(got, "$percentile") || strings.Contains(got, `"$match":{"item"`) {
+		t.Errorf("pipeline for old servers:\n%s", got)
+	}
+	if got := numericFilter("qty", bson.D{{Key: "a", Value: 1}}); got[0].Key != "$and" {
+		t.Errorf("numericFilter with a filter = %v", got)
+	}
+}
+
+func TestNumericStats(t *testing.T) {
+	s := numericStats{field: "price", documents: 1200, count: 1000, min: 0.5, max: 120, avg: 12.345678, sum: 12345.678, stddev: 3.25, percentiles: []float64{10, 20, 30, 99.5}}
+	got := s.String()
+	for _, want := range []string{"price: 1,000 numeric values, 200 documents without one", "min     0.5\n", "max     120\n", "avg     12.3457\n", "p50     10\n", "p99     99.5\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("stats lack %q:\n%s", want, got)
+		}
+	}
+	if got := (numericStats{field: "price", documents: 3}).String(); !strings.Contains(got, "no numeric value in the 3 matching documents") {
+		t.Errorf("without numbers: %q", got)
+	}
+}
+
+func TestFieldstatsArgs(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+	expectError(t, m, "fieldstats", "usage: fieldstats")
+	expectError(t, m, "fieldstats price '{'", "invalid filter")
+}
+
+func TestFieldstats(t *testing.T) {
+	// Without $percentile, before MongoDB 7.0, percentiles are read by
+	// sorting; both ways agree on the seeded quantities 1, 5 and 12.
+	for _, version := range []string{"6.0.0", "7.0.0"} {
+		m := newTestModel(seededFake())
+		m.serverVersion = version
+		run(t, m, "cd shop/orders")
+
+		run(t, m, "fieldstats qty")
+		got := output(t, m)
+		for _, want := range []string{"qty: 3 numeric values\n", "min     1\n", "max     12\n", "avg     6\n", "sum     18\n", "p50     5\n", "p99     12\n"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: fieldstats qty lacks %q:\n%s", version, want, got)
+			}
+		}
+		run(t, m, `fieldstats price '{"item": {"$ne": "pear"}}'`)
+		if got := output(t, m); !strings.Contains(got, "price: 2 numeric values") || !strings.Contains(got, "avg     1.25\n") {
+			t.Errorf("%s: fieldstats with a filter:\n%s", version, got)
+		}
+		run(t, m, "fieldstats item")
+		if got := output(t, m); got != "item has no numeric value in the 3 matching documents\n" {
+			t.Errorf("%s: fieldstats of a string field: %q", version, got)
+		}
+	}
+}
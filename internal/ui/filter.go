@@ -0,0 +1,404 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// filterNode evaluates a filter expression against a value, producing any
+// number of values the way jq does: `.items[]` turns one document into one
+// value per item, `select(...)` into none or the value itself.
+type filterNode func(v interface{}) []interface{}
+
+// valueList is the result of a filter whose output is not only documents,
+// shown one value per line.
+type valueList struct {
+	values []interface{}
+}
+
+func (l valueList) String() string {
+	var b strings.Builder
+	for _, v := range l.values {
+		b.WriteString(valueJSON(v))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// filter applies a jq-like expression to the documents shown, without going
+// back to the server, e.g. `filter '.items[].sku'` or
+// `filter 'select(.qty > 3)'`.
+func (m *model) filter(args []string) (tea.Model, tea.Cmd) {
+	if len(args) == 0 {
+		m.err = fmt.Errorf("usage: filter '<expression>'")
+		return m, nil
+	}
+	var input []interface{}
+	switch r := m.result.(type) {
+	case documentList:
+		for _, doc := range r.docs {
+			input = append(input, doc)
+		}
+	case *documentTree:
+		input = []interface{}{r.doc}
+	case valueList:
+		input = r.values
+	default:
+		m.err = fmt.Errorf("filter: no documents shown, run find or ls in a collection first")
+		return m, nil
+	}
+
+	eval, err := parseFilter(strings.Join(args, " "))
+	if err != nil {
+		m.err = fmt.Errorf("filter: %w", err)
+		return m, nil
+	}
+	var out []interface{}
+	for _, v := range input {
+		out = append(out, eval(v)...)
+	}
+
+	docs := documentList{docs: []bson.M{}}
+	for _, v := range out {
+		doc, ok := v.(bson.M)
+		if !ok {
+			m.result, m.err = valueList{values: out}, nil
+			return m, nil
+		}
+		docs.docs = append(docs.docs, doc)
+	}
+	m.result, m.err = docs, nil
+	return m, nil
+}
+
+// parseFilter parses a filter expression: paths such as `.`, `.a.b`,
+// `.items[]` and `.items[0]`, `select(<condition>)` and `|` between them.
+// Conditions compare paths and literals (numbers, "strings", true, false
+// and null) with == != < <= > >= and combine with and and or.
+func parseFilter(expr string) (filterNode, error) {
+	p := &filterParser{input: expr}
+	node, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected '%s' at position %d", p.input[p.pos:], p.pos+1)
+	}
+	return node, nil
+}
+
+type filterParser struct {
+	input string
+	pos   int
+}
+
+func (p *filterParser) skipSpace() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// accept consumes s if it comes next.
+func (p *filterParser) accept(s string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.input[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) pipe() (filterNode, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("|") {
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		first, then := left, right
+		left = func(v interface{}) []interface{} {
+			var out []interface{}
+			for _, w := range first(v) {
+				out = append(out, then(w)...)
+			}
+			return out
+		}
+	}
+	return left, nil
+}
+
+func (p *filterParser) term() (filterNode, error) {
+	p.skipSpace()
+	if !p.accept("select(") {
+		return p.path()
+	}
+	cond, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(")") {
+		return nil, fmt.Errorf("missing ')' at position %d", p.pos+1)
+	}
+	return func(v interface{}) []interface{} {
+		if cond(v) {
+			return []interface{}{v}
+		}
+		return nil
+	}, nil
+}
+
+// path parses a path, which must start with a dot.
+func (p *filterParser) path() (filterNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.input) || p.input[p.pos] != '.' {
+		return nil, fmt.Errorf("expected a path such as .field at position %d", p.pos+1)
+	}
+	var steps []filterNode
+	for p.pos < len(p.input) {
+		switch c := p.input[p.pos]; {
+		case c == '.':
+			p.pos++
+			start := p.pos
+			for p.pos < len(p.input) && isFieldChar(rune(p.input[p.pos])) {
+				p.pos++
+			}
+			if field := p.input[start:p.pos]; field != "" {
+				steps = append(steps, fieldStep(field))
+			} else if len(steps) > 0 || (p.pos < len(p.input) && p.input[p.pos] == '.') {
+				return nil, fmt.Errorf("expected a field name at position %d", p.pos+1)
+			}
+		case c == '[':
+			p.pos++
+			start := p.pos
+			for p.pos < len(p.input) && p.input[p.pos] != ']' {
+				p.pos++
+			}
+			if p.pos == len(p.input) {
+				return nil, fmt.Errorf("missing ']' at position %d", p.pos+1)
+			}
+			index := strings.TrimSpace(p.input[start:p.pos])
+			p.pos++
+			if index == "" {
+				steps = append(steps, iterateStep)
+				continue
+			}
+			n, err := strconv.Atoi(index)
+			if err != nil {
+				return nil, fmt.Errorf("invalid index '%s'", index)
+			}
+			steps = append(steps, indexStep(n))
+		default:
+			return chainSteps(steps), nil
+		}
+	}
+	return chainSteps(steps), nil
+}
+
+func isFieldChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$'
+}
+
+// chainSteps applies steps one after the other; no steps is the identity.
+func chainSteps(steps []filterNode) filterNode {
+	return func(v interface{}) []interface{} {
+		values := []interface{}{v}
+		for _, step := range steps {
+			var next []interface{}
+			for _, w := range values {
+				next = append(next, step(w)...)
+			}
+			values = next
+		}
+		return values
+	}
+}
+
+// fieldStep gives the field of a document, null if it is absent.
+func fieldStep(field string) filterNode {
+	return func(v interface{}) []interface{} {
+		doc, _ := v.(bson.M)
+		return []interface{}{doc[field]}
+	}
+}
+
+// iterateStep gives the elements of an array or the values of a document.
+func iterateStep(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case bson.A:
+		return v
+	case bson.M:
+		var out []interface{}
+		for _, w := range v {
+			out = append(out, w)
+		}
+		return out
+	}
+	return nil
+}
+
+// indexStep gives an element of an array, counting from the end if n is
+// negative, and null past either end.
+func indexStep(n int) filterNode {
+	return func(v interface{}) []interface{} {
+		a, _ := v.(bson.A)
+		i := n
+		if i < 0 {
+			i += len(a)
+		}
+		if i < 0 || i >= len(a) {
+			return []interface{}{nil}
+		}
+		return []interface{}{a[i]}
+	}
+}
+
+// filterCond decides whether select keeps a value.
+type filterCond func(v interface{}) bool
+
+func (p *filterParser) or() (filterCond, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or ") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		a, b := left, right
+		left = func(v interface{}) bool { return a(v) || b(v) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) and() (filterCond, error) {
+	left, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and ") {
+		right, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		a, b := left, right
+		left = func(v interface{}) bool { return a(v) && b(v) }
+	}
+	return left, nil
+}
+
+// comparisons maps operators to what compareValues must return. Longer
+// operators come first so <= is not read as <.
+var comparisons = []struct {
+	op   string
+	test func(c int) bool
+}{
+	{"==", func(c int) bool { return c == 0 }},
+	{"!=", func(c int) bool { return c != 0 }},
+	{"<=", func(c int) bool { return c <= 0 }},
+	{">=", func(c int) bool { return c >= 0 }},
+	{"<", func(c int) bool { return c < 0 }},
+	{">", func(c int) bool { return c > 0 }},
+}
+
+// comparison parses `<operand> <op> <operand>`, or a lone operand, which
+// holds when it is neither null nor false. A comparison holds if it does
+// for any of the values the operands produce.
+func (p *filterParser) comparison() (filterCond, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range comparisons {
+		if !p.accept(c.op) {
+			continue
+		}
+		right, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		test := c.test
+		return func(v interface{}) bool {
+			for _, a := range left(v) {
+				for _, b := range right(v) {
+					if test(compareFilterValues(a, b)) {
+						return true
+					}
+				}
+			}
+			return false
+		}, nil
+	}
+	return func(v interface{}) bool {
+		for _, a := range left(v) {
+			if a != nil && a != false {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// operand parses a path or a literal.
+func (p *filterParser) operand() (filterNode, error) {
+	p.skipSpace()
+	rest := p.input[p.pos:]
+	for _, lit := range []struct {
+		text  string
+		value interface{}
+	}{{"true", true}, {"false", false}, {"null", nil}} {
+		if strings.HasPrefix(rest, lit.text) {
+			p.pos += len(lit.text)
+			return constant(lit.value), nil
+		}
+	}
+	if strings.HasPrefix(rest, `"`) {
+		s, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return nil, fmt.Errorf("unterminated string at position %d", p.pos+1)
+		}
+		p.pos += len(s)
+		value, _ := strconv.Unquote(s)
+		return constant(value), nil
+	}
+	if rest != "" && (rest[0] == '-' || (rest[0] >= '0' && rest[0] <= '9')) {
+		end := 1
+		for end < len(rest) && (rest[end] == '.' || (rest[end] >= '0' && rest[end] <= '9')) {
+			end++
+		}
+		n, err := strconv.ParseFloat(rest[:end], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", rest[:end])
+		}
+		p.pos += end
+		return constant(n), nil
+	}
+	return p.path()
+}
+
+func constant(value interface{}) filterNode {
+	return func(interface{}) []interface{} { return []interface{}{value} }
+}
+
+// compareFilterValues orders values like compareValues, with null before
+// everything else.
+func compareFilterValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return compareValues(a, b)
+}
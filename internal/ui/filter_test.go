@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseFilter(t *testing.T) {
+	doc := bson.M{
+		"name":  "ann",
+		"qty":   int32(5),
+		"items": bson.A{bson.M{"sku": "a1", "n": 2.0}, bson.M{"sku": "b2", "n": 7.0}},
+		"tags":  bson.A{"x", "y"},
+	}
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{". | .tags", `["x","y"]`},
+		{".name", `"ann"`},
+		{".missing", `null`},
+		{".items[].sku", `"a1" "b2"`},
+		{".tags[-1]", `"y"`},
+		{".items[] | select(.n > 3) | .sku", `"b2"`},
+		{`select(.name == "ann" and .qty >= 5) | .qty`, `5`},
+		{`select(.name == "bob" or .qty < 1)`, ``},
+		{`select(.items[].n == 7) | .name`, `"ann"`},
+		{`select(.missing) | .name`, ``},
+	}
+	for _, tt := range tests {
+		eval, err := parseFilter(tt.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		var got []string
+		for _, v := range eval(doc) {
+			got = append(got, valueJSON(v))
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s = %s, want %s", tt.expr, strings.Join(got, " "), tt.want)
+		}
+	}
+
+	for _, expr := range []string{"name", ".items[", "select(.a > 1", ".a ]", ".a..b"} {
+		if _, err := parseFilter(expr); err == nil {
+			t.Errorf("%s: parsed, want an error", expr)
+		}
+	}
+}
+
+func TestFilterCommand(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+	run(t, m, "find --limit 0")
+
+	run(t, m, "filter 'select(.qty > 3)'")
+	if got := docCount(t, m); got != 2 {
+		t.Fatalf("select(.qty > 3) kept %d documents, want 2", got)
+	}
+	run(t, m, "filter .item")
+	if got := output(t, m); got != "\"apple\"\n\"plum\"\n" {
+		t.Fatalf("filter .item: %q", got)
+	}
+}
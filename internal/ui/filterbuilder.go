@@ -0,0 +1,419 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultQuerySample is how many documents are sampled to offer fields.
+const defaultQuerySample = 200
+
+// queryOperator is an operator the filter builder offers.
+type queryOperator struct {
+	name  string // As shown and typed, e.g. ">="
+	op    string // Query operator, empty for equality
+	about string
+}
+
+var queryOperators = []queryOperator{
+	{"=", "", "equals"},
+	{"!=", "$ne", "does not equal"},
+	{">", "$gt", "greater than"},
+	{">=", "$gte", "greater than or equal to"},
+	{"<", "$lt", "less than"},
+	{"<=", "$lte", "less than or equal to"},
+	{"in", "$in", "one of a comma-separated list"},
+	{"nin", "$nin", "none of a comma-separated list"},
+	{"exists", "$exists", "is present (y) or missing (n)"},
+	{"matches", "$regex", "matches a regular expression"},
+	{"type", "$type", "has a BSON type, e.g. string or date"},
+}
+
+// queryCondition is one field condition of a built filter.
+type queryCondition struct {
+	field string
+	op    queryOperator
+	value interface{}
+}
+
+func (c queryCondition) String() string {
+	return fmt.Sprintf("%s %s %s", c.field, c.op.name, valueJSON(c.value))
+}
+
+// filter returns the condition as a query on its field.
+func (c queryCondition) filter() bson.E {
+	if c.op.op == "" {
+		return bson.E{Key: c.field, Value: c.value}
+	}
+	return bson.E{Key: c.field, Value: bson.D{{Key: c.op.op, Value: c.value}}}
+}
+
+// queryBuilder puts a filter together from conditions. Conditions within a
+// group must all hold (AND); a document matches when any group does (OR).
+type queryBuilder struct {
+	db, coll string
+	fields   []*fieldStats // Fields of sampled documents, nil until sampled
+	groups   [][]queryCondition
+}
+
+// queryFieldsMsg carries the fields sampled for the builder.
+type queryFieldsMsg struct {
+	fields []*fieldStats
+}
+
+// filter returns the filter the conditions make up.
+func (qb *queryBuilder) filter() bson.D {
+	var groups []bson.D
+	for _, group := range qb.groups {
+		if len(group) > 0 {
+			groups = append(groups, groupFilter(group))
+		}
+	}
+	switch len(groups) {
+	case 0:
+		return bson.D{}
+	case 1:
+		return groups[0]
+	}
+	or := bson.A{}
+	for _, g := range groups {
+		or = append(or, g)
+	}
+	return bson.D{{Key: "$or", Value: or}}
+}
+
+// groupFilter ANDs conditions: as one document when each field appears
+// once, with $and otherwise.
+func groupFilter(group []queryCondition) bson.D {
+	seen := map[string]bool{}
+	var doc bson.D
+	for _, c := range group {
+		if seen[c.field] {
+			and := bson.A{}
+			for _, c := range group {
+				and = append(and, bson.D{c.filter()})
+			}
+			return bson.D{{Key: "$and", Value: and}}
+		}
+		seen[c.field] = true
+		doc = append(doc, c.filter())
+	}
+	return doc
+}
+
+// remove deletes condition n, counting from 1 across groups, along with its
+// group if it was the last in it.
+func (qb *queryBuilder) remove(n int) error {
+	for g, group := range qb.groups {
+		if n <= len(group) {
+			qb.groups[g] = append(group[:n-1], group[n:]...)
+			if len(qb.groups[g]) == 0 && len(qb.groups) > 1 {
+				qb.groups = append(qb.groups[:g], qb.groups[g+1:]...)
+			}
+			return nil
+		}
+		n -= len(group)
+	}
+	return fmt.Errorf("there is no condition %d", n)
+}
+
+// fieldType is the most common type sampled for field, empty if unknown.
+func (qb *queryBuilder) fieldType(field string) string {
+	for _, f := range qb.fields {
+		if f.path == field {
+			if names := f.typeNames(); len(names) > 0 {
+				return names[0]
+			}
+		}
+	}
+	return ""
+}
+
+// pickField reads the answer to the field question: a number from the list
+// or a field path.
+func (qb *queryBuilder) pickField(answer string) (string, error) {
+	if answer == "" {
+		return "", fmt.Errorf("a field is needed")
+	}
+	if n, err := strconv.Atoi(answer); err == nil {
+		if n < 1 || n > len(qb.fields) {
+			return "", fmt.Errorf("pick a field between 1 and %d", len(qb.fields))
+		}
+		return qb.fields[n-1].path, nil
+	}
+	return answer, nil
+}
+
+// pickOperator reads the answer to the operator question: a number from the
+// list or an operator name.
+func pickOperator(answer string) (queryOperator, error) {
+	if answer == "" {
+		return queryOperators[0], nil
+	}
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(queryOperators) {
+		return queryOperators[n-1], nil
+	}
+	for _, op := range queryOperators {
+		if answer == op.name || answer == op.op {
+			return op, nil
+		}
+	}
+	return queryOperator{}, fmt.Errorf("unknown operator: %s", answer)
+}
+
+// parseQueryValue reads a value typed for op, as the BSON type the field was
+// sampled with where that helps: numbers, booleans, dates and ObjectIds are
+// otherwise easy to get wrong by hand. Values in double quotes are strings.
+func parseQueryValue(answer string, op queryOperator, fieldType string) (interface{}, error) {
+	switch op.op {
+	case "$exists":
+		return yesNo(answer, true)
+	case "$regex":
+		if answer == "" {
+			return nil, fmt.Errorf("a pattern is needed")
+		}
+		return primitive.Regex{Pattern: answer}, nil
+	case "$type":
+		for _, name := range bsonTypeNames {
+			if answer == name {
+				return answer, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown type: %s", answer)
+	case "$in", "$nin":
+		values := bson.A{}
+		for _, item := range strings.Split(answer, ",") {
+			v, err := parseTypedValue(strings.TrimSpace(item), fieldType)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+	return parseTypedValue(answer, fieldType)
+}
+
+func parseTypedValue(s, fieldType string) (interface{}, error) {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return s[1 : len(s)-1], nil
+	}
+	switch fieldType {
+	case "int", "long", "double", "decimal":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a number", s)
+		}
+		return f, nil
+	case "bool":
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not true or false", s)
+		}
+		return b, nil
+	case "date":
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%s is not a date such as 2024-05-01 or 2024-05-01T12:00:00Z", s)
+	case "objectId":
+		id, err := primitive.ObjectIDFromHex(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not an ObjectId", s)
+		}
+		return id, nil
+	case "null":
+		if s == "null" {
+			return nil, nil
+		}
+	}
+	return s, nil
+}
+
+// fieldQuestion lists the sampled fields to pick from.
+func (qb *queryBuilder) fieldQuestion() string {
+	if len(qb.fields) == 0 {
+		return "field (dotted path): "
+	}
+	var b strings.Builder
+	for i, f := range qb.fields {
+		b.WriteString(fmt.Sprintf("%3d  %s (%s)\n", i+1, f.path, strings.Join(f.typeNames(), ", ")))
+	}
+	b.WriteString("field (number or dotted path): ")
+	return b.String()
+}
+
+func operatorQuestion(field, fieldType string) string {
+	var b strings.Builder
+	for i, op := range queryOperators {
+		b.WriteString(fmt.Sprintf("%3d  %-7s  %s\n", i+1, op.name, op.about))
+	}
+	if fieldType != "" {
+		field += " (" + fieldType + ")"
+	}
+	b.WriteString(fmt.Sprintf("operator for %s (number or name) [=]: ", field))
+	return b.String()
+}
+
+func (qb *queryBuilder) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("filter builder on %s.%s (Esc to close)\n\n", qb.db, qb.coll))
+	n := 0
+	for g, group := range qb.groups {
+		if g > 0 {
+			b.WriteString("  or\n")
+		}
+		for i, c := range group {
+			n++
+			join := "   "
+			if i > 0 {
+				join = "and"
+			}
+			b.WriteString(fmt.Sprintf("%3d  %s %s\n", n, join, c))
+		}
+	}
+	if n == 0 {
+		b.WriteString("  no conditions yet, see `query add`\n")
+	}
+	b.WriteString("\nfilter: " + stageJSON(qb.filter()) + "\n")
+	return b.String()
+}
+
+func (m *model) query(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: query | add | or | rm <n> | clear | find [options] | count | close"
+
+	if len(args) == 0 {
+		return m.openQueryBuilder()
+	}
+	qb := m.queryBuilder
+	if qb == nil {
+		m.err = fmt.Errorf("query: no filter is being built, start with `query`")
+		return m, nil
+	}
+	m.err = nil
+
+	switch args[0] {
+	case "add":
+		if len(args) != 1 {
+			m.err = fmt.Errorf("usage: query add")
+			return m, nil
+		}
+		return m, m.addQueryCondition()
+
+	case "or":
+		if len(args) != 1 {
+			m.err = fmt.Errorf("usage: query or")
+			return m, nil
+		}
+		if len(qb.groups[len(qb.groups)-1]) == 0 {
+			m.err = fmt.Errorf("query or: add a condition to the current group first")
+			return m, nil
+		}
+		qb.groups = append(qb.groups, nil)
+		return m, m.addQueryCondition()
+
+	case "rm":
+		n, err := strconv.Atoi(strings.Join(args[1:], ""))
+		if len(args) != 2 || err != nil {
+			m.err = fmt.Errorf("usage: query rm <n>")
+			return m, nil
+		}
+		if err := qb.remove(n); err != nil {
+			m.err = fmt.Errorf("query rm: %w", err)
+		}
+		return m, nil
+
+	case "clear":
+		qb.groups = [][]queryCondition{nil}
+		return m, nil
+
+	case "find", "count":
+		filter := stageJSON(qb.filter())
+		if args[0] == "count" {
+			return m.count(append([]string{filter}, args[1:]...))
+		}
+		return m.find(append([]string{filter}, args[1:]...))
+
+	case "close":
+		m.queryBuilder = nil
+		return m, nil
+
+	default:
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+}
+
+// openQueryBuilder starts building a filter for the current collection and
+// samples it for the fields to offer.
+func (m *model) openQueryBuilder() (tea.Model, tea.Cmd) {
+	db, coll, err := m.collectionPath("query")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.queryBuilder = &queryBuilder{db: db, coll: coll, groups: [][]queryCondition{nil}}
+	m.result = nil
+	m.err = nil
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		analysis, err := sampleCollection(ctx, m.store, db, coll, defaultQuerySample)
+		if err != nil {
+			return mongoMsg{err: fmt.Errorf("query: sampling fields: %w", err)}
+		}
+		var fields []*fieldStats
+		for _, f := range analysis.fields {
+			if f.types["object"] == 0 || len(f.types) > 1 { // Sub-documents are offered by their fields
+				fields = append(fields, f)
+			}
+		}
+		return queryFieldsMsg{fields: fields}
+	})
+}
+
+// addQueryCondition asks for a field, an operator and a value, and adds the
+// condition to the last group.
+func (m *model) addQueryCondition() tea.Cmd {
+	qb := m.queryBuilder
+	c := &queryCondition{}
+	steps := []wizardStep{
+		{question: qb.fieldQuestion(), apply: func(s string) (err error) {
+			c.field, err = qb.pickField(s)
+			return err
+		}},
+		{apply: func(s string) (err error) {
+			c.op, err = pickOperator(s)
+			return err
+		}},
+		{apply: func(s string) (err error) {
+			c.value, err = parseQueryValue(s, c.op, qb.fieldType(c.field))
+			return err
+		}},
+	}
+	// Later questions depend on earlier answers, so they are worded when
+	// they are reached
+	steps[1].skip = func() bool {
+		steps[1].question = operatorQuestion(c.field, qb.fieldType(c.field))
+		return false
+	}
+	steps[2].skip = func() bool {
+		steps[2].question = fmt.Sprintf("value for %s %s: ", c.field, c.op.name)
+		return false
+	}
+	return m.wizard(steps, func() tea.Cmd {
+		last := len(qb.groups) - 1
+		qb.groups[last] = append(qb.groups[last], *c)
+		return nil
+	})
+}
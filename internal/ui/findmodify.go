@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+// parseUpdate parses an update: a document of update operators such as
+// {"$set": {...}}, or an aggregation pipeline. A document without operators
+// would replace the whole document, which `replace` is for.
+func parseUpdate(s string) (interface{}, error) {
+	if strings.HasPrefix(strings.TrimSpace(s), "[") {
+		pipeline, err := commands.ParsePipeline(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid update pipeline: %w", err)
+		}
+		return pipeline, nil
+	}
+	update, err := commands.ParseDocument(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid update: %w", err)
+	}
+	if len(update) == 0 {
+		return nil, fmt.Errorf("the update is empty")
+	}
+	for _, e := range update {
+		if !strings.HasPrefix(e.Key, "$") {
+			return nil, fmt.Errorf("the update has field '%s' instead of an operator such as $set; use `replace` to replace whole documents", e.Key)
+		}
+	}
+	return update, nil
+}
+
+// findModifyFlags are the options of findupdate and finddelete.
+type findModifyFlags struct {
+	filter     bson.D
+	sort       bson.D
+	projection bson.D
+	after      bool
+	rest       []string // Positional arguments after the filter
+}
+
+func parseFindModify(name string, args []string, withUpdate bool) (findModifyFlags, error) {
+	var f findModifyFlags
+	fs := commands.NewFlagSet(name)
+	sort := fs.String("sort", "", "which document to pick when several match, e.g. '{\"createdAt\": 1}' for the oldest")
+	projection := fs.String("projection", "", "fields of the document to show, e.g. '{\"status\": 1}'")
+	usage, want := "usage: finddelete '<filter>' [--sort '<sort>'] [--projection '<fields>']", 1
+	var ret *string
+	if withUpdate {
+		ret = fs.String("return", "before", "return the document as it was before or after the update")
+		usage, want = "usage: findupdate '<filter>' '<update>' [--sort '<sort>'] [--projection '<fields>'] [--return before|after]", 2
+	}
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) != want {
+		return f, fmt.Errorf(usage)
+	}
+	if f.filter, err = commands.ParseDocument(positional[0]); err != nil {
+		return f, fmt.Errorf("%s: invalid filter: %w", name, err)
+	}
+	if *sort != "" {
+		if f.sort, err = commands.ParseDocument(*sort); err != nil {
+			return f, fmt.Errorf("%s: invalid sort: %w", name, err)
+		}
+	}
+	if *projection != "" {
+		if f.projection, err = commands.ParseDocument(*projection); err != nil {
+			return f, fmt.Errorf("%s: invalid projection: %w", name, err)
+		}
+	}
+	if withUpdate {
+		switch *ret {
+		case "before":
+		case "after":
+			f.after = true
+		default:
+			return f, fmt.Errorf("%s: --return takes before or after", name)
+		}
+	}
+	f.rest = positional[1:]
+	return f, nil
+}
+
+// findModified shows the document of a findAndModify, or says that none
+// matched.
+func findModified(doc bson.D, err error, name string) mongoMsg {
+	if err != nil {
+		if errors.Is(err, store.ErrNoDocuments) {
+			return mongoMsg{result: message(fmt.Sprintf("%s: no document matches", name))}
+		}
+		return mongoMsg{err: err}
+	}
+	return mongoMsg{result: newDocumentTree(doc), written: 1}
+}
+
+// findupdate atomically updates the first document matching a filter and
+// returns it, as a worker claiming an item of a queue collection would.
+func (m *model) findupdate(args []string) (tea.Model, tea.Cmd) {
+	db, coll, err := m.collectionPath("findupdate")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	f, err := parseFindModify("findupdate", args, true)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	update, err := parseUpdate(f.rest[0])
+	if err != nil {
+		m.err = fmt.Errorf("findupdate: %w", err)
+		return m, nil
+	}
+	deprecated, err := checkOperators(m.serverVersion, f.filter)
+	if err != nil {
+		m.err = fmt.Errorf("findupdate: %w", err)
+		return m, nil
+	}
+	if m.dryRun {
+		return m, m.dryRunFindModify(db, coll, f, "update", deprecated)
+	}
+
+	opts := options.FindOneAndUpdate()
+	if f.sort != nil {
+		opts.SetSort(f.sort)
+	}
+	if f.projection != nil {
+		opts.SetProjection(f.projection)
+	}
+	if f.after {
+		opts.SetReturnDocument(options.After)
+	}
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		doc, err := m.store.FindOneAndUpdate(ctx, db, coll, f.filter, update, opts)
+		msg := findModified(doc, err, "findupdate")
+		msg.warnings = deprecated
+		return msg
+	})
+}
+
+// finddelete atomically deletes the first document matching a filter and
+// returns it.
+func (m *model) finddelete(args []string) (tea.Model, tea.Cmd) {
+	db, coll, err := m.collectionPath("finddelete")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	f, err := parseFindModify("finddelete", args, false)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	deprecated, err := checkOperators(m.serverVersion, f.filter)
+	if err != nil {
+		m.err = fmt.Errorf("finddelete: %w", err)
+		return m, nil
+	}
+	if m.dryRun {
+		return m, m.dryRunFindModify(db, coll, f, "delete", deprecated)
+	}
+
+	opts := options.FindOneAndDelete()
+	if f.sort != nil {
+		opts.SetSort(f.sort)
+	}
+	if f.projection != nil {
+		opts.SetProjection(f.projection)
+	}
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		doc, err := m.store.FindOneAndDelete(ctx, db, coll, f.filter, opts)
+		msg := findModified(doc, err, "finddelete")
+		msg.warnings = deprecated
+		return msg
+	})
+}
+
+// dryRunFindModify shows the document findupdate or finddelete would pick,
+// masked like any other document read.
+func (m *model) dryRunFindModify(db, coll string, f findModifyFlags, verb string, deprecated []string) tea.Cmd {
+	opts := options.Find().SetLimit(1)
+	if f.sort != nil {
+		opts.SetSort(f.sort)
+	}
+	if f.projection != nil {
+		opts.SetProjection(f.projection)
+	}
+	return m.run(func(ctx context.Context) tea.Msg {
+		cur, err := m.store.Find(ctx, db, coll, f.filter, opts)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		defer cur.Close(ctx)
+		if !cur.Next(ctx) {
+			if err := cur.Err(); err != nil {
+				return mongoMsg{err: err}
+			}
+			return mongoMsg{result: message(fmt.Sprintf("dry run: no document matches, nothing would %s", verb)), warnings: deprecated}
+		}
+		var doc bson.D
+		if err := cur.Decode(&doc); err != nil {
+			return mongoMsg{err: err}
+		}
+		heading := fmt.Sprintf("dry run: would %s this document of %s.%s", verb, db, coll)
+		return mongoMsg{result: dryRunDocument{heading: heading, doc: doc}, warnings: deprecated}
+	})
+}
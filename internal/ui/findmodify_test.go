@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseUpdate(t *testing.T) {
+	update, err := parseUpdate(`{"$set": {"status": "claimed"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := update.(bson.D); !ok {
+		t.Errorf("update is %T, want bson.D", update)
+	}
+	if _, err := parseUpdate(`[{"$set": {"n": 1}}]`); err != nil {
+		t.Errorf("pipeline update: %v", err)
+	}
+
+	for s, want := range map[string]string{
+		`{}`:                         "empty",
+		`{"status": "claimed"}`:      "use `replace`",
+		`{"$set": {"n": 1}, "x": 1}`: "field 'x'",
+		`{"$set":`:                   "invalid update",
+	} {
+		if _, err := parseUpdate(s); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", s, err, want)
+		}
+	}
+}
+
+func TestFindModifyArgs(t *testing.T) {
+	m := newTestModel(seededFake())
+	expectError(t, m, "findupdate {} '{\"$set\": {\"a\": 1}}'", "cd into a collection first")
+	run(t, m, "cd shop/orders")
+	expectError(t, m, "findupdate {}", "usage: findupdate")
+	expectError(t, m, "finddelete {} {}", "usage: finddelete")
+	expectError(t, m, "finddelete {} --return after", "usage: finddelete")
+	expectError(t, m, "findupdate {} '{\"$set\": {\"a\": 1}}' --return later", "--return takes before or after")
+	expectError(t, m, "findupdate {} '{\"a\": 1}'", "use `replace`")
+	expectError(t, m, "finddelete {} --sort '{'", "invalid sort")
+}
+
+func TestFindModifyDryRun(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+
+	run(t, m, `findupdate '{"qty": {"$gt": 2}}' '{"$inc": {"qty": -1}}' --sort '{"qty": -1}' --dry-run`)
+	if got := output(t, m); !strings.Contains(got, "would update this document of shop.orders") || !strings.Contains(got, "plum") {
+		t.Errorf("dry run: %q", got)
+	}
+	m.masks = maskRules{"price"}
+	run(t, m, `finddelete '{"item": "plum"}' --dry-run`)
+	if got := output(t, m); strings.Contains(got, "0.5") || !strings.Contains(got, maskedValue) {
+		t.Errorf("masked dry run: %q", got)
+	}
+	m.masks = nil
+	run(t, m, `finddelete '{"item": "kiwi"}' --dry-run`)
+	if got := output(t, m); !strings.Contains(got, "no document matches, nothing would delete") {
+		t.Errorf("dry run without a match: %q", got)
+	}
+
+	m.readOnly = true
+	expectError(t, m, `finddelete {}`, "read-only")
+}
+
+func TestFindModify(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+
+	run(t, m, `findupdate '{"qty": {"$gt": 2}}' '{"$set": {"claimed": true}}' --sort '{"qty": -1}'`)
+	if got := output(t, m); !strings.Contains(got, "plum") || strings.Contains(got, "claimed") {
+		t.Errorf("findupdate, before: %q", got)
+	}
+	run(t, m, `findupdate '{"claimed": {"$exists": false}}' '{"$set": {"claimed": true}}' --sort '{"qty": 1}' --return after --projection '{"item": 1, "claimed": 1}'`)
+	if got := output(t, m); !strings.Contains(got, "pear") || !strings.Contains(got, "claimed") || strings.Contains(got, "price") {
+		t.Errorf("findupdate, after with a projection: %q", got)
+	}
+	if n := matching(t, m, `{"claimed": true}`); n != 2 {
+		t.Errorf("%d documents claimed, want 2", n)
+	}
+	run(t, m, `findupdate '{"item": "kiwi"}' '{"$set": {"claimed": true}}'`)
+	if got := output(t, m); !strings.Contains(got, "findupdate: no document matches") {
+		t.Errorf("findupdate without a match: %q", got)
+	}
+
+	run(t, m, `finddelete '{}' --sort '{"price": -1}' --projection '{"price": 0}'`)
+	if got := output(t, m); !strings.Contains(got, "pear") || strings.Contains(got, "price") {
+		t.Errorf("finddelete: %q", got)
+	}
+	if matching(t, m, `{}`) != 2 || matching(t, m, `{"item": "pear"}`) != 0 {
+		t.Error("finddelete did not delete the document it returned")
+	}
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+// governorConfig is the configured query governor. A zero value leaves
+// queries alone.
+type governorConfig config.Governor
+
+// maxTime returns the maxTimeMS to set on queries, or 0 for none.
+func (g governorConfig) maxTime() time.Duration {
+	return time.Duration(g.MaxTimeMS) * time.Millisecond
+}
+
+// check explains cmd, a find or count command on db.coll, and predicts how
+// many documents it scans: a collection scan reads every document, up to
+// limit if nothing but a limit restricts it. Queries using an index are let
+// through. Over the limit it returns an error, or a warning when the
+// governor only warns.
+func (g governorConfig) check(ctx context.Context, st store.Store, db, coll string, cmd bson.D, limit int64) (string, error) {
+	if g.MaxScannedDocs <= 0 {
+		return "", nil
+	}
+
+	var explain bson.M
+	explainCmd := bson.D{{Key: "explain", Value: cmd}, {Key: "verbosity", Value: "queryPlanner"}}
+	if err := st.RunCommand(ctx, db, explainCmd, &explain); err != nil {
+		return "", fmt.Errorf("governor: explain failed: %w", err)
+	}
+	planner, _ := explain["queryPlanner"].(bson.M)
+	if !hasStage(planner["winningPlan"], "COLLSCAN") {
+		return "", nil
+	}
+
+	predicted, err := st.EstimatedDocumentCount(ctx, db, coll)
+	if err != nil {
+		return "", fmt.Errorf("governor: %w", err)
+	}
+	if limit > 0 && limit < predicted {
+		predicted = limit
+	}
+	if predicted <= g.MaxScannedDocs {
+		return "", nil
+	}
+
+	problem := fmt.Sprintf("collection scan of ~%d documents in %s exceeds the governor limit of %d", predicted, coll, g.MaxScannedDocs)
+	if g.Action == config.GovernorWarn {
+		return problem, nil
+	}
+	return "", fmt.Errorf("governor: %s; add an index, narrow the query or `set governor off`", problem)
+}
+
+// activeGovernor returns the governor to apply, which does nothing while it
+// is switched off with `set governor off`.
+func (m *model) activeGovernor() governorConfig {
+	if !m.governorOn {
+		return governorConfig{}
+	}
+	return m.governor
+}
+
+// checkGovernor runs governor.check on db.coll. Without a scan limit there is
+// nothing to check and the server is not asked.
+func (m *model) checkGovernor(ctx context.Context, governor governorConfig, db, coll string, cmd bson.D, limit int64) (string, error) {
+	if governor.MaxScannedDocs <= 0 {
+		return "", nil
+	}
+	return governor.check(ctx, m.store, db, coll, cmd, limit)
+}
+
+// nonEmpty returns s as a one-element slice, or nil if it is empty.
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+// hasStage reports whether an explain plan contains a stage, looking through
+// nested input stages.
+func hasStage(plan interface{}, stage string) bool {
+	switch p := plan.(type) {
+	case bson.M:
+		if s, _ := p["stage"].(string); s == stage {
+			return true
+		}
+		for _, v := range p {
+			if hasStage(v, stage) {
+				return true
+			}
+		}
+	case bson.A:
+		for _, v := range p {
+			if hasStage(v, stage) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+const (
+	defaultGroupLimit = 10
+	groupBarWidth     = 30
+)
+
+// groupPipeline counts the documents matching filter by the value of field,
+// most frequent first, keeping the top limit values and the total. With
+// unwind, the elements of array values are counted instead.
+func groupPipeline(field string, filter bson.D, limit int, unwind bool) []bson.D {
+	var pipeline []bson.D
+	if len(filter) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: filter}})
+	}
+	if unwind {
+		pipeline = append(pipeline, bson.D{{Key: "$unwind", Value: "$" + field}})
+	}
+	return append(pipeline, bson.D{{Key: "$facet", Value: bson.D{
+		{Key: "top", Value: bson.A{
+			bson.D{{Key: "$sortByCount", Value: "$" + field}},
+			bson.D{{Key: "$limit", Value: limit}},
+		}},
+		{Key: "total", Value: bson.A{bson.D{{Key: "$count", Value: "n"}}}},
+		{Key: "distinct", Value: bson.A{
+			bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$" + field}}}},
+			bson.D{{Key: "$count", Value: "n"}},
+		}},
+	}}})
+}
+
+// groupCount is a value of the grouped field and how many documents have
+// it.
+type groupCount struct {
+	value interface{}
+	count int64
+}
+
+// valueDistribution is the result of `groupby`.
+type valueDistribution struct {
+	field    string
+	groups   []groupCount
+	total    int64 // Documents (or array elements) counted
+	distinct int64
+	hidden   bool // The field is masked, so only the counts are shown
+}
+
+func (d valueDistribution) String() string {
+	if d.total == 0 {
+		return "no documents match\n"
+	}
+	labels := make([]string, len(d.groups))
+	width := len(d.field)
+	for i, g := range d.groups {
+		switch {
+		case d.hidden:
+			labels[i] = maskedValue
+		case g.value == nil:
+			labels[i] = "(null or missing)"
+		default:
+			labels[i] = valueJSON(g.value)
+		}
+		width = max(width, len(labels[i]))
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%-*s  %10s  %6s\n", width, d.field, "count", "%"))
+	var shown int64
+	for i, g := range d.groups {
+		share := float64(g.count) / float64(d.total)
+		bar := strings.Repeat("█", int(share*groupBarWidth+0.5))
+		b.WriteString(fmt.Sprintf("%-*s  %10s  %5.1f%%  %s\n", width, labels[i], groupDigits(g.count), 100*share, bar))
+		shown += g.count
+	}
+	if rest := d.distinct - int64(len(d.groups)); rest > 0 {
+		b.WriteString(fmt.Sprintf("%-*s  %10s  %5.1f%%\n", width, fmt.Sprintf("(%d other values)", rest), groupDigits(d.total-shown), 100*float64(d.total-shown)/float64(d.total)))
+	}
+	b.WriteString(fmt.Sprintf("\n%s values in %s documents\n", groupDigits(d.distinct), groupDigits(d.total)))
+	return b.String()
+}
+
+// masked hides the values of a masked field, or of a field nested in one.
+func (d valueDistribution) masked(rules maskRules) result {
+	segments := strings.Split(d.field, ".")
+	for n := 1; n <= len(segments); n++ {
+		if rules.matches(segments[:n]) {
+			d.hidden = true
+		}
+	}
+	return d
+}
+
+// groupby shows the distribution of a field's values in the current
+// collection.
+func (m *model) groupby(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: groupby <field> ['<filter>'] [--limit N] [--unwind]"
+
+	db, coll, err := m.collectionPath("groupby")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	fs := commands.NewFlagSet("groupby")
+	limit := fs.Int("limit", defaultGroupLimit, "most frequent values to show")
+	unwind := fs.Bool("unwind", false, "count the elements of array values separately")
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) < 1 || len(positional) > 2 || *limit <= 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	field := strings.TrimPrefix(positional[0], "$")
+	var filter bson.D
+	if len(positional) == 2 {
+		if filter, err = commands.ParseDocument(positional[1]); err != nil {
+			m.err = fmt.Errorf("groupby: invalid filter: %w", err)
+			return m, nil
+		}
+	}
+	deprecated, err := checkOperators(m.serverVersion, filter)
+	if err != nil {
+		m.err = fmt.Errorf("groupby: %w", err)
+		return m, nil
+	}
+
+	pipeline := groupPipeline(field, filter, *limit, *unwind)
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		cur, err := m.store.Aggregate(ctx, db, coll, pipeline)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		defer cur.Close(ctx)
+		var facets []struct {
+			Top []struct {
+				ID    interface{} `bson:"_id"`
+				Count int64       `bson:"count"`
+			} `bson:"top"`
+			Total    []struct{ N int64 } `bson:"total"`
+			Distinct []struct{ N int64 } `bson:"distinct"`
+		}
+		if err := cur.All(ctx, &facets); err != nil {
+			return mongoMsg{err: err}
+		}
+		d := valueDistribution{field: field}
+		if len(facets) == 1 {
+			for _, g := range facets[0].Top {
+				d.groups = append(d.groups, groupCount{value: g.ID, count: g.Count})
+			}
+			if len(facets[0].Total) == 1 {
+				d.total = facets[0].Total[0].N
+			}
+			if len(facets[0].Distinct) == 1 {
+				d.distinct = facets[0].Distinct[0].N
+			}
+		}
+		return mongoMsg{result: d, warnings: deprecated}
+	})
+}
@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestGroupPipeline(t *testing.T) {
+	got := extJSON(bson.D{{Key: "p", Value: groupPipeline("status", bson.D{{Key: "qty", Value: 1}}, 5, true)}})
+	for _, want := range []string{`{"$match":{"qty":1}}`, `{"$unwind":"$status"}`, `{"$sortByCount":"$status"}`, `{"$limit":5}`, `{"$count":"n"}`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("pipeline lacks %s:\n%s", want, got)
+		}
+	}
+	if got := groupPipeline("status", nil, 5, false); len(got) != 1 {
+		t.Errorf("pipeline without filter or unwind has %d stages", len(got))
+	}
+}
+
+func TestValueDistribution(t *testing.T) {
+	d := valueDistribution{
+		field:    "status",
+		groups:   []groupCount{{"shipped", 6000}, {"open", 3000}, {nil, 500}},
+		total:    10000,
+		distinct: 5,
+	}
+	got := d.String()
+	for _, want := range []string{
+		`"shipped"               6,000   60.0%  ` + strings.Repeat("█", 18),
+		"(null or missing)         500    5.0%  ██\n",
+		"(2 other values)          500    5.0%",
+		"5 values in 10,000 documents",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("distribution lacks %q:\n%s", want, got)
+		}
+	}
+
+	masked := d.masked(maskRules{"status"}).String()
+	if strings.Contains(masked, "shipped") || !strings.Contains(masked, maskedValue) {
+		t.Errorf("masked distribution:\n%s", masked)
+	}
+	if got := (valueDistribution{field: "status"}).String(); got != "no documents match\n" {
+		t.Errorf("empty distribution: %q", got)
+	}
+}
+
+func TestGroupbyArgs(t *testing.T) {
+	m := newTestModel(seededFake())
+	expectError(t, m, "groupby status", "cd into a collection first")
+	run(t, m, "cd shop/orders")
+	expectError(t, m, "groupby", "usage: groupby")
+	expectError(t, m, "groupby status --limit 0", "usage: groupby")
+	expectError(t, m, "groupby status '{'", "invalid filter")
+}
+
+func TestGroupby(t *testing.T) {
+	fake := seededFake()
+	fake.Seed("shop", "posts",
+		bson.D{{Key: "tags", Value: bson.A{"go", "db"}}},
+		bson.D{{Key: "tags", Value: bson.A{"go"}}},
+		bson.D{{Key: "tags", Value: bson.A{}}},
+	)
+	m := newTestModel(fake)
+	run(t, m, "cd shop/orders")
+
+	run(t, m, "groupby item --limit 2")
+	got := output(t, m)
+	for _, want := range []string{`"apple"           1   33.3%`, "(1 other values)", "3 values in 3 documents"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("groupby lacks %q:\n%s", want, got)
+		}
+	}
+	run(t, m, `groupby item '{"qty": {"$gt": 100}}'`)
+	if got := output(t, m); got != "no documents match\n" {
+		t.Errorf("groupby without matches: %q", got)
+	}
+
+	run(t, m, "cd ../posts")
+	run(t, m, "groupby tags --unwind")
+	got = output(t, m)
+	for _, want := range []string{`"go"           2   66.7%`, "2 values in 3 documents"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("groupby --unwind lacks %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	"github.com/nick-popovic/mon-go/internal/config"
+)
+
+const (
+	// trackInterval is how often tracked collections are recorded while
+	// mon-go runs.
+	trackInterval = time.Hour
+	// maxGrowthSamples bounds the history of a collection, about three
+	// months of hourly samples.
+	maxGrowthSamples = 2200
+)
+
+// growthSample is the size of a collection at one time.
+type growthSample struct {
+	Time        time.Time `json:"t"`
+	Count       int64     `json:"count"`
+	Size        int64     `json:"size"`
+	StorageSize int64     `json:"storageSize"`
+}
+
+// growthHistory holds the samples of tracked collections by deployment,
+// then by "db.collection". A tracked collection has an entry even before
+// its first sample.
+type growthHistory map[string]map[string][]growthSample
+
+// growthMu serializes updates of the growth file, which the background
+// recorder and the track command both write.
+var growthMu sync.Mutex
+
+// growthPath returns the file growth history is kept in, next to the
+// config file.
+func growthPath() (string, error) {
+	path, err := config.Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "growth.json"), nil
+}
+
+func loadGrowth() (growthHistory, error) {
+	history := growthHistory{}
+	path, err := growthPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return history, nil
+}
+
+func saveGrowth(history growthHistory) error {
+	path, err := growthPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(history) // Compact: the file grows with every sample
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// updateGrowth loads the history, lets fn change it and saves it.
+func updateGrowth(fn func(growthHistory) error) error {
+	growthMu.Lock()
+	defer growthMu.Unlock()
+	history, err := loadGrowth()
+	if err != nil {
+		return err
+	}
+	if err := fn(history); err != nil {
+		return err
+	}
+	return saveGrowth(history)
+}
+
+// deploymentKey names the deployment of a connection string by its hosts,
+// so the history does not depend on credentials or options.
+func deploymentKey(uri string) string {
+	_, rest, found := strings.Cut(uri, "://")
+	if !found {
+		rest = uri
+	}
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		rest = rest[i+1:]
+	}
+	if i := strings.IndexAny(rest, "/?"); i >= 0 {
+		rest = rest[:i]
+	}
+	return rest
+}
+
+// growthRecordedMsg reports a recording of tracked collections.
+type growthRecordedMsg struct {
+	recorded int
+	err      error
+}
+
+// trackTickMsg triggers the periodic recording.
+type trackTickMsg struct{}
+
+func scheduleTracking() tea.Cmd {
+	return tea.Tick(trackInterval, func(time.Time) tea.Msg { return trackTickMsg{} })
+}
+
+// recordGrowth records the size of the given collections, or of every
+// collection tracked for this deployment if namespaces is nil. Unless force
+// is set, collections sampled less than trackInterval ago are skipped, so
+// restarting mon-go does not record twice.
+func (m *model) recordGrowth(namespaces []string, force bool) tea.Cmd {
+	st, deployment := m.store, deploymentKey(m.connectionString)
+	return func() tea.Msg {
+		if st == nil {
+			return growthRecordedMsg{err: errors.New("track: not connected")}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		history, err := loadGrowth()
+		if err != nil {
+			return growthRecordedMsg{err: err}
+		}
+		if namespaces == nil {
+			for ns := range history[deployment] {
+				namespaces = append(namespaces, ns)
+			}
+		}
+		samples := map[string]growthSample{}
+		for _, ns := range namespaces {
+			previous := history[deployment][ns]
+			if !force && len(previous) > 0 && time.Since(previous[len(previous)-1].Time) < trackInterval {
+				continue
+			}
+			db, coll, _ := strings.Cut(ns, ".")
+			var stats struct {
+				Count       int64 `bson:"count"`
+				Size        int64 `bson:"size"`
+				StorageSize int64 `bson:"storageSize"`
+			}
+			if err := st.RunCommand(ctx, db, bson.D{{Key: "collStats", Value: coll}}, &stats); err != nil {
+				return growthRecordedMsg{err: fmt.Errorf("track: %s: %w", ns, err)}
+			}
+			samples[ns] = growthSample{Time: time.Now().UTC(), Count: stats.Count, Size: stats.Size, StorageSize: stats.StorageSize}
+		}
+		if len(samples) == 0 {
+			return growthRecordedMsg{}
+		}
+
+		err = updateGrowth(func(history growthHistory) error {
+			for ns, sample := range samples {
+				tracked, ok := history[deployment][ns]
+				if !ok {
+					continue // Untracked while it was being read
+				}
+				tracked = append(tracked, sample)
+				if len(tracked) > maxGrowthSamples {
+					tracked = tracked[len(tracked)-maxGrowthSamples:]
+				}
+				history[deployment][ns] = tracked
+			}
+			return nil
+		})
+		return growthRecordedMsg{recorded: len(samples), err: err}
+	}
+}
+
+// trackedList is the result of `track ls`.
+type trackedList struct {
+	namespaces []string
+	samples    map[string][]growthSample
+}
+
+func (l trackedList) String() string {
+	if len(l.namespaces) == 0 {
+		return "no tracked collections, add one with track add\n"
+	}
+	width := len("collection")
+	for _, ns := range l.namespaces {
+		width = max(width, len(ns))
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%-*s  %7s  %-16s  %12s  %10s\n", width, "collection", "samples", "last", "documents", "size"))
+	for _, ns := range l.namespaces {
+		samples := l.samples[ns]
+		if len(samples) == 0 {
+			b.WriteString(fmt.Sprintf("%-*s  %7d  %s\n", width, ns, 0, "-"))
+			continue
+		}
+		last := samples[len(samples)-1]
+		b.WriteString(fmt.Sprintf("%-*s  %7d  %-16s  %12s  %10s\n", width, ns, len(samples), last.Time.Local().Format("2006-01-02 15:04"), groupDigits(last.Count), commands.FormatByteSize(last.Size)))
+	}
+	return b.String()
+}
+
+// growthReport is the result of `growth`: charts of a collection's
+// documents and size over time, and how fast they grow.
+type growthReport struct {
+	ns      string
+	samples []growthSample
+}
+
+func (r growthReport) String() string {
+	if len(r.samples) < 2 {
+		return fmt.Sprintf("%s has %d samples; growth needs two, recorded at least %s apart while mon-go runs, or with track now\n", r.ns, len(r.samples), trackInterval)
+	}
+	labels := make([]string, len(r.samples))
+	counts := make([]float64, len(r.samples))
+	sizes := make([]float64, len(r.samples))
+	for i, s := range r.samples {
+		labels[i] = s.Time.Local().Format("2006-01-02")
+		counts[i] = float64(s.Count)
+		sizes[i] = float64(s.Size) / (1 << 20)
+	}
+	first, last := r.samples[0], r.samples[len(r.samples)-1]
+	days := last.Time.Sub(first.Time).Hours() / 24
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s: documents\n", r.ns))
+	b.WriteString(chart{kind: "line", labels: labels, values: counts}.String())
+	b.WriteString("\ndata size (MB)\n")
+	b.WriteString(chart{kind: "line", labels: labels, values: sizes}.String())
+	b.WriteString(fmt.Sprintf("\n%d samples from %s to %s\n", len(r.samples), first.Time.Local().Format("2006-01-02 15:04"), last.Time.Local().Format("2006-01-02 15:04")))
+	delta := last.Count - first.Count
+	sign := "+"
+	if delta < 0 {
+		sign, delta = "-", -delta
+	}
+	b.WriteString(fmt.Sprintf("documents %s -> %s (%s%s)", groupDigits(first.Count), groupDigits(last.Count), sign, groupDigits(delta)))
+	if days >= 1 {
+		b.WriteString(fmt.Sprintf(", %+.0f per day", float64(last.Count-first.Count)/days))
+	}
+	b.WriteString(fmt.Sprintf("\ndata size %s -> %s, storage %s -> %s\n", commands.FormatByteSize(first.Size), commands.FormatByteSize(last.Size), commands.FormatByteSize(first.StorageSize), commands.FormatByteSize(last.StorageSize)))
+	return b.String()
+}
+
+// trackTarget resolves the collection argument of track and growth, the
+// current collection by default, to "db.collection".
+func (m *model) trackTarget(name string, args []string) (string, error) {
+	if len(args) == 0 {
+		db, coll, err := m.collectionPath(name)
+		if err != nil {
+			return "", err
+		}
+		return db + "." + coll, nil
+	}
+	path := m.resolvePath(args[0])
+	if len(path) != 2 {
+		return "", fmt.Errorf("%s: '%s' does not name a collection", name, args[0])
+	}
+	return path[0] + "." + path[1], nil
+}
+
+// track manages the collections whose counts and sizes are recorded for
+// `growth`.
+func (m *model) track(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: track [ls] | add [[db/]collection] | rm [[db/]collection] | now"
+	deployment := deploymentKey(m.connectionString)
+
+	sub := "ls"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	switch sub {
+	case "ls":
+		if len(args) != 0 {
+			m.err = fmt.Errorf(usage)
+			return m, nil
+		}
+		history, err := loadGrowth()
+		if err != nil {
+			m.err = fmt.Errorf("track: %w", err)
+			return m, nil
+		}
+		l := trackedList{samples: history[deployment]}
+		for ns := range l.samples {
+			l.namespaces = append(l.namespaces, ns)
+		}
+		sort.Strings(l.namespaces)
+		m.err, m.result = nil, l
+		return m, nil
+
+	case "add", "rm":
+		if len(args) > 1 {
+			m.err = fmt.Errorf(usage)
+			return m, nil
+		}
+		ns, err := m.trackTarget("track "+sub, args)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		err = updateGrowth(func(history growthHistory) error {
+			if sub == "rm" {
+				if _, ok := history[deployment][ns]; !ok {
+					return fmt.Errorf("%s is not tracked", ns)
+				}
+				delete(history[deployment], ns)
+				if len(history[deployment]) == 0 {
+					delete(history, deployment)
+				}
+				return nil
+			}
+			if history[deployment] == nil {
+				history[deployment] = map[string][]growthSample{}
+			}
+			if _, ok := history[deployment][ns]; ok {
+				return fmt.Errorf("%s is already tracked", ns)
+			}
+			history[deployment][ns] = []growthSample{}
+			return nil
+		})
+		if err != nil {
+			m.err = fmt.Errorf("track %s: %w", sub, err)
+			return m, nil
+		}
+		m.err = nil
+		if sub == "rm" {
+			m.result = message(fmt.Sprintf("stopped tracking %s and forgot its history", ns))
+			return m, nil
+		}
+		m.result = message(fmt.Sprintf("tracking %s, recorded every %s while mon-go runs; see its history with growth", ns, trackInterval))
+		return m, m.recordGrowth([]string{ns}, true)
+
+	case "now":
+		if len(args) != 0 {
+			m.err = fmt.Errorf(usage)
+			return m, nil
+		}
+		return m, m.run(func(context.Context) tea.Msg {
+			msg := m.recordGrowth(nil, true)().(growthRecordedMsg)
+			if msg.err != nil {
+				return mongoMsg{err: msg.err}
+			}
+			return mongoMsg{result: message(fmt.Sprintf("recorded %d tracked collections", msg.recorded))}
+		})
+
+	default:
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+}
+
+// growth shows the recorded history of a tracked collection.
+func (m *model) growth(args []string) (tea.Model, tea.Cmd) {
+	if len(args) > 1 {
+		m.err = fmt.Errorf("usage: growth [[db/]collection]")
+		return m, nil
+	}
+	ns, err := m.trackTarget("growth", args)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	history, err := loadGrowth()
+	if err != nil {
+		m.err = fmt.Errorf("growth: %w", err)
+		return m, nil
+	}
+	samples, ok := history[deploymentKey(m.connectionString)][ns]
+	if !ok {
+		m.err = fmt.Errorf("growth: %s is not tracked, start with track add", ns)
+		return m, nil
+	}
+	m.err, m.result = nil, growthReport{ns: ns, samples: samples}
+	return m, nil
+}
+
+// logGrowthError reports failures of background recordings, which have no
+// command to show them under.
+func logGrowthError(err error) {
+	slog.Warn("recording tracked collections failed", "error", err)
+}
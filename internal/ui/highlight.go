@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	jsonKeyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	jsonStringStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	jsonNumberStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	jsonLiteralStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("5"))
+)
+
+// prettyJSON renders doc as indented relaxed Extended JSON.
+func prettyJSON(doc interface{}) (string, error) {
+	out, err := bson.MarshalExtJSONIndent(doc, false, false, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// valueJSON renders a single value as relaxed Extended JSON on one line.
+func valueJSON(v interface{}) string {
+	out, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: v}}, false, false) // Values only marshal inside a document
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(string(out), `{"v":`), "}")
+}
+
+// highlightJSON colors the keys, strings, numbers and literals of JSON text.
+// It is a display helper: the input is assumed to be well-formed.
+func highlightJSON(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end < len(s) {
+				end++ // Include the closing quote
+			}
+			token := s[i:end]
+
+			rest := strings.TrimLeft(s[end:], " \t")
+			if strings.HasPrefix(rest, ":") {
+				b.WriteString(jsonKeyStyle.Render(token))
+			} else {
+				b.WriteString(jsonStringStyle.Render(token))
+			}
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(s) && strings.IndexByte("0123456789.eE+-", s[end]) >= 0 {
+				end++
+			}
+			b.WriteString(jsonNumberStyle.Render(s[i:end]))
+			i = end
+		case strings.HasPrefix(s[i:], "true"), strings.HasPrefix(s[i:], "null"):
+			b.WriteString(jsonLiteralStyle.Render(s[i : i+4]))
+			i += 4
+		case strings.HasPrefix(s[i:], "false"):
+			b.WriteString(jsonLiteralStyle.Render(s[i : i+5]))
+			i += 5
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
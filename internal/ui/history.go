@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+)
+
+// maxHistory is how many commands are kept for suggestions.
+const maxHistory = 1000
+
+// historyPath returns the file typed commands are kept in, next to the
+// config file.
+func historyPath() (string, error) {
+	path, err := config.Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "history"), nil
+}
+
+// loadHistory reads the commands typed in earlier sessions, most recent
+// first and each once. The file is appended to by every session, so it is
+// cut back to the entries kept once it has grown well past them. Losing
+// the history is not worth failing to start over.
+func loadHistory() []string {
+	path, err := historyPath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("loading history failed", "error", err)
+		}
+		return nil
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var history []string
+	seen := map[string]bool{}
+	for i := len(lines) - 1; i >= 0 && len(history) < maxHistory; i-- {
+		if line := lines[i]; line != "" && !seen[line] {
+			seen[line] = true
+			history = append(history, line)
+		}
+	}
+	if len(lines) > 2*maxHistory {
+		kept := make([]string, len(history))
+		for i, line := range history {
+			kept[len(history)-1-i] = line
+		}
+		if err := os.WriteFile(path, []byte(strings.Join(kept, "\n")+"\n"), 0o600); err != nil {
+			slog.Warn("compacting history failed", "error", err)
+		}
+	}
+	return history
+}
+
+// addHistory remembers a typed command for suggestions, in this session
+// and the next ones. Commands with credentials in a connection string are
+// not kept.
+func (m *model) addHistory(input string) {
+	if input == "" || redactURI(input) != input {
+		return
+	}
+	history := []string{input}
+	for _, h := range m.history {
+		if h != input && len(history) < maxHistory {
+			history = append(history, h)
+		}
+	}
+	m.history = history
+	m.textInput.SetSuggestions(m.history)
+
+	path, err := historyPath()
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o700)
+	}
+	if err == nil {
+		err = appendToFile(path, input+"\n")
+	}
+	if err != nil {
+		slog.Warn("saving history failed", "error", err)
+	}
+}
+
+// acceptSuggestion completes the input with the suggestion shown after it
+// when → is pressed at the end of the line, as in fish.
+func (m *model) acceptSuggestion(msg tea.KeyMsg) bool {
+	if msg.Type != tea.KeyRight || m.prompt != nil {
+		return false
+	}
+	value := []rune(m.textInput.Value())
+	suggestion := []rune(m.textInput.CurrentSuggestion())
+	if m.textInput.Position() != len(value) || len(suggestion) <= len(value) {
+		return false
+	}
+	m.textInput.SetValue(string(value) + string(suggestion[len(value):]))
+	m.textInput.CursorEnd()
+	return true
+}
@@ -0,0 +1,325 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+const defaultIndexSample = 1000
+
+// indexPreview shows, on a sample of the collection, what a partial or
+// wildcard index would cover before it is built.
+type indexPreview struct {
+	namespace  string
+	partial    bson.D
+	sampled    int
+	matched    int
+	estimated  int64 // Documents in the collection
+	wildcard   string
+	projection bson.D
+	paths      []fieldCoverage
+}
+
+// fieldCoverage is a field path of the sampled documents, in how many of them
+// it occurs, and whether the wildcard index would index it.
+type fieldCoverage struct {
+	path    string
+	seen    int
+	indexed bool
+}
+
+func (p indexPreview) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("preview on %d sampled documents of %s\n", p.sampled, p.namespace))
+	if p.partial != nil {
+		share := 0.0
+		if p.sampled > 0 {
+			share = float64(p.matched) / float64(p.sampled)
+		}
+		b.WriteString(fmt.Sprintf("partial filter %s: %d of %d sampled documents (%.1f%%) would be indexed, about %d of %d in the collection\n",
+			extJSON(p.partial), p.matched, p.sampled, 100*share, int64(share*float64(p.estimated)), p.estimated))
+	}
+	if p.wildcard != "" {
+		b.WriteString(fmt.Sprintf("wildcard %s", p.wildcard))
+		if p.projection != nil {
+			b.WriteString(fmt.Sprintf(" with projection %s", extJSON(p.projection)))
+		}
+		if p.partial != nil {
+			b.WriteString(", on the documents matching the filter")
+		}
+		b.WriteString(":\n")
+		if len(p.paths) == 0 {
+			b.WriteString("  no fields to index\n")
+		}
+		width := 0
+		for _, f := range p.paths {
+			width = max(width, len(f.path))
+		}
+		for _, f := range p.paths {
+			status := "not indexed"
+			if f.indexed {
+				status = "indexed"
+			}
+			b.WriteString(fmt.Sprintf("  %-11s  %-*s  in %d documents\n", status, width, f.path, f.seen))
+		}
+	}
+	return b.String()
+}
+
+// extJSON formats a document as relaxed Extended JSON, for messages.
+func extJSON(doc bson.D) string {
+	data, err := bson.MarshalExtJSON(doc, false, false)
+	if err != nil {
+		return fmt.Sprint(doc)
+	}
+	return string(data)
+}
+
+func (m *model) index(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: index create '<keys>' [--name N] [--unique] [--partial '<filter>'] [--wildcard-projection '<projection>'] [--sample N] [--yes]"
+	if len(args) == 0 || args[0] != "create" {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	fs := commands.NewFlagSet("index create")
+	name := fs.String("name", "", "index name, generated from the keys by default")
+	unique := fs.Bool("unique", false, "reject duplicate keys")
+	partial := fs.String("partial", "", "partialFilterExpression: only documents matching it are indexed")
+	projection := fs.String("wildcard-projection", "", "fields a $** index includes or excludes")
+	sample := fs.Int("sample", defaultIndexSample, "number of documents the preview is computed on")
+	yes := fs.Bool("yes", false, "build without previewing")
+	positional, err := commands.ParseFlags(fs, args[1:])
+	if err != nil || len(positional) != 1 || *sample <= 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	db, coll, err := m.collectionPath("index create")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	keys, err := commands.ParseDocument(positional[0])
+	if err != nil || len(keys) == 0 {
+		m.err = fmt.Errorf("index create: invalid keys: %s", positional[0])
+		return m, nil
+	}
+	spec := mongo.IndexModel{Keys: keys}
+	opts := options.Index()
+	if *name != "" {
+		opts.SetName(*name)
+	}
+	if *unique {
+		opts.SetUnique(true)
+	}
+
+	var filter bson.D
+	if *partial != "" {
+		if filter, err = commands.ParseDocument(*partial); err != nil {
+			m.err = fmt.Errorf("index create: invalid partial filter: %w", err)
+			return m, nil
+		}
+		opts.SetPartialFilterExpression(filter)
+	}
+	wildcard := ""
+	for _, key := range keys {
+		if key.Key == "$**" || strings.HasSuffix(key.Key, ".$**") {
+			wildcard = key.Key
+		}
+	}
+	var proj bson.D
+	if *projection != "" {
+		if wildcard != "$**" {
+			m.err = fmt.Errorf("index create: --wildcard-projection needs a $** key")
+			return m, nil
+		}
+		if proj, err = commands.ParseDocument(*projection); err != nil {
+			m.err = fmt.Errorf("index create: invalid wildcard projection: %w", err)
+			return m, nil
+		}
+		opts.SetWildcardProjection(proj)
+	}
+	spec.Options = opts
+
+	create := func(ctx context.Context) tea.Msg {
+		created, err := m.store.CreateIndex(ctx, db, coll, spec)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		return mongoMsg{result: message(fmt.Sprintf("created index '%s' on %s.%s", created, db, coll))}
+	}
+	if *yes || (filter == nil && wildcard == "") {
+		return m, m.runWithTimeout(0, create) // Builds can take a while on big collections
+	}
+
+	size := *sample
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		preview, err := previewIndex(ctx, m.store, db, coll, filter, wildcard, proj, size)
+		if err != nil {
+			return mongoMsg{err: fmt.Errorf("index create: preview failed: %w", err)}
+		}
+		return confirmMsg{
+			result:   preview,
+			question: "build this index? [y/N] ",
+			onYes:    func() tea.Cmd { return m.runWithTimeout(0, create) },
+			declined: "index not created",
+		}
+	})
+}
+
+// previewIndex samples db.coll and counts the documents the partial filter
+// matches, then which field paths of the matching documents a wildcard
+// index would include.
+func previewIndex(ctx context.Context, st store.Store, db, coll string, filter bson.D, wildcard string, projection bson.D, size int) (indexPreview, error) {
+	preview := indexPreview{namespace: db + "." + coll, partial: filter, wildcard: wildcard, projection: projection}
+
+	cur, err := st.Aggregate(ctx, db, coll, mongo.Pipeline{{{Key: "$sample", Value: bson.D{{Key: "size", Value: size}}}}})
+	if err != nil {
+		return preview, err
+	}
+	var docs []bson.M
+	if err := cur.All(ctx, &docs); err != nil {
+		return preview, err
+	}
+	preview.sampled = len(docs)
+	if preview.estimated, err = st.EstimatedDocumentCount(ctx, db, coll); err != nil {
+		return preview, err
+	}
+
+	if filter != nil && len(docs) > 0 {
+		ids := make(bson.A, len(docs))
+		for i, doc := range docs {
+			ids[i] = doc["_id"]
+		}
+		// The server evaluates the filter, on exactly the sampled documents
+		query := bson.D{{Key: "$and", Value: bson.A{bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}}, filter}}}
+		cur, err := st.Find(ctx, db, coll, query, options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}}))
+		if err != nil {
+			return preview, err
+		}
+		var matches []bson.M
+		if err := cur.All(ctx, &matches); err != nil {
+			return preview, err
+		}
+		matched := map[string]bool{}
+		for _, doc := range matches {
+			matched[fmt.Sprint(doc["_id"])] = true
+		}
+		kept := docs[:0]
+		for _, doc := range docs {
+			if matched[fmt.Sprint(doc["_id"])] {
+				kept = append(kept, doc)
+			}
+		}
+		docs = kept
+		preview.matched = len(docs)
+	}
+
+	if wildcard != "" {
+		seen := map[string]int{}
+		for _, doc := range docs {
+			paths := map[string]bool{}
+			collectPaths(doc, "", paths)
+			for path := range paths {
+				seen[path]++
+			}
+		}
+		for path, n := range seen {
+			preview.paths = append(preview.paths, fieldCoverage{path: path, seen: n, indexed: wildcardCovers(wildcard, projection, path)})
+		}
+		sort.Slice(preview.paths, func(i, j int) bool {
+			a, b := preview.paths[i], preview.paths[j]
+			if a.indexed != b.indexed {
+				return a.indexed
+			}
+			return a.path < b.path
+		})
+	}
+	return preview, nil
+}
+
+// collectPaths adds the paths of the leaf fields of v, the values a wildcard
+// index holds: embedded documents, also inside arrays, are descended into.
+func collectPaths(v interface{}, prefix string, paths map[string]bool) {
+	switch v := v.(type) {
+	case bson.M:
+		for key, value := range v {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			collectPaths(value, path, paths)
+		}
+	case bson.D:
+		collectPaths(v.Map(), prefix, paths)
+	case bson.A:
+		scalars := false
+		for _, elem := range v {
+			switch elem.(type) {
+			case bson.M, bson.D:
+				collectPaths(elem, prefix, paths)
+			default:
+				scalars = true
+			}
+		}
+		if scalars || len(v) == 0 {
+			paths[prefix] = true
+		}
+	default:
+		paths[prefix] = true
+	}
+}
+
+// wildcardCovers reports whether the wildcard index key, with projection for
+// a $** key, indexes the field at path. _id is only indexed when the
+// projection includes it.
+func wildcardCovers(key string, projection bson.D, path string) bool {
+	under := func(prefix string) bool {
+		return path == prefix || strings.HasPrefix(path, prefix+".")
+	}
+	if key != "$**" {
+		return under(strings.TrimSuffix(key, ".$**"))
+	}
+
+	included := func(v interface{}) bool {
+		if b, ok := v.(bool); ok {
+			return b
+		}
+		n, ok := asInt64(v)
+		return ok && n != 0
+	}
+	if under("_id") {
+		for _, e := range projection {
+			if e.Key == "_id" {
+				return included(e.Value)
+			}
+		}
+		return false
+	}
+	inclusive, matched := false, false
+	for _, e := range projection {
+		if e.Key == "_id" {
+			continue
+		}
+		if included(e.Value) {
+			inclusive = true
+			if under(e.Key) {
+				matched = true
+			}
+		} else if under(e.Key) {
+			return false
+		}
+	}
+	return !inclusive || matched
+}
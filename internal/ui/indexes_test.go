@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+)
+
+func TestIndexCreate(t *testing.T) {
+	m := newTestModel(seededFake())
+	expectError(t, m, "index create '{\"qty\": 1}'", "cd into a collection first")
+	run(t, m, "cd shop/orders")
+
+	run(t, m, "index create '{\"qty\": 1, \"price\": -1}'")
+	if got := output(t, m); got != "created index 'qty_1_price_-1' on shop.orders\n" {
+		t.Errorf("index create: %q", got)
+	}
+	run(t, m, "index create '{\"item\": 1}' --name byItem --unique")
+	if got := output(t, m); got != "created index 'byItem' on shop.orders\n" {
+		t.Errorf("index create --name: %q", got)
+	}
+	expectError(t, m, "index create '{\"item\": 1}' --name other", "Index already exists with a different name: byItem")
+	expectError(t, m, "index create '{\"price\": 1}' --name byItem", "byItem")
+}
+
+func TestGovernor(t *testing.T) {
+	m := newTestModel(seededFake())
+	m.governor = governorConfig{MaxScannedDocs: 2}
+	run(t, m, "cd shop/orders")
+
+	expectError(t, m, `find '{"qty": {"$gt": 3}}'`, "collection scan of ~3 documents in orders exceeds the governor limit of 2")
+	expectError(t, m, `count '{"qty": {"$gt": 3}}'`, "exceeds the governor limit")
+	run(t, m, "find --limit 2")
+	if m.err != nil {
+		t.Errorf("a scan bounded by its limit: %v", m.err)
+	}
+
+	run(t, m, "index create '{\"qty\": 1}'")
+	run(t, m, `find '{"qty": {"$gt": 3}}'`)
+	if got := docCount(t, m); got != 2 {
+		t.Errorf("indexed find: %d documents", got)
+	}
+
+	m.governor.Action = config.GovernorWarn
+	run(t, m, "collmod --index qty_1 --hide")
+	run(t, m, `count '{"qty": {"$gt": 3}}'`)
+	if got := output(t, m); got != "2 documents\n" || len(m.warnings) != 1 || !strings.Contains(m.warnings[0], "exceeds the governor limit") {
+		t.Errorf("count with a warning governor: %q, warnings %q", got, m.warnings)
+	}
+}
+
+func TestIndexPreview(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+
+	run(t, m, `index create '{"item": 1}' --partial '{"qty": {"$gt": 2}}'`)
+	if got := output(t, m); !strings.Contains(got, "2 of 3 sampled documents (66.7%) would be indexed") {
+		t.Errorf("preview of a partial index:\n%s", got)
+	}
+	answer(t, m, "n")
+	if got := output(t, m); got != "index not created\n" {
+		t.Errorf("declining the preview: %q", got)
+	}
+
+	run(t, m, `index create '{"$**": 1}' --name w --wildcard-projection '{"qty": 0}'`)
+	got := output(t, m)
+	for _, want := range []string{"indexed      item   in 3 documents", "not indexed  qty    in 3 documents"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("preview of a wildcard index lacks %q:\n%s", want, got)
+		}
+	}
+	answer(t, m, "y")
+	if got := output(t, m); got != "created index 'w' on shop.orders\n" {
+		t.Errorf("building after the preview: %q", got)
+	}
+}
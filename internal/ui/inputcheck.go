@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+var (
+	inputMistakeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	mistakeCaretStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
+)
+
+// inputMistake checks the JSON arguments of the command being typed, so
+// syntax errors show before Enter is pressed. It returns the rune index in
+// the input of the first one and what it is. Shell commands, the pipeline
+// after a | and filter expressions, which only look like JSON, are not
+// checked.
+func (m *model) inputMistake() (int, error) {
+	if m.prompt != nil {
+		return -1, nil
+	}
+	value := m.textInput.Value()
+	if strings.HasPrefix(value, shellEscape) {
+		return -1, nil
+	}
+	command, _, piped := splitPipeline(value)
+	if fields := strings.Fields(command); len(fields) == 0 || fields[0] == "filter" {
+		return -1, nil
+	}
+	at, err := commands.CheckJSON(command)
+	if err != nil && piped {
+		at += utf8.RuneCountInString(value) - utf8.RuneCountInString(strings.TrimLeft(value, " \t")) // splitPipeline trims
+	}
+	return at, err
+}
+
+// mistakeLine points at a mistake in the input, under the input line
+// written so far. The caret is left out when the input scrolls sideways,
+// as it would no longer line up.
+func (m *model) mistakeLine(line string, at int, err error) string {
+	value := []rune(m.textInput.Value())
+	if m.textInput.Width > 0 && len(value) > m.textInput.Width {
+		return mistakeCaretStyle.Render(err.Error())
+	}
+	column := lipgloss.Width(line) + lipgloss.Width(string(value[:at]))
+	return strings.Repeat(" ", column) + mistakeCaretStyle.Render("^ "+err.Error())
+}
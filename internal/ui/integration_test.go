@@ -0,0 +1,269 @@
+//go:build integration
+
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+)
+
+// The integration tests run commands end to end against a real server:
+//
+//	go test -tags integration ./...
+//
+// They start MongoDB in a Docker container (MON_GO_TEST_IMAGE, mongo:7 by
+// default) that is removed when the tests finish, or use the server at
+// MON_GO_TEST_URI. Each test works in a database of its own. The container
+// is run with the docker CLI rather than testcontainers-go, which would add
+// its many dependencies to the module for a few commands.
+
+const defaultTestImage = "mongo:7"
+
+var (
+	serverOnce sync.Once
+	serverURI  string
+	serverErr  error
+)
+
+// testServer returns the connection string of the test server, starting its
+// container on first use. Tests are skipped when there is neither Docker nor
+// MON_GO_TEST_URI.
+func testServer(t *testing.T) string {
+	t.Helper()
+	serverOnce.Do(func() {
+		if uri := os.Getenv("MON_GO_TEST_URI"); uri != "" {
+			serverURI = uri
+			return
+		}
+		serverURI, serverErr = startContainer()
+	})
+	if serverErr == errNoDocker {
+		t.Skip("docker not found; set MON_GO_TEST_URI to use a running server")
+	}
+	if serverErr != nil {
+		t.Fatalf("starting MongoDB: %v", serverErr)
+	}
+	return serverURI
+}
+
+var errNoDocker = fmt.Errorf("docker not found")
+
+// startContainer runs MongoDB on a free local port and waits until it
+// answers.
+func startContainer() (string, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", errNoDocker
+	}
+	image := os.Getenv("MON_GO_TEST_IMAGE")
+	if image == "" {
+		image = defaultTestImage
+	}
+	out, err := exec.Command("docker", "run", "--detach", "--rm", "--publish", "127.0.0.1::27017", image).Output()
+	if err != nil {
+		return "", fmt.Errorf("docker run %s: %w", image, commandError(err))
+	}
+	id := strings.TrimSpace(string(out))
+	afterTests = append(afterTests, func() {
+		exec.Command("docker", "rm", "--force", id).Run()
+	})
+
+	out, err = exec.Command("docker", "port", id, "27017/tcp").Output()
+	if err != nil {
+		return "", fmt.Errorf("docker port: %w", commandError(err))
+	}
+	addr := strings.TrimSpace(strings.Split(string(out), "\n")[0])
+	uri := "mongodb://" + addr + "/?directConnection=true"
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return "", err
+	}
+	defer client.Disconnect(context.Background())
+	for {
+		if err = client.Ping(ctx, nil); err == nil {
+			return uri, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("server did not come up: %w", err)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+func commandError(err error) error {
+	if exit, ok := err.(*exec.ExitError); ok && len(exit.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exit.Stderr)))
+	}
+	return err
+}
+
+// newIntegrationModel connects a model to the test server and returns it
+// with the name of a database for the test, dropped when it ends.
+func newIntegrationModel(t *testing.T) (*model, string) {
+	t.Helper()
+	m := initialModel(testServer(t), config.Config{SafeMode: config.SafeModeOff})
+	if m.err != nil {
+		t.Fatal(m.err)
+	}
+	db := fmt.Sprintf("mon_go_test_%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		m.client.Database(db).Drop(context.Background())
+		m.shutdown()
+	})
+	return &m, db
+}
+
+func TestIntegrationNavigateAndQuery(t *testing.T) {
+	m, db := newIntegrationModel(t)
+
+	run(t, m, "mkdir "+db+"/orders")
+	output(t, m)
+	run(t, m, "ls")
+	if got := output(t, m); !strings.Contains(got, db) {
+		t.Fatalf("ls at the root does not show %s: %q", db, got)
+	}
+
+	run(t, m, "cd "+db)
+	run(t, m, "ls")
+	if got := output(t, m); !strings.Contains(got, "orders") {
+		t.Errorf("ls in the database: %q", got)
+	}
+
+	run(t, m, "cd orders")
+	for i, item := range []string{"apple", "pear", "plum", "fig", "kiwi", "lime", "date"} {
+		run(t, m, fmt.Sprintf(`insert '{"item": "%s", "qty": %d}'`, item, i))
+		if got := output(t, m); !strings.Contains(got, "inserted document") {
+			t.Fatalf("insert: %q", got)
+		}
+	}
+
+	run(t, m, `find '{"qty": {"$gte": 3}}' --sort '{"qty": 1}' --limit 0`)
+	if n := docCount(t, m); n != 4 {
+		t.Errorf("find with a filter: got %d documents, want 4", n)
+	}
+	run(t, m, `count '{"item": {"$in": ["apple", "pear"]}}'`)
+	if got := output(t, m); !strings.Contains(got, "2 documents") {
+		t.Errorf("count: %q", got)
+	}
+
+	run(t, m, "ls")
+	if n := docCount(t, m); n != defaultListLimit {
+		t.Errorf("first page: got %d documents", n)
+	}
+	run(t, m, "next")
+	if n := docCount(t, m); n != 2 {
+		t.Errorf("second page: got %d documents, want 2", n)
+	}
+	run(t, m, "prev")
+	if n := docCount(t, m); n != defaultListLimit {
+		t.Errorf("prev: got %d documents", n)
+	}
+
+	run(t, m, `find '{"item": "plum"}'`)
+	id := m.result.(documentList).docs[0]["_id"].(primitive.ObjectID)
+	run(t, m, "cd "+id.Hex())
+	run(t, m, "ls")
+	if got := output(t, m); !strings.Contains(got, "plum") {
+		t.Errorf("ls of a document: %q", got)
+	}
+}
+
+func TestIntegrationReadOnly(t *testing.T) {
+	m, db := newIntegrationModel(t)
+	run(t, m, "mkdir "+db+"/things")
+	output(t, m)
+	run(t, m, "cd "+db+"/things")
+
+	run(t, m, "set readonly on")
+	expectError(t, m, `insert '{"a": 1}'`, "refused in read-only mode")
+	run(t, m, "set readonly off")
+	run(t, m, `insert '{"a": 1}'`)
+	output(t, m)
+	run(t, m, "count")
+	if got := output(t, m); !strings.Contains(got, "1 document") {
+		t.Errorf("count after insert: %q", got)
+	}
+}
+
+func TestIntegrationWrites(t *testing.T) {
+	m, db := newIntegrationModel(t)
+	run(t, m, "mkdir "+db+"/queue")
+	output(t, m)
+	run(t, m, "cd "+db+"/queue")
+	for i, status := range []string{"ready", "ready", "ready", "done"} {
+		run(t, m, fmt.Sprintf(`insert '{"_id": %d, "status": "%s", "at": %d}'`, i+1, status, 10-i))
+		output(t, m)
+	}
+	expectCount := func(filter, want string) {
+		t.Helper()
+		run(t, m, "count '"+filter+"'")
+		if got := output(t, m); !strings.Contains(got, want) {
+			t.Errorf("count %s: %q, want %s", filter, got, want)
+		}
+	}
+
+	run(t, m, `update '{"status": "ready"}' '{"$set": {"priority": 1}}' --many`)
+	if got := output(t, m); !strings.Contains(got, "matched 3, modified 3") {
+		t.Errorf("update --many: %q", got)
+	}
+	run(t, m, `update '{"_id": 9}' '{"$set": {"status": "ready"}}' --upsert`)
+	if got := output(t, m); !strings.Contains(got, "inserted one with _id 9") {
+		t.Errorf("update --upsert: %q", got)
+	}
+
+	run(t, m, `replace '{"_id": 4}' '{"status": "archived"}'`)
+	if got := output(t, m); !strings.Contains(got, "matched 1, modified 1") {
+		t.Errorf("replace: %q", got)
+	}
+	expectCount(`{"_id": 4, "status": "archived", "at": {"$exists": false}}`, "1 document")
+
+	run(t, m, `findupdate '{"status": "ready"}' '{"$set": {"status": "claimed"}}' --sort '{"at": -1}' --return after --projection '{"status": 1}'`)
+	if got := output(t, m); !strings.Contains(got, "claimed") || strings.Contains(got, "priority") {
+		t.Errorf("findupdate: %q", got)
+	}
+	expectCount(`{"_id": 1, "status": "claimed"}`, "1 document")
+	run(t, m, `finddelete '{"status": "ready"}' --sort '{"at": -1}'`)
+	if got := output(t, m); !strings.Contains(got, "ready") {
+		t.Errorf("finddelete: %q", got)
+	}
+	expectCount(`{"_id": 2}`, "0 documents")
+
+	file := filepath.Join(t.TempDir(), "ops.json")
+	ops := `[{insertOne: {document: {_id: 20, status: 'new'}}}, {insertOne: {document: {_id: 20}}}, {deleteMany: {filter: {status: 'archived'}}}]`
+	if err := os.WriteFile(file, []byte(ops), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	run(t, m, "bulk "+file)
+	if got := output(t, m); !strings.Contains(got, "not run, the ordered bulk write stopped at 2") || !strings.Contains(got, "1 of 3 operations failed") {
+		t.Errorf("ordered bulk: %q", got)
+	}
+	expectCount(`{"status": "archived"}`, "1 document")
+	run(t, m, "bulk "+file+" --unordered")
+	if got := output(t, m); !strings.Contains(got, "deleted 1") || !strings.Contains(got, "2 of 3 operations failed") {
+		t.Errorf("unordered bulk: %q", got)
+	}
+	expectCount(`{"status": "archived"}`, "0 documents")
+
+	run(t, m, `deletemany '{"status": {"$in": ["ready", "claimed"]}}'`)
+	answer(t, m, "3")
+	if got := output(t, m); !strings.Contains(got, "deleted 3 documents") {
+		t.Errorf("deletemany: %q", got)
+	}
+	expectCount(`{}`, "1 document")
+}
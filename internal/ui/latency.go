@@ -0,0 +1,256 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// latencyKinds are the operations $collStats reports latency for, in the
+// order they are shown.
+var latencyKinds = []string{"reads", "writes", "commands", "transactions"}
+
+const latencyBarWidth = 30
+
+// latencyBucket counts the operations that took from micros up to the lower
+// bound of the next bucket.
+type latencyBucket struct {
+	micros, count int64
+}
+
+// opLatency is the latency of one kind of operation since the server
+// started.
+type opLatency struct {
+	ops, micros int64 // Total operations and time they took
+	histogram   []latencyBucket
+}
+
+func (l opLatency) average() int64 {
+	if l.ops == 0 {
+		return 0
+	}
+	return l.micros / l.ops
+}
+
+// collectionLatency is the latencyStats of a collection.
+type collectionLatency struct {
+	ns    string
+	kinds map[string]opLatency
+}
+
+func (c collectionLatency) total() int64 {
+	var total int64
+	for _, l := range c.kinds {
+		total += l.micros
+	}
+	return total
+}
+
+// parseLatencyStats reads the latencyStats document of a $collStats stage.
+func parseLatencyStats(ns string, stats bson.M) collectionLatency {
+	c := collectionLatency{ns: ns, kinds: map[string]opLatency{}}
+	for _, kind := range latencyKinds {
+		doc, ok := stats[kind].(bson.M)
+		if !ok {
+			continue
+		}
+		ops, _ := asInt64(doc["ops"])
+		micros, _ := asInt64(doc["latency"])
+		l := opLatency{ops: ops, micros: micros}
+		histogram, _ := doc["histogram"].(bson.A)
+		for _, b := range histogram {
+			bucket, ok := b.(bson.M)
+			if !ok {
+				continue
+			}
+			lower, _ := asInt64(bucket["micros"])
+			count, _ := asInt64(bucket["count"])
+			l.histogram = append(l.histogram, latencyBucket{micros: lower, count: count})
+		}
+		sort.Slice(l.histogram, func(i, j int) bool { return l.histogram[i].micros < l.histogram[j].micros })
+		c.kinds[kind] = l
+	}
+	return c
+}
+
+// formatMicros formats a latency in microseconds, e.g. "850µs" or "1.2ms".
+func formatMicros(us int64) string {
+	switch {
+	case us < 1000:
+		return fmt.Sprintf("%dµs", us)
+	case us < 1000000:
+		return fmt.Sprintf("%.1fms", float64(us)/1000)
+	}
+	return fmt.Sprintf("%.2fs", float64(us)/1000000)
+}
+
+// latencyBreakdown is the result of `latency` for a collection: the
+// histogram of each kind of operation.
+type latencyBreakdown collectionLatency
+
+func (c latencyBreakdown) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("latency of %s since the server started\n", c.ns))
+	shown := false
+	for _, kind := range latencyKinds {
+		l, ok := c.kinds[kind]
+		if !ok || l.ops == 0 {
+			continue
+		}
+		shown = true
+		b.WriteString(fmt.Sprintf("\n%s: %s operations, %s on average, %s in total\n", kind, groupDigits(l.ops), formatMicros(l.average()), formatMicros(l.micros)))
+		var most int64
+		for _, bucket := range l.histogram {
+			most = max(most, bucket.count)
+		}
+		if most == 0 {
+			continue
+		}
+		for i, bucket := range l.histogram {
+			upper := "+"
+			if i+1 < len(l.histogram) {
+				upper = " - " + formatMicros(l.histogram[i+1].micros)
+			}
+			bar := strings.Repeat("█", int(float64(bucket.count)/float64(most)*latencyBarWidth+0.5))
+			b.WriteString(fmt.Sprintf("  %18s  %10s  %5.1f%%  %s\n", formatMicros(bucket.micros)+upper, groupDigits(bucket.count), 100*float64(bucket.count)/float64(l.ops), bar))
+		}
+	}
+	if !shown {
+		b.WriteString("no operations recorded\n")
+	}
+	return b.String()
+}
+
+// latencyTable is the result of `latency` for a database: the operations
+// and average latency of each collection, those that spent the most time
+// first.
+type latencyTable []collectionLatency
+
+func (t latencyTable) String() string {
+	if len(t) == 0 {
+		return "no collections\n"
+	}
+	width := len("collection")
+	for _, c := range t {
+		width = max(width, len(c.ns))
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%-*s", width, "collection"))
+	for _, kind := range latencyKinds {
+		b.WriteString(fmt.Sprintf("  %12s  %8s", kind, "avg"))
+	}
+	b.WriteString(fmt.Sprintf("  %9s\n", "total"))
+	for _, c := range t {
+		b.WriteString(fmt.Sprintf("%-*s", width, c.ns))
+		for _, kind := range latencyKinds {
+			l := c.kinds[kind]
+			avg := "-"
+			if l.ops > 0 {
+				avg = formatMicros(l.average())
+			}
+			b.WriteString(fmt.Sprintf("  %12s  %8s", groupDigits(l.ops), avg))
+		}
+		b.WriteString(fmt.Sprintf("  %9s\n", formatMicros(c.total())))
+	}
+	return b.String()
+}
+
+// readLatency reads the latencyStats of db.coll, with histograms.
+func (m *model) readLatency(ctx context.Context, db, coll string) (collectionLatency, error) {
+	pipeline := bson.A{bson.D{{Key: "$collStats", Value: bson.D{{Key: "latencyStats", Value: bson.D{{Key: "histograms", Value: true}}}}}}}
+	cur, err := m.store.Aggregate(ctx, db, coll, pipeline)
+	if err != nil {
+		return collectionLatency{}, fmt.Errorf("latency: %s.%s: %w", db, coll, err)
+	}
+	defer cur.Close(ctx)
+	// A sharded collection has a document per shard, whose counts add up.
+	total := collectionLatency{ns: db + "." + coll, kinds: map[string]opLatency{}}
+	for cur.Next(ctx) {
+		var doc struct {
+			LatencyStats bson.M `bson:"latencyStats"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return collectionLatency{}, err
+		}
+		shard := parseLatencyStats(total.ns, doc.LatencyStats)
+		for kind, l := range shard.kinds {
+			sum := total.kinds[kind]
+			sum.ops += l.ops
+			sum.micros += l.micros
+			sum.histogram = mergeHistograms(sum.histogram, l.histogram)
+			total.kinds[kind] = sum
+		}
+	}
+	return total, cur.Err()
+}
+
+// mergeHistograms adds up the counts of buckets with the same bounds.
+func mergeHistograms(a, b []latencyBucket) []latencyBucket {
+	counts := map[int64]int64{}
+	for _, bucket := range append(a, b...) {
+		counts[bucket.micros] += bucket.count
+	}
+	merged := make([]latencyBucket, 0, len(counts))
+	for micros, count := range counts {
+		merged = append(merged, latencyBucket{micros: micros, count: count})
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].micros < merged[j].micros })
+	return merged
+}
+
+// latency shows how long operations on a collection took as histograms, or
+// compares the collections of a database to find the busiest.
+func (m *model) latency(args []string) (tea.Model, tea.Cmd) {
+	if len(args) > 1 {
+		m.err = fmt.Errorf("usage: latency [[db/]collection] (inside a database)")
+		return m, nil
+	}
+	path := m.currentPath
+	if len(args) == 1 {
+		path = m.resolvePath(args[0])
+	}
+	if len(path) == 0 || len(path) > 2 {
+		m.err = fmt.Errorf("usage: latency [[db/]collection] (inside a database)")
+		return m, nil
+	}
+
+	db := path[0]
+	if len(path) == 2 {
+		coll := path[1]
+		return m, m.run(func(ctx context.Context) tea.Msg {
+			c, err := m.readLatency(ctx, db, coll)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			return mongoMsg{result: latencyBreakdown(c)}
+		})
+	}
+	return m, m.runWithTimeout(0, func(ctx context.Context) tea.Msg { // One query per collection
+		namespaces, err := m.store.ListNamespaces(ctx, db)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		var table latencyTable
+		for _, ns := range namespaces {
+			if ns.Kind != "collection" {
+				continue
+			}
+			c, err := m.readLatency(ctx, db, ns.Name)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			table = append(table, c)
+		}
+		sort.SliceStable(table, func(i, j int) bool {
+			if table[i].total() != table[j].total() {
+				return table[i].total() > table[j].total()
+			}
+			return table[i].ns < table[j].ns
+		})
+		return mongoMsg{result: table}
+	})
+}
@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func latencyStatsDoc() bson.M {
+	return bson.M{
+		"reads": bson.M{"latency": int64(5000), "ops": int64(4), "histogram": bson.A{
+			bson.M{"micros": int64(1024), "count": int64(1)},
+			bson.M{"micros": int64(128), "count": int64(3)},
+		}},
+		"writes":   bson.M{"latency": int64(0), "ops": int64(0), "histogram": bson.A{}},
+		"commands": bson.M{"latency": int64(2500000), "ops": int64(1), "histogram": bson.A{bson.M{"micros": int64(2097152), "count": int64(1)}}},
+	}
+}
+
+func TestParseLatencyStats(t *testing.T) {
+	c := parseLatencyStats("shop.orders", latencyStatsDoc())
+	reads := c.kinds["reads"]
+	if reads.ops != 4 || reads.average() != 1250 || len(reads.histogram) != 2 || reads.histogram[0].micros != 128 {
+		t.Fatalf("reads = %+v", reads)
+	}
+	if c.total() != 2505000 {
+		t.Fatalf("total = %d", c.total())
+	}
+
+	got := latencyBreakdown(c).String()
+	for _, want := range []string{
+		"reads: 4 operations, 1.2ms on average, 5.0ms in total",
+		"128µs - 1.0ms           3   75.0%",
+		"1.0ms+           1   25.0%",
+		"commands: 1 operations, 2.50s on average",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "writes") {
+		t.Errorf("writes without operations shown:\n%s", got)
+	}
+	if got := latencyBreakdown(parseLatencyStats("shop.empty", bson.M{})).String(); !strings.Contains(got, "no operations recorded") {
+		t.Errorf("empty breakdown:\n%s", got)
+	}
+}
+
+func TestMergeHistograms(t *testing.T) {
+	got := mergeHistograms(
+		[]latencyBucket{{micros: 2, count: 1}, {micros: 8, count: 2}},
+		[]latencyBucket{{micros: 4, count: 5}, {micros: 8, count: 3}},
+	)
+	want := []latencyBucket{{2, 1}, {4, 5}, {8, 5}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestLatencyArguments(t *testing.T) {
+	m := newTestModel(seededFake())
+	expectError(t, m, "latency", "usage: latency")
+	expectError(t, m, "latency a b", "usage: latency")
+	expectError(t, m, "latency /shop/orders/1", "usage: latency")
+}
+
+func TestLatency(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+	run(t, m, `find '{"qty": 5}'`)
+	run(t, m, `insert '{"item": "fig"}'`)
+	run(t, m, "count")
+
+	run(t, m, "latency")
+	got := output(t, m)
+	for _, want := range []string{"latency of shop.orders", "reads: 1 operations", "writes: 1 operations", "commands: 1 operations"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("latency lacks %q:\n%s", want, got)
+		}
+	}
+
+	run(t, m, "latency /shop")
+	lines := strings.Split(output(t, m), "\n")
+	if len(lines) < 3 || !strings.HasPrefix(lines[1], "shop.orders") || !strings.HasPrefix(lines[2], "shop.customers") {
+		t.Errorf("latency of a database, busiest first:\n%s", strings.Join(lines, "\n"))
+	}
+}
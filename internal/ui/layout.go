@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// minInputWidth keeps the input line usable in a very narrow terminal.
+const minInputWidth = 10
+
+// scrollStep is how many columns ← and → scroll truncated output by.
+const scrollStep = 8
+
+// resize adapts the input line to a new terminal size. The output is fitted
+// to it when the view is drawn.
+func (m *model) resize(msg tea.WindowSizeMsg) {
+	m.width, m.height = msg.Width, msg.Height
+	// The prompt before the input shows the path, so it has no fixed width;
+	// leave it a third of the line.
+	m.textInput.Width = max(minInputWidth, msg.Width*2/3)
+}
+
+// fitScreen wraps the lines of s wider than width and, when s is taller than
+// height, cuts it off with a line saying how much is not shown, so the
+// prompt at the top stays in view. A width or height of 0 is unknown and
+// leaves s alone.
+func fitScreen(s string, width, height int) string {
+	if width <= 0 && height <= 0 {
+		return s
+	}
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if width > 0 {
+		var wrapped []string
+		for _, line := range lines {
+			if ansi.StringWidth(line) <= width {
+				wrapped = append(wrapped, line)
+				continue
+			}
+			wrapped = append(wrapped, strings.Split(ansi.Wrap(line, width, ""), "\n")...)
+		}
+		lines = wrapped
+	}
+	if height > 0 && len(lines) > height {
+		note := fmt.Sprintf("… %d more lines, make the window taller to see them", len(lines)-height+1)
+		if width > 0 {
+			note = ansi.Truncate(note, width, "…")
+		}
+		lines = append(lines[:height-1], timingStyle.Render(note))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// truncateLines cuts off the lines of s wider than width, showing the
+// columns from offset on. An ellipsis marks each end of a line that goes on
+// past the edge of the screen. A width of 0 is unknown and leaves s alone.
+func truncateLines(s string, width, offset int) string {
+	if width <= 0 {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		w := ansi.StringWidth(line)
+		if w <= width {
+			continue
+		}
+		start := min(offset, w-width)
+		from, to := start, start+width
+		var prefix, suffix string
+		if start > 0 {
+			from, prefix = from+1, "…"
+		}
+		if to < w {
+			to, suffix = to-1, "…"
+		}
+		line = prefix + ansi.Cut(line, from, to) + suffix
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// scrollKey scrolls a table a column at a time with ← and →, or truncated
+// output a few columns at a time, reporting whether it used the key. Like a
+// document tree, it only takes the arrow keys while the input line is empty.
+func (m *model) scrollKey(msg tea.KeyMsg) bool {
+	if m.result == nil || m.prompt != nil || m.running != nil || m.err != nil || m.textInput.Value() != "" {
+		return false
+	}
+	var delta int
+	switch msg.Type {
+	case tea.KeyLeft:
+		delta = -1
+	case tea.KeyRight:
+		delta = 1
+	default:
+		return false
+	}
+	if m.scrollTable(delta) {
+		return true
+	}
+	if !m.truncate {
+		return false
+	}
+	widest := 0
+	for _, line := range strings.Split(m.resultView(), "\n") {
+		widest = max(widest, ansi.StringWidth(line))
+	}
+	m.scrollX = max(0, min(m.scrollX+delta*scrollStep, widest-m.width))
+	return true
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFitScreen(t *testing.T) {
+	s := "short\n" + strings.TrimSpace(strings.Repeat("word ", 6)) + "\n"
+	if got := fitScreen(s, 0, 0); got != s {
+		t.Fatalf("unknown size changed the view: %q", got)
+	}
+	if got := fitScreen(s, 12, 0); got != "short\nword word\nword word\nword word\n" {
+		t.Fatalf("wrapped to 12: %q", got)
+	}
+	got := fitScreen("a\nb\nc\nd\n", 0, 3)
+	if !strings.HasPrefix(got, "a\nb\n") || !strings.Contains(got, "2 more lines") {
+		t.Fatalf("cut to 3 lines: %q", got)
+	}
+}
+
+func TestResize(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+	run(t, m, "set table on")
+	run(t, m, "find")
+
+	m.Update(tea.WindowSizeMsg{Width: 30, Height: 6})
+	if m.textInput.Width != 20 {
+		t.Fatalf("input width = %d, want 20", m.textInput.Width)
+	}
+	view := m.View()
+	lines := strings.Split(strings.TrimSuffix(view, "\n"), "\n")
+	if len(lines) > 6 {
+		t.Fatalf("view of %d lines in a 6 line terminal:\n%s", len(lines), view)
+	}
+	for _, line := range lines {
+		if w := len([]rune(line)); w > 30 && !strings.Contains(line, "\x1b") {
+			t.Fatalf("line of %d columns in a 30 column terminal: %q", w, line)
+		}
+	}
+
+	table := documentTable(m.result.(documentList), tableOptions{width: 30})
+	if !strings.Contains(table, "more columns do not fit") || strings.Contains(strings.Split(table, "\n")[0], "qty") {
+		t.Fatalf("table fitted to 30 columns:\n%s", table)
+	}
+}
+
+func TestTruncateLines(t *testing.T) {
+	s := "short\n0123456789abcdef\n"
+	if got := truncateLines(s, 8, 0); got != "short\n0123456…\n" {
+		t.Fatalf("truncated to 8: %q", got)
+	}
+	if got := truncateLines(s, 8, 4); got != "short\n…56789a…\n" {
+		t.Fatalf("scrolled by 4: %q", got)
+	}
+	if got := truncateLines(s, 8, 20); got != "short\n…9abcdef\n" {
+		t.Fatalf("scrolled past the end: %q", got)
+	}
+}
+
+func TestScrollKey(t *testing.T) {
+	m := newTestModel(seededFake())
+	m.Update(tea.WindowSizeMsg{Width: 20, Height: 40})
+	run(t, m, "set wrap off")
+	run(t, m, "cd shop/orders")
+	run(t, m, "find")
+	if !m.truncate {
+		t.Fatal("set wrap off left wrapping on")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if m.scrollX != scrollStep {
+		t.Fatalf("scrollX after → = %d, want %d", m.scrollX, scrollStep)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	if m.scrollX != 0 {
+		t.Fatalf("scrollX after ←← = %d, want 0", m.scrollX)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(m.View(), "\n"), "\n")[1:] {
+		if w := len([]rune(line)); w > 20 && !strings.Contains(line, "\x1b") {
+			t.Fatalf("line of %d columns in a 20 column terminal: %q", w, line)
+		}
+	}
+}
+
+func TestScrollTable(t *testing.T) {
+	m := newTestModel(seededFake())
+	m.Update(tea.WindowSizeMsg{Width: 40, Height: 40})
+	run(t, m, "cd shop/orders")
+	run(t, m, "set table on")
+	run(t, m, "find")
+
+	header := func() string { return strings.Join(strings.Fields(strings.Split(m.resultView(), "\n")[0]), " ") }
+	if got := header(); got != "_id item price" {
+		t.Fatalf("header = %q, want _id item price", got)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if got := header(); got != "_id price qty" {
+		t.Fatalf("header after → = %q, want _id price qty", got)
+	}
+	if !strings.Contains(m.resultView(), "1 columns to the left (←): item") {
+		t.Fatalf("scrolled past columns not named:\n%s", m.resultView())
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if m.tableScroll != 2 {
+		t.Fatalf("tableScroll = %d, want it to stop at the last column, 2", m.tableScroll)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	if got := header(); got != "_id item price" {
+		t.Fatalf("header after scrolling back = %q", got)
+	}
+}
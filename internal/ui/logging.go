@@ -0,0 +1,250 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+	"github.com/nick-popovic/mon-go/internal/release"
+)
+
+// setupLogging installs the default slog logger described by cfg and
+// returns a function closing the log file.
+func setupLogging(cfg config.Log) (func(), error) {
+	if cfg.Level == "" {
+		slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+		return func() {}, nil
+	}
+
+	path := cfg.File
+	if path == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, "mon-go", "mon-go.log")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := slog.NewTextHandler(f, &slog.HandlerOptions{Level: config.LogLevels[cfg.Level]})
+	slog.SetDefault(slog.New(handler))
+	return func() { f.Close() }, nil
+}
+
+// logEnvironment logs what a bug report needs to know about the build.
+func logEnvironment() {
+	attrs := []any{"version", release.Current(), "go", runtime.Version(), "os", runtime.GOOS + "/" + runtime.GOARCH}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		attrs = append(attrs, "build", info.Main.Version)
+		for _, dep := range info.Deps {
+			if dep.Path == "go.mongodb.org/mongo-driver" {
+				attrs = append(attrs, "driver", dep.Version)
+			}
+		}
+	}
+	slog.Info("starting", attrs...)
+}
+
+var credentialsPattern = regexp.MustCompile(`//[^/@]*@`)
+
+// redactURI hides the credentials of a connection string so it can be
+// logged.
+func redactURI(uri string) string {
+	return credentialsPattern.ReplaceAllString(uri, "//***@")
+}
+
+// stripCredentials removes the username and password of a connection
+// string, so it can be kept on disk.
+func stripCredentials(uri string) string {
+	return credentialsPattern.ReplaceAllString(uri, "//")
+}
+
+// commandMonitor feeds readTraces and logs server commands at debug level.
+// Failed attempts are logged as warnings, which includes those the driver
+// retries.
+var commandMonitor = &event.CommandMonitor{
+	Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+		readMonitor.Started(ctx, e)
+		recordSent(ctx, e)
+		if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+			return
+		}
+		slog.Debug("command started", "command", e.CommandName, "db", e.DatabaseName,
+			"connection", e.ConnectionID, "requestID", e.RequestID, "body", sanitizeCommand(e.Command))
+	},
+	Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+		slog.Debug("command succeeded", "command", e.CommandName, "requestID", e.RequestID,
+			"connection", e.ConnectionID, "duration", e.Duration)
+	},
+	Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+		slog.Warn("command failed", "command", e.CommandName, "requestID", e.RequestID,
+			"connection", e.ConnectionID, "duration", e.Duration, "error", e.Failure)
+	},
+}
+
+// unsanitizedFields are command fields logged as they are: they hold names,
+// sizes and key patterns rather than data.
+var unsanitizedFields = map[string]bool{
+	"sort": true, "projection": true, "hint": true, "key": true, "indexes": true,
+	"limit": true, "skip": true, "batchSize": true, "cursor": true, "maxTimeMS": true, "$db": true,
+}
+
+// droppedFields are command fields left out of the log as noise.
+var droppedFields = map[string]bool{"lsid": true, "$clusterTime": true, "txnNumber": true, "$readPreference": true}
+
+// sanitizeCommand formats a server command for the debug log with the
+// values in its filters, documents and pipelines replaced by their type,
+// e.g. {"find": "users", "filter": {"email": "?string"}}, so logs attached to
+// bug reports show the shape of queries without the data in them. The
+// driver already leaves out the body of authentication commands.
+func sanitizeCommand(cmd bson.Raw) string {
+	elems, err := cmd.Elements()
+	if err != nil {
+		return fmt.Sprintf("<invalid command: %v>", err)
+	}
+	doc := bson.D{}
+	for _, e := range elems {
+		key := e.Key()
+		switch {
+		case droppedFields[key]:
+		case unsanitizedFields[key]:
+			doc = append(doc, bson.E{Key: key, Value: e.Value()})
+		default:
+			doc = append(doc, bson.E{Key: key, Value: sanitizeValue(e.Value(), true)})
+		}
+	}
+	data, err := bson.MarshalExtJSON(doc, false, false)
+	if err != nil {
+		return fmt.Sprintf("<unprintable command: %v>", err)
+	}
+	return string(data)
+}
+
+// sanitizeValue replaces the leaf values of v by their type. Top-level
+// strings and numbers, such as collection names and the command's own
+// value, are kept.
+func sanitizeValue(v bson.RawValue, topLevel bool) interface{} {
+	switch v.Type {
+	case bsontype.EmbeddedDocument:
+		elems, _ := v.Document().Elements()
+		doc := bson.D{}
+		for _, e := range elems {
+			doc = append(doc, bson.E{Key: e.Key(), Value: sanitizeValue(e.Value(), false)})
+		}
+		return doc
+	case bsontype.Array:
+		values, _ := v.Array().Values()
+		arr := bson.A{}
+		for _, elem := range values {
+			arr = append(arr, sanitizeValue(elem, false))
+		}
+		return arr
+	case bsontype.String, bsontype.Int32, bsontype.Int64, bsontype.Double, bsontype.Boolean:
+		if topLevel || v.Type == bsontype.Boolean {
+			return v
+		}
+	}
+	return "?" + v.Type.String()
+}
+
+// loggedCommand is what the log records of a typed line: its command name,
+// since the rest can hold filter values, documents, connection strings and
+// shell commands.
+func loggedCommand(input string) string {
+	if strings.HasPrefix(input, "!") {
+		return "!"
+	}
+	if fields := strings.Fields(input); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// operationStack returns the stack of the code starting an operation, to
+// log with the operation's error if it is unexpected. It is only taken when
+// the debug log is on.
+func operationStack() string {
+	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		return ""
+	}
+	return strings.TrimSpace(string(debug.Stack()))
+}
+
+// unexpectedError reports whether err is neither the server refusing a
+// command nor the operation being cancelled or timing out, so a stack helps
+// finding where it comes from.
+func unexpectedError(err error) bool {
+	var serverErr mongo.ServerError
+	return !errors.As(err, &serverErr) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// errorAttrs describes err for the log beyond its message: the chain of
+// wrapped error types, and the codes and labels of server errors, which
+// tell apart failures with the same text.
+func errorAttrs(err error) []any {
+	var chain []string
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		chain = append(chain, fmt.Sprintf("%T", e))
+	}
+	attrs := []any{"error", err, "errorChain", strings.Join(chain, " > ")}
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		attrs = append(attrs, "code", cmdErr.Code, "codeName", cmdErr.Name, "labels", cmdErr.Labels)
+	}
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		for _, we := range writeErr.WriteErrors {
+			attrs = append(attrs, "writeError", fmt.Sprintf("index %d code %d: %s", we.Index, we.Code, we.Message))
+		}
+		attrs = append(attrs, "labels", writeErr.Labels)
+	}
+	return attrs
+}
+
+// serverMonitor logs changes in the deployment, such as elections or lost
+// members.
+var serverMonitor = &event.ServerMonitor{
+	ServerDescriptionChanged: func(e *event.ServerDescriptionChangedEvent) {
+		if e.PreviousDescription.Kind == e.NewDescription.Kind {
+			return
+		}
+		slog.Info("server changed", "address", e.Address,
+			"from", e.PreviousDescription.Kind.String(), "to", e.NewDescription.Kind.String())
+	},
+	ServerHeartbeatFailed: func(e *event.ServerHeartbeatFailedEvent) {
+		slog.Warn("server heartbeat failed", "connection", e.ConnectionID, "error", e.Failure)
+	},
+}
+
+// safely runs fn, turning a panic into an error result after logging it
+// with its stack, so one broken command does not take the shell down.
+func safely(ctx context.Context, fn func(ctx context.Context) tea.Msg) (msg tea.Msg) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("panic", "panic", r, "stack", strings.TrimSpace(string(debug.Stack())))
+			msg = mongoMsg{err: fmt.Errorf("internal error: %v", r)}
+		}
+	}()
+	return fn(ctx)
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestSanitizeCommand(t *testing.T) {
+	cmd, err := bson.Marshal(bson.D{
+		{Key: "find", Value: "users"},
+		{Key: "filter", Value: bson.D{{Key: "email", Value: "ann@example.com"}, {Key: "age", Value: bson.D{{Key: "$gt", Value: 30}}}}},
+		{Key: "sort", Value: bson.D{{Key: "age", Value: -1}}},
+		{Key: "limit", Value: 5},
+		{Key: "lsid", Value: bson.D{{Key: "id", Value: "x"}}},
+		{Key: "$db", Value: "app"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := sanitizeCommand(cmd)
+	want := `{"find":"users","filter":{"email":"?string","age":{"$gt":"?32-bit integer"}},"sort":{"age":-1},"limit":5,"$db":"app"}`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestErrorAttrs(t *testing.T) {
+	err := fmt.Errorf("find: %w", mongo.CommandError{Code: 2, Name: "BadValue", Message: "unknown operator"})
+	attrs := errorAttrs(err)
+	text := fmt.Sprint(attrs...)
+	if !strings.Contains(text, "*fmt.wrapError > mongo.CommandError") || !strings.Contains(text, "BadValue") {
+		t.Errorf("got %v", attrs)
+	}
+	if attrs := errorAttrs(errors.New("plain")); len(attrs) != 4 {
+		t.Errorf("plain error: got %v", attrs)
+	}
+}
+
+func TestLogLeavesOutArguments(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+	run(t, m, `find '{"item": "secret-filter"}'`)
+	run(t, m, `insert '{"item": "secret-document"}'`)
+	m.textInput.SetValue(`find '{"item": "secret-rejected"`)
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	log := buf.String()
+	if strings.Contains(log, "secret") {
+		t.Errorf("log holds command arguments:\n%s", log)
+	}
+	if !strings.Contains(log, "command=insert") || !strings.Contains(log, `msg="command rejected" command=find`) {
+		t.Errorf("log is missing commands:\n%s", log)
+	}
+}
+
+func TestUnexpectedErrorLoggedWithStack(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	m := newTestModel(seededFake())
+	m.lastInput = "frobnicate"
+	drain(m, m.run(func(ctx context.Context) tea.Msg { return mongoMsg{err: errors.New("broken")} }))
+	if log := buf.String(); !strings.Contains(log, "stack=") || !strings.Contains(log, "TestUnexpectedErrorLoggedWithStack") {
+		t.Errorf("unexpected error without the stack that started it:\n%s", log)
+	}
+
+	buf.Reset()
+	drain(m, m.run(func(ctx context.Context) tea.Msg {
+		return mongoMsg{err: mongo.CommandError{Code: 2, Name: "BadValue"}}
+	}))
+	if log := buf.String(); strings.Contains(log, "stack=") {
+		t.Errorf("server error logged with a stack:\n%s", log)
+	}
+}
@@ -0,0 +1,290 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+// statsWorkers bounds how many stats commands `ls -l` runs at once, so
+// clusters with hundreds of databases or collections are listed quickly
+// without flooding the server.
+const statsWorkers = 8
+
+// statsRow is a database or collection of a long listing. Its cells are
+// filled in once its stats command returns.
+type statsRow struct {
+	index  int
+	name   string
+	kind   string   // Shown after the name if set, e.g. "view"
+	cells  []string // One per column after the name, nil until loaded
+	err    error
+	loaded bool
+}
+
+// statsTable is the result of `ls -l`. It is shown while it fills in, so
+// rows appear as their statistics arrive.
+type statsTable struct {
+	nameHeader string
+	columns    []string
+	rows       []statsRow
+	pending    int
+	truncated  bool
+	updates    chan statsRow
+}
+
+// statsTableMsg starts showing a long listing, or reports why the names
+// could not be listed.
+type statsTableMsg struct {
+	id    int
+	table *statsTable
+	err   error
+}
+
+// statsRowMsg delivers the statistics of one row, or with done set, the end
+// of the listing.
+type statsRowMsg struct {
+	id   int
+	row  statsRow
+	done bool
+}
+
+// statsLoader fetches the cells of a row.
+type statsLoader func(ctx context.Context, row statsRow) ([]string, error)
+
+// longList runs a long listing: list returns the rows, with whatever is
+// known without a stats command already filled in, and load fetches the
+// rest of each row, statsWorkers at a time.
+func (m *model) longList(list func(ctx context.Context) (*statsTable, error), load statsLoader) tea.Cmd {
+	ctx, op := m.startOperation(commandTimeout)
+	start := func() tea.Msg {
+		table, err := list(ctx)
+		if err != nil {
+			return statsTableMsg{id: op.id, err: err}
+		}
+		table.pending = 0
+		for i := range table.rows {
+			table.rows[i].index = i
+			if !table.rows[i].loaded {
+				table.pending++
+			}
+		}
+		table.updates = make(chan statsRow, table.pending) // Workers never block on a cancelled listing
+
+		jobs := make(chan statsRow)
+		var wg sync.WaitGroup
+		for i := 0; i < statsWorkers && i < table.pending; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for row := range jobs {
+					row.cells, row.err = load(ctx, row)
+					row.loaded = true
+					table.updates <- row
+				}
+			}()
+		}
+		go func() {
+			for _, row := range table.rows {
+				if ctx.Err() != nil {
+					break
+				}
+				if !row.loaded {
+					jobs <- row
+				}
+			}
+			close(jobs)
+			wg.Wait()
+			close(table.updates)
+		}()
+		return statsTableMsg{id: op.id, table: table}
+	}
+	return tea.Batch(start, m.tick())
+}
+
+// lsDatabases lists databases with their statistics.
+func (m *model) lsDatabases(opts lsOptions) tea.Cmd {
+	list := func(ctx context.Context) (*statsTable, error) {
+		databases, err := m.store.ListDatabases(ctx)
+		if err != nil {
+			return nil, err
+		}
+		table := &statsTable{nameHeader: "database", columns: []string{"collections", "objects", "data", "indexes", "on disk"}}
+		var specs []mongo.DatabaseSpecification
+		for _, spec := range databases {
+			if m.namespaces.visible(spec.Name, "") && matchesPattern(opts.pattern, spec.Name) {
+				specs = append(specs, spec)
+			}
+		}
+		if opts.sort != "" {
+			names := make([]string, len(specs))
+			for i, spec := range specs {
+				names[i] = spec.Name
+			}
+			if names, err = m.sortNames(ctx, "", names, opts.sort); err != nil {
+				return nil, err
+			}
+			specs = inOrder(specs, names, func(spec mongo.DatabaseSpecification) string { return spec.Name })
+		}
+		if !opts.showAll && len(specs) > defaultListLimit {
+			specs = specs[:defaultListLimit]
+			table.truncated = true
+		}
+		for _, spec := range specs {
+			table.rows = append(table.rows, statsRow{name: spec.Name,
+				cells: []string{"…", "…", "…", "…", commands.FormatByteSize(spec.SizeOnDisk)}})
+		}
+		return table, nil
+	}
+	load := func(ctx context.Context, row statsRow) ([]string, error) {
+		var stats struct {
+			Collections int64 `bson:"collections"`
+			Objects     int64 `bson:"objects"`
+			DataSize    int64 `bson:"dataSize"`
+			IndexSize   int64 `bson:"indexSize"`
+		}
+		cmd := bson.D{{Key: "dbStats", Value: 1}}
+		if err := m.store.RunCommand(ctx, row.name, cmd, &stats); err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprint(stats.Collections), fmt.Sprint(stats.Objects),
+			commands.FormatByteSize(stats.DataSize), commands.FormatByteSize(stats.IndexSize), row.cells[4]}, nil
+	}
+	return m.longList(list, load)
+}
+
+// lsCollections lists the collections of db with their statistics.
+func (m *model) lsCollections(db string, opts lsOptions) tea.Cmd {
+	list := func(ctx context.Context) (*statsTable, error) {
+		colls, err := m.names.Collections(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		colls = m.namespaces.collections(db, colls)
+		matching := colls[:0:0]
+		var names []string
+		for _, coll := range colls {
+			if matchesPattern(opts.pattern, coll.Name) {
+				matching = append(matching, coll)
+				names = append(names, coll.Name)
+			}
+		}
+		colls = matching
+		if opts.sort != "" {
+			if names, err = m.sortNames(ctx, db, names, opts.sort); err != nil {
+				return nil, err
+			}
+			colls = inOrder(colls, names, func(ns store.Namespace) string { return ns.Name })
+		}
+		table := &statsTable{nameHeader: "collection", columns: []string{"documents", "data", "storage", "indexes", "index size"}}
+		if !opts.showAll && len(colls) > defaultListLimit {
+			colls = colls[:defaultListLimit]
+			table.truncated = true
+		}
+		for _, coll := range colls {
+			row := statsRow{name: coll.Name, cells: []string{"…", "…", "…", "…", "…"}}
+			if coll.Kind != "collection" {
+				row.kind = coll.Kind
+			}
+			if coll.Kind == "view" {
+				row.loaded = true // Views have no storage of their own
+				row.cells = []string{"", "", "", "", ""}
+			}
+			table.rows = append(table.rows, row)
+		}
+		return table, nil
+	}
+	load := func(ctx context.Context, row statsRow) ([]string, error) {
+		var stats struct {
+			Count          int64 `bson:"count"`
+			Size           int64 `bson:"size"`
+			StorageSize    int64 `bson:"storageSize"`
+			NIndexes       int64 `bson:"nindexes"`
+			TotalIndexSize int64 `bson:"totalIndexSize"`
+		}
+		cmd := bson.D{{Key: "collStats", Value: row.name}}
+		if err := m.store.RunCommand(ctx, db, cmd, &stats); err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprint(stats.Count), commands.FormatByteSize(stats.Size), commands.FormatByteSize(stats.StorageSize),
+			fmt.Sprint(stats.NIndexes), commands.FormatByteSize(stats.TotalIndexSize)}, nil
+	}
+	return m.longList(list, load)
+}
+
+// next waits for the next row to be loaded.
+func (t *statsTable) next(id int) tea.Cmd {
+	updates := t.updates
+	return func() tea.Msg {
+		row, ok := <-updates
+		return statsRowMsg{id: id, row: row, done: !ok}
+	}
+}
+
+func (t *statsTable) fill(row statsRow) {
+	t.rows[row.index] = row
+	t.pending--
+}
+
+func (t *statsTable) String() string {
+	label := func(row statsRow) string {
+		if row.kind != "" {
+			return fmt.Sprintf("%s  [%s]", row.name, row.kind)
+		}
+		return row.name
+	}
+	width := len(t.nameHeader)
+	for _, row := range t.rows {
+		if w := len(label(row)); w > width {
+			width = w
+		}
+	}
+
+	var b strings.Builder
+	line := func(name string, cells []string) {
+		b.WriteString(fmt.Sprintf("%-*s", width, name))
+		for _, cell := range cells {
+			b.WriteString("  ")
+			b.WriteString(strings.Repeat(" ", max(0, 11-lipgloss.Width(cell))))
+			b.WriteString(cell)
+		}
+		b.WriteString("\n")
+	}
+	line(t.nameHeader, t.columns)
+	for _, row := range t.rows {
+		if row.err != nil {
+			b.WriteString(fmt.Sprintf("%-*s  error: %v\n", width, label(row), row.err))
+			continue
+		}
+		line(label(row), row.cells)
+	}
+	if t.pending > 0 {
+		b.WriteString(fmt.Sprintf("loading %d of %d...\n", t.pending, len(t.rows)))
+	}
+	if t.truncated {
+		b.WriteString(truncatedNotice)
+	}
+	return b.String()
+}
+
+// inOrder puts items in the order of their names.
+func inOrder[T any](items []T, names []string, name func(T) string) []T {
+	byName := make(map[string]T, len(items))
+	for _, item := range items {
+		byName[name(item)] = item
+	}
+	ordered := make([]T, len(names))
+	for i, n := range names {
+		ordered[i] = byName[n]
+	}
+	return ordered
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLsLong(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "ls -l --sort size")
+	got := output(t, m)
+	shop, admin := strings.Index(got, "\nshop "), strings.Index(got, "\nadmin ")
+	if !strings.HasPrefix(got, "database  collections") || shop < 0 || admin < shop {
+		t.Fatalf("ls -l --sort size:\n%s", got)
+	}
+	if !strings.Contains(got, "2            3         184B        8.0KB       16.0KB") {
+		t.Errorf("ls -l: statistics of shop missing in\n%s", got)
+	}
+
+	run(t, m, "cd shop")
+	run(t, m, `view create recent orders '[{"$match": {"qty": {"$gt": 1}}}]'`)
+	run(t, m, "ls -l --sort count")
+	got = output(t, m)
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 4 || strings.Join(strings.Fields(lines[1]), " ") != "orders 3 184B 4.0KB 1 4.0KB" ||
+		strings.Join(strings.Fields(lines[2]), " ") != "customers 0 0B 4.0KB 1 4.0KB" {
+		t.Fatalf("ls -l --sort count:\n%s", got)
+	}
+	if strings.Join(strings.Fields(lines[3]), " ") != "recent [view]" {
+		t.Errorf("a view has statistics: %q", lines[3])
+	}
+}
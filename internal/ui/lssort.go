@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// lsOptions are the arguments of ls.
+type lsOptions struct {
+	showAll bool   // -a: no limit
+	long    bool   // -l: with statistics
+	pattern string // Glob the databases or collections listed must match
+	sort    string // Order of databases and collections, or field documents are sorted by
+}
+
+// namespaceSorts are the orders of ls --sort for databases and collections.
+// All but name put the largest first.
+var namespaceSorts = []string{"name", "size", "count", "modified"}
+
+// parseLs reads the arguments of ls for a listing at path, e.g.
+// `ls -l --sort size users_*`.
+func parseLs(args []string, at []string) (lsOptions, error) {
+	var opts lsOptions
+	usage := fmt.Errorf("usage: ls [-l|-a|-la] [--sort name|size|count|modified] [pattern], or ls [--sort [-]<field>] in a collection")
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--sort" && i+1 < len(args):
+			i++
+			opts.sort = args[i]
+		case strings.HasPrefix(arg, "--sort="):
+			opts.sort = strings.TrimPrefix(arg, "--sort=")
+		case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Trim(arg[1:], "la") == "":
+			opts.showAll = opts.showAll || strings.Contains(arg, "a")
+			opts.long = opts.long || strings.Contains(arg, "l")
+		case !strings.HasPrefix(arg, "-") && opts.pattern == "":
+			opts.pattern = arg
+		default:
+			return opts, usage
+		}
+	}
+
+	if opts.pattern != "" {
+		if len(at) > 1 {
+			return opts, fmt.Errorf("ls: patterns match databases and collections, use find to pick documents")
+		}
+		if _, err := path.Match(opts.pattern, ""); err != nil {
+			return opts, fmt.Errorf("ls: '%s' is not a valid pattern: %w", opts.pattern, err)
+		}
+	}
+	switch {
+	case opts.sort == "":
+	case len(at) > 2:
+		return opts, fmt.Errorf("ls --sort: a document has nothing to sort")
+	case len(at) == 2:
+		if strings.TrimPrefix(opts.sort, "-") == "" {
+			return opts, fmt.Errorf("ls --sort: expected a field, e.g. --sort -createdAt for the newest first")
+		}
+	case !contains(namespaceSorts, opts.sort):
+		return opts, fmt.Errorf("ls --sort: expected %s, got %s", strings.Join(namespaceSorts, ", "), opts.sort)
+	case len(at) == 0 && opts.sort == "modified":
+		return opts, fmt.Errorf("ls --sort modified: only inside a database, for its collections")
+	}
+	return opts, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldSort is the sort document of ls --sort in a collection: the field,
+// descending with a - prefix.
+func fieldSort(by string) bson.D {
+	if field, ok := strings.CutPrefix(by, "-"); ok {
+		return bson.D{{Key: field, Value: -1}}
+	}
+	return bson.D{{Key: by, Value: 1}}
+}
+
+// sortNames orders the databases, or the collections of db, for ls --sort:
+// by name, or largest first by size on disk, number of documents, or when
+// the newest document was inserted. Insertion is told by the newest
+// ObjectId _id, so it misses updates and collections with other _ids sort
+// last, as do those whose statistics cannot be read, such as views. The
+// statistics are read statsWorkers at a time.
+func (m *model) sortNames(ctx context.Context, db string, names []string, by string) ([]string, error) {
+	sorted := append([]string(nil), names...)
+	if by == "name" || by == "" {
+		sort.Strings(sorted)
+		return sorted, nil
+	}
+	if m.store == nil && by != "modified" {
+		return nil, fmt.Errorf("ls --sort %s: not connected", by)
+	}
+
+	var sizes map[string]int64
+	if db == "" && by == "size" {
+		databases, err := m.store.ListDatabases(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sizes = map[string]int64{}
+		for _, spec := range databases {
+			sizes[spec.Name] = spec.SizeOnDisk
+		}
+	}
+	key := func(name string) int64 {
+		if sizes != nil {
+			return sizes[name]
+		}
+		if by == "modified" {
+			return m.insertedLast(ctx, db, name)
+		}
+		var stats struct {
+			Count    int64 `bson:"count"`
+			Objects  int64 `bson:"objects"`
+			Size     int64 `bson:"size"`
+			DataSize int64 `bson:"dataSize"`
+		}
+		on, cmd := db, bson.D{{Key: "collStats", Value: name}}
+		if db == "" {
+			on, cmd = name, bson.D{{Key: "dbStats", Value: 1}}
+		}
+		if err := m.store.RunCommand(ctx, on, cmd, &stats); err != nil {
+			return -1
+		}
+		if by == "count" {
+			return stats.Count + stats.Objects // Only one of them is set
+		}
+		return stats.Size + stats.DataSize
+	}
+
+	keys := make(map[string]int64, len(names))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, statsWorkers)
+	for _, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			workers <- struct{}{}
+			k := key(name)
+			<-workers
+			mu.Lock()
+			keys[name] = k
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return keys[sorted[i]] > keys[sorted[j]] })
+	return sorted, nil
+}
+
+// insertedLast returns when the newest document of a collection was
+// inserted, in seconds, by its ObjectId _id, or -1 if that cannot be told.
+func (m *model) insertedLast(ctx context.Context, db, coll string) int64 {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(1)
+	cur, err := m.store.Find(ctx, db, coll, bson.M{}, opts)
+	if err != nil {
+		return -1
+	}
+	defer cur.Close(ctx)
+	var doc struct {
+		ID interface{} `bson:"_id"`
+	}
+	if !cur.Next(ctx) || cur.Decode(&doc) != nil {
+		return -1
+	}
+	if id, ok := doc.ID.(primitive.ObjectID); ok {
+		return id.Timestamp().Unix()
+	}
+	return -1
+}
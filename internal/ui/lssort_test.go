@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLsSort(t *testing.T) {
+	fake := seededFake()
+	old := primitive.NewObjectIDFromTimestamp(time.Now().Add(-30 * 24 * time.Hour))
+	fake.Seed("shop", "archive", bson.D{{Key: "_id", Value: old}, {Key: "item", Value: "fig"}})
+	m := newTestModel(fake)
+
+	run(t, m, "cd shop")
+	run(t, m, "ls --sort modified")
+	if got := output(t, m); got != "orders\narchive\ncustomers\n" {
+		t.Errorf("ls --sort modified: %q", got)
+	}
+	run(t, m, "ls --sort name c*")
+	if got := output(t, m); got != "customers\n" {
+		t.Errorf("ls --sort name c*: %q", got)
+	}
+	run(t, m, "ls --sort size")
+	if got := output(t, m); got != "orders\narchive\ncustomers\n" {
+		t.Errorf("ls --sort size: %q", got)
+	}
+	expectError(t, m, "ls --sort biggest", "expected name, size, count, modified")
+
+	run(t, m, "cd orders")
+	run(t, m, "ls --sort -qty")
+	docs := m.result.(documentList).docs
+	if len(docs) != 3 || docs[0]["item"] != "plum" || docs[2]["item"] != "pear" {
+		t.Errorf("ls --sort -qty: %v", docs)
+	}
+	if strings.Join(m.lastFind, " ") != `--sort {"qty":-1}` {
+		t.Errorf("ls --sort -qty runs again as find %q", m.lastFind)
+	}
+}
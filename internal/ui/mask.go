@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"path"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const maskedValue = "***"
+
+// maskRules redacts sensitive fields before documents are shown. A rule
+// without dots, such as "password" or "*token*", matches a field of that
+// name at any depth. A dotted rule, such as "auth.*.secret", matches the
+// full path of a field, with each segment matched as a glob.
+type maskRules []string
+
+// matches reports whether the field at the dotted path fields is masked.
+func (rules maskRules) matches(fields []string) bool {
+	for _, rule := range rules {
+		segments := strings.Split(rule, ".")
+		if len(segments) == 1 {
+			if ok, _ := path.Match(rule, fields[len(fields)-1]); ok {
+				return true
+			}
+			continue
+		}
+		if len(segments) != len(fields) {
+			continue
+		}
+		matched := true
+		for i, segment := range segments {
+			if ok, _ := path.Match(segment, fields[i]); !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// doc returns a copy of doc with masked fields redacted. doc itself is left
+// untouched so unmasked results are still available to the caller.
+func (rules maskRules) doc(doc bson.M) bson.M {
+	if len(rules) == 0 {
+		return doc
+	}
+	return rules.value(doc, nil).(bson.M)
+}
+
+func (rules maskRules) value(v interface{}, fields []string) interface{} {
+	switch v := v.(type) {
+	case bson.M:
+		masked := make(bson.M, len(v))
+		for key, value := range v {
+			fieldPath := append(fields[:len(fields):len(fields)], key)
+			if rules.matches(fieldPath) {
+				masked[key] = maskedValue
+			} else {
+				masked[key] = rules.value(value, fieldPath)
+			}
+		}
+		return masked
+	case bson.D:
+		masked := make(bson.D, len(v))
+		for i, elem := range v {
+			fieldPath := append(fields[:len(fields):len(fields)], elem.Key)
+			if rules.matches(fieldPath) {
+				masked[i] = bson.E{Key: elem.Key, Value: maskedValue}
+			} else {
+				masked[i] = bson.E{Key: elem.Key, Value: rules.value(elem.Value, fieldPath)}
+			}
+		}
+		return masked
+	case bson.A:
+		masked := make(bson.A, len(v))
+		for i, elem := range v {
+			masked[i] = rules.value(elem, fields) // Array elements share the array's path
+		}
+		return masked
+	default:
+		return v
+	}
+}
+
+// maskable is implemented by results that contain documents.
+type maskable interface {
+	masked(rules maskRules) result
+}
+
+func (l documentList) masked(rules maskRules) result {
+	docs := make([]bson.M, len(l.docs))
+	for i, doc := range l.docs {
+		docs[i] = rules.doc(doc)
+	}
+	return documentList{docs: docs, truncated: l.truncated, page: l.page}
+}
+
+// maskResult redacts r if it contains documents.
+func maskResult(r result, rules maskRules) result {
+	if mr, ok := r.(maskable); ok && len(rules) > 0 {
+		return mr.masked(rules)
+	}
+	return r
+}
@@ -0,0 +1,199 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+// measuredCounters are the serverStatus counters measure reports, by dotted
+// path, with the label they are shown under.
+var measuredCounters = []struct {
+	path  string
+	label string
+}{
+	{"metrics.queryExecutor.scanned", "keys examined"},
+	{"metrics.queryExecutor.scannedObjects", "documents examined"},
+	{"metrics.document.returned", "documents returned"},
+	{"metrics.document.inserted", "documents inserted"},
+	{"metrics.document.updated", "documents updated"},
+	{"metrics.document.deleted", "documents deleted"},
+	{"wiredTiger.cache.pages requested from the cache", "cache pages requested"},
+	{"wiredTiger.cache.pages read into cache", "cache pages read from disk"},
+	{"wiredTiger.cache.bytes read into cache", "cache bytes read from disk"},
+	{"wiredTiger.cache.bytes written from cache", "cache bytes written"},
+	{"opcounters.query", "queries"},
+	{"opcounters.getmore", "getMores"},
+	{"opcounters.command", "commands"},
+}
+
+// serverSnapshot holds the counters measure compares.
+type serverSnapshot struct {
+	path        []string // Where measure was run; its collection's indexes are tracked
+	counters    map[string]int64
+	indexAccess map[string]int64 // Accesses per index of the collection
+	withIndexes bool
+	takenAt     time.Time
+}
+
+// measurement is a `measure` in progress: the snapshot taken before the
+// measured command, and that command's operation once it runs.
+type measurement struct {
+	input  string
+	before serverSnapshot
+	opID   int
+}
+
+// measureStartMsg carries the snapshot taken before the measured command.
+type measureStartMsg struct {
+	input  string
+	before serverSnapshot
+}
+
+// measureResult is the result of the measured command followed by what it
+// cost the server.
+type measureResult struct {
+	inner   result
+	cost    statsResult
+	elapsed time.Duration
+}
+
+func (r measureResult) String() string {
+	var b strings.Builder
+	if r.inner != nil {
+		b.WriteString(r.inner.String())
+		b.WriteString("\n")
+	}
+	b.WriteString(fmt.Sprintf("server cost (%s, server-wide deltas: other clients' activity is included)\n", r.elapsed.Truncate(time.Millisecond)))
+	b.WriteString(r.cost.String())
+	return b.String()
+}
+
+// measure runs a command between two snapshots of the server's counters and
+// reports how they changed.
+func (m *model) measure(args []string) (tea.Model, tea.Cmd) {
+	if len(args) == 0 || args[0] == "measure" {
+		m.err = fmt.Errorf("usage: measure <command> [args...]")
+		return m, nil
+	}
+	input := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(m.lastInput), "measure"))
+	path := append([]string{}, m.currentPath...)
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		before, err := takeSnapshot(ctx, m.store, path)
+		if err != nil {
+			return mongoMsg{err: fmt.Errorf("measure: %w", err)}
+		}
+		return measureStartMsg{input: input, before: before}
+	})
+}
+
+// startMeasured runs the measured command once the first snapshot is taken.
+func (m *model) startMeasured(msg measureStartMsg) (tea.Model, tea.Cmd) {
+	_, cmd := m.processCommand(msg.input)
+	if m.running == nil {
+		m.measuring = nil
+		if m.err == nil {
+			m.err = fmt.Errorf("measure: '%s' does not run anything on the server", msg.input)
+		}
+		return m, cmd
+	}
+	m.measuring = &measurement{input: msg.input, before: msg.before, opID: m.running.id}
+	return m, cmd
+}
+
+// finishMeasure takes the second snapshot and shows the deltas with the
+// measured command's result.
+func (m *model) finishMeasure() tea.Cmd {
+	measured := m.measuring
+	m.measuring = nil
+	if m.err != nil {
+		return nil // The measured command failed, its error says more
+	}
+	inner := m.result
+	return m.run(func(ctx context.Context) tea.Msg {
+		after, err := takeSnapshot(ctx, m.store, measured.before.path)
+		if err != nil {
+			return mongoMsg{err: fmt.Errorf("measure: %w", err)}
+		}
+		return mongoMsg{result: measureResult{
+			inner:   inner,
+			cost:    snapshotDelta(measured.before, after),
+			elapsed: after.takenAt.Sub(measured.before.takenAt),
+		}}
+	})
+}
+
+func takeSnapshot(ctx context.Context, st store.Store, path []string) (serverSnapshot, error) {
+	snap := serverSnapshot{path: path, counters: map[string]int64{}, takenAt: time.Now()}
+	var status bson.M
+	if err := st.RunCommand(ctx, "admin", bson.D{{Key: "serverStatus", Value: 1}}, &status); err != nil {
+		return snap, err
+	}
+	for _, c := range measuredCounters {
+		if n, ok := asInt64(lookupPath(status, strings.SplitN(c.path, ".", 3))); ok {
+			snap.counters[c.path] = n
+		}
+	}
+
+	if len(path) < 2 {
+		return snap, nil
+	}
+	cur, err := st.Aggregate(ctx, path[0], path[1], bson.A{bson.D{{Key: "$indexStats", Value: bson.D{}}}})
+	if err != nil {
+		return snap, nil // Views and missing privileges have no index stats
+	}
+	defer cur.Close(ctx)
+	var stats []struct {
+		Name     string `bson:"name"`
+		Accesses struct {
+			Ops int64 `bson:"ops"`
+		} `bson:"accesses"`
+	}
+	if err := cur.All(ctx, &stats); err != nil {
+		return snap, nil
+	}
+	snap.withIndexes = true
+	snap.indexAccess = map[string]int64{}
+	for _, s := range stats {
+		snap.indexAccess[s.Name] += s.Accesses.Ops // Summed over the members reporting
+	}
+	return snap, nil
+}
+
+func snapshotDelta(before, after serverSnapshot) statsResult {
+	var res statsResult
+	for _, c := range measuredCounters {
+		b, okB := before.counters[c.path]
+		a, okA := after.counters[c.path]
+		if !okB || !okA {
+			continue
+		}
+		delta := a - b
+		var value interface{} = delta
+		if strings.Contains(c.label, "bytes") {
+			value = commands.FormatByteSize(delta)
+		}
+		res.fields = append(res.fields, statField{name: c.label, value: value})
+	}
+	if before.withIndexes && after.withIndexes {
+		names := make([]string, 0, len(after.indexAccess))
+		for name := range after.indexAccess {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if delta := after.indexAccess[name] - before.indexAccess[name]; delta > 0 {
+				res.fields = append(res.fields, statField{name: "index " + name + " accesses", value: delta})
+			}
+		}
+	}
+	return res
+}
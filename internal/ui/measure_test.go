@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMeasure(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+
+	run(t, m, `measure find '{"qty": 5}'`)
+	got := output(t, m)
+	for _, want := range []string{"item:apple", "keys examined       0\n", "documents examined  3\n", "documents returned  1\n", "queries             1\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("measure of a collection scan lacks %q:\n%s", want, got)
+		}
+	}
+
+	run(t, m, "index create '{\"qty\": 1}'")
+	run(t, m, `measure find '{"qty": 5}'`)
+	got = output(t, m)
+	for _, want := range []string{"keys examined         1\n", "documents examined    1\n", "index qty_1 accesses  1\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("measure of an indexed query lacks %q:\n%s", want, got)
+		}
+	}
+
+	run(t, m, `measure insert '{"item": "fig"}'`)
+	if got := output(t, m); !strings.Contains(got, "documents inserted  1\n") {
+		t.Errorf("measure of an insert:\n%s", got)
+	}
+}
@@ -0,0 +1,1193 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	"github.com/nick-popovic/mon-go/internal/config"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+const defaultConnectionString = "mongodb://localhost:27017"
+const defaultListLimit = 5
+const commandTimeout = 5 * time.Second
+const elapsedResolution = 100 * time.Millisecond
+
+type model struct {
+	client            *mongo.Client
+	appName           string      // Identifies this session's operations on the server
+	store             store.Store // Used by the navigation and query commands, so they can be tested
+	names             *store.NamespaceCache
+	currentPath       []string   // ["database", "collection", "document_id"]
+	previousPath      []string   // Path before the last cd, for cd -; nil before the first
+	pathStack         [][]string // Paths saved by pushd, most recent first
+	textInput         textinput.Model
+	spinner           spinner.Model // Animated while an operation runs
+	result            result        // Last successful result, nil if there is nothing to show
+	err               error
+	showAllResults    bool
+	prompt            *prompt    // Pending question that has taken over the input line
+	running           *operation // Command currently talking to the server, nil when idle
+	lastOpID          int
+	lastInput         string
+	consistency       consistency
+	readOnly          bool            // Writes are refused at command dispatch
+	readOnlyForced    string          // What made the whole session read-only, e.g. --read-only; set readonly off is refused while set
+	env               string          // Environment of the profile connected with: dev, staging, prod or ""
+	profile           string          // Name of the profile connected with, "" for a connection string
+	rememberLastPath  bool            // The profile goes back to its last path when connecting
+	promptTemplate    string          // Prompt with variables such as {path}, "" for the default
+	promptCount       *promptCountMsg // Estimated documents of the collection, for {count}
+	history           []string        // Commands typed, most recent first, suggested while typing
+	auditLog          string          // File commands are appended to, "" for none
+	recordPath        string          // File the session is recorded to, "" when not recording
+	replaying         *replay         // Recording being replayed, nil when not replaying
+	envConfirmed      bool            // The write being dispatched was confirmed for production
+	connectionString  string
+	productionSignals []string // Why the deployment was taken for production, if it was
+	masks             maskRules
+	namespaces        namespaceRules   // Namespaces shown and written to
+	unmask            bool             // The command being dispatched asked for --unmask
+	dryRun            bool             // The command being dispatched asked for --dry-run
+	writing           bool             // The command being dispatched writes
+	chart             string           // The command being dispatched asked for --chart bar or line
+	pipe              string           // Shell pipeline the command being dispatched is piped into
+	board             *watchboard      // Live change counters, nil unless watchboard is running
+	builder           *pipelineBuilder // Pipeline being built, nil unless pipeline is open
+	queryBuilder      *queryBuilder    // Filter being built, nil unless query is open
+	servedBy          *servedBy        // Secondary that served the shown result, if any
+	warnings          []string
+	governor          governorConfig
+	governorOn        bool
+	measuring         *measurement                // A measure waiting for its command to finish
+	tableView         bool                        // Show documents as a table
+	columns           map[string][]computedColumn // Computed columns by namespace
+	layouts           map[string][]string         // Table columns chosen with the columns command, by namespace
+	autorefresh       time.Duration               // Interval of re-running lastListing, 0 for never
+	autorefreshGen    int
+	lastListing       string        // Last command that auto-refresh may re-run
+	batchSize         int32         // Documents per cursor round trip, 0 for the server's default
+	results           *resultSet    // Paged result of the last find or document listing
+	serverVersion     string        // Version of the connected server, "" if unknown
+	timing            bool          // Show how long commands took below their result
+	elapsed           time.Duration // How long the shown result took, 0 if it did not come from the server
+	verbose           bool          // Show the server commands each command sends
+	sent              []string      // Server commands sent for the shown result, in verbose mode
+	atlas             config.Atlas  // API key of the atlas commands
+	slowOps           time.Duration // Alert on operations running this long, 0 for never
+	slowOpsGen        int
+	slowOpsFound      []activeOp      // Operations over slowOps at the last check, longest first
+	palette           *commandPalette // Open command palette, nil unless Ctrl+K was pressed
+	recentPaths       []string        // Namespaces visited with cd, most recent first
+	width, height     int             // Size of the terminal, 0 until it is known
+	truncate          bool            // Cut off output lines wider than the terminal instead of wrapping them
+	scrollX           int             // Columns of truncated output scrolled past
+	tableScroll       int             // Table columns scrolled past, after _id
+	tableSort         string          // Column the shown documents are sorted by, - prefix for descending
+	lastFind          []string        // Arguments of the find or ls shown, to run again sorted by the server
+}
+
+// operation is a command in flight. Its context is cancelled when the user
+// presses Esc, and its id lets late results of a cancelled operation be told
+// apart from the current one.
+type operation struct {
+	id      int
+	label   string
+	started time.Time
+	cancel  context.CancelFunc
+	unmask  bool   // Show masked fields in this operation's result
+	chart   string // Draw this operation's result as a chart of this kind
+	pipe    string // Shell pipeline to run on this operation's result
+	write   bool   // The operation writes, so the audit log records what it wrote
+	stack   string // Where the operation was started, for the debug log
+	detach  func() // Stops the operation where it can resume from, if it can
+}
+
+// opDoneMsg wraps the message produced by an operation.
+type opDoneMsg struct {
+	id  int
+	msg tea.Msg
+}
+
+// prompt is a question asked on the input line, e.g. for a password. The
+// answer is handed to onSubmit instead of being run as a command.
+type prompt struct {
+	label    string
+	onSubmit func(answer string) tea.Cmd
+}
+
+type mongoMsg struct {
+	result   result
+	err      error
+	servedBy *servedBy     // Secondary that served the read, if any
+	warnings []string      // Shown below the result
+	results  *resultSet    // Result set the result is a page of, if any
+	elapsed  time.Duration // How long the operation took
+	sent     []string      // Server commands the operation sent, in verbose mode
+	written  int64         // Documents a write inserted, updated or deleted, for the audit log
+	move     *pathMove     // Where the operation goes, made current with its result
+}
+
+func initialModel(connectionString string, cfg config.Config) model {
+	ti := textinput.New()
+	ti.Placeholder = "Enter command..."
+	ti.Focus()
+	ti.Width = 50
+	ti.ShowSuggestions = true // From the history, see addHistory
+	history := loadHistory()
+	ti.SetSuggestions(history)
+
+	// Connect to MongoDB.  Handle errors gracefully.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	slog.Info("connecting", "uri", redactURI(connectionString))
+	clientOpts := options.Client().ApplyURI(connectionString).SetMonitor(commandMonitor).SetServerMonitor(serverMonitor)
+	appName := sessionAppName(clientOpts)
+	client, err := mongo.Connect(ctx, clientOpts)
+	if err != nil {
+		// Instead of fatal, return an error state in the model.
+		slog.Error("connect failed", "error", err)
+		return model{textInput: ti, err: fmt.Errorf("failed to connect to MongoDB: %w", err)}
+	}
+
+	err = client.Ping(ctx, readpref.Primary())
+	if err != nil {
+		slog.Error("ping failed", "error", err)
+		return model{textInput: ti, err: fmt.Errorf("failed to ping MongoDB: %w", err)}
+	}
+	slog.Info("connected")
+
+	c, err := detectConsistency(ctx, client, clientOpts)
+	if err != nil {
+		return model{textInput: ti, err: fmt.Errorf("failed to inspect deployment: %w", err)}
+	}
+
+	var signals []string
+	if cfg.SafeMode == config.SafeModeAuto {
+		signals = productionSignals(ctx, client, connectionString, clientOpts, c.replicaSet)
+	}
+
+	st := store.Client{Client: client}
+	slowOps, _ := time.ParseDuration(cfg.SlowOps) // Checked by config.Load
+	return model{
+		client:            client,
+		connectionString:  connectionString,
+		store:             st,
+		names:             store.NewNamespaceCache(st),
+		columns:           map[string][]computedColumn{},
+		layouts:           loadLayouts(),
+		appName:           appName,
+		currentPath:       []string{},
+		textInput:         ti,
+		spinner:           spinner.New(spinner.WithSpinner(spinner.Dot)),
+		result:            nil,
+		err:               nil,
+		consistency:       c,
+		readOnly:          len(signals) > 0,
+		productionSignals: signals,
+		masks:             cfg.MaskFields,
+		namespaces:        namespaceRules(cfg.Namespaces),
+		governor:          governorConfig(cfg.Governor),
+		governorOn:        true,
+		batchSize:         cfg.BatchSize,
+		timing:            true,
+		serverVersion:     serverVersion(ctx, client),
+		atlas:             cfg.Atlas,
+		auditLog:          cfg.AuditLog,
+		slowOps:           slowOps,
+		truncate:          cfg.Lines == config.LinesTruncate,
+		promptTemplate:    cfg.Prompt,
+		history:           history,
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	if m.client == nil { // Not connected, or a test model on a Fake: nothing to poll in the background
+		return textinput.Blink
+	}
+	cmds := []tea.Cmd{textinput.Blink, m.recordGrowth(nil, false), scheduleTracking(), m.refreshPromptCount()}
+	if m.slowOps > 0 {
+		cmds = append(cmds, m.pollSlowOps())
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.resize(msg)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.palette != nil {
+			return m, m.paletteKey(msg)
+		}
+		if m.pipelineKey(msg) {
+			return m, nil
+		}
+		if tree, ok := m.result.(*documentTree); ok && m.treeKeys() && tree.key(msg) {
+			return m, nil
+		}
+		if m.acceptSuggestion(msg) || m.bracketKey(msg) {
+			return m, nil
+		}
+		if m.scrollKey(msg) {
+			return m, nil
+		}
+		if handled, cmd := m.sortKey(msg); handled {
+			return m, cmd
+		}
+		switch msg.Type {
+		case tea.KeyEnter:
+			if m.prompt != nil {
+				answer := m.textInput.Value()
+				onSubmit := m.prompt.onSubmit
+				m.endPrompt()
+				cmd := onSubmit(answer)
+				return m, tea.Batch(cmd, m.replayWhenIdle())
+			}
+			input := strings.TrimSpace(m.textInput.Value())
+			m.textInput.SetValue("") // Clear input after processing
+			m.addHistory(input)
+			next, cmd := m.processCommand(input)
+			if m.err != nil {
+				slog.Warn("command rejected", append([]any{"command", loggedCommand(input)}, errorAttrs(m.err)...)...)
+			}
+			return next, cmd
+
+		case tea.KeyEsc, tea.KeyCtrlC:
+			m.replaying = nil // Esc also stops a replay
+			if m.running != nil {
+				return m, m.cancelRunning()
+			}
+			if m.prompt != nil {
+				m.endPrompt() // Abandon the question, not the program
+				return m, nil
+			}
+			if m.board != nil {
+				m.stopWatchboard()
+				return m, nil
+			}
+			if m.builder != nil {
+				m.builder = nil
+				return m, nil
+			}
+			if m.queryBuilder != nil {
+				m.queryBuilder = nil
+				return m, nil
+			}
+			return m, m.quit()
+
+		case tea.KeyCtrlR:
+			if m.prompt == nil {
+				return m, m.refresh()
+			}
+
+		case tea.KeyCtrlD:
+			if m.prompt == nil && m.textInput.Value() == "" {
+				return m, m.quit()
+			}
+
+		case tea.KeyCtrlK:
+			m.openPalette()
+			return m, nil
+
+		case tea.KeyCtrlO:
+			if cmd := m.showSlowOps(); cmd != nil {
+				return m, cmd
+			}
+		}
+
+	case opDoneMsg:
+		if m.running == nil || m.running.id != msg.id {
+			return m, nil // Result of a cancelled operation
+		}
+		if mm, ok := msg.msg.(mongoMsg); ok {
+			mm.elapsed = time.Since(m.running.started)
+			msg.msg = mm
+			if mm.err != nil {
+				attrs := append([]any{"command", loggedCommand(m.running.label), "elapsed", mm.elapsed}, errorAttrs(mm.err)...)
+				if m.running.stack != "" && unexpectedError(mm.err) {
+					attrs = append(attrs, "stack", m.running.stack)
+				}
+				slog.Warn("command failed", attrs...)
+			} else {
+				slog.Info("command done", "command", loggedCommand(m.running.label), "elapsed", mm.elapsed)
+			}
+			if !m.running.unmask {
+				mm.result = maskResult(mm.result, m.masks)
+				msg.msg = mm
+			}
+			if m.running.chart != "" && mm.err == nil {
+				mm.result, mm.err = newChart(m.running.chart, mm.result)
+				msg.msg = mm
+			}
+			if pipe := m.running.pipe; pipe != "" && mm.err == nil && mm.result != nil {
+				m.audit(m.running.label, m.running.write, mm.written, nil)
+				m.recordCommand(m.running.label, m.running.write, mm.written, mm.result, nil)
+				m.running.cancel()
+				m.running = nil
+				var closeResults tea.Cmd
+				if rs := mm.results; rs != nil {
+					closeResults = func() tea.Msg { rs.close(); return nil } // Only the page is piped
+				}
+				return m, tea.Batch(closeResults, m.runPipeline(pipe, mm.result))
+			}
+		}
+		op := m.running
+		m.running.cancel()
+		m.running = nil
+		next, cmd := m.Update(msg.msg)
+		cmd = tea.Batch(cmd, m.refreshPromptCount()) // Commands may change the collection, or where we are
+		if mm, ok := msg.msg.(mongoMsg); ok {
+			m.audit(op.label, op.write, mm.written, mm.err)
+			m.recordCommand(op.label, op.write, mm.written, m.result, mm.err)
+		}
+		if after := m.afterOperation(msg.id); after != nil {
+			return m, tea.Batch(after, m.refreshPromptCount())
+		}
+		return next, cmd
+
+	case spinner.TickMsg:
+		if m.running == nil {
+			return m, nil // Let the animation stop while idle
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case statsTableMsg:
+		if m.running == nil || m.running.id != msg.id {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.running.cancel()
+			m.running = nil
+			return m.Update(mongoMsg{err: msg.err})
+		}
+		m.result, m.err, m.servedBy, m.warnings, m.elapsed, m.sent = msg.table, nil, nil, nil, 0, nil
+		return m, msg.table.next(msg.id)
+
+	case statsRowMsg:
+		if m.running == nil || m.running.id != msg.id {
+			return m, nil // Row of a cancelled listing
+		}
+		table, ok := m.result.(*statsTable)
+		if !ok {
+			return m, nil
+		}
+		if msg.done {
+			pipe := m.running.pipe
+			m.running.cancel()
+			m.running = nil
+			if pipe != "" {
+				return m, m.runPipeline(pipe, table)
+			}
+			return m, m.afterOperation(msg.id)
+		}
+		table.fill(msg.row)
+		return m, table.next(msg.id)
+
+	case confirmMsg:
+		return m.confirm(msg)
+
+	case measureStartMsg:
+		return m.startMeasured(msg)
+
+	case promptCountMsg:
+		m.promptCount = &msg
+		return m, nil
+
+	case replayStepMsg:
+		return m, m.replayStep(msg)
+
+	case trackTickMsg:
+		return m, tea.Batch(m.recordGrowth(nil, false), scheduleTracking())
+
+	case growthRecordedMsg:
+		if msg.err != nil {
+			logGrowthError(msg.err)
+		}
+		return m, nil
+
+	case slowOpsTickMsg:
+		if msg.gen != m.slowOpsGen || m.slowOps == 0 {
+			return m, nil
+		}
+		return m, m.pollSlowOps()
+
+	case slowOpsMsg:
+		return m, m.slowOpsPolled(msg)
+
+	case autorefreshMsg:
+		if msg.gen != m.autorefreshGen || m.autorefresh == 0 {
+			return m, nil // Tick of an interval that was changed since
+		}
+		return m.runAutorefresh()
+
+	case queryFieldsMsg:
+		if m.queryBuilder != nil {
+			m.queryBuilder.fields = msg.fields
+		}
+		return m, nil
+
+	case changeEventMsg:
+		if m.board == nil {
+			return m, nil // Event that was queued before the board was stopped
+		}
+		m.board.record(msg)
+		return m, m.board.next()
+
+	case watchboardTickMsg:
+		if m.board == nil {
+			return m, nil
+		}
+		return m, watchboardTick()
+
+	case editRequestMsg:
+		return m, m.edit(msg.content, msg.onDone)
+
+	case editDoneMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		return m, msg.onDone(msg.edited)
+
+	case mongoMsg:
+		m.result = msg.result
+		m.err = msg.err
+		m.servedBy = msg.servedBy
+		m.warnings = msg.warnings
+		m.elapsed = msg.elapsed
+		m.sent = msg.sent
+		m.scrollX = 0
+		if msg.move != nil {
+			m.moveTo(*msg.move)
+		}
+		return m, m.setResults(msg.results)
+
+	case error:
+		m.err = msg
+		return m, nil
+	}
+
+	m.textInput, cmd = m.textInput.Update(msg) // Always update the text input
+	return m, cmd
+}
+
+func (m model) View() string {
+	var b strings.Builder
+	b.WriteString(m.readOnlyBanner())
+	b.WriteString(m.slowOpsBanner())
+	b.WriteString(m.recordBanner())
+	if m.prompt != nil {
+		b.WriteString(m.prompt.label)
+	} else {
+		b.WriteString(m.envPrompt(m.promptText()))
+		if !strings.Contains(m.promptTemplate, "{session}") {
+			b.WriteString(m.consistency.indicator())
+		}
+	}
+	input := m.textInput
+	if m.promptTemplate != "" && m.prompt == nil {
+		input.Prompt = "" // The template ends how it likes
+	}
+	at, mistake := m.inputMistake()
+	if mistake != nil {
+		input.TextStyle = inputMistakeStyle
+	}
+	line := b.String()[strings.LastIndex(b.String(), "\n")+1:] + input.Prompt
+	if view, ok := bracketView(input); ok {
+		b.WriteString(view)
+	} else {
+		b.WriteString(input.View()) // this adds the > prompt at the end
+	}
+	if mistake != nil {
+		b.WriteString("\n")
+		b.WriteString(m.mistakeLine(line, at, mistake))
+	}
+	b.WriteString("\n\n")
+	head := b.Len()
+
+	if m.palette != nil {
+		b.WriteString(m.palette.String())
+		return fitScreen(b.String(), m.width, m.height)
+	}
+	if m.board != nil {
+		b.WriteString(m.board.String())
+		b.WriteString("\n")
+	}
+	if m.builder != nil {
+		b.WriteString(m.builder.String())
+		b.WriteString("\n")
+	}
+	if m.queryBuilder != nil {
+		b.WriteString(m.queryBuilder.String())
+		b.WriteString("\n")
+	}
+
+	if m.running == nil {
+		for _, line := range m.sent {
+			b.WriteString(sentStyle.Render("> " + line))
+			b.WriteString("\n")
+		}
+	}
+	if m.running != nil {
+		elapsed := time.Since(m.running.started).Truncate(elapsedResolution)
+		b.WriteString(fmt.Sprintf("%s running '%s' %s (press Esc to cancel)\n", m.spinner.View(), m.running.label, elapsed))
+	} else if m.err != nil {
+		b.WriteString(fmt.Sprintf("Error: %v\n", m.err))
+	} else if m.result != nil {
+		b.WriteString(m.resultView())
+		if m.timing && m.elapsed > 0 {
+			b.WriteString(timingStyle.Render(timingLine(m.result, m.elapsed)))
+			b.WriteString("\n")
+		}
+		if m.servedBy != nil {
+			b.WriteString("\n")
+			b.WriteString(m.servedBy.String())
+		}
+		for _, w := range m.warnings {
+			b.WriteString(fmt.Sprintf("warning: %s\n", w))
+		}
+	}
+	view := b.String()
+	if m.truncate {
+		view = view[:head] + truncateLines(view[head:], m.width, m.scrollX)
+	}
+	return fitScreen(view, m.width, m.height)
+}
+
+// resultView renders the shown result, as a table if table view is on and
+// the result is a list of documents.
+func (m model) resultView() string {
+	if docs, ok := m.result.(documentList); ok && m.tableView {
+		return documentTable(docs, m.tableOptions())
+	}
+	return m.result.String()
+}
+
+func (m *model) processCommand(input string) (tea.Model, tea.Cmd) {
+	var parts []string
+	if js, ok := splitMongosh(input); ok {
+		parts = []string{mongoshCommand, js}
+	} else if line, ok := splitShell(input); ok {
+		parts = []string{shellEscape, line}
+	} else {
+		if command, pipeline, ok := splitPipeline(input); ok {
+			return m.piped(command, pipeline)
+		}
+		var err error
+		if parts, err = commands.SplitArgs(input); err != nil {
+			m.err = err
+			return m, nil
+		}
+	}
+	if len(parts) == 0 {
+		return m, nil // No command entered
+	}
+
+	m.elapsed, m.sent = 0, nil
+	command := parts[0]
+	var args []string
+	var err error
+	args, m.unmask = commands.StripFlag(parts[1:], "--unmask")
+	args, m.dryRun = commands.StripFlag(args, "--dry-run")
+	m.writing = isMutating(command, args) && !m.dryRun
+	args, m.chart, err = commands.StripOption(args, "--chart")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	if m.chart != "" && !chartKinds[m.chart] {
+		m.err = fmt.Errorf("--chart takes bar or line")
+		return m, nil
+	}
+	m.lastInput = input
+	slog.Info("command", "command", loggedCommand(input), "path", strings.Join(m.currentPath, "/"))
+	opsBefore := m.lastOpID
+	defer func() {
+		if m.lastOpID == opsBefore && m.prompt == nil {
+			m.audit(input, m.writing, 0, m.err) // Done without an operation; those are recorded when they finish
+			m.recordCommand(input, m.writing, 0, m.result, m.err)
+		}
+	}()
+
+	if m.dryRun && !supportsDryRun(command, args) {
+		m.err = fmt.Errorf("%s: --dry-run is not supported", command)
+		return m, nil
+	}
+	if m.readOnly && isMutating(command, args) && !m.dryRun {
+		m.err = m.readOnlyError(command)
+		return m, nil
+	}
+	if isMutating(command, args) && !m.dryRun {
+		if err := m.checkWrite(command, args); err != nil {
+			m.err = err
+			return m, nil
+		}
+	}
+	if m.env == config.EnvProd && isMutating(command, args) && !m.dryRun && !m.envConfirmed {
+		return m.confirmProd(input)
+	}
+
+	if isRefreshable(command, args) {
+		m.lastListing = input
+	}
+
+	switch command {
+	case "exit", "quit":
+		return m, m.quit()
+	case "refresh":
+		return m, m.refresh()
+	case "user", "users":
+		return m.user(args)
+	case "role":
+		return m.role(args)
+	case "mkdir":
+		return m.mkdir(args)
+	case "set":
+		return m.set(args)
+	case "ttl":
+		return m.ttl(args)
+	case "index":
+		return m.index(args)
+	case "params":
+		return m.params(args)
+	case "connstr":
+		return m.connstr(args)
+	case "export":
+		return m.export(args)
+	case "whatsnew":
+		return m.whatsnew(args)
+	case mongoshCommand:
+		return m.mongosh(args)
+	case shellEscape:
+		return m.shell(args)
+	case "schema":
+		return m.schema(args)
+	case "watchboard":
+		return m.watchboard(args)
+	case "analyze":
+		return m.analyze(args)
+	case "find":
+		return m.find(args)
+	case "count":
+		return m.count(args)
+	case "sample":
+		return m.sample(args)
+	case "groupby":
+		return m.groupby(args)
+	case "fieldstats":
+		return m.fieldstats(args)
+	case "track":
+		return m.track(args)
+	case "growth":
+		return m.growth(args)
+	case "latency":
+		return m.latency(args)
+	case "currentop":
+		return m.currentop(args)
+	case "search":
+		return m.search(args)
+	case "vsearch":
+		return m.vsearch(args)
+	case "atlas":
+		return m.atlasCommand(args)
+	case "searchindex":
+		return m.searchindex(args)
+	case "pipeline":
+		return m.pipeline(args)
+	case "query":
+		return m.query(args)
+	case "diff":
+		return m.diff(args)
+	case "compare":
+		return m.compare(args)
+	case "seed":
+		return m.seed(args)
+	case "bulk":
+		return m.bulk(args)
+	case "findupdate":
+		return m.findupdate(args)
+	case "finddelete":
+		return m.finddelete(args)
+	case "view":
+		return m.view(args)
+	case "stats":
+		return m.stats(args)
+	case "collmod":
+		return m.collmod(args)
+	case "insert":
+		return m.insert(args)
+	case "update":
+		return m.update(args)
+	case "replace":
+		return m.replace(args)
+	case "deletemany":
+		return m.deletemany(args)
+	case "template":
+		return m.template(args)
+	case "column":
+		return m.column(args)
+	case "columns":
+		return m.columnLayout(args)
+	case "measure":
+		return m.measure(args)
+	case "synthesize":
+		return m.synthesize(args)
+	case "sortby":
+		return m.sortby(args)
+	case "filter":
+		return m.filter(args)
+	case "tab":
+		return m.tabs(args)
+	case "record":
+		return m.record(args)
+	case "replay":
+		return m.replayFile(args)
+	case "next":
+		return m.nextPage()
+	case "prev":
+		return m.prevPage()
+	case "cd":
+		if len(args) == 0 {
+			m.previousPath, m.currentPath = m.currentPath, []string{} // Go to root
+			m.result = message("in /")
+			return m, nil
+		}
+		if args[0] == "-" {
+			return m.cdBack()
+		}
+		return m, m.cd(args[0])
+	case "pwd":
+		return m.pwd(args)
+	case "pushd":
+		return m.pushd(args)
+	case "popd":
+		return m.popd(args)
+	case "dirs":
+		return m.dirs(args)
+	case "ls":
+		opts, err := parseLs(args, m.currentPath)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.lastFind, m.tableSort = []string{}, "" // A listing of documents is a find without a filter
+		if len(m.currentPath) == 2 && opts.sort != "" {
+			sortJSON, _ := bson.MarshalExtJSON(fieldSort(opts.sort), false, false)
+			m.lastFind = []string{"--sort", string(sortJSON)}
+		}
+		if opts.long && len(m.currentPath) == 0 {
+			return m, m.lsDatabases(opts)
+		}
+		if opts.long && len(m.currentPath) == 1 {
+			return m, m.lsCollections(m.currentPath[0], opts)
+		}
+		return m, m.ls(opts)
+	default:
+		m.err = fmt.Errorf("unknown command: %s", command)
+		return m, nil
+	}
+}
+
+// run starts fn as the running operation. fn receives a context that ends
+// when the command times out or the user cancels it.
+func (m *model) run(fn func(ctx context.Context) tea.Msg) tea.Cmd {
+	return m.runWithTimeout(commandTimeout, fn)
+}
+
+// runWithTimeout is run for commands that may take longer than
+// commandTimeout; a timeout of 0 means they only end when cancelled.
+func (m *model) runWithTimeout(timeout time.Duration, fn func(ctx context.Context) tea.Msg) tea.Cmd {
+	ctx, op := m.startOperation(timeout)
+	var trace *readTrace
+	if m.consistency.secondaryReads {
+		trace = &readTrace{}
+		ctx = context.WithValue(ctx, readTraceKey{}, trace)
+	}
+	var sent *sentCommands
+	if m.verbose {
+		sent = &sentCommands{}
+		ctx = context.WithValue(ctx, sentCommandsKey{}, sent)
+	}
+
+	return tea.Batch(func() tea.Msg {
+		msg := safely(ctx, fn)
+		if trace != nil {
+			msg = annotateServedBy(ctx, m.client, trace, msg)
+		}
+		if mm, ok := msg.(mongoMsg); ok && sent != nil {
+			mm.sent = sent.all()
+			msg = mm
+		}
+		return opDoneMsg{id: op.id, msg: msg}
+	}, m.tick())
+}
+
+// afterOperation returns what to do once operation id has finished and its
+// result is shown, if anything.
+func (m *model) afterOperation(id int) tea.Cmd {
+	if m.measuring != nil && m.measuring.opID == id {
+		return m.finishMeasure()
+	}
+	return m.replayWhenIdle()
+}
+
+// startOperation makes a new operation the running one, cancelling the
+// previous one, and returns the context its work must use. A timeout of 0
+// means none.
+func (m *model) startOperation(timeout time.Duration) (context.Context, *operation) {
+	if m.running != nil {
+		m.running.cancel() // Only one command talks to the server at a time
+	}
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	if m.consistency.session != nil {
+		ctx = mongo.NewSessionContext(ctx, m.consistency.session)
+	}
+	m.lastOpID++
+	op := &operation{id: m.lastOpID, label: m.lastInput, started: time.Now(), cancel: cancel, unmask: m.unmask, chart: m.chart, pipe: m.pipe, write: m.writing, stack: operationStack()}
+	m.running = op
+	return ctx, op
+}
+
+// tick starts the spinner, which also refreshes the running operation's
+// elapsed time. Ticks left over from an earlier operation are dropped by the
+// spinner, so starting it again is harmless.
+func (m *model) tick() tea.Cmd {
+	return m.spinner.Tick
+}
+
+// ask puts a question on the input line. When masked is set the answer is
+// not echoed, which is what password entry needs.
+func (m *model) ask(label string, masked bool, onSubmit func(answer string) tea.Cmd) {
+	m.prompt = &prompt{label: label, onSubmit: onSubmit}
+	m.err = nil
+	m.textInput.SetSuggestions(nil) // Answers are not commands
+	if masked {
+		m.textInput.EchoMode = textinput.EchoPassword
+	}
+	m.textInput.SetValue("")
+}
+
+// confirmMsg shows what a command is about to do and asks before doing it.
+type confirmMsg struct {
+	result   result
+	question string
+	onYes    func() tea.Cmd
+	declined string // Shown when the answer is no
+	expect   string // Answer required instead of y or yes, for dangerous operations
+}
+
+// confirm shows msg's result and runs onYes if the user agrees.
+func (m *model) confirm(msg confirmMsg) (tea.Model, tea.Cmd) {
+	m.result = msg.result
+	m.ask(msg.question, false, func(answer string) tea.Cmd {
+		answer = strings.TrimSpace(answer)
+		if msg.expect != "" {
+			if strings.ReplaceAll(answer, ",", "") == strings.ReplaceAll(msg.expect, ",", "") { // Counts typed with or without separators
+				return msg.onYes()
+			}
+			m.result = message(msg.declined)
+			return nil
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return msg.onYes()
+		default:
+			m.result = message(msg.declined)
+			return nil
+		}
+	})
+	return m, nil
+}
+
+// endPrompt returns the input line to normal command entry.
+func (m *model) endPrompt() {
+	m.prompt = nil
+	m.textInput.EchoMode = textinput.EchoNormal
+	m.textInput.SetValue("")
+	m.textInput.SetSuggestions(m.history)
+}
+
+// resolvePath applies a relative path to the current path.
+func (m *model) resolvePath(target string) []string {
+	newPath := make([]string, len(m.currentPath))
+	copy(newPath, m.currentPath)
+	if strings.HasPrefix(target, "/") {
+		newPath = newPath[:0] // Absolute paths start from the root
+	}
+
+	parts := strings.Split(target, "/") // Handle relative and absolute paths
+	for _, part := range parts {
+		if part == ".." {
+			if len(newPath) > 0 {
+				newPath = newPath[:len(newPath)-1] // Go up one level
+			}
+		} else if part != "." && part != "" { // Handle "." (current dir) and empty parts
+			newPath = append(newPath, part)
+		}
+	}
+	return newPath
+}
+
+func (m *model) cd(target string) tea.Cmd {
+	newPath := m.resolvePath(target)
+	return m.run(func(ctx context.Context) tea.Msg {
+		return m.changePath(ctx, newPath)
+	})
+}
+
+// changePath returns the move to newPath once it is known to exist. The
+// database and collection may be globs such as prod-* or logs.?, which must
+// match one name. The move is only made when the result is shown, so a
+// cancelled cd stays where it was.
+func (m *model) changePath(ctx context.Context, newPath []string) mongoMsg {
+	if len(newPath) == 1 && !isGlob(newPath[0]) && !m.namespaces.visible(newPath[0], "") {
+		return mongoMsg{err: fmt.Errorf("database '%s' is hidden by the namespaces config", newPath[0])}
+	}
+	if len(newPath) > 1 && !isGlob(newPath[0]) && !isGlob(newPath[1]) && !m.namespaces.visible(newPath[0], newPath[1]) {
+		return mongoMsg{err: fmt.Errorf("collection '%s.%s' is hidden by the namespaces config", newPath[0], newPath[1])}
+	}
+
+	if len(newPath) > 0 {
+		// Check if database exists
+		dbNames, err := m.names.Databases(ctx)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		name, err := matchName(newPath[0], m.namespaces.databases(dbNames))
+		if err != nil {
+			return mongoMsg{err: fmt.Errorf("database '%s' %w", newPath[0], err)}
+		}
+		newPath[0] = name
+	}
+	if len(newPath) > 1 {
+		// Check if collection exists
+		colls, err := m.names.Collections(ctx, newPath[0])
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		colls = m.namespaces.collections(newPath[0], colls)
+		names := make([]string, len(colls))
+		for i, coll := range colls {
+			names[i] = coll.Name
+		}
+		name, err := matchName(newPath[1], names)
+		if errors.Is(err, errNoSuchName) {
+			return mongoMsg{err: fmt.Errorf("collection '%s' does not exist in database '%s'", newPath[1], newPath[0])}
+		}
+		if err != nil {
+			return mongoMsg{err: fmt.Errorf("collection '%s' in database '%s' %w", newPath[1], newPath[0], err)}
+		}
+		newPath[1] = name
+	}
+	return mongoMsg{result: message("in " + pathString(newPath)), move: &pathMove{to: newPath}} // Said too for scripts and recordings
+}
+
+// refresh clears the namespace cache, so changes made outside mon-go show up.
+func (m *model) refresh() tea.Cmd {
+	m.names.Invalidate()
+	m.err = nil
+	m.result = message("namespace cache cleared")
+	return nil
+}
+
+func (m *model) ls(opts lsOptions) tea.Cmd {
+	return m.run(func(ctx context.Context) tea.Msg {
+		limit := defaultListLimit
+		if opts.showAll {
+			limit = -1 // Indicate no limit
+		}
+
+		switch len(m.currentPath) {
+		case 0: // List databases
+			dbNames, err := m.names.Databases(ctx)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			names := matchingNames(opts.pattern, m.namespaces.databases(dbNames))
+			if opts.sort != "" {
+				if names, err = m.sortNames(ctx, "", names, opts.sort); err != nil {
+					return mongoMsg{err: err}
+				}
+			}
+			return mongoMsg{result: newNameList(names, limit)}
+
+		case 1: // List collections in the database
+			dbName := m.currentPath[0]
+			colls, err := m.names.Collections(ctx, dbName)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			colls = m.namespaces.collections(dbName, colls)
+			var collNames []string
+			kinds := map[string]string{}
+			for _, coll := range colls {
+				collNames = append(collNames, coll.Name)
+				if coll.Kind != "collection" {
+					kinds[coll.Name] = coll.Kind // Mark views and other special namespaces
+				}
+			}
+			collNames = matchingNames(opts.pattern, collNames)
+			if opts.sort != "" {
+				if collNames, err = m.sortNames(ctx, dbName, collNames, opts.sort); err != nil {
+					return mongoMsg{err: err}
+				}
+			}
+			list := newNameList(collNames, limit)
+			list.kinds = kinds
+			return mongoMsg{result: list}
+
+		case 2: // List documents in the collection
+			dbName := m.currentPath[0]
+			collName := m.currentPath[1]
+			findOptions := options.Find()
+			if m.batchSize > 0 {
+				findOptions.SetBatchSize(m.batchSize)
+			}
+			if opts.sort != "" {
+				findOptions.SetSort(fieldSort(opts.sort))
+			}
+			cur, err := m.store.Find(ctx, dbName, collName, bson.M{}, findOptions)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+
+			if limit == -1 {
+				defer cur.Close(ctx)
+				var docs documentList
+				if err := cur.All(ctx, &docs.docs); err != nil {
+					return mongoMsg{err: err}
+				}
+				return mongoMsg{result: docs}
+			}
+			results, docs, err := openResultSet(ctx, cur, limit)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			return mongoMsg{result: docs, results: results}
+
+		case 3: // Show a single document
+			dbName := m.currentPath[0]
+			collName := m.currentPath[1]
+			docID := m.currentPath[2]
+
+			objectID, err := primitive.ObjectIDFromHex(docID)
+			if err != nil {
+				return mongoMsg{err: fmt.Errorf("invalid document ID: %s", docID)}
+			}
+			doc, err := findDocument(ctx, m.store, dbName, collName, objectID)
+			if err != nil {
+				if err == errDocumentNotFound {
+					return mongoMsg{err: fmt.Errorf("document with ID '%s' not found", docID)}
+				}
+				return mongoMsg{err: err}
+			}
+			return mongoMsg{result: newDocumentTree(doc)}
+
+		default:
+			return mongoMsg{err: fmt.Errorf("invalid path depth")}
+		}
+	})
+}
+
+// Run parses the command line, runs the terminal UI until it is quit and
+// exits the process on failure.
+func Run() {
+	readOnly := flag.Bool("read-only", false, "refuse every command that writes, for the whole session")
+	debugLog := flag.String("debug", "", "write a debug log to this file: the name of every command, the server commands it sends with the values in filters left out, round-trip times and errors, with the stack of unexpected ones")
+	inline := flag.Bool("inline", false, "draw in the terminal instead of the alternate screen, so the last output stays in the scrollback after quitting")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: mon-go [--read-only] [--inline] [--debug <logfile>] [connection string | profile]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	connectionString := defaultConnectionString
+	if flag.NArg() > 0 {
+		connectionString = flag.Arg(0)
+	}
+	conn, err := resolveConnection(connectionString) // A name instead of a connection string is a saved profile
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	var forceReadOnly string
+	if *readOnly {
+		forceReadOnly = "--read-only"
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	if *debugLog != "" {
+		cfg.Log = config.Log{Level: "debug", File: *debugLog}
+	}
+	closeLog, err := setupLogging(cfg.Log)
+	if err != nil {
+		fmt.Printf("Failed to open log: %v\n", err)
+		os.Exit(1)
+	}
+	defer closeLog()
+	logEnvironment()
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("panic", "panic", r, "stack", string(debug.Stack()))
+			panic(r)
+		}
+	}()
+
+	w := newWorkspace(cfg, forceReadOnly)
+	if !cfg.RestoreWorkspace || flag.NArg() > 0 || !w.restore() {
+		w.add(w.newModel(conn))
+	}
+	w.tabs[w.active].m.showWhatsNew()
+	var opts []tea.ProgramOption
+	if !*inline {
+		opts = append(opts, tea.WithAltScreen())
+	}
+	p := tea.NewProgram(w, opts...)
+
+	_, err = p.Run()
+	if cfg.RestoreWorkspace {
+		w.save()
+	}
+	w.rememberPaths()
+	w.shutdown()
+	if err != nil {
+		slog.Error("program failed", "error", err)
+		fmt.Printf("Alas, there's been an error: %v", err)
+		closeLog()
+		os.Exit(1)
+	}
+}
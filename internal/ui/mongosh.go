@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// mongoshCommand is typed at the prompt to hand a snippet to mongosh.
+const mongoshCommand = "!mongosh"
+
+// splitMongosh returns the snippet of a `!mongosh <js>` input. The snippet
+// is taken as typed, without the quoting rules of other commands.
+func splitMongosh(input string) (string, bool) {
+	rest, ok := strings.CutPrefix(input, mongoshCommand)
+	if !ok || (rest != "" && rest[0] != ' ') {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// mongoshPrelude connects mongosh to the shell's deployment and current
+// namespace before the snippet runs: db is the current database and, in a
+// collection, coll the current collection. The connection string is read
+// from the environment so it does not show up in the process list.
+func mongoshPrelude(db, coll string) string {
+	var b strings.Builder
+	b.WriteString("db = connect(process.env.MON_GO_URI);\n")
+	if db != "" {
+		b.WriteString(fmt.Sprintf("db = db.getSiblingDB(%s);\n", jsString(db)))
+	}
+	if coll != "" {
+		b.WriteString(fmt.Sprintf("var coll = db.getCollection(%s);\n", jsString(coll)))
+	}
+	return b.String()
+}
+
+// mongosh runs a JavaScript snippet with an installed mongosh, connected like
+// the shell, and shows its output. It is the escape hatch for operations
+// the native commands do not cover.
+func (m *model) mongosh(args []string) (tea.Model, tea.Cmd) {
+	if len(args) != 1 || args[0] == "" {
+		m.err = fmt.Errorf("usage: !mongosh <javascript>, e.g. !mongosh coll.getShardDistribution()")
+		return m, nil
+	}
+	path, err := exec.LookPath("mongosh")
+	if err != nil {
+		m.err = fmt.Errorf("!mongosh: mongosh is not installed or not in PATH")
+		return m, nil
+	}
+	var db, coll string
+	if len(m.currentPath) > 0 {
+		db = m.currentPath[0]
+	}
+	if len(m.currentPath) > 1 {
+		coll = m.currentPath[1]
+	}
+	script := mongoshPrelude(db, coll) + args[0]
+	uri := m.connectionString
+
+	return m, m.runWithTimeout(0, func(ctx context.Context) tea.Msg { // Esc kills mongosh
+		cmd := exec.CommandContext(ctx, path, "--nodb", "--quiet", "--eval", script)
+		cmd.Env = append(os.Environ(), "MON_GO_URI="+uri)
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		err := cmd.Run()
+		text := strings.TrimRight(out.String(), "\n")
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return mongoMsg{err: fmt.Errorf("mongosh exited with status %d:\n%s", exit.ExitCode(), text)}
+		}
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		if text == "" {
+			text = "(no output)"
+		}
+		return mongoMsg{result: message(text + "\n")}
+	})
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeMongosh puts a mongosh on PATH that prints its connection string and
+// script, or fails when the script contains "fail".
+func fakeMongosh(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	script := `#!/bin/sh
+echo "uri=$MON_GO_URI"
+case "$4" in *fail*) echo "boom" >&2; exit 3;; esac
+printf '%s\n' "$4"
+`
+	if err := os.WriteFile(filepath.Join(dir, "mongosh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestMongoshPassthrough(t *testing.T) {
+	fakeMongosh(t)
+	m := newTestModel(seededFake())
+	m.connectionString = "mongodb://u:p@db1:27017/"
+	run(t, m, "cd shop/orders")
+
+	run(t, m, `!mongosh coll.find({item: "it's"}).count()`)
+	got := output(t, m)
+	for _, want := range []string{"uri=mongodb://u:p@db1:27017/", `db = db.getSiblingDB("shop");`, `var coll = db.getCollection("orders");`, `coll.find({item: "it's"}).count()`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %q:\n%s", want, got)
+		}
+	}
+
+	expectError(t, m, "!mongosh fail()", "exited with status 3:\nuri=mongodb://u:p@db1:27017/\nboom")
+	expectError(t, m, "!mongosh", "usage: !mongosh")
+
+	m.readOnly = true
+	expectError(t, m, "!mongosh db.x.drop()", "refused in read-only mode")
+}
@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	"github.com/nick-popovic/mon-go/internal/config"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+// namespaceRules restrict which databases and collections are shown and
+// written to, as configured under namespaces. The zero value allows
+// everything.
+type namespaceRules config.Namespaces
+
+// matchesNamespace reports whether a rule covers the collection coll of db,
+// or with coll empty the database itself. A rule naming a database covers
+// its collections too.
+func matchesNamespace(rule, db, coll string) bool {
+	ruleDB, ruleColl, hasColl := strings.Cut(rule, ".")
+	if ok, _ := path.Match(ruleDB, db); !ok {
+		return false
+	}
+	if !hasColl {
+		return true
+	}
+	if coll == "" {
+		return false
+	}
+	ok, _ := path.Match(ruleColl, coll)
+	return ok
+}
+
+func anyNamespace(rules []string, db, coll string) bool {
+	for _, rule := range rules {
+		if matchesNamespace(rule, db, coll) {
+			return true
+		}
+	}
+	return false
+}
+
+// visible reports whether the collection coll of db, or with coll empty the
+// database, is shown. A database is shown if show lets any of its
+// collections through.
+func (r namespaceRules) visible(db, coll string) bool {
+	if len(r.Show) > 0 {
+		shown := anyNamespace(r.Show, db, coll)
+		if coll == "" {
+			for _, rule := range r.Show {
+				ruleDB, _, _ := strings.Cut(rule, ".")
+				if ok, _ := path.Match(ruleDB, db); ok {
+					shown = true
+				}
+			}
+		}
+		if !shown {
+			return false
+		}
+	}
+	return !anyNamespace(r.Hide, db, coll)
+}
+
+// writable reports whether the collection coll of db, or with coll empty the
+// database, may be written to.
+func (r namespaceRules) writable(db, coll string) bool {
+	if len(r.Writable) > 0 && !anyNamespace(r.Writable, db, coll) {
+		return false
+	}
+	return !anyNamespace(r.ReadOnly, db, coll)
+}
+
+// databases returns the names among names that are shown.
+func (r namespaceRules) databases(names []string) []string {
+	var shown []string
+	for _, name := range names {
+		if r.visible(name, "") {
+			shown = append(shown, name)
+		}
+	}
+	return shown
+}
+
+// collections returns the collections of db among colls that are shown.
+func (r namespaceRules) collections(db string, colls []store.Namespace) []store.Namespace {
+	var shown []store.Namespace
+	for _, coll := range colls {
+		if r.visible(db, coll.Name) {
+			shown = append(shown, coll)
+		}
+	}
+	return shown
+}
+
+// checkWrite refuses a command that writes to a namespace the rules keep
+// from being written to.
+func (m *model) checkWrite(command string, args []string) error {
+	var db, coll string
+	path := m.writeTarget(command, args)
+	if len(path) > 0 {
+		db = path[0]
+	}
+	if len(path) > 1 {
+		coll = path[1]
+	}
+	if db == "" || m.namespaces.writable(db, coll) {
+		return nil
+	}
+	ns := strings.Trim(db+"."+coll, ".")
+	return fmt.Errorf("%s: refused, writes to %s are not allowed by the namespaces config", command, ns)
+}
+
+// writeTarget returns the path command writes to: the current one, or for
+// commands that name the collection they create or fill, that collection,
+// resolved like a cd path once the flags are set apart from it.
+func (m *model) writeTarget(command string, args []string) []string {
+	var fs *flag.FlagSet
+	switch command {
+	case "mkdir":
+		fs, _ = mkdirFlagSet()
+	case "synthesize":
+		fs, _, _, _, _ = synthesizeFlagSet()
+	case "view":
+		if len(args) > 1 && len(m.currentPath) > 0 {
+			return []string{m.currentPath[0], args[1]} // view create <name> makes it in the current database
+		}
+		return m.currentPath
+	default:
+		return m.currentPath
+	}
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) != 1 {
+		return m.currentPath // The command itself reports its usage
+	}
+	return m.resolvePath(positional[0])
+}
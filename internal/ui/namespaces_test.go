@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+func TestNamespaceRules(t *testing.T) {
+	r := namespaceRules{Show: []string{"shop.*", "billing"}, Hide: []string{"shop.secret*"}, ReadOnly: []string{"billing.*"}}
+	tests := []struct {
+		db, coll          string
+		visible, writable bool
+	}{
+		{"shop", "", true, true},
+		{"shop", "orders", true, true},
+		{"shop", "secrets", false, true},
+		{"billing", "invoices", true, false},
+		{"billing", "", true, true},
+		{"admin", "", false, true},
+	}
+	for _, tt := range tests {
+		if got := r.visible(tt.db, tt.coll); got != tt.visible {
+			t.Errorf("visible(%s, %s) = %v", tt.db, tt.coll, got)
+		}
+		if got := r.writable(tt.db, tt.coll); got != tt.writable {
+			t.Errorf("writable(%s, %s) = %v", tt.db, tt.coll, got)
+		}
+	}
+	if r := (namespaceRules{Writable: []string{"scratch"}}); r.writable("shop", "orders") || !r.writable("scratch", "tmp") {
+		t.Error("writable allowlist not applied")
+	}
+}
+
+func TestNamespacesEnforced(t *testing.T) {
+	m := newTestModel(seededFake())
+	m.namespaces = namespaceRules{Hide: []string{"admin", "shop.customers"}, ReadOnly: []string{"shop.orders"}}
+
+	run(t, m, "ls")
+	if got := output(t, m); strings.Contains(got, "admin") || !strings.Contains(got, "shop") {
+		t.Fatalf("ls at the root: %q", got)
+	}
+	expectError(t, m, "cd admin", "hidden by the namespaces config")
+	run(t, m, "cd shop")
+	run(t, m, "ls")
+	if got := output(t, m); strings.Contains(got, "customers") {
+		t.Fatalf("hidden collection listed: %q", got)
+	}
+	expectError(t, m, "cd customers", "hidden by the namespaces config")
+
+	run(t, m, "cd orders")
+	expectError(t, m, `insert '{"a": 1}'`, "writes to shop.orders are not allowed")
+	run(t, m, "count") // Reads still work
+	if m.err != nil {
+		t.Fatal(m.err)
+	}
+}
+
+func TestCheckWrite(t *testing.T) {
+	m := newTestModel(seededFake())
+	m.namespaces = namespaceRules{ReadOnly: []string{"billing.*"}}
+	tests := []struct {
+		path    []string
+		input   string
+		refused bool
+	}{
+		{nil, "mkdir billing/invoices", true},
+		{[]string{"shop"}, "mkdir /billing/invoices", true},
+		{[]string{"shop"}, "mkdir ../billing/invoices", true},
+		{[]string{"billing"}, "mkdir --capped --size 1MB invoices", true},
+		{[]string{"billing"}, "mkdir invoices --capped --size 1MB", true},
+		{[]string{"billing"}, "mkdir /shop/logs", false},
+		{[]string{"shop"}, "mkdir logs", false},
+		{[]string{"billing"}, "view create totals invoices '[]'", true},
+		{[]string{"shop"}, "view create totals orders '[]'", false},
+		{[]string{"shop"}, "synthesize /billing/invoices --like orders --count 5", true},
+		{[]string{"billing"}, "synthesize --like /shop/orders --count 5 /shop/fake", false},
+		{[]string{"billing", "invoices"}, `insert '{"a": 1}'`, true},
+		{[]string{"shop", "orders"}, `insert '{"a": 1}'`, false},
+	}
+	for _, tt := range tests {
+		m.currentPath = tt.path
+		args, err := commands.SplitArgs(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.checkWrite(args[0], args[1:]); (err != nil) != tt.refused {
+			t.Errorf("%s in /%s: checkWrite = %v", tt.input, strings.Join(tt.path, "/"), err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+// resultSet is an open cursor shown a page at a time. Documents are kept
+// once fetched, so going back a page is served from memory and only pages
+// past the furthest one seen query the server.
+type resultSet struct {
+	mu        sync.Mutex // An abandoned operation may still be fetching when the set is closed
+	cursor    store.Cursor
+	docs      []bson.M // Every document fetched so far, unmasked
+	pageSize  int
+	current   int // Index of the page shown
+	exhausted bool
+}
+
+// openResultSet fetches the first page of cur.
+func openResultSet(ctx context.Context, cur store.Cursor, pageSize int) (*resultSet, documentList, error) {
+	rs := &resultSet{cursor: cur, pageSize: pageSize}
+	docs, err := rs.page(ctx, 0)
+	if err != nil {
+		rs.close()
+		return nil, documentList{}, err
+	}
+	return rs, docs, nil
+}
+
+// page returns page n, fetching from the cursor only what is not cached yet.
+// One document past the page is fetched to tell whether there are more.
+func (rs *resultSet) page(ctx context.Context, n int) (documentList, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	want := (n+1)*rs.pageSize + 1
+	for len(rs.docs) < want && !rs.exhausted {
+		if !rs.cursor.Next(ctx) {
+			if err := rs.cursor.Err(); err != nil {
+				return documentList{}, err
+			}
+			rs.exhausted = true
+			break
+		}
+		var doc bson.M
+		if err := rs.cursor.Decode(&doc); err != nil {
+			return documentList{}, err
+		}
+		rs.docs = append(rs.docs, doc)
+	}
+
+	start := n * rs.pageSize
+	if start >= len(rs.docs) && n > 0 {
+		return documentList{}, fmt.Errorf("next: no more documents")
+	}
+	end := min(start+rs.pageSize, len(rs.docs))
+	rs.current = n
+	return documentList{docs: rs.docs[start:end], page: rs.footer(start, end)}, nil
+}
+
+// footer tells which documents a page holds and how to move on.
+func (rs *resultSet) footer(start, end int) string {
+	more := len(rs.docs) > end || !rs.exhausted
+	switch {
+	case rs.current == 0 && !more:
+		return "" // Everything fits on the first page
+	case rs.current == 0:
+		return fmt.Sprintf("page 1, documents 1–%d (`next` for more)", end)
+	case more:
+		return fmt.Sprintf("page %d, documents %d–%d (`next` for more, `prev` to go back)", rs.current+1, start+1, end)
+	default:
+		return fmt.Sprintf("page %d, documents %d–%d of %d (`prev` to go back)", rs.current+1, start+1, end, len(rs.docs))
+	}
+}
+
+// complete reports whether every document of the set fits on one page, so
+// that page is the whole result.
+func (rs *resultSet) complete() bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.exhausted && len(rs.docs) <= rs.pageSize
+}
+
+// close releases the cursor on the server. The fetched documents stay
+// available for going back.
+func (rs *resultSet) close() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.cursor == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	if err := rs.cursor.Close(ctx); err != nil {
+		slog.Warn("closing cursor failed", "error", err)
+	}
+	rs.cursor = nil
+	rs.exhausted = true
+}
+
+// setResults makes rs the paged result set, closing the previous one in the
+// background.
+func (m *model) setResults(rs *resultSet) tea.Cmd {
+	old := m.results
+	if old == rs {
+		return nil
+	}
+	m.results = rs
+	if old == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		old.close()
+		return nil
+	}
+}
+
+// nextPage shows the page after the current one, fetching it if needed.
+func (m *model) nextPage() (tea.Model, tea.Cmd) {
+	rs := m.results
+	if rs == nil {
+		m.err = fmt.Errorf("next: no paged result, run find or ls in a collection first")
+		return m, nil
+	}
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		docs, err := rs.page(ctx, rs.current+1)
+		if err != nil {
+			return mongoMsg{err: err, results: rs}
+		}
+		return mongoMsg{result: docs, results: rs}
+	})
+}
+
+// prevPage shows the page before the current one. It is always cached, so
+// the server is not asked again.
+func (m *model) prevPage() (tea.Model, tea.Cmd) {
+	rs := m.results
+	if rs == nil {
+		m.err = fmt.Errorf("prev: no paged result, run find or ls in a collection first")
+		return m, nil
+	}
+	if rs.current == 0 {
+		m.err = fmt.Errorf("prev: already on the first page")
+		return m, nil
+	}
+	docs, err := rs.page(context.Background(), rs.current-1)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.result = docs
+	if !m.unmask {
+		m.result = maskResult(docs, m.masks)
+	}
+	m.err = nil
+	m.servedBy = nil
+	m.warnings = nil
+	m.elapsed, m.sent = 0, nil // Served from memory
+	return m, nil
+}
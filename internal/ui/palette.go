@@ -0,0 +1,247 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	paletteRows  = 12 // Entries shown at once
+	recentTarget = 10 // Namespaces remembered for the palette
+)
+
+var (
+	paletteSelectedStyle = lipgloss.NewStyle().Reverse(true)
+	paletteSummaryStyle  = lipgloss.NewStyle().Faint(true)
+)
+
+// paletteEntry is a choice of the command palette. Choosing a complete
+// entry runs it; any other is put on the input line for its arguments to
+// be filled in.
+type paletteEntry struct {
+	input    string
+	summary  string
+	complete bool
+}
+
+// paletteCommands lists every command, in the order of the README.
+var paletteCommands = []paletteEntry{
+	{"cd ", "go to a database, collection or document", false},
+	{"cd -", "go back to the previous path", true},
+	{"pwd", "show the current path and connection", true},
+	{"pushd ", "go to a path, saving the current one", false},
+	{"popd", "go back to the last path saved with pushd", true},
+	{"dirs", "list the paths saved with pushd", true},
+	{"ls", "list databases, collections or documents", true},
+	{"ls -l", "list with counts and sizes", true},
+	{"ls -l --sort size", "list the biggest databases or collections first", true},
+	{"user ls", "list users of the current database", true},
+	{"user create ", "create a user", false},
+	{"role ls", "list custom roles", true},
+	{"role show ", "show the privileges of a role", false},
+	{"mkdir ", "create a collection", false},
+	{"stats", "count, sizes and indexes of the collection", true},
+	{"latency", "operation latency histograms", true},
+	{"currentop", "operations in progress on the server", true},
+	{"view show", "show the definition of views", true},
+	{"view create ", "create a view", false},
+	{"query", "build a filter", true},
+	{"pipeline", "build an aggregation pipeline", true},
+	{"find '{}'", "find documents matching a filter", false},
+	{"column ls", "list computed columns", true},
+	{"column add ", "add a computed column", false},
+	{"columns ", "choose the columns of the table", false},
+	{"sortby ", "sort the shown documents", false},
+	{"filter ", "narrow down the shown documents without a query", false},
+	{"diff ", "compare two documents", false},
+	{"compare ", "compare two collections", false},
+	{"count", "count documents", true},
+	{"sample", "show random documents", true},
+	{"groupby ", "distribution of a field's values", false},
+	{"fieldstats ", "statistics of a numeric field", false},
+	{"track", "list tracked collections", true},
+	{"track add", "record the size of the collection over time", true},
+	{"growth", "chart the recorded size of the collection", true},
+	{"search ", "full-text search", false},
+	{"vsearch ", "vector search", false},
+	{"insert '{}'", "insert a document", false},
+	{"update '{}' '{\"$set\": {}}'", "update documents", false},
+	{"replace '{}' '{}'", "replace a document", false},
+	{"deletemany '{}'", "delete matching documents", false},
+	{"bulk ", "run the write operations of a file", false},
+	{"findupdate '{}' '{\"$set\": {}}'", "atomically update a document and show it", false},
+	{"finddelete '{}'", "atomically delete a document and show it", false},
+	{"template show", "show the document template", true},
+	{"template set '{}'", "set the document template", false},
+	{"seed ", "insert generated documents", false},
+	{"synthesize --like ", "insert documents similar to another collection's", false},
+	{"ttl ls", "list TTL indexes", true},
+	{"ttl set ", "expire documents after a date field", false},
+	{"index create '{}'", "create an index", false},
+	{"searchindex ls", "list Atlas Search indexes", true},
+	{"schema show", "show the validator", true},
+	{"schema set", "edit the validator", true},
+	{"collmod ", "change options of the collection", false},
+	{"analyze", "field types and frequencies of a sample", true},
+	{"params", "server parameters", true},
+	{"connstr", "build a connection string", true},
+	{"atlas clusters", "list Atlas clusters", true},
+	{"export ", "write documents to a file", false},
+	{"whatsnew", "what changed in this version", true},
+	{"!mongosh ", "run JavaScript with mongosh", false},
+	{"!", "run a shell command", false},
+	{"tab", "list open tabs", true},
+	{"tab new ", "open a tab connected to another deployment", false},
+	{"tab close", "close the current tab", true},
+	{"record ", "record the session to a file", false},
+	{"record stop", "stop recording", true},
+	{"replay ", "run the commands of a recording", false},
+	{"measure ", "server activity caused by a command", false},
+	{"watchboard", "live change counters", true},
+	{"next", "next page", true},
+	{"prev", "previous page", true},
+	{"refresh", "clear the namespace cache", true},
+	{"set", "show session settings", true},
+	{"exit", "quit", true},
+}
+
+// commandPalette is the searchable list of commands and recent targets
+// opened with Ctrl+K.
+type commandPalette struct {
+	entries  []paletteEntry
+	query    string
+	matches  []int // Indexes into entries of those matching the query
+	selected int   // Index into matches
+}
+
+func newCommandPalette(recent []string) *commandPalette {
+	p := &commandPalette{}
+	for _, path := range recent {
+		p.entries = append(p.entries, paletteEntry{input: "cd " + path, summary: "recent", complete: true})
+	}
+	p.entries = append(p.entries, paletteCommands...)
+	p.filter()
+	return p
+}
+
+// filter keeps the entries containing every word of the query, those that
+// start with it first.
+func (p *commandPalette) filter() {
+	words := strings.Fields(strings.ToLower(p.query))
+	prefix := strings.ToLower(strings.TrimSpace(p.query))
+	var first, rest []int
+	for i, e := range p.entries {
+		text := strings.ToLower(e.input + " " + e.summary)
+		matches := true
+		for _, w := range words {
+			if !strings.Contains(text, w) {
+				matches = false
+				break
+			}
+		}
+		switch {
+		case !matches:
+		case prefix != "" && strings.HasPrefix(strings.ToLower(e.input), prefix):
+			first = append(first, i)
+		default:
+			rest = append(rest, i)
+		}
+	}
+	p.matches = append(first, rest...)
+	p.selected = 0
+}
+
+func (p *commandPalette) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Command palette: %s▏\n", p.query))
+	if len(p.matches) == 0 {
+		b.WriteString("  no matching command\n")
+	}
+	start := 0
+	if p.selected >= paletteRows {
+		start = p.selected - paletteRows + 1
+	}
+	for i := start; i < len(p.matches) && i < start+paletteRows; i++ {
+		e := p.entries[p.matches[i]]
+		line := fmt.Sprintf("  %-32s %s", e.input, paletteSummaryStyle.Render(e.summary))
+		if i == p.selected {
+			line = paletteSelectedStyle.Render(fmt.Sprintf("> %-32s %s", e.input, e.summary))
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if len(p.matches) > paletteRows {
+		b.WriteString(fmt.Sprintf("  %d of %d\n", p.selected+1, len(p.matches)))
+	}
+	b.WriteString("\n↑/↓ select · Enter run · Tab edit first · Esc close\n")
+	return b.String()
+}
+
+// openPalette shows the command palette unless a command or question has
+// the input line.
+func (m *model) openPalette() {
+	if m.running != nil || m.prompt != nil {
+		return
+	}
+	m.palette = newCommandPalette(m.recentPaths)
+}
+
+// paletteKey handles a key while the palette is open.
+func (m *model) paletteKey(msg tea.KeyMsg) tea.Cmd {
+	p := m.palette
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC, tea.KeyCtrlK:
+		m.palette = nil
+	case tea.KeyUp:
+		if p.selected > 0 {
+			p.selected--
+		}
+	case tea.KeyDown:
+		if p.selected < len(p.matches)-1 {
+			p.selected++
+		}
+	case tea.KeyBackspace:
+		if r := []rune(p.query); len(r) > 0 {
+			p.query = string(r[:len(r)-1])
+			p.filter()
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		p.query += string(msg.Runes)
+		if msg.Type == tea.KeySpace {
+			p.query += " "
+		}
+		p.filter()
+	case tea.KeyEnter, tea.KeyTab:
+		if len(p.matches) == 0 {
+			return nil
+		}
+		e := p.entries[p.matches[p.selected]]
+		m.palette = nil
+		if e.complete && msg.Type == tea.KeyEnter {
+			_, cmd := m.processCommand(e.input)
+			return cmd
+		}
+		m.textInput.SetValue(e.input)
+		m.textInput.CursorEnd()
+	}
+	return nil
+}
+
+// rememberPath records a namespace visited with cd for the palette, most
+// recent first.
+func (m *model) rememberPath(path []string) {
+	if len(path) == 0 {
+		return
+	}
+	target := "/" + strings.Join(path, "/")
+	recent := []string{target}
+	for _, p := range m.recentPaths {
+		if p != target && len(recent) < recentTarget {
+			recent = append(recent, p)
+		}
+	}
+	m.recentPaths = recent
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func typeKeys(m *model, s string) {
+	for _, r := range s {
+		if r == ' ' {
+			m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+			continue
+		}
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func TestPaletteFilter(t *testing.T) {
+	p := newCommandPalette(nil)
+	if len(p.matches) != len(paletteCommands) {
+		t.Fatalf("empty query matches %d of %d commands", len(p.matches), len(paletteCommands))
+	}
+	p.query = "se"
+	p.filter()
+	if got := p.entries[p.matches[0]].input; !strings.HasPrefix(got, "se") {
+		t.Fatalf("first match of %q is %q, want one starting with it", p.query, got)
+	}
+	p.query = "random doc"
+	p.filter()
+	if len(p.matches) != 1 || p.entries[p.matches[0]].input != "sample" {
+		t.Fatalf("matches of %q: %v", p.query, p.matches)
+	}
+	p.query = "nothing like this"
+	p.filter()
+	if len(p.matches) != 0 || !strings.Contains(p.String(), "no matching command") {
+		t.Fatalf("matches of %q: %v", p.query, p.matches)
+	}
+}
+
+func TestPaletteRunsAndPrefills(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+	run(t, m, "cd /shop")
+	run(t, m, "cd orders")
+	if strings.Join(m.recentPaths, " ") != "/shop/orders /shop" {
+		t.Fatalf("recent paths = %v", m.recentPaths)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+	if m.palette == nil || !strings.Contains(m.View(), "cd /shop/orders") {
+		t.Fatalf("Ctrl+K did not open the palette with recent targets:\n%s", m.View())
+	}
+	typeKeys(m, "count")
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	drain(m, cmd)
+	if m.palette != nil || output(t, m) != "3 documents\n" {
+		t.Fatalf("choosing count: palette %v, output %q", m.palette, output(t, m))
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+	typeKeys(m, "insert")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.palette != nil || m.textInput.Value() != "insert '{}'" {
+		t.Fatalf("choosing insert: palette %v, input %q", m.palette, m.textInput.Value())
+	}
+
+	m.textInput.SetValue("")
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+	typeKeys(m, "stats")
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if m.textInput.Value() != "stats" {
+		t.Fatalf("Tab on stats: input %q", m.textInput.Value())
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.palette != nil {
+		t.Fatal("Esc did not close the palette")
+	}
+}
@@ -0,0 +1,278 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+// parameterDefaults are the defaults of commonly tuned server parameters, so
+// `params` can point out the ones that were changed. Parameters not listed
+// here are shown without a verdict.
+var parameterDefaults = map[string]interface{}{
+	"allowDiskUseByDefault":                        true,
+	"cursorTimeoutMillis":                          600000,
+	"diagnosticDataCollectionEnabled":              true,
+	"enableLocalhostAuthBypass":                    true,
+	"internalDocumentSourceGroupMaxMemoryBytes":    104857600,
+	"internalQueryMaxBlockingSortMemoryUsageBytes": 104857600,
+	"internalQueryPlannerMaxIndexedSolutions":      64,
+	"logLevel":                               0,
+	"maxIndexBuildMemoryUsageMegabytes":      200,
+	"maxNumActiveUserIndexBuilds":            3,
+	"maxTransactionLockRequestTimeoutMillis": 5,
+	"notablescan":                            false,
+	"periodicNoopIntervalSecs":               10,
+	"quiet":                                  false,
+	"replWriterThreadCount":                  16,
+	"transactionLifetimeLimitSeconds":        60,
+	"ttlMonitorEnabled":                      true,
+	"ttlMonitorSleepSecs":                    60,
+}
+
+var changedParamStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Bold(true)
+
+// serverParam is a server or cluster parameter and what is known about it.
+type serverParam struct {
+	name      string
+	value     interface{}
+	cluster   bool // Set with setClusterParameter, stored in the cluster
+	startOnly bool // Only settable at startup
+}
+
+// changed reports whether the parameter is known to differ from its default,
+// and the default.
+func (p serverParam) changed() (bool, interface{}) {
+	def, ok := parameterDefaults[p.name]
+	if !ok || p.cluster {
+		return false, nil
+	}
+	if a, okA := asFloat(p.value); okA {
+		if b, okB := asFloat(def); okB {
+			return a != b, def
+		}
+	}
+	return fmt.Sprint(p.value) != fmt.Sprint(def), def
+}
+
+// paramList is the result of `params`.
+type paramList struct {
+	params []serverParam
+}
+
+func (l paramList) String() string {
+	if len(l.params) == 0 {
+		return "no matching parameters\n"
+	}
+	width := 0
+	for _, p := range l.params {
+		width = max(width, len(p.name))
+	}
+	var b strings.Builder
+	for _, p := range l.params {
+		value := formatParamValue(p.value)
+		var notes []string
+		if changed, def := p.changed(); changed {
+			value = changedParamStyle.Render(value)
+			notes = append(notes, fmt.Sprintf("default %s", formatParamValue(def)))
+		}
+		if p.cluster {
+			notes = append(notes, "cluster")
+		}
+		if p.startOnly {
+			notes = append(notes, "startup only")
+		}
+		b.WriteString(fmt.Sprintf("%-*s  %s", width, p.name, value))
+		if len(notes) > 0 {
+			b.WriteString(fmt.Sprintf("  (%s)", strings.Join(notes, ", ")))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func formatParamValue(v interface{}) string {
+	switch v := v.(type) {
+	case bson.M, bson.D, bson.A:
+		data, err := bson.MarshalExtJSON(bson.M{"v": v}, false, false)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return strings.TrimSuffix(strings.TrimPrefix(string(data), `{"v":`), "}")
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (m *model) params(args []string) (tea.Model, tea.Cmd) {
+	if len(args) > 0 && args[0] == "set" {
+		return m.setParam(args[1:])
+	}
+
+	fs := commands.NewFlagSet("params")
+	changedOnly := fs.Bool("changed", false, "only list parameters known to differ from their default")
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) > 1 {
+		m.err = fmt.Errorf("usage: params [<name filter>] [--changed] | params set [--cluster] <name> <value>")
+		return m, nil
+	}
+	filter := ""
+	if len(positional) == 1 {
+		filter = strings.ToLower(positional[0])
+	}
+
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		params, err := listParams(ctx, m.store)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		var list paramList
+		for _, p := range params {
+			if filter != "" && !strings.Contains(strings.ToLower(p.name), filter) {
+				continue
+			}
+			if changed, _ := p.changed(); *changedOnly && !changed {
+				continue
+			}
+			list.params = append(list.params, p)
+		}
+		return mongoMsg{result: list}
+	})
+}
+
+// listParams returns the server parameters, and the cluster parameters on
+// deployments that have them, sorted by name.
+func listParams(ctx context.Context, st store.Store) ([]serverParam, error) {
+	var reply bson.M
+	detailed := bson.D{{Key: "getParameter", Value: bson.D{{Key: "allParameters", Value: true}, {Key: "showDetails", Value: true}}}}
+	if err := st.RunCommand(ctx, "admin", detailed, &reply); err != nil {
+		// Servers before 4.4 only know the plain form
+		if err := st.RunCommand(ctx, "admin", bson.D{{Key: "getParameter", Value: "*"}}, &reply); err != nil {
+			return nil, err
+		}
+	}
+
+	var params []serverParam
+	for name, value := range reply {
+		if name == "ok" || strings.HasPrefix(name, "$") || name == "operationTime" {
+			continue
+		}
+		p := serverParam{name: name, value: value}
+		if details, ok := value.(bson.M); ok {
+			if v, ok := details["value"]; ok {
+				p.value = v
+				runtime, _ := details["settableAtRuntime"].(bool)
+				p.startOnly = !runtime
+			}
+		}
+		params = append(params, p)
+	}
+
+	var cluster struct {
+		Params []bson.M `bson:"clusterParameters"`
+	}
+	// Standalone servers and versions before 6.0 have no cluster parameters
+	if err := st.RunCommand(ctx, "admin", bson.D{{Key: "getClusterParameter", Value: "*"}}, &cluster); err == nil {
+		for _, doc := range cluster.Params {
+			name, _ := doc["_id"].(string)
+			delete(doc, "_id")
+			delete(doc, "clusterParameterTime")
+			params = append(params, serverParam{name: name, value: doc, cluster: true})
+		}
+	}
+
+	sort.Slice(params, func(i, j int) bool { return params[i].name < params[j].name })
+	return params, nil
+}
+
+// setParam changes a server parameter with setParameter, or with --cluster a
+// cluster parameter with setClusterParameter, after showing the current value
+// and asking.
+func (m *model) setParam(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: params set [--cluster] <name> <value>"
+	fs := commands.NewFlagSet("params set")
+	cluster := fs.Bool("cluster", false, "set a cluster parameter, given as a JSON document")
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) != 2 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	name := positional[0]
+	value, err := parseParamValue(positional[1], *cluster)
+	if err != nil {
+		m.err = fmt.Errorf("params set: %w", err)
+		return m, nil
+	}
+
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		params, err := listParams(ctx, m.store)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		var current *serverParam
+		for i := range params {
+			if params[i].name == name && params[i].cluster == *cluster {
+				current = &params[i]
+			}
+		}
+		if current == nil {
+			return mongoMsg{err: fmt.Errorf("params set: no such parameter: %s", name)}
+		}
+		if current.startOnly {
+			return mongoMsg{err: fmt.Errorf("params set: %s can only be set at startup, in the server's config file or command line", name)}
+		}
+
+		cmd := bson.D{{Key: "setParameter", Value: 1}, {Key: name, Value: value}}
+		persistence := "it lasts until the server restarts and applies to the connected server only; add it to setParameter in the config file of every member to keep it"
+		if *cluster {
+			cmd = bson.D{{Key: "setClusterParameter", Value: bson.D{{Key: name, Value: value}}}}
+			persistence = "cluster parameters are stored in the cluster and survive restarts"
+		}
+		change := message(fmt.Sprintf("%s: %s -> %s\n%s", name, formatParamValue(current.value), formatParamValue(value), persistence))
+		return confirmMsg{
+			result:   change,
+			question: fmt.Sprintf("set %s? [y/N] ", name),
+			onYes: func() tea.Cmd {
+				return m.run(func(ctx context.Context) tea.Msg {
+					var reply bson.M
+					if err := m.store.RunCommand(ctx, "admin", cmd, &reply); err != nil {
+						return mongoMsg{err: err}
+					}
+					if was, ok := reply["was"]; ok {
+						return mongoMsg{result: message(fmt.Sprintf("%s set to %s (was %s)", name, formatParamValue(value), formatParamValue(was)))}
+					}
+					return mongoMsg{result: message(fmt.Sprintf("%s set to %s", name, formatParamValue(value)))}
+				})
+			},
+			declined: "parameter not changed",
+		}
+	})
+}
+
+// parseParamValue reads a value given on the command line as JSON, such as
+// true, 250 or "text", falling back to a plain string. Cluster parameters
+// are documents.
+func parseParamValue(s string, document bool) (interface{}, error) {
+	if document {
+		doc, err := commands.ParseDocument(s)
+		if err != nil {
+			return nil, fmt.Errorf("cluster parameters are documents, e.g. '{\"enabled\": true}': %w", err)
+		}
+		return doc, nil
+	}
+	var wrapper bson.M
+	if err := bson.UnmarshalExtJSON([]byte(`{"v": `+s+`}`), false, &wrapper); err == nil {
+		return wrapper["v"], nil
+	}
+	return s, nil
+}
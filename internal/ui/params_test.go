@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParams(t *testing.T) {
+	m := newTestModel(seededFake())
+
+	run(t, m, "params")
+	got := output(t, m)
+	for _, want := range []string{"changeStreamOptions", "(cluster)", "enableLocalhostAuthBypass  true  (startup only)", "notablescan"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("params: %q, want %q", got, want)
+		}
+	}
+	run(t, m, "params log")
+	if got := output(t, m); !strings.Contains(got, "logLevel") || strings.Contains(got, "notablescan") {
+		t.Errorf("params log: %q", got)
+	}
+
+	run(t, m, "params set logLevel 2")
+	if got := output(t, m); !strings.Contains(got, "logLevel: 0 -> 2") {
+		t.Errorf("params set before confirming: %q", got)
+	}
+	answer(t, m, "y")
+	if got := output(t, m); got != "logLevel set to 2 (was 0)\n" {
+		t.Errorf("params set: %q", got)
+	}
+	run(t, m, "params logLevel")
+	if got := output(t, m); !strings.Contains(got, "logLevel  2") {
+		t.Errorf("params after setting: %q", got)
+	}
+
+	expectError(t, m, "params set enableLocalhostAuthBypass false", "can only be set at startup")
+	expectError(t, m, "params set nosuchparam 1", "no such parameter")
+
+	run(t, m, `params set --cluster changeStreamOptions '{"preAndPostImages": {"expireAfterSeconds": 100}}'`)
+	answer(t, m, "y")
+	run(t, m, "params changeStream")
+	if got := output(t, m); !strings.Contains(got, `{"preAndPostImages":{"expireAfterSeconds":100}}`) {
+		t.Errorf("params after setting a cluster parameter: %q", got)
+	}
+}
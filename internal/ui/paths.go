@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pathString writes a path the way it is typed, e.g. /shop/orders.
+func pathString(path []string) string {
+	return "/" + strings.Join(path, "/")
+}
+
+// pwd shows the full current path and what it is on, for scripts and
+// recordings where the prompt is easy to miss.
+func (m *model) pwd(args []string) (tea.Model, tea.Cmd) {
+	if len(args) > 0 {
+		m.err = fmt.Errorf("usage: pwd")
+		return m, nil
+	}
+	on := redactURI(m.connectionString)
+	if m.profile != "" {
+		on = fmt.Sprintf("profile %s, %s", m.profile, on)
+	}
+	if m.env != "" {
+		on += ", env " + m.env
+	}
+	if m.readOnly {
+		on += ", read-only"
+	}
+	m.result, m.err = message(fmt.Sprintf("%s\non %s", pathString(m.currentPath), on)), nil
+	return m, nil
+}
+
+// pathMove is a change of the current path made by an operation, applied
+// by Update when its result arrives rather than by the operation itself.
+type pathMove struct {
+	to   []string
+	pop  bool // Take the top off the path stack, for popd and pushd's swap
+	push bool // Put the path moved from on the path stack, for pushd
+}
+
+// moveTo makes the path of mv the current one. A move changing the path
+// stack shows the stack.
+func (m *model) moveTo(mv pathMove) {
+	from := m.currentPath
+	m.previousPath, m.currentPath = m.currentPath, mv.to
+	m.tableScroll = 0 // Columns differ between collections
+	m.rememberPath(mv.to)
+	if mv.pop && len(m.pathStack) > 0 {
+		m.pathStack = m.pathStack[1:]
+	}
+	if mv.push {
+		m.pathStack = append([][]string{from}, m.pathStack...)
+	}
+	if mv.pop || mv.push {
+		m.result = pathStackList(m.currentPath, m.pathStack)
+	}
+}
+
+// cdBack goes back to the path before the last cd, so `cd -` again returns:
+// handy when going back and forth between two collections.
+func (m *model) cdBack() (tea.Model, tea.Cmd) {
+	if m.previousPath == nil {
+		m.err = fmt.Errorf("cd -: no previous path yet")
+		return m, nil
+	}
+	return m, m.cd(pathString(m.previousPath))
+}
+
+// pushd goes to a path like cd, saving the current one on the path stack,
+// e.g. `pushd /logs/app` to look something up and `popd` to come back.
+// Without a path it swaps the current path with the one on top of the
+// stack.
+func (m *model) pushd(args []string) (tea.Model, tea.Cmd) {
+	if len(args) > 1 {
+		m.err = fmt.Errorf("usage: pushd [path]")
+		return m, nil
+	}
+	var target string
+	swap := len(args) == 0
+	if swap {
+		if len(m.pathStack) == 0 {
+			m.err = fmt.Errorf("pushd: no other path, the path stack is empty")
+			return m, nil
+		}
+		target = pathString(m.pathStack[0])
+	} else {
+		target = args[0]
+	}
+	newPath := m.resolvePath(target)
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		msg := m.changePath(ctx, newPath)
+		if msg.move != nil {
+			msg.move.pop, msg.move.push = swap, true
+		}
+		return msg
+	})
+}
+
+// popd goes back to the path on top of the path stack and takes it off.
+func (m *model) popd(args []string) (tea.Model, tea.Cmd) {
+	if len(args) > 0 {
+		m.err = fmt.Errorf("usage: popd")
+		return m, nil
+	}
+	if len(m.pathStack) == 0 {
+		m.err = fmt.Errorf("popd: the path stack is empty")
+		return m, nil
+	}
+	top := append([]string(nil), m.pathStack[0]...) // changePath fills in the names of globs
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		msg := m.changePath(ctx, top)
+		if msg.move != nil {
+			msg.move.pop = true
+		}
+		return msg
+	})
+}
+
+// dirs lists the current path followed by the path stack.
+func (m *model) dirs(args []string) (tea.Model, tea.Cmd) {
+	if len(args) > 0 {
+		m.err = fmt.Errorf("usage: dirs")
+		return m, nil
+	}
+	m.result, m.err = pathStackList(m.currentPath, m.pathStack), nil
+	return m, nil
+}
+
+// pathStackList numbers the current path 0 and the paths of the stack
+// after it, the one popd goes to being 1.
+func pathStackList(current []string, stack [][]string) result {
+	var b strings.Builder
+	for i, path := range append([][]string{current}, stack...) {
+		fmt.Fprintf(&b, "%d  %s\n", i, pathString(path))
+	}
+	return message(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// isGlob tells whether a name is a pattern with *, ? or [...] in it.
+func isGlob(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// errNoSuchName is matchName finding nothing, worded to follow the name.
+var errNoSuchName = errors.New("does not exist")
+
+// matchName returns the one of names a path element stands for: the same
+// name, or the only one matching a glob. Several matches are an error
+// listing them, to pick from.
+func matchName(element string, names []string) (string, error) {
+	if !isGlob(element) {
+		for _, name := range names {
+			if name == element {
+				return name, nil
+			}
+		}
+		return "", errNoSuchName
+	}
+	if _, err := path.Match(element, ""); err != nil {
+		return "", fmt.Errorf("is not a valid pattern: %w", err)
+	}
+	matches := matchingNames(element, names)
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("matches nothing")
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("matches %d names, pick one: %s", len(matches), strings.Join(matches, ", "))
+}
+
+// matchesPattern tells whether a name matches the pattern given to ls,
+// which every name does when there is none.
+func matchesPattern(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+func matchingNames(pattern string, names []string) []string {
+	var matching []string
+	for _, name := range names {
+		if matchesPattern(pattern, name) {
+			matching = append(matching, name)
+		}
+	}
+	return matching
+}
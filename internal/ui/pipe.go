@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// splitPipeline splits `find {...} | jq .email | sort` into the command and
+// the shell pipeline after the first `|` outside quotes. The /.../ of a
+// regular expression in filter shorthand, as in `find name=/^bob|alice/`,
+// counts as quotes, so its | is never taken for a pipe, even while it is
+// unterminated. ok is false when there is no such `|`.
+func splitPipeline(input string) (command, pipeline string, ok bool) {
+	var quote rune
+	escaped := false
+	for i, r := range input {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '/' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '/' && i > 0 && input[i-1] == '=':
+			quote = r
+		case r == '|':
+			return strings.TrimSpace(input[:i]), strings.TrimSpace(input[i+1:]), true
+		}
+	}
+	return input, "", false
+}
+
+// piped runs command and hands its result to a shell pipeline. A command
+// that talks to the server carries the pipeline on its operation, which
+// starts it once the result arrives; the result of any other command is
+// piped right away.
+func (m *model) piped(command, pipeline string) (tea.Model, tea.Cmd) {
+	if command == "" || pipeline == "" {
+		m.err = fmt.Errorf("|: expected a command on both sides, e.g. find | sort")
+		return m, nil
+	}
+	if m.readOnly {
+		m.err = m.readOnlyError("|") // The pipeline is a shell command like !, which can write anything
+		return m, nil
+	}
+	before := m.lastOpID
+	m.pipe = pipeline
+	next, cmd := m.processCommand(command)
+	m.pipe = ""
+	if m.running != nil && m.lastOpID != before {
+		m.running.label = command + " | " + pipeline // Logged as typed once the result is piped
+	}
+	if m.err != nil || m.lastOpID != before || m.prompt != nil || m.result == nil {
+		return next, cmd
+	}
+	return m, tea.Batch(cmd, m.runPipeline(pipeline, m.result))
+}
+
+// pipelineInput renders r as the input of a pipeline: documents and values
+// one per line as relaxed Extended JSON, any other result as it is shown.
+func pipelineInput(r result) []byte {
+	var b bytes.Buffer
+	switch r := r.(type) {
+	case documentList:
+		for _, doc := range r.docs {
+			data, err := bson.MarshalExtJSON(doc, false, false)
+			if err != nil {
+				data = []byte(valueJSON(doc))
+			}
+			b.Write(data)
+			b.WriteByte('\n')
+		}
+	case *documentTree:
+		b.WriteString(valueJSON(r.doc))
+		b.WriteByte('\n')
+	case valueList:
+		for _, v := range r.values {
+			b.WriteString(valueJSON(v))
+			b.WriteByte('\n')
+		}
+	default:
+		b.WriteString(r.String())
+	}
+	return b.Bytes()
+}
+
+// shellCommand returns the command that runs a pipeline with the system
+// shell.
+func shellCommand(ctx context.Context, pipeline string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", pipeline)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", pipeline)
+}
+
+// runPipeline runs a shell pipeline with r on its standard input and shows
+// what it writes. Esc kills it.
+func (m *model) runPipeline(pipeline string, r result) tea.Cmd {
+	input := pipelineInput(r)
+	m.lastInput, m.writing = "| "+pipeline, false // The pipeline runs locally
+	return m.runWithTimeout(0, func(ctx context.Context) tea.Msg {
+		cmd := shellCommand(ctx, pipeline)
+		cmd.Stdin = bytes.NewReader(input)
+		var out, stderr bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			text := strings.TrimRight(stderr.String()+out.String(), "\n")
+			return mongoMsg{err: fmt.Errorf("%s: exited with status %d:\n%s", pipeline, exit.ExitCode(), text)}
+		}
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		text := strings.TrimRight(stderr.String()+out.String(), "\n")
+		if text == "" {
+			text = "(no output)"
+		}
+		return mongoMsg{result: message(text)}
+	})
+}
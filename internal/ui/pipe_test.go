@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestSplitPipeline(t *testing.T) {
+	tests := []struct {
+		input, command, pipeline string
+		ok                       bool
+	}{
+		{"find", "find", "", false},
+		{`find '{"a": "x|y"}' | jq .a | sort`, `find '{"a": "x|y"}'`, "jq .a | sort", true},
+		{`filter '.a | .b'`, `filter '.a | .b'`, "", false},
+		{"ls|wc -l", "ls", "wc -l", true},
+		{"find name=/a|b/", "find name=/a|b/", "", false},
+		{`find name=/a\/|b/ x!=/c|d/ | wc -l`, `find name=/a\/|b/ x!=/c|d/`, "wc -l", true},
+		{"find name=/a|rm -rf x", "find name=/a|rm -rf x", "", false}, // Unterminated
+	}
+	for _, tt := range tests {
+		command, pipeline, ok := splitPipeline(tt.input)
+		if command != tt.command || pipeline != tt.pipeline || ok != tt.ok {
+			t.Errorf("splitPipeline(%q) = %q, %q, %v", tt.input, command, pipeline, ok)
+		}
+	}
+}
+
+func TestPipe(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+
+	run(t, m, `find --limit 0 | grep -o '"item":"[a-z]*"' | sort -r`)
+	if got := output(t, m); got != "\"item\":\"plum\"\n\"item\":\"pear\"\n\"item\":\"apple\"\n" {
+		t.Fatalf("find piped through grep and sort: %q", got)
+	}
+
+	run(t, m, "find --limit 0")
+	run(t, m, "filter .qty | sort -n | tail -1") // Not a server command, piped right away
+	if got := output(t, m); got != "12\n" {
+		t.Fatalf("filter piped through sort: %q", got)
+	}
+
+	expectError(t, m, "find | false", "exited with status 1")
+}
@@ -0,0 +1,387 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+const (
+	// defaultPipelineSample is how many documents previews run on unless
+	// --sample says otherwise.
+	defaultPipelineSample = 100
+
+	// pipelinePreviewLimit caps the documents a preview shows.
+	pipelinePreviewLimit = 20
+)
+
+var selectedStageStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
+
+// writeStages are the stages a preview stops before, as they would write.
+var writeStages = map[string]bool{"$out": true, "$merge": true}
+
+// pipelineBuilder is an aggregation pipeline being put together stage by
+// stage for a collection. selected is the stage edits and previews apply
+// to, -1 when there are no stages.
+type pipelineBuilder struct {
+	db, coll string
+	stages   []bson.D
+	selected int
+	sample   int
+	previewN int // Stages in the last preview, 0 if there was none
+}
+
+// checkStage checks that stage has the shape of an aggregation stage: a
+// single $-prefixed operator.
+func checkStage(stage bson.D) error {
+	if len(stage) != 1 || !strings.HasPrefix(stage[0].Key, "$") {
+		return fmt.Errorf("a stage is a document with a single $ operator, e.g. {\"$match\": {...}}")
+	}
+	return nil
+}
+
+// parseStage parses a stage written as JSON.
+func parseStage(s string) (bson.D, error) {
+	stage, err := commands.ParseDocument(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stage: %w", err)
+	}
+	if err := checkStage(stage); err != nil {
+		return nil, err
+	}
+	return stage, nil
+}
+
+// add inserts stage after the selected one and selects it.
+func (pb *pipelineBuilder) add(stage bson.D) {
+	at := pb.selected + 1
+	pb.stages = append(pb.stages[:at], append([]bson.D{stage}, pb.stages[at:]...)...)
+	pb.selected = at
+}
+
+// remove deletes the selected stage, selecting the one before it.
+func (pb *pipelineBuilder) remove() error {
+	if pb.selected < 0 {
+		return fmt.Errorf("the pipeline has no stages")
+	}
+	pb.stages = append(pb.stages[:pb.selected], pb.stages[pb.selected+1:]...)
+	if pb.selected > 0 || len(pb.stages) == 0 {
+		pb.selected--
+	}
+	return nil
+}
+
+// move swaps the selected stage with its neighbour delta away, keeping it
+// selected.
+func (pb *pipelineBuilder) move(delta int) error {
+	to := pb.selected + delta
+	if pb.selected < 0 || to < 0 || to >= len(pb.stages) {
+		return fmt.Errorf("cannot move the stage further")
+	}
+	pb.stages[pb.selected], pb.stages[to] = pb.stages[to], pb.stages[pb.selected]
+	pb.selected = to
+	return nil
+}
+
+// selectStage selects the stage at 0-based index i, if there is one.
+func (pb *pipelineBuilder) selectStage(i int) {
+	if i >= 0 && i < len(pb.stages) {
+		pb.selected = i
+	}
+}
+
+// previewPipeline is the pipeline a preview runs: a sample of the
+// collection, then the stages up to and including the selected one. Stages
+// that write end the preview early; skipped names the first one left out.
+func (pb *pipelineBuilder) previewPipeline() (pipeline bson.A, n int, skipped string) {
+	pipeline = bson.A{bson.D{{Key: "$sample", Value: bson.D{{Key: "size", Value: pb.sample}}}}}
+	for _, stage := range pb.stages[:pb.selected+1] {
+		if writeStages[stage[0].Key] {
+			skipped = stage[0].Key
+			break
+		}
+		pipeline = append(pipeline, stage)
+		n++
+	}
+	return append(pipeline, bson.D{{Key: "$limit", Value: pipelinePreviewLimit}}), n, skipped
+}
+
+// stageJSON renders a stage on one line.
+func stageJSON(stage bson.D) string {
+	out, err := bson.MarshalExtJSON(stage, false, false)
+	if err != nil {
+		return fmt.Sprintf("%v", stage)
+	}
+	return string(out)
+}
+
+// json renders the pipeline as an indented JSON array, ready to paste into
+// code or a shell.
+func (pb *pipelineBuilder) json() ([]byte, error) {
+	var b strings.Builder
+	b.WriteString("[")
+	for i, stage := range pb.stages {
+		out, err := bson.MarshalExtJSONIndent(stage, false, false, "  ", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  ")
+		b.Write(out)
+	}
+	if len(pb.stages) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+	return []byte(b.String()), nil
+}
+
+func (pb *pipelineBuilder) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("pipeline on %s.%s, previews sample %d documents (↑/↓ select, shift+↑/↓ move, Esc to close)\n\n", pb.db, pb.coll, pb.sample))
+	if len(pb.stages) == 0 {
+		b.WriteString("  no stages yet, see `pipeline add`\n")
+	}
+	for i, stage := range pb.stages {
+		line := fmt.Sprintf("%2d  %s", i+1, stageJSON(stage))
+		if i == pb.selected {
+			b.WriteString(selectedStageStyle.Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+	if pb.previewN > 0 {
+		b.WriteString(fmt.Sprintf("\nthe last preview ran stages 1-%d on the sample\n", pb.previewN))
+	}
+	return b.String()
+}
+
+func (m *model) pipeline(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: pipeline [--sample <n>] ['<pipeline>'] | add ['<stage>'] | edit ['<stage>'] | rm | up | down | select <n> | preview | export [file] | close"
+
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || strings.HasPrefix(args[0], "[") {
+		return m.openPipeline(args)
+	}
+	pb := m.builder
+	if pb == nil {
+		m.err = fmt.Errorf("pipeline: no pipeline is open, start one with `pipeline`")
+		return m, nil
+	}
+	m.err = nil
+
+	switch args[0] {
+	case "add", "edit":
+		if len(args) > 2 {
+			m.err = fmt.Errorf("usage: pipeline %s ['<stage>']", args[0])
+			return m, nil
+		}
+		if args[0] == "edit" && pb.selected < 0 {
+			m.err = fmt.Errorf("pipeline edit: the pipeline has no stages")
+			return m, nil
+		}
+		apply := func(stage bson.D) {
+			if args[0] == "add" {
+				pb.add(stage)
+			} else {
+				pb.stages[pb.selected] = stage
+			}
+		}
+		if len(args) == 2 {
+			stage, err := parseStage(args[1])
+			if err != nil {
+				m.err = fmt.Errorf("pipeline %s: %w", args[0], err)
+				return m, nil
+			}
+			apply(stage)
+			return m, nil
+		}
+		content := "{\"$match\": {}}\n"
+		if args[0] == "edit" {
+			out, err := bson.MarshalExtJSONIndent(pb.stages[pb.selected], false, false, "", "  ")
+			if err != nil {
+				m.err = fmt.Errorf("pipeline edit: %w", err)
+				return m, nil
+			}
+			content = string(out) + "\n"
+		}
+		command := args[0]
+		return m, m.edit([]byte(content), func(edited []byte) tea.Cmd {
+			stage, err := parseStage(string(edited))
+			if err != nil {
+				m.err = fmt.Errorf("pipeline %s: %w", command, err)
+				return nil
+			}
+			apply(stage)
+			return nil
+		})
+
+	case "rm":
+		if err := pb.remove(); err != nil {
+			m.err = fmt.Errorf("pipeline rm: %w", err)
+		}
+		return m, nil
+
+	case "up", "down":
+		delta := -1
+		if args[0] == "down" {
+			delta = 1
+		}
+		if err := pb.move(delta); err != nil {
+			m.err = fmt.Errorf("pipeline %s: %w", args[0], err)
+		}
+		return m, nil
+
+	case "select":
+		n, err := strconv.Atoi(strings.Join(args[1:], ""))
+		if len(args) != 2 || err != nil || n < 1 || n > len(pb.stages) {
+			m.err = fmt.Errorf("usage: pipeline select <n>, with n between 1 and %d", len(pb.stages))
+			return m, nil
+		}
+		pb.selectStage(n - 1)
+		return m, nil
+
+	case "preview":
+		if len(args) != 1 {
+			m.err = fmt.Errorf("usage: pipeline preview")
+			return m, nil
+		}
+		return m, m.previewPipeline()
+
+	case "export":
+		if len(args) > 2 {
+			m.err = fmt.Errorf("usage: pipeline export [file]")
+			return m, nil
+		}
+		out, err := pb.json()
+		if err != nil {
+			m.err = fmt.Errorf("pipeline export: %w", err)
+			return m, nil
+		}
+		if len(args) == 1 {
+			m.result = message(strings.TrimSuffix(highlightJSON(string(out)), "\n"))
+			return m, nil
+		}
+		if err := os.WriteFile(args[1], out, 0644); err != nil {
+			m.err = fmt.Errorf("pipeline export: %w", err)
+			return m, nil
+		}
+		m.result = message(fmt.Sprintf("wrote the %d-stage pipeline to %s", len(pb.stages), args[1]))
+		return m, nil
+
+	case "close":
+		m.builder = nil
+		return m, nil
+
+	default:
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+}
+
+// openPipeline starts a builder for the current collection, optionally with
+// the stages of an existing pipeline.
+func (m *model) openPipeline(args []string) (tea.Model, tea.Cmd) {
+	db, coll, err := m.collectionPath("pipeline")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	fs := commands.NewFlagSet("pipeline")
+	sample := fs.Int("sample", defaultPipelineSample, "documents to sample for previews")
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	if len(positional) > 1 || *sample < 1 {
+		m.err = fmt.Errorf("usage: pipeline [--sample <n>] ['<pipeline>']")
+		return m, nil
+	}
+
+	pb := &pipelineBuilder{db: db, coll: coll, selected: -1, sample: *sample}
+	if len(positional) == 1 {
+		stages, err := commands.ParsePipeline(positional[0])
+		if err != nil {
+			m.err = fmt.Errorf("pipeline: invalid pipeline: %w", err)
+			return m, nil
+		}
+		for i, stage := range stages {
+			if err := checkStage(stage); err != nil {
+				m.err = fmt.Errorf("pipeline: stage %d: %w", i+1, err)
+				return m, nil
+			}
+		}
+		pb.stages = stages
+		pb.selected = len(stages) - 1
+	}
+	m.builder = pb
+	m.result = nil
+	m.err = nil
+	return m, nil
+}
+
+// previewPipeline runs the stages up to the selected one on a sample of the
+// collection.
+func (m *model) previewPipeline() tea.Cmd {
+	pb := m.builder
+	if pb.selected < 0 {
+		m.err = fmt.Errorf("pipeline preview: the pipeline has no stages")
+		return nil
+	}
+	pipeline, n, skipped := pb.previewPipeline()
+	deprecated, err := checkOperators(m.serverVersion, pipeline)
+	if err != nil {
+		m.err = fmt.Errorf("pipeline preview: %w", err)
+		return nil
+	}
+	if skipped != "" {
+		deprecated = append(deprecated, fmt.Sprintf("the preview stops before %s, which would write", skipped))
+	}
+	pb.previewN = n
+	db, coll := pb.db, pb.coll
+	return m.run(func(ctx context.Context) tea.Msg {
+		cur, err := m.store.Aggregate(ctx, db, coll, pipeline)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		defer cur.Close(ctx)
+		var docs documentList
+		if err := cur.All(ctx, &docs.docs); err != nil {
+			return mongoMsg{err: err}
+		}
+		return mongoMsg{result: docs, warnings: deprecated}
+	})
+}
+
+// pipelineKey handles the keys that select and move stages while the
+// builder is open. It reports whether it used the key.
+func (m *model) pipelineKey(msg tea.KeyMsg) bool {
+	pb := m.builder
+	if pb == nil || m.prompt != nil || m.running != nil {
+		return false
+	}
+	switch msg.Type {
+	case tea.KeyUp:
+		pb.selectStage(pb.selected - 1)
+	case tea.KeyDown:
+		pb.selectStage(pb.selected + 1)
+	case tea.KeyShiftUp:
+		pb.move(-1)
+	case tea.KeyShiftDown:
+		pb.move(1)
+	default:
+		return false
+	}
+	return true
+}
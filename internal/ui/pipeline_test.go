@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+func stageKeys(pb *pipelineBuilder) string {
+	var keys []string
+	for _, stage := range pb.stages {
+		keys = append(keys, stage[0].Key)
+	}
+	return strings.Join(keys, " ")
+}
+
+func TestPipelineBuilder(t *testing.T) {
+	fake := store.NewFake()
+	fake.Seed("shop", "orders")
+	m := newTestModel(fake)
+	run(t, m, "cd shop/orders")
+
+	run(t, m, "pipeline --sample 50")
+	if m.builder == nil || m.builder.sample != 50 {
+		t.Fatalf("builder = %+v, want one sampling 50 documents", m.builder)
+	}
+	run(t, m, `pipeline add '{"$match": {"status": "open"}}'`)
+	run(t, m, `pipeline add '{"$group": {"_id": "$customer"}}'`)
+	run(t, m, `pipeline add '{"$sort": {"_id": 1}}'`)
+	if got := stageKeys(m.builder); got != "$match $group $sort" {
+		t.Fatalf("stages = %s", got)
+	}
+
+	run(t, m, `pipeline add '{"status": "open"}'`)
+	if m.err == nil || !strings.Contains(m.err.Error(), "single $ operator") {
+		t.Errorf("adding a bare filter: err = %v", m.err)
+	}
+
+	run(t, m, "pipeline select 2")
+	run(t, m, "pipeline up")
+	if got := stageKeys(m.builder); got != "$group $match $sort" || m.builder.selected != 0 {
+		t.Errorf("after up: stages = %s, selected %d", got, m.builder.selected)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyShiftDown})
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if got := stageKeys(m.builder); got != "$match $group $sort" || m.builder.selected != 2 {
+		t.Errorf("after shift+down, down: stages = %s, selected %d", got, m.builder.selected)
+	}
+	if m.textInput.Value() != "" {
+		t.Errorf("arrow keys reached the input: %q", m.textInput.Value())
+	}
+
+	run(t, m, `pipeline edit '{"$sort": {"_id": -1}}'`)
+	run(t, m, "pipeline select 2")
+	run(t, m, "pipeline rm")
+	if got := stageKeys(m.builder); got != "$match $sort" || m.builder.selected != 0 {
+		t.Errorf("after rm: stages = %s, selected %d", got, m.builder.selected)
+	}
+
+	file := filepath.Join(t.TempDir(), "pipeline.json")
+	run(t, m, "pipeline export "+file)
+	if m.err != nil {
+		t.Fatal(m.err)
+	}
+	out, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stages, err := commands.ParsePipeline(string(out))
+	if err != nil {
+		t.Fatalf("exported pipeline does not parse: %v\n%s", err, out)
+	}
+	want := []bson.D{
+		{{Key: "$match", Value: bson.D{{Key: "status", Value: "open"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: int32(-1)}}}},
+	}
+	if len(stages) != len(want) || stageJSON(stages[0]) != stageJSON(want[0]) || stageJSON(stages[1]) != stageJSON(want[1]) {
+		t.Errorf("exported %s", out)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.builder != nil {
+		t.Error("Esc did not close the builder")
+	}
+}
+
+func TestPipelineOpenWithStages(t *testing.T) {
+	fake := store.NewFake()
+	fake.Seed("shop", "orders")
+	m := newTestModel(fake)
+	run(t, m, "cd shop/orders")
+
+	run(t, m, `pipeline '[{"$match": {}}, {"$project": {"x": 1}}]'`)
+	if m.err != nil || m.builder == nil || stageKeys(m.builder) != "$match $project" || m.builder.selected != 1 {
+		t.Fatalf("builder = %+v, err = %v", m.builder, m.err)
+	}
+	run(t, m, `pipeline '[{"x": 1}]'`)
+	if m.err == nil || !strings.Contains(m.err.Error(), "stage 1") {
+		t.Errorf("err = %v, want the bad stage named", m.err)
+	}
+}
+
+func TestPipelinePreviewStopsBeforeWrites(t *testing.T) {
+	pb := &pipelineBuilder{sample: 10, stages: []bson.D{
+		{{Key: "$match", Value: bson.D{}}},
+		{{Key: "$merge", Value: "other"}},
+		{{Key: "$project", Value: bson.D{}}},
+	}}
+
+	pb.selected = 0
+	pipeline, n, skipped := pb.previewPipeline()
+	if n != 1 || skipped != "" || len(pipeline) != 3 {
+		t.Errorf("through $match: %v, %d, %q", pipeline, n, skipped)
+	}
+	pb.selected = 2
+	pipeline, n, skipped = pb.previewPipeline()
+	if n != 1 || skipped != "$merge" || len(pipeline) != 3 {
+		t.Errorf("through $project: %v, %d, %q", pipeline, n, skipped)
+	}
+}
+
+func TestPipelinePreview(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+	run(t, m, `pipeline '[{"$match": {"qty": {"$gt": 1}}}, {"$group": {"_id": null, "total": {"$sum": "$qty"}}}]'`)
+
+	run(t, m, "pipeline preview")
+	if got := docCount(t, m); got != 1 {
+		t.Fatalf("preview through $group: %d documents", got)
+	}
+	if got := m.result.(documentList).docs[0]["total"]; got != int32(17) {
+		t.Errorf("preview through $group: total %v", got)
+	}
+	run(t, m, "pipeline select 1")
+	run(t, m, "pipeline preview")
+	if got := docCount(t, m); got != 2 {
+		t.Errorf("preview through $match: %d documents", got)
+	}
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultPrompt is the prompt when the config file sets none.
+const defaultPrompt = "mon-go ({path}) "
+
+// promptCountMsg is the estimated number of documents of a collection, for
+// {count} in the prompt.
+type promptCountMsg struct {
+	ns    string
+	count int64
+}
+
+// promptText renders the prompt template: {profile}, {env}, {path}, {db},
+// {coll}, {count} and {session} are replaced by the connection, the
+// environment, the path, its database and collection, the estimated number
+// of documents of the collection and the causal consistency of the session.
+func (m *model) promptText() string {
+	template := m.promptTemplate
+	if template == "" {
+		template = defaultPrompt
+	}
+	path, db, coll, count := "/", "", "", ""
+	if len(m.currentPath) > 0 {
+		path, db = strings.Join(m.currentPath, "/"), m.currentPath[0]
+	}
+	if len(m.currentPath) > 1 {
+		coll, count = m.currentPath[1], "?"
+		if c := m.promptCount; c != nil && c.ns == db+"."+coll {
+			count = groupDigits(c.count)
+		}
+	}
+	return strings.NewReplacer(
+		"{profile}", m.connectionName(),
+		"{env}", m.env,
+		"{path}", path,
+		"{db}", db,
+		"{coll}", coll,
+		"{count}", count,
+		"{session}", strings.TrimSpace(m.consistency.indicator()),
+	).Replace(template)
+}
+
+// refreshPromptCount estimates the number of documents of the current
+// collection if the prompt shows it. Failures leave it unknown and are
+// only logged; the prompt is not worth an error.
+func (m *model) refreshPromptCount() tea.Cmd {
+	if !strings.Contains(m.promptTemplate, "{count}") || len(m.currentPath) < 2 || m.store == nil {
+		return nil
+	}
+	st, db, coll := m.store, m.currentPath[0], m.currentPath[1]
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		defer cancel()
+		n, err := st.EstimatedDocumentCount(ctx, db, coll)
+		if err != nil {
+			slog.Debug("counting documents for the prompt failed", "error", err)
+			return nil
+		}
+		return promptCountMsg{ns: db + "." + coll, count: n}
+	}
+}
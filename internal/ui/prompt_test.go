@@ -0,0 +1,31 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPromptTemplate(t *testing.T) {
+	m := newTestModel(seededFake())
+	if got := m.promptText(); got != "mon-go (/) " {
+		t.Errorf("default prompt at the root: %q", got)
+	}
+
+	m.profile = "shop-dev"
+	m.promptTemplate = "{profile}:{db}/{coll} ({count} docs) > "
+	run(t, m, "cd shop/orders")
+	if got := m.promptText(); got != "shop-dev:shop/orders (3 docs) > " {
+		t.Errorf("with the estimated count: %q", got)
+	}
+	m.Update(promptCountMsg{ns: "shop.orders", count: 14382})
+	if got := m.promptText(); got != "shop-dev:shop/orders (14,382 docs) > " {
+		t.Errorf("with the count: %q", got)
+	}
+	run(t, m, "cd ..")
+	if got := m.promptText(); got != "shop-dev:shop/ ( docs) > " {
+		t.Errorf("in a database: %q", got)
+	}
+	if view := m.View(); !strings.HasPrefix(view, "shop-dev:shop/ ( docs) > ") || strings.Contains(view, "> > ") {
+		t.Errorf("view: %q", strings.SplitN(view, "\n", 2)[0])
+	}
+}
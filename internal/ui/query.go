@@ -0,0 +1,253 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+// queryFlags are the options shared by commands that read documents.
+type queryFlags struct {
+	sort      *string
+	sortBy    *string
+	limit     *int64
+	collation *string
+}
+
+// parseQuery parses the optional filter argument, or filter shorthand
+// arguments, and the query flags of a reading command.
+func parseQuery(name string, args []string, withSort bool) (bson.D, *options.Collation, queryFlags, error) {
+	fs := commands.NewFlagSet(name)
+	var qf queryFlags
+	if withSort {
+		qf.sort = fs.String("sort", "", "sort document, e.g. '{\"name\": 1}'")
+		qf.sortBy = fs.String("sort-by", "", "field or computed column to sort the fetched documents by, - prefix for descending")
+		qf.limit = fs.Int64("limit", defaultListLimit, "maximum number of documents, 0 for no limit")
+	}
+	qf.collation = fs.String("collation", "", "collation document or locale")
+
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil {
+		return nil, nil, qf, err
+	}
+	filter := bson.D{}
+	switch {
+	case len(positional) == 0:
+	case strings.HasPrefix(strings.TrimSpace(positional[0]), "{"):
+		if len(positional) > 1 {
+			return nil, nil, qf, fmt.Errorf("%s: expected at most one filter argument", name)
+		}
+		if filter, err = commands.ParseDocument(positional[0]); err != nil {
+			return nil, nil, qf, fmt.Errorf("%s: invalid filter: %w", name, err)
+		}
+	default: // Shorthand such as name=/^bob/i age>21
+		if filter, err = commands.ParseShorthand(positional); err != nil {
+			return nil, nil, qf, fmt.Errorf("%s: invalid filter: %w", name, err)
+		}
+	}
+
+	var collation *options.Collation
+	if *qf.collation != "" {
+		if collation, err = commands.ParseCollation(*qf.collation); err != nil {
+			return nil, nil, qf, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return filter, collation, qf, nil
+}
+
+// find lists the documents of the current collection matching a filter.
+func (m *model) find(args []string) (tea.Model, tea.Cmd) {
+	db, coll, err := m.collectionPath("find")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	filter, collation, qf, err := parseQuery("find", args, true)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	deprecated, err := checkOperators(m.serverVersion, filter)
+	if err != nil {
+		m.err = fmt.Errorf("find: %w", err)
+		return m, nil
+	}
+
+	findOptions := options.Find()
+	explain := bson.D{{Key: "find", Value: coll}, {Key: "filter", Value: filter}}
+	var sort bson.D
+	if *qf.sort != "" {
+		if sort, err = commands.ParseDocument(*qf.sort); err != nil {
+			m.err = fmt.Errorf("find: invalid sort: %w", err)
+			return m, nil
+		}
+		findOptions.SetSort(sort)
+		explain = append(explain, bson.E{Key: "sort", Value: sort})
+	}
+	limit := *qf.limit // Page size of the result set; 0 fetches everything at once
+	if m.batchSize > 0 {
+		findOptions.SetBatchSize(m.batchSize)
+	}
+	if collation != nil {
+		findOptions.SetCollation(collation)
+		explain = append(explain, bson.E{Key: "collation", Value: collation})
+	}
+	governor := m.activeGovernor()
+	if maxTime := governor.maxTime(); maxTime > 0 {
+		findOptions.SetMaxTime(maxTime)
+	}
+	scanLimit := int64(0) // A limit only bounds the scan when nothing else must be examined
+	if len(filter) == 0 && len(sort) == 0 {
+		scanLimit = limit
+	}
+	sortBy := strings.TrimPrefix(*qf.sortBy, "-")
+	descending := strings.HasPrefix(*qf.sortBy, "-")
+	computed := m.columns[db+"."+coll]
+	m.lastFind, m.tableSort = args, *qf.sortBy
+
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		warning, err := m.checkGovernor(ctx, governor, db, coll, explain, scanLimit)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+
+		cur, err := m.store.Find(ctx, db, coll, filter, findOptions)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+
+		if limit > 0 {
+			results, docs, err := openResultSet(ctx, cur, int(limit))
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			if sortBy != "" {
+				sortDocuments(docs.docs, sortBy, descending, computed) // Within the page
+			}
+			return mongoMsg{result: docs, results: results, warnings: append(deprecated, nonEmpty(warning)...)}
+		}
+		defer cur.Close(ctx)
+		var docs documentList
+		if err := cur.All(ctx, &docs.docs); err != nil {
+			return mongoMsg{err: err}
+		}
+		if sortBy != "" {
+			sortDocuments(docs.docs, sortBy, descending, computed)
+		}
+		return mongoMsg{result: docs, warnings: append(deprecated, nonEmpty(warning)...)}
+	})
+}
+
+// count counts the documents of the current collection matching a filter.
+func (m *model) count(args []string) (tea.Model, tea.Cmd) {
+	db, coll, err := m.collectionPath("count")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	filter, collation, _, err := parseQuery("count", args, false)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	deprecated, err := checkOperators(m.serverVersion, filter)
+	if err != nil {
+		m.err = fmt.Errorf("count: %w", err)
+		return m, nil
+	}
+
+	countOptions := options.Count()
+	explain := bson.D{{Key: "count", Value: coll}, {Key: "query", Value: filter}}
+	if collation != nil {
+		countOptions.SetCollation(collation)
+		explain = append(explain, bson.E{Key: "collation", Value: collation})
+	}
+	governor := m.activeGovernor()
+	if maxTime := governor.maxTime(); maxTime > 0 {
+		countOptions.SetMaxTime(maxTime)
+	}
+
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		warning, err := m.checkGovernor(ctx, governor, db, coll, explain, 0)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+
+		n, err := m.store.CountDocuments(ctx, db, coll, filter, countOptions)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		return mongoMsg{result: message(fmt.Sprintf("%d documents", n)), warnings: append(deprecated, nonEmpty(warning)...)}
+	})
+}
+
+const defaultSampleSize = 10
+
+// samplePipeline picks n random documents, of those matching filter if it is
+// not empty.
+func samplePipeline(n int, filter bson.D) []bson.D {
+	var pipeline []bson.D
+	if len(filter) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: filter}})
+	}
+	return append(pipeline, bson.D{{Key: "$sample", Value: bson.D{{Key: "size", Value: n}}}})
+}
+
+// sample shows random documents of the current collection, rather than the
+// first ones in natural order that find and ls show.
+func (m *model) sample(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: sample [n] ['<filter>']"
+
+	db, coll, err := m.collectionPath("sample")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	n := defaultSampleSize
+	if len(args) > 0 {
+		if size, err := strconv.Atoi(args[0]); err == nil {
+			if size <= 0 {
+				m.err = fmt.Errorf(usage)
+				return m, nil
+			}
+			n, args = size, args[1:]
+		}
+	}
+	if len(args) > 1 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	var filter bson.D
+	if len(args) == 1 {
+		if filter, err = commands.ParseDocument(args[0]); err != nil {
+			m.err = fmt.Errorf("sample: invalid filter: %w", err)
+			return m, nil
+		}
+	}
+	deprecated, err := checkOperators(m.serverVersion, filter)
+	if err != nil {
+		m.err = fmt.Errorf("sample: %w", err)
+		return m, nil
+	}
+
+	pipeline := samplePipeline(n, filter)
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		cur, err := m.store.Aggregate(ctx, db, coll, pipeline)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		defer cur.Close(ctx)
+		var docs documentList
+		if err := cur.All(ctx, &docs.docs); err != nil {
+			return mongoMsg{err: err}
+		}
+		return mongoMsg{result: docs, warnings: deprecated}
+	})
+}
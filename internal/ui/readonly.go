@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// productionDataSize is the amount of data above which a deployment is
+// assumed to be production.
+const productionDataSize = 50 << 30
+
+var bannerStyle = lipgloss.NewStyle().
+	Bold(true).
+	Foreground(lipgloss.Color("15")).
+	Background(lipgloss.Color("1")).
+	Padding(0, 1)
+
+// mutatingCommands lists the commands that change data or server state. For
+// command families only the listed subcommands write; nil means every use of
+// the command does.
+var mutatingCommands = map[string][]string{
+	"mkdir":       nil,
+	"collmod":     nil,
+	"insert":      nil,
+	"update":      nil,
+	"replace":     nil,
+	"deletemany":  nil,
+	"synthesize":  nil,
+	"seed":        nil,
+	"bulk":        nil,
+	"findupdate":  nil,
+	"finddelete":  nil,
+	"user":        {"create", "drop", "grant", "revoke", "import"},
+	"users":       {"create", "drop", "grant", "revoke", "import"},
+	"ttl":         {"set", "rm"},
+	"index":       {"create"},
+	"searchindex": {"create", "drop"},
+	"atlas":       {"pause", "resume"},
+	"params":      {"set"},
+	"schema":      {"set"},
+	"view":        {"create"},
+	"!mongosh":    nil, // Snippets may write
+	"!":           nil, // So may shell commands, e.g. mongorestore
+}
+
+// isMutating reports whether running command with args would write.
+func isMutating(command string, args []string) bool {
+	subcommands, ok := mutatingCommands[command]
+	if !ok {
+		return false
+	}
+	if subcommands == nil {
+		return true
+	}
+	if len(args) == 0 {
+		return false
+	}
+	for _, sub := range subcommands {
+		if args[0] == sub {
+			return true
+		}
+	}
+	return false
+}
+
+// productionSignals returns the reasons a deployment looks like production:
+// an SRV connection string, "prod" in a host or replica set name, or a large
+// amount of data. It is a heuristic, so errors just mean one signal fewer.
+func productionSignals(ctx context.Context, client *mongo.Client, connectionString string, clientOpts *options.ClientOptions, replicaSet string) []string {
+	var signals []string
+	if strings.HasPrefix(connectionString, "mongodb+srv://") {
+		signals = append(signals, "SRV connection string")
+	}
+	for _, host := range clientOpts.Hosts {
+		if strings.Contains(strings.ToLower(host), "prod") {
+			signals = append(signals, fmt.Sprintf("host '%s'", host))
+			break
+		}
+	}
+	if strings.Contains(strings.ToLower(replicaSet), "prod") {
+		signals = append(signals, fmt.Sprintf("replica set '%s'", replicaSet))
+	}
+	if dbs, err := client.ListDatabases(ctx, bson.M{}); err == nil && dbs.TotalSize >= productionDataSize {
+		signals = append(signals, fmt.Sprintf("%d GB of data", dbs.TotalSize>>30))
+	}
+	return signals
+}
+
+// readOnlyBanner is shown above the prompt while writes are disabled for the
+// session, or because the deployment looks like production.
+func (m *model) readOnlyBanner() string {
+	if m.readOnlyForced != "" {
+		return bannerStyle.Render(fmt.Sprintf("READ-ONLY (%s): writes are refused for this session.", m.readOnlyForced)) + "\n"
+	}
+	if !m.readOnly || len(m.productionSignals) == 0 {
+		return ""
+	}
+	text := fmt.Sprintf("READ-ONLY: this looks like production (%s). Use `set readonly off` to allow writes.",
+		strings.Join(m.productionSignals, ", "))
+	return bannerStyle.Render(text) + "\n"
+}
+
+// readOnlyError is the refusal of command, which would write, in a read-only
+// session.
+func (m *model) readOnlyError(command string) error {
+	if m.readOnlyForced != "" {
+		return fmt.Errorf("%s: refused, this session is read-only (%s)", command, m.readOnlyForced)
+	}
+	return fmt.Errorf("%s: refused in read-only mode, use `set readonly off` to allow writes", command)
+}
@@ -0,0 +1,331 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+var recordStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("1"))
+
+// A recording is a text file with a command per line, each followed by a
+// summary of its result on a comment line starting with #, e.g.
+//
+//	# 09:12:44 in /shop/orders
+//	deletemany '{"status": "stale"}'
+//	#   1204 documents written
+//
+// so it can be read as the steps taken, edited, and replayed.
+
+// record starts recording the session to a file, e.g. `record session.log`,
+// or stops with `record stop`.
+func (m *model) record(args []string) (tea.Model, tea.Cmd) {
+	if len(args) == 0 {
+		if m.recordPath == "" {
+			m.result, m.err = message("not recording, use record <file> to start"), nil
+		} else {
+			m.result, m.err = message(fmt.Sprintf("recording to %s", m.recordPath)), nil
+		}
+		return m, nil
+	}
+	if len(args) != 1 {
+		m.err = fmt.Errorf("usage: record <file> | record stop")
+		return m, nil
+	}
+	if args[0] == "stop" {
+		if m.recordPath == "" {
+			m.err = fmt.Errorf("record stop: not recording")
+			return m, nil
+		}
+		m.result, m.err = message(fmt.Sprintf("stopped recording to %s", m.recordPath)), nil
+		m.recordPath = ""
+		return m, nil
+	}
+	if m.recordPath != "" {
+		m.err = fmt.Errorf("record: already recording to %s, use record stop first", m.recordPath)
+		return m, nil
+	}
+
+	// Replaying starts from where the recording did
+	start := "cd"
+	if len(m.currentPath) > 0 {
+		start = "cd /" + strings.Join(m.currentPath, "/")
+	}
+	from := m.profile
+	if from == "" {
+		from = redactURI(m.connectionString)
+	}
+	header := fmt.Sprintf("# mon-go session recorded %s on %s\n%s\n", time.Now().Format("2006-01-02 15:04:05 MST"), from, start)
+	if err := appendToFile(args[0], header); err != nil {
+		m.err = fmt.Errorf("record: %w", err)
+		return m, nil
+	}
+	m.recordPath = args[0]
+	m.result, m.err = message(fmt.Sprintf("recording to %s, use record stop to stop", m.recordPath)), nil
+	return m, nil
+}
+
+// recordCommand appends a finished command and a summary of its result to
+// the recording, if one is in progress. Like the audit log, a recording that
+// cannot be written to is reported below the result.
+func (m *model) recordCommand(command string, write bool, written int64, r result, err error) {
+	if m.recordPath == "" || strings.TrimSpace(command) == "" || strings.HasPrefix(command, "|") {
+		return // The pipeline part of a command is recorded with the command
+	}
+	switch strings.Fields(command)[0] {
+	case "record", "replay":
+		return
+	}
+	entry := fmt.Sprintf("# %s in /%s\n%s\n#   %s\n",
+		time.Now().Format("15:04:05"), strings.Join(m.currentPath, "/"), command, resultSummary(r, write, written, err))
+	if err := appendToFile(m.recordPath, entry); err != nil {
+		slog.Error("recording failed", "error", err)
+		m.warnings = append(m.warnings, fmt.Sprintf("record: %v", err))
+	}
+}
+
+// resultSummary describes the result of a command in a line.
+func resultSummary(r result, write bool, written int64, err error) string {
+	switch {
+	case err != nil:
+		return "error: " + firstLine(err.Error())
+	case write:
+		return fmt.Sprintf("%d documents written", written)
+	}
+	switch r := r.(type) {
+	case nil:
+		return "done"
+	case documentList:
+		return fmt.Sprintf("%d documents", len(r.docs))
+	case *documentTree:
+		return "1 document"
+	case valueList:
+		return fmt.Sprintf("%d values", len(r.values))
+	}
+	line := firstLine(r.String())
+	if runes := []rune(line); len(runes) > 100 {
+		line = string(runes[:100]) + "…"
+	}
+	return line
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+// appendToFile appends text to the file at path, which is created readable
+// only by the user.
+func appendToFile(path, text string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(text)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// replay is a recording being replayed.
+type replay struct {
+	path     string
+	commands []string
+	next     int // Index of the command to run next
+	run      int
+	skipped  int // Writes the user chose not to run
+	shell    int // Shell commands, which are not replayed
+}
+
+// replayStepMsg runs the next command of the replay once the previous one
+// has finished. next tells steps scheduled twice for the same command apart.
+type replayStepMsg struct {
+	next int
+}
+
+// replayResult is the result of the last command replayed followed by a
+// summary of the replay.
+type replayResult struct {
+	inner   result
+	summary string
+}
+
+func (r replayResult) String() string {
+	if r.inner == nil {
+		return r.summary + "\n"
+	}
+	return r.inner.String() + "\n" + r.summary + "\n"
+}
+
+// parseRecording returns the commands of a recording: its lines that are
+// neither blank nor comments.
+func parseRecording(data string) []string {
+	var commands []string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			commands = append(commands, line)
+		}
+	}
+	return commands
+}
+
+// prefixCommands run the command that follows them, which is what tells
+// whether they write.
+var prefixCommands = map[string]bool{"measure": true}
+
+// unwrapPrefix returns the command a prefix command such as measure runs,
+// or input itself.
+func unwrapPrefix(input string) string {
+	input = strings.TrimSpace(input)
+	for {
+		word, rest, _ := strings.Cut(input, " ")
+		if !prefixCommands[word] || strings.TrimSpace(rest) == "" {
+			return input
+		}
+		input = strings.TrimSpace(rest)
+	}
+}
+
+// inputWrites tells whether a command as typed may write: if it writes
+// itself, or through a prefix command, or if it hands its result to a
+// shell pipeline, which can do anything.
+func inputWrites(input string) bool {
+	input = unwrapPrefix(input)
+	if _, _, piped := splitPipeline(input); piped {
+		return true
+	}
+	parts, err := commands.SplitArgs(input)
+	if err != nil || len(parts) == 0 {
+		return false
+	}
+	args, dryRun := commands.StripFlag(parts[1:], "--dry-run")
+	return isMutating(parts[0], args) && !dryRun
+}
+
+// replayFile runs the commands of a recording one after the other, asking
+// before each one that may write. Shell commands, measured ones too, are
+// not run. It stops at the first command that fails.
+func (m *model) replayFile(args []string) (tea.Model, tea.Cmd) {
+	if len(args) != 1 {
+		m.err = fmt.Errorf("usage: replay <file>")
+		return m, nil
+	}
+	if m.replaying != nil {
+		m.err = fmt.Errorf("replay: already replaying %s", m.replaying.path)
+		return m, nil
+	}
+	if args[0] == m.recordPath {
+		m.err = fmt.Errorf("replay: %s is being recorded to, use record stop first", args[0])
+		return m, nil
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		m.err = fmt.Errorf("replay: %w", err)
+		return m, nil
+	}
+	commands := parseRecording(string(data))
+	if len(commands) == 0 {
+		m.err = fmt.Errorf("replay: no commands in %s", args[0])
+		return m, nil
+	}
+	m.replaying = &replay{path: args[0], commands: commands}
+	m.err = nil
+	return m, m.replayWhenIdle()
+}
+
+// replayWhenIdle schedules the next command of the replay unless a command
+// is still running or asking something; it is scheduled again once it is
+// done.
+func (m *model) replayWhenIdle() tea.Cmd {
+	r := m.replaying
+	if r == nil || m.running != nil || m.prompt != nil {
+		return nil
+	}
+	next := r.next
+	return func() tea.Msg { return replayStepMsg{next: next} }
+}
+
+// replayStep runs the next command of the replay, or asks first if it
+// writes.
+func (m *model) replayStep(msg replayStepMsg) tea.Cmd {
+	r := m.replaying
+	if r == nil || msg.next != r.next || m.running != nil || m.prompt != nil {
+		return nil
+	}
+	if m.err != nil {
+		m.replaying = nil
+		m.err = fmt.Errorf("replay stopped at command %d of %d, '%s': %w", r.next, len(r.commands), r.commands[r.next-1], m.err)
+		return nil
+	}
+	for r.next < len(r.commands) && strings.HasPrefix(unwrapPrefix(r.commands[r.next]), shellEscape) {
+		r.shell++
+		r.next++
+	}
+	if r.next == len(r.commands) {
+		m.replaying = nil
+		m.result = replayResult{inner: m.result, summary: r.summary()}
+		return nil
+	}
+
+	input := r.commands[r.next]
+	r.next++
+	if !inputWrites(input) {
+		return m.replayRun(input)
+	}
+	m.ask(fmt.Sprintf("replay %d/%d writes: %s, run it? [y]es/[s]kip/[q]uit: ", r.next, len(r.commands), input), false, func(answer string) tea.Cmd {
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return m.replayRun(input)
+		case "s", "skip":
+			r.skipped++
+			return m.replayWhenIdle()
+		default:
+			m.replaying = nil
+			m.result = message("replay stopped: " + r.summary())
+			return nil
+		}
+	})
+	return nil
+}
+
+// replayRun runs a command of the replay as if it were typed.
+func (m *model) replayRun(input string) tea.Cmd {
+	m.replaying.run++
+	m.err = nil
+	_, cmd := m.processCommand(input)
+	return tea.Batch(cmd, m.replayWhenIdle())
+}
+
+func (r *replay) summary() string {
+	s := fmt.Sprintf("replayed %d of %d commands from %s", r.run, len(r.commands), r.path)
+	if r.skipped > 0 {
+		s += fmt.Sprintf(", %d writes skipped", r.skipped)
+	}
+	if r.shell > 0 {
+		s += fmt.Sprintf(", %d shell commands not run", r.shell)
+	}
+	return s
+}
+
+// recordBanner tells that the session is being recorded or replayed.
+func (m *model) recordBanner() string {
+	var b strings.Builder
+	if m.recordPath != "" {
+		b.WriteString(recordStyle.Render(fmt.Sprintf("● recording to %s", m.recordPath)))
+		b.WriteString("\n")
+	}
+	if r := m.replaying; r != nil {
+		b.WriteString(recordStyle.Render(fmt.Sprintf("▶ replaying %s: command %d of %d, Esc stops", r.path, r.next, len(r.commands))))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.log")
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop")
+	run(t, m, "record "+path)
+	run(t, m, "cd orders")
+	run(t, m, "count")
+	run(t, m, `insert '{"item": "fig"}'`)
+	run(t, m, "!echo hi")
+	run(t, m, "record stop")
+	run(t, m, "count")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{"\ncd /shop\n", "\ncd orders\n", "\ncount\n", "#   1 documents written", "\n!echo hi\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("recording lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "\nrecord ") {
+		t.Errorf("record itself was recorded:\n%s", got)
+	}
+	if cmds := parseRecording(got); len(cmds) != 5 {
+		t.Fatalf("commands of the recording: %q", cmds)
+	}
+
+	m = newTestModel(seededFake())
+	run(t, m, "replay "+path)
+	if m.prompt == nil || !strings.Contains(m.prompt.label, "insert") {
+		t.Fatalf("replay did not ask before the insert, path %v", m.currentPath)
+	}
+	if strings.Join(m.currentPath, "/") != "shop/orders" {
+		t.Errorf("replay ran in %v", m.currentPath)
+	}
+	answer(t, m, "y")
+	if m.replaying != nil || m.prompt != nil {
+		t.Fatal("replay did not finish")
+	}
+	out := output(t, m)
+	if !strings.Contains(out, "replayed 4 of 5 commands") || !strings.Contains(out, "1 shell commands not run") {
+		t.Errorf("summary: %q", out)
+	}
+	run(t, m, "count")
+	if got := output(t, m); !strings.Contains(got, "4") {
+		t.Errorf("count after replaying the insert: %q", got)
+	}
+
+	m = newTestModel(seededFake())
+	run(t, m, "replay "+path)
+	answer(t, m, "s")
+	run(t, m, "count")
+	if got := output(t, m); !strings.Contains(got, "3") {
+		t.Errorf("count after skipping the insert: %q", got)
+	}
+
+	os.WriteFile(path, []byte("cd shop/orders\nfrobnicate\ncount\n"), 0o600)
+	m = newTestModel(seededFake())
+	expectError(t, m, "replay "+path, "replay stopped at command 2 of 3, 'frobnicate'")
+	if m.replaying != nil {
+		t.Error("replay went on after a failed command")
+	}
+}
+
+func TestInputWrites(t *testing.T) {
+	for input, want := range map[string]bool{
+		"count":                              false,
+		`insert '{"item": "fig"}'`:           true,
+		`insert '{"item": "fig"}' --dry-run`: false,
+		`measure insert '{"item": "fig"}'`:   true,
+		"measure count":                      false,
+		"measure":                            false,
+		"find | cat":                         true,
+		"measure find | tee out.json":        true,
+		`find '{"item": "a|b"}'`:             false,
+	} {
+		if got := inputWrites(input); got != want {
+			t.Errorf("inputWrites(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestReplayMeasuredAndPiped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.log")
+	os.WriteFile(path, []byte("cd shop/orders\nmeasure insert '{\"item\": \"fig\"}'\nmeasure !echo hi\ncount | cat\ncount\n"), 0o600)
+	m := newTestModel(seededFake())
+	run(t, m, "replay "+path)
+	if m.prompt == nil || !strings.Contains(m.prompt.label, "measure insert") {
+		t.Fatalf("replay did not ask before the measured insert")
+	}
+	answer(t, m, "y")
+	if m.prompt == nil || !strings.Contains(m.prompt.label, "count | cat") {
+		t.Fatalf("replay did not ask before the piped command")
+	}
+	answer(t, m, "s")
+	if m.replaying != nil || m.prompt != nil {
+		t.Fatal("replay did not finish")
+	}
+	out := output(t, m)
+	if !strings.Contains(out, "4 documents") || !strings.Contains(out, "replayed 3 of 5 commands") ||
+		!strings.Contains(out, "1 writes skipped") || !strings.Contains(out, "1 shell commands not run") {
+		t.Errorf("replay: %q", out)
+	}
+}
@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+)
+
+// save remembers the open tabs for the next start, keeping the rest of the
+// state as it is. Tabs that never connected are left out. Failing to save
+// is only logged, on the way out.
+func (w *workspace) save() {
+	saved := &config.Workspace{SavedAt: time.Now()}
+	for i, t := range w.tabs {
+		m := t.m
+		if m.store == nil {
+			continue // Never connected
+		}
+		if i == w.active {
+			saved.Active = len(saved.Tabs)
+		}
+		tab := config.WorkspaceTab{Profile: m.profile, Path: "/" + strings.Join(m.currentPath, "/")}
+		if tab.Profile == "" {
+			tab.URI = stripCredentials(m.connectionString) // Passwords stay in profiles, or are typed again
+		}
+		if last := m.restorableCommand(); last != "" {
+			tab.LastCommand, tab.LastResult = last, resultSummary(m.result, false, 0, m.err)
+		}
+		saved.Tabs = append(saved.Tabs, tab)
+	}
+
+	state, err := config.LoadState()
+	if err != nil {
+		slog.Warn("loading state failed", "error", err)
+		return
+	}
+	state.Workspace = saved
+	if err := config.SaveState(state); err != nil {
+		slog.Warn("saving state failed", "error", err)
+	}
+}
+
+// restorableCommand returns the last command, unless it quit or was the
+// pipeline half of one.
+func (m *model) restorableCommand() string {
+	fields := strings.Fields(m.lastInput)
+	if len(fields) == 0 || fields[0] == "exit" || fields[0] == "quit" || strings.HasPrefix(m.lastInput, "|") {
+		return ""
+	}
+	return m.lastInput
+}
+
+// restore opens the tabs saved when mon-go last quit, each back at its path
+// with its last command on the input line, and tells whether there were
+// any. Tabs whose profile is gone are left out.
+func (w *workspace) restore() bool {
+	state, err := config.LoadState()
+	if err != nil {
+		slog.Warn("loading state failed", "error", err)
+		return false
+	}
+	saved := state.Workspace
+	if saved == nil {
+		return false
+	}
+	var missing []string
+	for i, tab := range saved.Tabs {
+		c := connection{uri: tab.URI}
+		if tab.Profile != "" {
+			if c, err = resolveConnection(tab.Profile); err != nil {
+				slog.Warn("restoring tab failed", "profile", tab.Profile, "error", err)
+				missing = append(missing, tab.Profile)
+				continue
+			}
+		}
+		if i == saved.Active {
+			w.active = len(w.tabs)
+		}
+		m := w.newModel(c)
+		w.add(m)
+		if m.store == nil {
+			continue // Failed to connect, which the tab shows
+		}
+		m.restoreTab(tab, saved.SavedAt)
+	}
+	if len(w.tabs) == 0 {
+		return false
+	}
+	if len(missing) > 0 {
+		m := w.tabs[w.active].m
+		m.warnings = append(m.warnings, fmt.Sprintf("tabs of profiles that no longer exist were not restored: %s", strings.Join(missing, ", ")))
+	}
+	return true
+}
+
+// restoreTab goes back to a saved tab's path and puts its last command on
+// the input line.
+func (m *model) restoreTab(tab config.WorkspaceTab, savedAt time.Time) {
+	m.returnTo(tab.Path)
+	text := fmt.Sprintf("restored from the session of %s", savedAt.Format("2006-01-02 15:04"))
+	if tab.LastCommand != "" {
+		text += fmt.Sprintf("\nlast command: %s (%s), press Enter to run it again", tab.LastCommand, tab.LastResult)
+		m.textInput.SetValue(tab.LastCommand)
+		m.textInput.CursorEnd()
+	}
+	m.result = message(text)
+}
+
+// returnTo goes to a path saved in an earlier session, unless the
+// namespaces config hides it now. Whether it still exists is left to the
+// commands run there, so starting does not wait for the server.
+func (m *model) returnTo(target string) bool {
+	path := m.resolvePath(target)
+	if len(path) == 0 || !m.namespaces.visible(path[0], pathElement(path, 1)) {
+		return false
+	}
+	m.currentPath = path
+	m.rememberPath(path)
+	return true
+}
+
+// returnToLastPath goes back to the path the profile was last at.
+func (m *model) returnToLastPath() {
+	state, err := config.LoadState()
+	if err != nil {
+		slog.Warn("loading state failed", "error", err)
+		return
+	}
+	if path := state.LastPaths[m.profile]; path != "" && m.returnTo(path) {
+		m.result = message(fmt.Sprintf("back in %s, where %s was last used", path, m.profile))
+	}
+}
+
+// rememberPaths saves the path of each tab whose profile has rememberPath,
+// that of the tab shown winning over other tabs of the same profile.
+func (w *workspace) rememberPaths() {
+	paths := map[string]string{}
+	for i := range w.tabs {
+		t := w.tabs[(w.active+1+i)%len(w.tabs)] // The tab shown comes last
+		if t.m.rememberLastPath && t.m.store != nil {
+			paths[t.m.profile] = "/" + strings.Join(t.m.currentPath, "/")
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	state, err := config.LoadState()
+	if err != nil {
+		slog.Warn("loading state failed", "error", err)
+		return
+	}
+	if state.LastPaths == nil {
+		state.LastPaths = map[string]string{}
+	}
+	for profile, path := range paths {
+		state.LastPaths[profile] = path
+	}
+	if err := config.SaveState(state); err != nil {
+		slog.Warn("saving state failed", "error", err)
+	}
+}
+
+func pathElement(path []string, i int) string {
+	if i < len(path) {
+		return path[i]
+	}
+	return ""
+}
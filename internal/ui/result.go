@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const truncatedNotice = "... (results truncated)\n"
+
+// result is the typed payload of a Mongo operation. Results are kept as data
+// rather than preformatted text so the same result can be rendered in
+// different views, exported or post-processed without re-querying.
+type result interface {
+	String() string
+}
+
+// nameList is a list of database or collection names.
+type nameList struct {
+	names     []string
+	truncated bool
+	kinds     map[string]string // Type of names that are not plain collections, e.g. "view"
+}
+
+// newNameList builds a nameList holding at most limit names (-1 for no limit).
+func newNameList(names []string, limit int) nameList {
+	if limit != -1 && len(names) > limit {
+		return nameList{names: names[:limit], truncated: true}
+	}
+	return nameList{names: names}
+}
+
+func (l nameList) String() string {
+	var b strings.Builder
+	for _, name := range l.names {
+		if kind, ok := l.kinds[name]; ok {
+			b.WriteString(fmt.Sprintf("%s  [%s]\n", name, kind))
+		} else {
+			b.WriteString(fmt.Sprintf("%s\n", name))
+		}
+	}
+	if l.truncated {
+		b.WriteString(truncatedNotice)
+	}
+	return b.String()
+}
+
+// documentList is a list of documents read from a collection.
+type documentList struct {
+	docs      []bson.M
+	truncated bool
+	page      string // Position in a paged result set, shown instead of truncatedNotice
+}
+
+func (l documentList) String() string {
+	var b strings.Builder
+	for _, doc := range l.docs {
+		b.WriteString(fmt.Sprintf("%v\n", doc))
+	}
+	b.WriteString(l.footer())
+	return b.String()
+}
+
+// footer tells whether there are more documents than shown.
+func (l documentList) footer() string {
+	switch {
+	case l.page != "":
+		return l.page + "\n"
+	case l.truncated:
+		return truncatedNotice
+	default:
+		return ""
+	}
+}
+
+// statField is a single named value of a statsResult.
+type statField struct {
+	name  string
+	value interface{}
+}
+
+// statsResult is an ordered set of named values, such as the output of a
+// server or collection statistics command.
+type statsResult struct {
+	fields []statField
+}
+
+func (s statsResult) String() string {
+	width := 0
+	for _, f := range s.fields {
+		if len(f.name) > width {
+			width = len(f.name)
+		}
+	}
+
+	var b strings.Builder
+	for _, f := range s.fields {
+		b.WriteString(fmt.Sprintf("%-*s  %v\n", width, f.name, f.value))
+	}
+	return b.String()
+}
+
+// message is a short confirmation of a command that returns no data.
+type message string
+
+func (msg message) String() string {
+	return string(msg) + "\n"
+}
+
+var timingStyle = lipgloss.NewStyle().Faint(true)
+
+// timingLine says how long the command that produced r took, e.g.
+// "12 documents in 42ms".
+func timingLine(r result, elapsed time.Duration) string {
+	took := formatElapsed(elapsed)
+	if docs, ok := r.(documentList); ok {
+		return fmt.Sprintf("%d documents in %s", len(docs.docs), took)
+	}
+	return "took " + took
+}
+
+// formatElapsed rounds d to what is worth reading: milliseconds below a
+// second, hundredths of a second below a minute, seconds above.
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return "<1ms"
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return d.Round(10 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
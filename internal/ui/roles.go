@@ -0,0 +1,184 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// privilege is a set of actions allowed on a resource.
+type privilege struct {
+	Resource bson.M   `bson:"resource"`
+	Actions  []string `bson:"actions"`
+}
+
+// resourceName renders a privilege resource as db.collection, using * for
+// "any" the way the MongoDB docs describe resources.
+func (p privilege) resourceName() string {
+	if cluster, _ := p.Resource["cluster"].(bool); cluster {
+		return "cluster"
+	}
+	if anyResource, _ := p.Resource["anyResource"].(bool); anyResource {
+		return "any resource"
+	}
+	db, _ := p.Resource["db"].(string)
+	coll, _ := p.Resource["collection"].(string)
+	if db == "" {
+		db = "*"
+	}
+	if coll == "" {
+		coll = "*"
+	}
+	return db + "." + coll
+}
+
+// roleInfo is the subset of a rolesInfo entry that mon-go displays.
+type roleInfo struct {
+	Role                string      `bson:"role"`
+	DB                  string      `bson:"db"`
+	IsBuiltin           bool        `bson:"isBuiltin"`
+	Roles               []roleRef   `bson:"roles"`
+	Privileges          []privilege `bson:"privileges"`
+	InheritedPrivileges []privilege `bson:"inheritedPrivileges"`
+}
+
+func (r roleInfo) inherits() string {
+	names := make([]string, len(r.Roles))
+	for i, ref := range r.Roles {
+		names[i] = ref.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// roleList is the result of `role ls`.
+type roleList struct {
+	roles []roleInfo
+}
+
+func (l roleList) String() string {
+	if len(l.roles) == 0 {
+		return "no roles\n"
+	}
+
+	width := 0
+	for _, r := range l.roles {
+		if n := len(r.Role) + 1 + len(r.DB); n > width {
+			width = n
+		}
+	}
+
+	var b strings.Builder
+	for _, r := range l.roles {
+		kind := "custom "
+		if r.IsBuiltin {
+			kind = "builtin"
+		}
+		b.WriteString(fmt.Sprintf("%-*s  %s  %s\n", width, r.Role+"@"+r.DB, kind, r.inherits()))
+	}
+	return b.String()
+}
+
+// roleDetail is the result of `role show`: a role and the privileges it
+// grants, including those inherited from other roles.
+type roleDetail struct {
+	role roleInfo
+}
+
+func (d roleDetail) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s@%s\n", d.role.Role, d.role.DB))
+	if inherits := d.role.inherits(); inherits != "" {
+		b.WriteString(fmt.Sprintf("inherits: %s\n", inherits))
+	}
+
+	privileges := d.role.InheritedPrivileges
+	if privileges == nil {
+		privileges = d.role.Privileges
+	}
+	if len(privileges) == 0 {
+		b.WriteString("no privileges\n")
+		return b.String()
+	}
+
+	width := 0
+	for _, p := range privileges {
+		if n := len(p.resourceName()); n > width {
+			width = n
+		}
+	}
+	b.WriteString("privileges:\n")
+	for _, p := range privileges {
+		b.WriteString(fmt.Sprintf("  %-*s  %s\n", width, p.resourceName(), strings.Join(p.Actions, ", ")))
+	}
+	return b.String()
+}
+
+func (m *model) role(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: role ls [--builtin] | show <name[@db]>"
+	if len(args) == 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	db := m.authDatabase()
+	switch args[0] {
+	case "ls":
+		builtin := len(args) > 1 && args[1] == "--builtin"
+		return m, m.listRoles(db, builtin)
+
+	case "show":
+		if len(args) != 2 {
+			m.err = fmt.Errorf("usage: role show <name[@db]>")
+			return m, nil
+		}
+		name, roleDB, found := strings.Cut(args[1], "@")
+		if !found {
+			roleDB = db
+		}
+		return m, m.showRole(name, roleDB)
+
+	default:
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+}
+
+// listRoles lists the custom roles of db, and the builtin ones if asked to.
+func (m *model) listRoles(db string, builtin bool) tea.Cmd {
+	return m.run(func(ctx context.Context) tea.Msg {
+		cmd := bson.D{{Key: "rolesInfo", Value: 1}, {Key: "showBuiltinRoles", Value: builtin}}
+
+		var res struct {
+			Roles []roleInfo `bson:"roles"`
+		}
+		if err := m.store.RunCommand(ctx, db, cmd, &res); err != nil {
+			return mongoMsg{err: err}
+		}
+		return mongoMsg{result: roleList{roles: res.Roles}}
+	})
+}
+
+// showRole shows the privileges granted by role name defined on db.
+func (m *model) showRole(name, db string) tea.Cmd {
+	return m.run(func(ctx context.Context) tea.Msg {
+		cmd := bson.D{
+			{Key: "rolesInfo", Value: bson.D{{Key: "role", Value: name}, {Key: "db", Value: db}}},
+			{Key: "showPrivileges", Value: true},
+			{Key: "showBuiltinRoles", Value: true},
+		}
+
+		var res struct {
+			Roles []roleInfo `bson:"roles"`
+		}
+		if err := m.store.RunCommand(ctx, db, cmd, &res); err != nil {
+			return mongoMsg{err: err}
+		}
+		if len(res.Roles) == 0 {
+			return mongoMsg{err: fmt.Errorf("role '%s' does not exist in database '%s'", name, db)}
+		}
+		return mongoMsg{result: roleDetail{role: res.Roles[0]}}
+	})
+}
@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+// validatorInfo is a collection's document validation configuration.
+type validatorInfo struct {
+	Validator        bson.D `bson:"validator"`
+	ValidationLevel  string `bson:"validationLevel"`
+	ValidationAction string `bson:"validationAction"`
+}
+
+// schemaResult is the result of `schema show`.
+type schemaResult struct {
+	collection string
+	validator  validatorInfo
+}
+
+func (s schemaResult) String() string {
+	if len(s.validator.Validator) == 0 {
+		return fmt.Sprintf("%s has no validator\n", s.collection)
+	}
+
+	level, action := s.validator.ValidationLevel, s.validator.ValidationAction
+	if level == "" {
+		level = "strict"
+	}
+	if action == "" {
+		action = "error"
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("validationLevel: %s\nvalidationAction: %s\n\n", level, action))
+	text, err := prettyJSON(s.validator.Validator)
+	if err != nil {
+		b.WriteString(fmt.Sprintf("%v\n", s.validator.Validator))
+		return b.String()
+	}
+	b.WriteString(highlightJSON(text))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// fetchValidator reads the validation options of collection coll in db.
+func fetchValidator(ctx context.Context, st store.Store, db, coll string) (validatorInfo, error) {
+	cur, err := st.ListCollections(ctx, db, bson.M{"name": coll})
+	if err != nil {
+		return validatorInfo{}, err
+	}
+	defer cur.Close(ctx)
+
+	if !cur.Next(ctx) {
+		if err := cur.Err(); err != nil {
+			return validatorInfo{}, err
+		}
+		return validatorInfo{}, fmt.Errorf("collection '%s' does not exist in database '%s'", coll, db)
+	}
+	var spec struct {
+		Options validatorInfo `bson:"options"`
+	}
+	if err := cur.Decode(&spec); err != nil {
+		return validatorInfo{}, err
+	}
+	return spec.Options, nil
+}
+
+func (m *model) schema(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: schema show | set [--level off|moderate|strict] [--action error|warn]"
+	if len(args) == 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	db, coll, err := m.collectionPath("schema")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	switch args[0] {
+	case "show":
+		return m, m.run(func(ctx context.Context) tea.Msg {
+			info, err := fetchValidator(ctx, m.store, db, coll)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			return mongoMsg{result: schemaResult{collection: coll, validator: info}}
+		})
+
+	case "set":
+		fs := commands.NewFlagSet("schema set")
+		level := fs.String("level", "", "validationLevel: off, moderate or strict")
+		action := fs.String("action", "", "validationAction: error or warn")
+		if positional, err := commands.ParseFlags(fs, args[1:]); err != nil || len(positional) > 0 {
+			m.err = fmt.Errorf(usage)
+			return m, nil
+		}
+		switch *level {
+		case "", "off", "moderate", "strict":
+		default:
+			m.err = fmt.Errorf("schema set: --level must be off, moderate or strict")
+			return m, nil
+		}
+		switch *action {
+		case "", "error", "warn":
+		default:
+			m.err = fmt.Errorf("schema set: --action must be error or warn")
+			return m, nil
+		}
+		return m, m.editSchema(db, coll, *level, *action)
+
+	default:
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+}
+
+// editSchema opens the validator of db.coll in the user's editor and applies
+// the edited version, along with level and action if set, using collMod.
+func (m *model) editSchema(dbName, collName, level, action string) tea.Cmd {
+	return m.run(func(ctx context.Context) tea.Msg {
+		info, err := fetchValidator(ctx, m.store, dbName, collName)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+
+		validator := info.Validator
+		if len(validator) == 0 {
+			validator = bson.D{{Key: "$jsonSchema", Value: bson.D{{Key: "bsonType", Value: "object"}}}}
+		}
+		text, err := prettyJSON(validator)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		original := []byte(text + "\n")
+
+		return editRequestMsg{content: original, onDone: func(edited []byte) tea.Cmd {
+			if bytes.Equal(bytes.TrimSpace(edited), bytes.TrimSpace(original)) && level == "" && action == "" {
+				return func() tea.Msg { return mongoMsg{result: message("schema unchanged")} }
+			}
+
+			var newValidator bson.D
+			if err := bson.UnmarshalExtJSON(edited, false, &newValidator); err != nil {
+				return func() tea.Msg { return mongoMsg{err: fmt.Errorf("schema set: invalid validator: %w", err)} }
+			}
+			cmd := bson.D{{Key: "collMod", Value: collName}, {Key: "validator", Value: newValidator}}
+			if level != "" {
+				cmd = append(cmd, bson.E{Key: "validationLevel", Value: level})
+			}
+			if action != "" {
+				cmd = append(cmd, bson.E{Key: "validationAction", Value: action})
+			}
+			return m.run(func(ctx context.Context) tea.Msg {
+				if err := m.store.RunCommand(ctx, dbName, cmd, nil); err != nil {
+					return mongoMsg{err: err}
+				}
+				return mongoMsg{result: message(fmt.Sprintf("validator of %s.%s updated", dbName, collName))}
+			})
+		}}
+	})
+}
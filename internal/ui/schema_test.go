@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runEditing runs input like run, answering the editor it opens with what
+// edit makes of the content instead of starting one.
+func runEditing(t *testing.T, m *model, input string, edit func(content string) string) {
+	t.Helper()
+	_, cmd := m.processCommand(input)
+	drainEditing(m, cmd, edit)
+	if m.running != nil {
+		t.Fatalf("%s: still running after its commands finished", input)
+	}
+}
+
+func drainEditing(m *model, cmd tea.Cmd, edit func(content string) string) {
+	if cmd == nil {
+		return
+	}
+	switch msg := cmd().(type) {
+	case nil, spinner.TickMsg:
+	case tea.BatchMsg:
+		for _, c := range msg {
+			drainEditing(m, c, edit)
+		}
+	case opDoneMsg:
+		if req, ok := msg.msg.(editRequestMsg); ok {
+			msg.msg = editDoneMsg{edited: []byte(edit(string(req.content))), onDone: req.onDone}
+		}
+		_, next := m.Update(msg)
+		drainEditing(m, next, edit)
+	default:
+		_, next := m.Update(msg)
+		drainEditing(m, next, edit)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+
+	run(t, m, "schema show")
+	if got := output(t, m); !strings.Contains(got, "no validator") {
+		t.Errorf("schema show without a validator: %q", got)
+	}
+
+	var original string
+	runEditing(t, m, "schema set --level moderate", func(content string) string {
+		original = content
+		return `{"$jsonSchema": {"required": ["item"]}}`
+	})
+	if !strings.Contains(original, `"bsonType": "object"`) {
+		t.Errorf("schema set starts from %q", original)
+	}
+	if got := output(t, m); got != "validator of shop.orders updated\n" {
+		t.Errorf("schema set: %q", got)
+	}
+	run(t, m, "schema show")
+	got := output(t, m)
+	for _, want := range []string{"validationLevel: moderate", `"item"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("schema show after set: %q, want %q", got, want)
+		}
+	}
+
+	runEditing(t, m, "schema set", func(content string) string { return content })
+	if got := output(t, m); got != "schema unchanged\n" {
+		t.Errorf("schema set without changes: %q", got)
+	}
+	expectError(t, m, "schema set --level lax", "--level must be off, moderate or strict")
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+const (
+	defaultSearchLimit = 20
+	searchScoreField   = "_score" // Unlikely to shadow a field of the documents
+)
+
+// textIndex finds the text index among a collection's index specs, as
+// listIndexes returns them, and the fields it covers. A collection has at
+// most one.
+func textIndex(specs []bson.M) (name string, fields []string, ok bool) {
+	for _, spec := range specs {
+		weights, isText := spec["weights"].(bson.M)
+		if !isText {
+			continue
+		}
+		name, _ = spec["name"].(string)
+		for field := range weights {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		return name, fields, true
+	}
+	return "", nil, false
+}
+
+// textSearch builds the $text filter of a search, whose terms are ORed
+// unless quoted as a phrase or negated with a leading -.
+func textSearch(terms, language string) bson.D {
+	search := bson.D{{Key: "$search", Value: terms}}
+	if language != "" {
+		search = append(search, bson.E{Key: "$language", Value: language})
+	}
+	return bson.D{{Key: "$text", Value: search}}
+}
+
+// search runs a full-text search of the current collection through its text
+// index, best matches first.
+func (m *model) search(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: search <terms...> [--limit N] [--language L]"
+
+	db, coll, err := m.collectionPath("search")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	fs := commands.NewFlagSet("search")
+	limit := fs.Int64("limit", defaultSearchLimit, "most documents to show")
+	language := fs.String("language", "", "language of the terms, for stemming and stop words, if not the index's")
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) == 0 || *limit <= 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	filter := textSearch(strings.Join(positional, " "), *language)
+	score := bson.D{{Key: searchScoreField, Value: bson.D{{Key: "$meta", Value: "textScore"}}}}
+	findOptions := options.Find().SetProjection(score).SetSort(score).SetLimit(*limit)
+
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		indexes, err := m.store.ListIndexes(ctx, db, coll)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		var specs []bson.M
+		if err := indexes.All(ctx, &specs); err != nil {
+			return mongoMsg{err: err}
+		}
+		name, fields, ok := textIndex(specs)
+		if !ok {
+			return mongoMsg{err: fmt.Errorf("search: %s.%s has no text index; create one with e.g. index create '{\"title\": \"text\", \"body\": \"text\"}'", db, coll)}
+		}
+
+		cur, err := m.store.Find(ctx, db, coll, filter, findOptions)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		defer cur.Close(ctx)
+		var docs documentList
+		if err := cur.All(ctx, &docs.docs); err != nil {
+			return mongoMsg{err: err}
+		}
+		if len(docs.docs) == 0 {
+			return mongoMsg{result: message(fmt.Sprintf("no document matches (text index %s on %s)", name, strings.Join(fields, ", ")))}
+		}
+		return mongoMsg{result: docs}
+	})
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestTextIndex(t *testing.T) {
+	specs := []bson.M{
+		{"name": "_id_", "key": bson.M{"_id": int32(1)}},
+		{"name": "title_text_body_text", "key": bson.M{"_fts": "text", "_ftsx": int32(1)}, "weights": bson.M{"title": int32(1), "body": int32(1)}},
+	}
+	name, fields, ok := textIndex(specs)
+	if !ok || name != "title_text_body_text" || !reflect.DeepEqual(fields, []string{"body", "title"}) {
+		t.Errorf("textIndex = %s, %v, %v", name, fields, ok)
+	}
+	if _, _, ok := textIndex(specs[:1]); ok {
+		t.Error("found a text index among plain ones")
+	}
+}
+
+func TestTextSearch(t *testing.T) {
+	got := textSearch("error timeout", "")
+	want := bson.D{{Key: "$text", Value: bson.D{{Key: "$search", Value: "error timeout"}}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("textSearch = %v", got)
+	}
+	if got := textSearch("fehler", "german"); len(got[0].Value.(bson.D)) != 2 {
+		t.Errorf("textSearch with a language = %v", got)
+	}
+}
+
+func TestSearchArgs(t *testing.T) {
+	m := newTestModel(seededFake())
+	expectError(t, m, "search error", "cd into a collection first")
+	run(t, m, "cd shop/orders")
+	expectError(t, m, "search", "usage: search")
+	expectError(t, m, "search error --limit 0", "usage: search")
+}
+
+func TestSearch(t *testing.T) {
+	fake := seededFake()
+	m := newTestModel(fake)
+	run(t, m, "cd shop/orders")
+	expectError(t, m, "search apple", "shop.orders has no text index")
+
+	run(t, m, "index create '{\"item\": \"text\"}'")
+	run(t, m, "insert '{\"item\": \"apple apple pie\"}'")
+	run(t, m, "search Apple")
+	if got := docCount(t, m); got != 2 {
+		t.Fatalf("search: %d documents", got)
+	}
+	if got := m.result.(documentList).docs[0]["item"]; got != "apple apple pie" {
+		t.Errorf("search ranks %v first", got)
+	}
+	run(t, m, "search banana")
+	if got := output(t, m); got != "no document matches (text index item_text on item)\n" {
+		t.Errorf("search without matches: %q", got)
+	}
+}
@@ -0,0 +1,218 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+// searchIndexTemplates are the definitions the editor opens with when
+// `searchindex create` is given none.
+var searchIndexTemplates = map[string]string{
+	"search": `{
+  "mappings": {
+    "dynamic": true
+  }
+}
+`,
+	"vectorSearch": `{
+  "fields": [
+    {"type": "vector", "path": "embedding", "numDimensions": 1536, "similarity": "cosine"},
+    {"type": "filter", "path": "category"}
+  ]
+}
+`,
+}
+
+// searchIndex is a search index as $listSearchIndexes describes it.
+type searchIndex struct {
+	Name         string `bson:"name"`
+	Type         string `bson:"type"`
+	Status       string `bson:"status"`
+	Queryable    bool   `bson:"queryable"`
+	Definition   bson.D `bson:"latestDefinition"`
+	StatusDetail []struct {
+		Hostname  string `bson:"hostname"`
+		Status    string `bson:"status"`
+		Queryable bool   `bson:"queryable"`
+	} `bson:"statusDetail"`
+}
+
+// searchIndexList is the result of `searchindex ls`.
+type searchIndexList []searchIndex
+
+func (l searchIndexList) String() string {
+	if len(l) == 0 {
+		return "no search indexes\n"
+	}
+	width := len("name")
+	for _, ix := range l {
+		width = max(width, len(ix.Name))
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%-*s  %-12s  %-12s  %s\n", width, "name", "type", "status", "queryable"))
+	for _, ix := range l {
+		b.WriteString(fmt.Sprintf("%-*s  %-12s  %-12s  %s\n", width, ix.Name, ix.Type, ix.Status, yesOrNo(ix.Queryable)))
+	}
+	return b.String()
+}
+
+// searchIndexStatus is the result of `searchindex status`.
+type searchIndexStatus searchIndex
+
+func (s searchIndexStatus) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s (%s): %s, queryable: %s\n", s.Name, s.Type, s.Status, yesOrNo(s.Queryable)))
+	for _, host := range s.StatusDetail {
+		b.WriteString(fmt.Sprintf("  %s  %s, queryable: %s\n", host.Hostname, host.Status, yesOrNo(host.Queryable)))
+	}
+	if s.Definition != nil {
+		b.WriteString("definition: " + extJSON(s.Definition) + "\n")
+	}
+	return b.String()
+}
+
+func yesOrNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// listSearchIndexes returns the search indexes of db.coll, or the one named
+// name if it is not empty.
+func listSearchIndexes(ctx context.Context, st store.Store, db, coll, name string) ([]searchIndex, error) {
+	cur, err := st.ListSearchIndexes(ctx, db, coll, name)
+	if err != nil {
+		return nil, err
+	}
+	var indexes []searchIndex
+	if err := cur.All(ctx, &indexes); err != nil {
+		return nil, err
+	}
+	return indexes, nil
+}
+
+// searchindex manages the Atlas Search and Vector Search indexes of the
+// current collection.
+func (m *model) searchindex(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: searchindex ls | status <name> | create <name> ['<definition>'] [--type search|vectorSearch] | drop <name>"
+
+	db, coll, err := m.collectionPath("searchindex")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	if len(args) == 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	switch args[0] {
+	case "ls":
+		if len(args) != 1 {
+			m.err = fmt.Errorf(usage)
+			return m, nil
+		}
+		return m, m.run(func(ctx context.Context) tea.Msg {
+			indexes, err := listSearchIndexes(ctx, m.store, db, coll, "")
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			return mongoMsg{result: searchIndexList(indexes)}
+		})
+
+	case "status":
+		if len(args) != 2 {
+			m.err = fmt.Errorf(usage)
+			return m, nil
+		}
+		name := args[1]
+		return m, m.run(func(ctx context.Context) tea.Msg {
+			indexes, err := listSearchIndexes(ctx, m.store, db, coll, name)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			if len(indexes) == 0 {
+				return mongoMsg{err: fmt.Errorf("searchindex: no search index named %s on %s.%s", name, db, coll)}
+			}
+			return mongoMsg{result: searchIndexStatus(indexes[0])}
+		})
+
+	case "create":
+		fs := commands.NewFlagSet("searchindex create")
+		kind := fs.String("type", "search", "search for Atlas Search, vectorSearch for Atlas Vector Search")
+		positional, err := commands.ParseFlags(fs, args[1:])
+		if err != nil || len(positional) < 1 || len(positional) > 2 {
+			m.err = fmt.Errorf(usage)
+			return m, nil
+		}
+		template, ok := searchIndexTemplates[*kind]
+		if !ok {
+			m.err = fmt.Errorf("searchindex create: --type must be search or vectorSearch")
+			return m, nil
+		}
+		name, indexType := positional[0], *kind
+		create := func(text string) tea.Cmd {
+			definition, err := commands.ParseDocument(text)
+			if err != nil {
+				m.err = fmt.Errorf("searchindex create: invalid definition: %w", err)
+				return nil
+			}
+			model := mongo.SearchIndexModel{Definition: definition, Options: options.SearchIndexes().SetName(name).SetType(indexType)}
+			return m.run(func(ctx context.Context) tea.Msg {
+				if _, err := m.store.CreateSearchIndex(ctx, db, coll, model); err != nil {
+					return mongoMsg{err: err}
+				}
+				return mongoMsg{result: message(fmt.Sprintf("building search index %s on %s.%s; follow it with searchindex status %s", name, db, coll, name))}
+			})
+		}
+		if len(positional) == 2 {
+			return m, create(positional[1])
+		}
+		return m, m.edit([]byte(template), func(edited []byte) tea.Cmd {
+			return create(string(edited))
+		})
+
+	case "drop":
+		if len(args) != 2 {
+			m.err = fmt.Errorf(usage)
+			return m, nil
+		}
+		name := args[1]
+		return m, m.run(func(ctx context.Context) tea.Msg {
+			indexes, err := listSearchIndexes(ctx, m.store, db, coll, name)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			if len(indexes) == 0 {
+				return mongoMsg{err: fmt.Errorf("searchindex: no search index named %s on %s.%s", name, db, coll)}
+			}
+			return confirmMsg{
+				result:   searchIndexStatus(indexes[0]),
+				question: fmt.Sprintf("drop search index %s? Queries using it fail until it is rebuilt [y/N] ", name),
+				onYes: func() tea.Cmd {
+					return m.run(func(ctx context.Context) tea.Msg {
+						if err := m.store.DropSearchIndex(ctx, db, coll, name); err != nil {
+							return mongoMsg{err: err}
+						}
+						return mongoMsg{result: message(fmt.Sprintf("dropping search index %s", name))}
+					})
+				},
+				declined: "search index not dropped",
+			}
+		})
+
+	default:
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+func TestSearchIndexTemplatesParse(t *testing.T) {
+	for kind, template := range searchIndexTemplates {
+		if _, err := commands.ParseDocument(template); err != nil {
+			t.Errorf("%s template: %v", kind, err)
+		}
+	}
+}
+
+func TestSearchIndexResults(t *testing.T) {
+	var ix searchIndex
+	raw := bson.D{
+		{Key: "name", Value: "default"}, {Key: "type", Value: "search"}, {Key: "status", Value: "BUILDING"}, {Key: "queryable", Value: false},
+		{Key: "latestDefinition", Value: bson.D{{Key: "mappings", Value: bson.D{{Key: "dynamic", Value: true}}}}},
+		{Key: "statusDetail", Value: bson.A{bson.D{{Key: "hostname", Value: "shard-00-01"}, {Key: "status", Value: "BUILDING"}, {Key: "queryable", Value: false}}}},
+	}
+	data, _ := bson.Marshal(raw)
+	if err := bson.Unmarshal(data, &ix); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := (searchIndexList{ix}).String(); !strings.Contains(got, "default  search        BUILDING      no") {
+		t.Errorf("ls:\n%s", got)
+	}
+	got := searchIndexStatus(ix).String()
+	for _, want := range []string{"default (search): BUILDING, queryable: no", "shard-00-01  BUILDING", `"dynamic":true`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("status lacks %q:\n%s", want, got)
+		}
+	}
+	if got := (searchIndexList{}).String(); got != "no search indexes\n" {
+		t.Errorf("empty ls: %q", got)
+	}
+}
+
+func TestSearchIndexArgs(t *testing.T) {
+	m := newTestModel(seededFake())
+	expectError(t, m, "searchindex ls", "cd into a collection first")
+	run(t, m, "cd shop/orders")
+	expectError(t, m, "searchindex", "usage: searchindex")
+	expectError(t, m, "searchindex drop", "usage: searchindex")
+	expectError(t, m, "searchindex create vec --type lucene", "--type must be search or vectorSearch")
+	m.readOnly = true
+	expectError(t, m, "searchindex drop default", "read-only")
+}
+
+func TestSearchIndexes(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+
+	run(t, m, `searchindex create default '{"mappings": {"dynamic": true}}'`)
+	if got := output(t, m); got != "building search index default on shop.orders; follow it with searchindex status default\n" {
+		t.Errorf("searchindex create: %q", got)
+	}
+	expectError(t, m, `searchindex create default '{"mappings": {"dynamic": true}}'`, "already exists")
+	run(t, m, `searchindex create vectors '{"fields": [{"type": "vector", "path": "embedding", "numDimensions": 3, "similarity": "cosine"}]}' --type vectorSearch`)
+
+	run(t, m, "searchindex ls")
+	got := output(t, m)
+	for _, want := range []string{"default  search", "vectors  vectorSearch"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("searchindex ls: %q, want %q", got, want)
+		}
+	}
+	run(t, m, "searchindex status default")
+	if got := output(t, m); !strings.Contains(got, "default (search): READY, queryable: yes") {
+		t.Errorf("searchindex status: %q", got)
+	}
+
+	run(t, m, "searchindex drop default")
+	answer(t, m, "y")
+	if got := output(t, m); got != "dropping search index default\n" {
+		t.Errorf("searchindex drop: %q", got)
+	}
+	expectError(t, m, "searchindex status default", "no search index named default on shop.orders")
+}
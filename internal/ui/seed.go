@@ -0,0 +1,345 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+// seedDryRunExamples is how many documents `seed --dry-run` shows.
+const seedDryRunExamples = 3
+
+var (
+	seedFirstNames = []string{"Ada", "Alan", "Grace", "Linus", "Margaret", "Ken", "Barbara", "Dennis", "Frances", "Edsger", "Radia", "Tim", "Hedy", "John", "Katherine", "Niklaus"}
+	seedLastNames  = []string{"Lovelace", "Turing", "Hopper", "Torvalds", "Hamilton", "Thompson", "Liskov", "Ritchie", "Allen", "Dijkstra", "Perlman", "Berners-Lee", "Lamarr", "McCarthy", "Johnson", "Wirth"}
+	seedCities     = []string{"Lisbon", "Osaka", "Nairobi", "Toronto", "Lyon", "Austin", "Kraków", "Melbourne", "Recife", "Tallinn", "Pune", "Bergen"}
+	seedCountries  = []string{"Portugal", "Japan", "Kenya", "Canada", "France", "United States", "Poland", "Australia", "Brazil", "Estonia", "India", "Norway"}
+	seedCompanies  = []string{"Acme", "Globex", "Initech", "Umbrella", "Hooli", "Stark Industries", "Wayne Enterprises", "Vandelay Industries", "Soylent", "Tyrell"}
+	seedDomains    = []string{"example.com", "example.org", "example.net"}
+	seedWords      = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do", "eiusmod", "tempor", "incididunt", "ut", "labore", "et", "dolore", "magna", "aliqua"}
+)
+
+// seedValue generates a value for each seeded document.
+type seedValue func(rng *rand.Rand, n int) interface{}
+
+// seedGenerators make generators from the words of a placeholder after its
+// name, e.g. {{int 1 100}}. n counts the documents generated, from 1.
+var seedGenerators = map[string]func(args []string) (seedValue, error){
+	"name": noArgs(func(rng *rand.Rand) interface{} {
+		return randomWord(rng, seedFirstNames) + " " + randomWord(rng, seedLastNames)
+	}),
+	"first_name": noArgs(func(rng *rand.Rand) interface{} { return randomWord(rng, seedFirstNames) }),
+	"last_name":  noArgs(func(rng *rand.Rand) interface{} { return randomWord(rng, seedLastNames) }),
+	"email": noArgs(func(rng *rand.Rand) interface{} {
+		return fmt.Sprintf("%s.%s%d@%s", strings.ToLower(randomWord(rng, seedFirstNames)), strings.ToLower(randomWord(rng, seedLastNames)), rng.Intn(100), randomWord(rng, seedDomains))
+	}),
+	"phone": noArgs(func(rng *rand.Rand) interface{} {
+		return fmt.Sprintf("+1-555-%03d-%04d", rng.Intn(1000), rng.Intn(10000))
+	}),
+	"city":    noArgs(func(rng *rand.Rand) interface{} { return randomWord(rng, seedCities) }),
+	"country": noArgs(func(rng *rand.Rand) interface{} { return randomWord(rng, seedCountries) }),
+	"company": noArgs(func(rng *rand.Rand) interface{} { return randomWord(rng, seedCompanies) }),
+	"word":    noArgs(func(rng *rand.Rand) interface{} { return randomWord(rng, seedWords) }),
+	"bool":    noArgs(func(rng *rand.Rand) interface{} { return rng.Intn(2) == 1 }),
+	"uuid": noArgs(func(rng *rand.Rand) interface{} {
+		var b [16]byte
+		rng.Read(b[:])
+		b[6] = b[6]&0x0f | 0x40 // Version 4
+		b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+		return primitive.Binary{Subtype: 4, Data: b[:]}
+	}),
+	"oid()": noArgs(func(*rand.Rand) interface{} { return primitive.NewObjectID() }),
+	"now()": noArgs(func(*rand.Rand) interface{} { return primitive.NewDateTimeFromTime(time.Now()) }),
+	"seq": func(args []string) (seedValue, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("seq takes no arguments")
+		}
+		return func(_ *rand.Rand, n int) interface{} { return n }, nil
+	},
+	"sentence": func(args []string) (seedValue, error) {
+		words, err := intArgs("sentence", args, 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		count := 8
+		if len(words) == 1 {
+			count = words[0]
+		}
+		return func(rng *rand.Rand, _ int) interface{} {
+			text := make([]string, count)
+			for i := range text {
+				text[i] = randomWord(rng, seedWords)
+			}
+			s := strings.Join(text, " ")
+			return strings.ToUpper(s[:1]) + s[1:] + "."
+		}, nil
+	},
+	"int": func(args []string) (seedValue, error) {
+		bounds, err := intArgs("int", args, 2, 2)
+		if err != nil {
+			return nil, err
+		}
+		lo, hi := bounds[0], bounds[1]
+		if hi < lo {
+			return nil, fmt.Errorf("int: %d is below %d", hi, lo)
+		}
+		return func(rng *rand.Rand, _ int) interface{} { return lo + rng.Intn(hi-lo+1) }, nil
+	},
+	"float": func(args []string) (seedValue, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("usage: {{float <min> <max>}}")
+		}
+		lo, err1 := strconv.ParseFloat(args[0], 64)
+		hi, err2 := strconv.ParseFloat(args[1], 64)
+		if err1 != nil || err2 != nil || hi < lo {
+			return nil, fmt.Errorf("usage: {{float <min> <max>}}")
+		}
+		return func(rng *rand.Rand, _ int) interface{} {
+			return float64(int64((lo+rng.Float64()*(hi-lo))*100)) / 100 // Two decimals, like prices
+		}, nil
+	},
+	"date": func(args []string) (seedValue, error) {
+		if len(args) == 0 || len(args) > 2 {
+			return nil, fmt.Errorf("usage: {{date <from> [<to>]}}, e.g. {{date -30d}} or {{date 2024-01-01 2024-12-31}}")
+		}
+		from, err := seedTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		to := time.Now()
+		if len(args) == 2 {
+			if to, err = seedTime(args[1]); err != nil {
+				return nil, err
+			}
+		}
+		if to.Before(from) {
+			from, to = to, from
+		}
+		span := to.Sub(from)
+		return func(rng *rand.Rand, _ int) interface{} {
+			offset := time.Duration(rng.Int63n(int64(span) + 1))
+			return primitive.NewDateTimeFromTime(from.Add(offset))
+		}, nil
+	},
+	"pick": func(args []string) (seedValue, error) {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("usage: {{pick <value> <value>...}}")
+		}
+		values := make([]interface{}, len(args))
+		for i, arg := range args {
+			values[i] = templateValue(arg)
+		}
+		return func(rng *rand.Rand, _ int) interface{} { return values[rng.Intn(len(values))] }, nil
+	},
+}
+
+func noArgs(generate func(rng *rand.Rand) interface{}) func(args []string) (seedValue, error) {
+	return func(args []string) (seedValue, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+		}
+		return func(rng *rand.Rand, _ int) interface{} { return generate(rng) }, nil
+	}
+}
+
+func randomWord(rng *rand.Rand, words []string) string {
+	return words[rng.Intn(len(words))]
+}
+
+func intArgs(name string, args []string, min, max int) ([]int, error) {
+	if len(args) < min || len(args) > max {
+		return nil, fmt.Errorf("%s takes %d to %d numbers", name, min, max)
+	}
+	ints := make([]int, len(args))
+	for i, arg := range args {
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s is not a whole number", name, arg)
+		}
+		ints[i] = n
+	}
+	return ints, nil
+}
+
+// seedTime reads a date argument: an offset from now such as -30d, +2h or
+// -90m, or a date such as 2024-05-01.
+func seedTime(s string) (time.Time, error) {
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		unit := map[byte]time.Duration{'d': 24 * time.Hour, 'h': time.Hour, 'm': time.Minute, 's': time.Second}[s[len(s)-1]]
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if unit == 0 || err != nil {
+			return time.Time{}, fmt.Errorf("date: %s is not an offset such as -30d, -12h or +15m", s)
+		}
+		return time.Now().Add(time.Duration(n) * unit), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("date: %s is not a date such as 2024-05-01 or an offset such as -30d", s)
+}
+
+// compileSeedTemplate turns a template into a generator of documents. It
+// checks every placeholder up front, so a typo fails before anything is
+// inserted.
+func compileSeedTemplate(v interface{}) (seedValue, error) {
+	switch v := v.(type) {
+	case bson.D:
+		fields := make([]seedValue, len(v))
+		for i, e := range v {
+			f, err := compileSeedTemplate(e.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", e.Key, err)
+			}
+			fields[i] = f
+		}
+		return func(rng *rand.Rand, n int) interface{} {
+			doc := make(bson.D, len(v))
+			for i, e := range v {
+				doc[i] = bson.E{Key: e.Key, Value: fields[i](rng, n)}
+			}
+			return doc
+		}, nil
+	case bson.A:
+		items := make([]seedValue, len(v))
+		for i, item := range v {
+			f, err := compileSeedTemplate(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = f
+		}
+		return func(rng *rand.Rand, n int) interface{} {
+			arr := make(bson.A, len(items))
+			for i, item := range items {
+				arr[i] = item(rng, n)
+			}
+			return arr
+		}, nil
+	case string:
+		matches := placeholderPattern.FindAllStringSubmatchIndex(v, -1)
+		if len(matches) == 0 {
+			break
+		}
+		generators := make([]seedValue, len(matches))
+		for i, match := range matches {
+			words := strings.Fields(v[match[2]:match[3]])
+			newGenerator, ok := seedGenerators[words[0]]
+			if !ok {
+				return nil, fmt.Errorf("unknown placeholder {{%s}}", words[0])
+			}
+			g, err := newGenerator(words[1:])
+			if err != nil {
+				return nil, err
+			}
+			generators[i] = g
+		}
+		if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(v) {
+			return generators[0], nil // Keeps the value's type
+		}
+		return func(rng *rand.Rand, n int) interface{} {
+			var b strings.Builder
+			last := 0
+			for i, match := range matches {
+				b.WriteString(v[last:match[0]])
+				b.WriteString(fmt.Sprint(generators[i](rng, n)))
+				last = match[1]
+			}
+			b.WriteString(v[last:])
+			return b.String()
+		}, nil
+	}
+	return func(*rand.Rand, int) interface{} { return v }, nil
+}
+
+// seed inserts n generated documents into the current collection.
+func (m *model) seed(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: seed <n> ['<template>'] [--seed N]"
+
+	db, coll, err := m.collectionPath("seed")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	fs := commands.NewFlagSet("seed")
+	randomSeed := fs.Int64("seed", 0, "random seed, for repeatable datasets")
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) < 1 || len(positional) > 2 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	n, err := strconv.Atoi(positional[0])
+	if err != nil || n < 1 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	var text string
+	if len(positional) == 2 {
+		text = positional[1]
+	} else {
+		templates, err := loadTemplates()
+		if err != nil {
+			m.err = fmt.Errorf("seed: %w", err)
+			return m, nil
+		}
+		var ok bool
+		if text, ok = templates[db+"."+coll]; !ok {
+			m.err = fmt.Errorf("seed: give a template, or save one for %s.%s with `template set`", db, coll)
+			return m, nil
+		}
+	}
+	template, err := commands.ParseDocument(text)
+	if err != nil {
+		m.err = fmt.Errorf("seed: invalid template: %w", err)
+		return m, nil
+	}
+	generate, err := compileSeedTemplate(template)
+	if err != nil {
+		m.err = fmt.Errorf("seed: %w", err)
+		return m, nil
+	}
+	if *randomSeed == 0 {
+		*randomSeed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(*randomSeed))
+
+	if m.dryRun {
+		var b strings.Builder
+		b.WriteString(fmt.Sprintf("dry run: would insert %d generated documents into %s.%s, such as:\n", n, db, coll))
+		for i := 1; i <= n && i <= seedDryRunExamples; i++ {
+			b.WriteString(extJSON(generate(rng, i).(bson.D)))
+			b.WriteString("\n")
+		}
+		m.err = nil
+		m.result = message(strings.TrimSuffix(b.String(), "\n"))
+		return m, nil
+	}
+
+	return m, m.runWithTimeout(0, func(ctx context.Context) tea.Msg {
+		inserted := 0
+		for inserted < n {
+			batch := make([]interface{}, 0, synthesizeBatch)
+			for len(batch) < synthesizeBatch && inserted+len(batch) < n {
+				batch = append(batch, generate(rng, inserted+len(batch)+1))
+			}
+			ids, err := m.store.InsertMany(ctx, db, coll, batch)
+			inserted += len(ids)
+			if err != nil {
+				return mongoMsg{err: fmt.Errorf("seed: inserted %d documents, then: %w", inserted, err)}
+			}
+		}
+		m.names.InvalidateDB(db)
+		return mongoMsg{result: message(fmt.Sprintf("inserted %d generated documents into %s.%s", inserted, db, coll)), written: int64(inserted)}
+	})
+}
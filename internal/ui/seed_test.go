@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+func TestSeedTemplate(t *testing.T) {
+	template, err := commands.ParseDocument(`{"n": "{{seq}}", "name": "{{name}}", "age": "{{int 18 30}}", "joined": "{{date -30d}}",
+		"label": "user-{{seq}} from {{city}}", "tier": "{{pick gold silver 3}}", "tags": ["{{word}}", "fixed"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generate, err := compileSeedTemplate(template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for n := 1; n <= 50; n++ {
+		doc := generate(rng, n).(bson.D).Map()
+		if doc["n"] != n {
+			t.Errorf("seq = %v, want %d", doc["n"], n)
+		}
+		if age, ok := doc["age"].(int); !ok || age < 18 || age > 30 {
+			t.Errorf("age = %#v", doc["age"])
+		}
+		joined, ok := doc["joined"].(primitive.DateTime)
+		if !ok || time.Since(joined.Time()) > 30*24*time.Hour+time.Minute || joined.Time().After(time.Now()) {
+			t.Errorf("joined = %#v", doc["joined"])
+		}
+		if label, _ := doc["label"].(string); !strings.HasPrefix(label, "user-") || strings.Contains(label, "{{") {
+			t.Errorf("label = %q", label)
+		}
+		switch doc["tier"] {
+		case "gold", "silver", int32(3):
+		default:
+			t.Errorf("tier = %#v", doc["tier"])
+		}
+		if tags := doc["tags"].(bson.A); len(tags) != 2 || tags[1] != "fixed" {
+			t.Errorf("tags = %v", tags)
+		}
+	}
+
+	for _, bad := range []string{`{"a": "{{nope}}"}`, `{"a": "{{int 5}}"}`, `{"a": "{{int 9 1}}"}`, `{"a": "{{date yesterday}}"}`} {
+		template, _ := commands.ParseDocument(bad)
+		if _, err := compileSeedTemplate(template); err == nil {
+			t.Errorf("%s was accepted", bad)
+		}
+	}
+}
+
+func TestSeedCommand(t *testing.T) {
+	fake := store.NewFake()
+	fake.Seed("dev", "users")
+	m := newTestModel(fake)
+	run(t, m, "cd dev/users")
+
+	run(t, m, `seed 3 '{"name": "{{name}}"}' --dry-run`)
+	if got := output(t, m); !strings.Contains(got, "would insert 3 generated documents") {
+		t.Errorf("dry run: %q", got)
+	}
+	if n, _ := fake.CountDocuments(context.Background(), "dev", "users", bson.D{}); n != 0 {
+		t.Errorf("dry run inserted %d documents", n)
+	}
+
+	run(t, m, `seed 2500 '{"name": "{{name}}", "n": "{{seq}}"}' --seed 7`)
+	if got := output(t, m); !strings.Contains(got, "inserted 2500 generated documents") {
+		t.Fatalf("seed: %q, %v", got, m.err)
+	}
+	if n, _ := fake.CountDocuments(context.Background(), "dev", "users", bson.D{{Key: "n", Value: 2500}}); n != 1 {
+		t.Errorf("%d documents are numbered 2500, want 1", n)
+	}
+
+	expectError(t, m, `seed 1 '{"a": "{{bogus}}"}'`, "unknown placeholder")
+	m.readOnly = true
+	expectError(t, m, `seed 1 '{"a": 1}'`, "read-only")
+}
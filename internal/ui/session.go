@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// consistency tracks whether reads are guaranteed to observe the shell's own
+// writes. This only matters on a replica set with secondary reads enabled,
+// where a read may be served by a member that has not replicated a write
+// yet. Operations then run in a session, which is causally consistent unless
+// the user turns it off.
+type consistency struct {
+	replicaSet     string
+	secondaryReads bool
+	causal         bool
+	session        mongo.Session
+}
+
+// detectConsistency inspects the deployment and the client's read
+// preference, starting a causally consistent session when reads may go to a
+// secondary.
+func detectConsistency(ctx context.Context, client *mongo.Client, clientOpts *options.ClientOptions) (consistency, error) {
+	var hello struct {
+		SetName string `bson:"setName"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return consistency{}, err
+	}
+
+	c := consistency{replicaSet: hello.SetName}
+	if c.replicaSet == "" || clientOpts.ReadPreference == nil || clientOpts.ReadPreference.Mode() == readpref.PrimaryMode {
+		return c, nil
+	}
+	c.secondaryReads = true
+	return c, c.setCausal(client, true)
+}
+
+// relevant reports whether reads can miss the shell's own writes at all.
+func (c *consistency) relevant() bool {
+	return c.secondaryReads
+}
+
+// setCausal replaces the session with one that has causal consistency on or
+// off.
+func (c *consistency) setCausal(client *mongo.Client, on bool) error {
+	if c.session != nil {
+		c.session.EndSession(context.Background())
+		c.session = nil
+	}
+	session, err := client.StartSession(options.Session().SetCausalConsistency(on))
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	c.session = session
+	c.causal = on
+	return nil
+}
+
+// indicator is shown next to the path while reads may go to a secondary.
+func (c *consistency) indicator() string {
+	if !c.relevant() {
+		return ""
+	}
+	if c.causal {
+		return "[causal on] "
+	}
+	return "[causal off: writes may not be visible to reads] "
+}
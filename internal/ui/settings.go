@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// parseOnOff parses the value of a boolean setting.
+func parseOnOff(value string) (bool, error) {
+	switch value {
+	case "on", "true":
+		return true, nil
+	case "off", "false":
+		return false, nil
+	default:
+		return false, fmt.Errorf("expected on or off, got '%s'", value)
+	}
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// set shows or changes session settings. Without arguments it lists the
+// current values.
+func (m *model) set(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: set [causal on|off | readonly on|off | governor on|off | table on|off | wrap on|off | timing on|off | verbose on|off | autorefresh <duration>|off | slowops <duration>|off]"
+
+	if len(args) == 0 {
+		m.result = statsResult{fields: []statField{
+			{name: "causal", value: m.causalSetting()},
+			{name: "readonly", value: onOff(m.readOnly)},
+			{name: "governor", value: m.governorSetting()},
+			{name: "table", value: onOff(m.tableView)},
+			{name: "wrap", value: onOff(!m.truncate)},
+			{name: "timing", value: onOff(m.timing)},
+			{name: "verbose", value: onOff(m.verbose)},
+			{name: "autorefresh", value: m.autorefreshSetting()},
+			{name: "slowops", value: m.slowOpsSetting()},
+		}}
+		m.err = nil
+		return m, nil
+	}
+	if len(args) != 2 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	switch args[0] {
+	case "causal":
+		on, err := parseOnOff(args[1])
+		if err != nil {
+			m.err = fmt.Errorf("set causal: %w", err)
+			return m, nil
+		}
+		if !m.consistency.relevant() {
+			m.err = fmt.Errorf("set causal: reads are served by the primary, so your writes are always visible")
+			return m, nil
+		}
+		if err := m.consistency.setCausal(m.client, on); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.err = nil
+		if on {
+			m.result = message("causal consistency on: reads will observe your own writes")
+		} else {
+			m.result = message("causal consistency off: reads from secondaries may not observe your latest writes")
+		}
+		return m, nil
+
+	case "readonly":
+		on, err := parseOnOff(args[1])
+		if err != nil {
+			m.err = fmt.Errorf("set readonly: %w", err)
+			return m, nil
+		}
+		if !on && m.readOnlyForced != "" {
+			m.err = fmt.Errorf("set readonly: this session is read-only (%s); reconnect without it to write", m.readOnlyForced)
+			return m, nil
+		}
+		m.readOnly = on
+		m.err = nil
+		m.result = message("read-only mode " + onOff(on))
+		return m, nil
+
+	case "governor":
+		on, err := parseOnOff(args[1])
+		if err != nil {
+			m.err = fmt.Errorf("set governor: %w", err)
+			return m, nil
+		}
+		m.governorOn = on
+		m.err = nil
+		m.result = message("query governor " + onOff(on))
+		return m, nil
+
+	case "autorefresh":
+		return m.setAutorefresh(args[1])
+
+	case "slowops":
+		return m.setSlowOps(args[1])
+
+	case "table":
+		on, err := parseOnOff(args[1])
+		if err != nil {
+			m.err = fmt.Errorf("set table: %w", err)
+			return m, nil
+		}
+		m.tableView = on
+		m.err = nil
+		if m.result == nil {
+			m.result = message("table view " + onOff(on))
+		}
+		return m, nil
+
+	case "wrap":
+		on, err := parseOnOff(args[1])
+		if err != nil {
+			m.err = fmt.Errorf("set wrap: %w", err)
+			return m, nil
+		}
+		m.truncate, m.scrollX = !on, 0
+		m.err = nil
+		if m.result == nil {
+			if on {
+				m.result = message("long lines wrap")
+			} else {
+				m.result = message("long lines are cut off, ← and → scroll them")
+			}
+		}
+		return m, nil
+
+	case "timing":
+		on, err := parseOnOff(args[1])
+		if err != nil {
+			m.err = fmt.Errorf("set timing: %w", err)
+			return m, nil
+		}
+		m.timing = on
+		m.err = nil
+		if m.result == nil {
+			m.result = message("timing " + onOff(on))
+		}
+		return m, nil
+
+	case "verbose":
+		on, err := parseOnOff(args[1])
+		if err != nil {
+			m.err = fmt.Errorf("set verbose: %w", err)
+			return m, nil
+		}
+		m.verbose = on
+		m.err = nil
+		m.result = message("verbose mode " + onOff(on))
+		return m, nil
+
+	default:
+		m.err = fmt.Errorf("set: unknown setting '%s'", args[0])
+		return m, nil
+	}
+}
+
+func (m *model) causalSetting() string {
+	if !m.consistency.relevant() {
+		return "n/a (reads go to the primary)"
+	}
+	return onOff(m.consistency.causal)
+}
+
+func (m *model) governorSetting() string {
+	if m.governor == (governorConfig{}) {
+		return "not configured"
+	}
+	return onOff(m.governorOn)
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// shellEscape is typed before a shell command to run it, e.g. `!ls`.
+const shellEscape = "!"
+
+// splitShell returns the shell command of a `!<command>` input, taken as
+// typed.
+func splitShell(input string) (string, bool) {
+	rest, ok := strings.CutPrefix(input, shellEscape)
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// shellQuote quotes s as a single argument of the system shell.
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// expandPlaceholders replaces %uri, %db and %ns in a shell command with the
+// connection string, the current database and the current namespace, each
+// quoted as one argument.
+func (m *model) expandPlaceholders(line string) (string, error) {
+	var db, ns string
+	if len(m.currentPath) > 0 {
+		db = m.currentPath[0]
+		ns = strings.Join(m.currentPath[:min(len(m.currentPath), 2)], ".")
+	}
+	values := map[string]string{"%uri": m.connectionString, "%db": db, "%ns": ns}
+
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		expanded := false
+		for _, name := range []string{"%uri", "%db", "%ns"} {
+			if !strings.HasPrefix(line[i:], name) {
+				continue
+			}
+			if values[name] == "" {
+				return "", fmt.Errorf("%s: not in a database, cd into one first", name)
+			}
+			b.WriteString(shellQuote(values[name]))
+			i += len(name) - 1
+			expanded = true
+			break
+		}
+		if !expanded {
+			b.WriteByte(line[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// shell suspends the UI and runs a command with the system shell, e.g.
+// `!mongodump --uri %uri --db %db`, waiting for Enter before returning so
+// its output can be read.
+func (m *model) shell(args []string) (tea.Model, tea.Cmd) {
+	if len(args) != 1 || args[0] == "" {
+		m.err = fmt.Errorf("usage: !<command>, e.g. !mongodump --uri %%uri --db %%db")
+		return m, nil
+	}
+	typed := args[0]
+	line, err := m.expandPlaceholders(typed)
+	if err != nil {
+		m.err = fmt.Errorf("!: %w", err)
+		return m, nil
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", line+" & pause")
+	} else {
+		cmd = exec.Command("sh", "-c", line+"\nstatus=$?\nprintf '\\n[exit status %s, press Enter to return to mon-go] ' $status\nread _\nexit $status")
+	}
+	m.err = nil
+	return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return mongoMsg{err: fmt.Errorf("!%s: exited with status %d", typed, exit.ExitCode())}
+		}
+		if err != nil {
+			return mongoMsg{err: fmt.Errorf("!%s: %w", typed, err)}
+		}
+		return mongoMsg{result: message(fmt.Sprintf("!%s: done", typed))}
+	})
+}
@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestExpandPlaceholders(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("quoting differs on Windows")
+	}
+	m := newTestModel(seededFake())
+	m.connectionString = "mongodb://u:p@host/?a=1&b=2"
+	if _, err := m.expandPlaceholders("mongodump --db %db"); err == nil {
+		t.Fatal("placeholder of the database expanded at the root, want an error")
+	}
+
+	m.currentPath = []string{"shop", "orders", "65f0c0ffee"}
+	got, err := m.expandPlaceholders("mongodump --uri %uri --db %db --collection %ns 100%")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "mongodump --uri 'mongodb://u:p@host/?a=1&b=2' --db 'shop' --collection 'shop.orders' 100%"; got != want {
+		t.Fatalf("expanded to %q, want %q", got, want)
+	}
+	if got := shellQuote("it's"); got != `'it'\''s'` {
+		t.Fatalf("shellQuote = %q", got)
+	}
+}
+
+func TestShellReadOnly(t *testing.T) {
+	m := newTestModel(seededFake())
+	m.readOnly = true
+	expectError(t, m, "!mongorestore --uri %uri", "refused in read-only mode")
+	expectError(t, m, "find | tee /tmp/orders", "refused in read-only mode")
+}
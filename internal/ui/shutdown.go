@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// disconnectTimeout bounds how long quitting waits for the client to close
+// its cursors and connections.
+const disconnectTimeout = 5 * time.Second
+
+// quit asks the workspace to leave mon-go, which first asks about the
+// commands still running in any tab.
+func (m *model) quit() tea.Cmd {
+	return func() tea.Msg { return quitMsg{} }
+}
+
+// quit leaves mon-go, first asking in tab t what to do with the commands
+// still running in any tab: wait for them to finish, cancel them, or, when
+// some can be resumed later, detach them. Detaching stops those at a
+// checkpoint saved for resuming, such as an export's, and cancels the rest.
+func (w *workspace) quit(t *tab) tea.Cmd {
+	running := w.running()
+	if len(running) == 0 {
+		return tea.Quit
+	}
+	var labels []string
+	detachable := false
+	for _, r := range running {
+		label := fmt.Sprintf("'%s'", r.m.running.label)
+		if len(w.tabs) > 1 {
+			label += fmt.Sprintf(" in tab %d", w.index(r)+1)
+		}
+		labels = append(labels, label)
+		detachable = detachable || r.m.running.detach != nil
+	}
+	choices := "[w]ait for it, [c]ancel it"
+	if len(labels) > 1 {
+		choices = "[w]ait for them, [c]ancel them"
+	}
+	if detachable {
+		choices += ", [d]etach to resume later"
+	}
+	question := fmt.Sprintf("%s is still running: %s, or keep working? ", labels[0], choices)
+	if len(labels) > 1 {
+		question = fmt.Sprintf("%s and %s are still running: %s, or keep working? ",
+			strings.Join(labels[:len(labels)-1], ", "), labels[len(labels)-1], choices)
+	}
+	t.m.ask(question, false, func(answer string) tea.Cmd {
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "w", "wait":
+			if len(w.running()) == 0 {
+				return tea.Quit // Finished while we were asking
+			}
+			w.quitWhenDone = true
+			return nil
+		case "c", "cancel":
+			var cmds []tea.Cmd
+			for _, r := range w.running() {
+				cmds = append(cmds, r.m.cancelRunning())
+			}
+			return tea.Sequence(append(cmds, tea.Quit)...)
+		case "d", "detach":
+			if !detachable {
+				return nil
+			}
+			var cmds []tea.Cmd
+			for _, r := range w.running() {
+				if r.m.running.detach != nil {
+					r.m.running.detach() // Its result comes once the checkpoint is saved
+				} else {
+					cmds = append(cmds, r.m.cancelRunning())
+				}
+			}
+			if len(w.running()) == 0 {
+				return tea.Sequence(append(cmds, tea.Quit)...)
+			}
+			w.quitWhenDone = true
+			return tea.Sequence(cmds...)
+		default:
+			return nil
+		}
+	})
+	return nil
+}
+
+// running returns the tabs running a command.
+func (w *workspace) running() []*tab {
+	var running []*tab
+	for _, t := range w.tabs {
+		if t.m.running != nil {
+			running = append(running, t)
+		}
+	}
+	return running
+}
+
+// shutdown releases what is left once the program has stopped: change
+// streams of a watchboard, a command still running, the cursor of a paged
+// result, and the client's connections.
+func (m *model) shutdown() {
+	if m.client == nil {
+		return // Never connected
+	}
+	m.stopWatchboard()
+	if m.running != nil {
+		m.cancelRunning()
+		m.killOwnOps()
+	}
+	if m.results != nil {
+		m.results.close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), disconnectTimeout)
+	defer cancel()
+	if err := m.client.Disconnect(ctx); err != nil {
+		slog.Warn("disconnect failed", "error", err)
+		return
+	}
+	slog.Info("disconnected")
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// slowOpsPoll is how often the slow operation watcher runs currentOp.
+	slowOpsPoll = 5 * time.Second
+	// minSlowOps keeps the watcher from alerting on ordinary operations.
+	minSlowOps = time.Second
+)
+
+var slowOpsStyle = lipgloss.NewStyle().
+	Bold(true).
+	Foreground(lipgloss.Color("0")).
+	Background(lipgloss.Color("3"))
+
+// slowOpsMsg carries the operations found running for longer than the
+// threshold. gen tells polls of an earlier `set slowops` apart from the
+// current one.
+type slowOpsMsg struct {
+	gen int
+	ops []activeOp
+	err error
+}
+
+// setSlowOps changes how long an operation may run before the watcher
+// alerts; "off" or 0 disables it.
+func (m *model) setSlowOps(value string) (tea.Model, tea.Cmd) {
+	threshold := time.Duration(0)
+	if value != "off" {
+		d, err := time.ParseDuration(value)
+		if err != nil || (d != 0 && d < minSlowOps) {
+			m.err = fmt.Errorf("set slowops: expected a duration of at least %s such as 10s, or off", minSlowOps)
+			return m, nil
+		}
+		threshold = d
+	}
+
+	m.slowOps = threshold
+	m.slowOpsGen++
+	m.slowOpsFound = nil
+	m.err = nil
+	if threshold == 0 {
+		m.result = message("slow operation alerts off")
+		return m, nil
+	}
+	m.result = message(fmt.Sprintf("alerting when an operation runs for %s or longer, checked every %s", threshold, slowOpsPoll))
+	return m, m.pollSlowOps()
+}
+
+// pollSlowOps looks for slow operations in the background, without
+// replacing the shown result.
+func (m *model) pollSlowOps() tea.Cmd {
+	gen, threshold, st := m.slowOpsGen, m.slowOps, m.store
+	return func() tea.Msg {
+		if st == nil {
+			return slowOpsMsg{gen: gen, err: fmt.Errorf("not connected")}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		defer cancel()
+		ops, err := m.currentOps(ctx, currentOpFilter(threshold))
+		return slowOpsMsg{gen: gen, ops: ops, err: err}
+	}
+}
+
+func (m *model) scheduleSlowOps() tea.Cmd {
+	return tea.Tick(slowOpsPoll, func(time.Time) tea.Msg { return slowOpsTickMsg{gen: m.slowOpsGen} })
+}
+
+// slowOpsTickMsg starts the next poll.
+type slowOpsTickMsg struct {
+	gen int
+}
+
+// slowOpsPolled records the result of a poll and schedules the next one.
+// Errors, e.g. a user not allowed to run currentOp, are only logged.
+func (m *model) slowOpsPolled(msg slowOpsMsg) tea.Cmd {
+	if msg.gen != m.slowOpsGen || m.slowOps == 0 {
+		return nil // Poll of a threshold that was changed since
+	}
+	if msg.err != nil {
+		slog.Warn("checking for slow operations failed", "error", msg.err)
+	}
+	m.slowOpsFound = msg.ops
+	return m.scheduleSlowOps()
+}
+
+// slowOpsBanner warns of operations running for longer than the threshold,
+// on every screen until they finish.
+func (m *model) slowOpsBanner() string {
+	if m.slowOps == 0 || len(m.slowOpsFound) == 0 {
+		return ""
+	}
+	longest := m.slowOpsFound[0]
+	text := fmt.Sprintf("SLOW: %d operations running for %s or longer, the longest %s on %s. Press Ctrl+O to see them.",
+		len(m.slowOpsFound), m.slowOps, formatElapsed(longest.running), longest.ns)
+	if len(m.slowOpsFound) == 1 {
+		text = fmt.Sprintf("SLOW: an operation has been running for %s on %s. Press Ctrl+O to see it.", formatElapsed(longest.running), longest.ns)
+	}
+	return slowOpsStyle.Render(text) + "\n"
+}
+
+// showSlowOps runs currentop for the operations the banner warns of.
+func (m *model) showSlowOps() tea.Cmd {
+	if m.slowOps == 0 || len(m.slowOpsFound) == 0 || m.running != nil || m.prompt != nil {
+		return nil
+	}
+	_, cmd := m.processCommand("currentop --min " + m.slowOps.String())
+	return cmd
+}
+
+func (m *model) slowOpsSetting() string {
+	if m.slowOps == 0 {
+		return "off"
+	}
+	return m.slowOps.String()
+}
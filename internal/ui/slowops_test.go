@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSlowOpsAlert(t *testing.T) {
+	fake := seededFake()
+	m := newTestModel(fake)
+	m.processCommand("set slowops 500ms")
+	if m.err == nil || !strings.Contains(m.err.Error(), "at least 1s") {
+		t.Fatalf("set slowops 500ms: got error %v", m.err)
+	}
+
+	m.processCommand("set slowops 10s") // Its polls are not run: each schedules the next
+	if m.slowOps != 10*time.Second || !strings.Contains(output(t, m), "10s or longer") {
+		t.Fatalf("set slowops 10s: %v, %q", m.slowOps, output(t, m))
+	}
+	if m.slowOpsBanner() != "" {
+		t.Fatalf("banner before any poll: %q", m.slowOpsBanner())
+	}
+
+	fake.StartOp(bson.D{{Key: "active", Value: true}, {Key: "op", Value: "query"}, {Key: "ns", Value: "shop.orders"},
+		{Key: "microsecs_running", Value: int64(42_000_000)}})
+	fake.StartOp(bson.D{{Key: "active", Value: true}, {Key: "op", Value: "query"}, {Key: "ns", Value: "shop.orders"},
+		{Key: "microsecs_running", Value: int64(3_000_000)}})
+	polled, ok := m.pollSlowOps()().(slowOpsMsg)
+	if !ok || polled.err != nil || len(polled.ops) != 1 {
+		t.Fatalf("poll: %+v", polled)
+	}
+	ops := polled.ops
+	m.Update(slowOpsMsg{gen: m.slowOpsGen - 1, ops: ops})
+	if m.slowOpsBanner() != "" {
+		t.Fatalf("poll of an earlier setting was shown: %q", m.slowOpsBanner())
+	}
+	m.Update(slowOpsMsg{gen: m.slowOpsGen, ops: ops})
+	if banner := m.slowOpsBanner(); !strings.Contains(banner, "running for 42s on shop.orders") {
+		t.Fatalf("banner = %q", banner)
+	}
+	if !strings.Contains(m.View(), "Ctrl+O") {
+		t.Fatalf("banner missing from the view:\n%s", m.View())
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	if m.running == nil || !strings.HasPrefix(m.running.label, "currentop") {
+		t.Fatalf("Ctrl+O did not run currentop: %+v", m.running)
+	}
+	m.running.cancel()
+	m.running = nil
+
+	m.Update(slowOpsMsg{gen: m.slowOpsGen})
+	if m.slowOpsBanner() != "" {
+		t.Fatalf("banner after the operations finished: %q", m.slowOpsBanner())
+	}
+
+	m.processCommand("set slowops off")
+	if m.slowOps != 0 || output(t, m) != "slow operation alerts off\n" {
+		t.Fatalf("set slowops off: %v, %q", m.slowOps, output(t, m))
+	}
+}
@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// readCommands are the server commands whose serving member is reported.
+var readCommands = map[string]bool{
+	"find":      true,
+	"getMore":   true,
+	"aggregate": true,
+	"count":     true,
+	"distinct":  true,
+}
+
+type readTraceKey struct{}
+
+// readTrace records which member served the reads of an operation. It is
+// carried in the operation's context and filled in by the command monitor.
+type readTrace struct {
+	mu   sync.Mutex
+	host string
+}
+
+func (t *readTrace) record(host string) {
+	t.mu.Lock()
+	t.host = host
+	t.mu.Unlock()
+}
+
+func (t *readTrace) lastHost() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.host
+}
+
+// readMonitor is installed on the client to feed readTraces.
+var readMonitor = &event.CommandMonitor{
+	Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+		if !readCommands[e.CommandName] {
+			return
+		}
+		if t, ok := ctx.Value(readTraceKey{}).(*readTrace); ok {
+			t.record(hostOfConnection(e.ConnectionID))
+		}
+	},
+}
+
+// hostOfConnection strips the connection number from a driver connection ID
+// such as "db2.example.com:27017[-12]".
+func hostOfConnection(id string) string {
+	if i := strings.LastIndex(id, "[-"); i >= 0 {
+		return id[:i]
+	}
+	return id
+}
+
+// servedBy describes the secondary that served a read.
+type servedBy struct {
+	host  string
+	state string
+	lag   time.Duration
+	known bool // Whether state and lag could be determined
+}
+
+func (s servedBy) String() string {
+	if !s.known {
+		return fmt.Sprintf("served by %s\n", s.host)
+	}
+	return fmt.Sprintf("served by %s (%s, %s behind primary)\n", s.host, s.state, s.lag)
+}
+
+// memberStaleness looks up the state and replication lag of host. A nil
+// result means host is the primary, so there is nothing to report.
+func memberStaleness(ctx context.Context, client *mongo.Client, host string) *servedBy {
+	var status struct {
+		Members []struct {
+			Name       string    `bson:"name"`
+			StateStr   string    `bson:"stateStr"`
+			OptimeDate time.Time `bson:"optimeDate"`
+		} `bson:"members"`
+	}
+	cmd := bson.D{{Key: "replSetGetStatus", Value: 1}}
+	if err := client.Database("admin").RunCommand(ctx, cmd).Decode(&status); err != nil {
+		return &servedBy{host: host} // Most likely not allowed to see the status
+	}
+
+	var primary time.Time
+	for _, member := range status.Members {
+		if member.StateStr == "PRIMARY" {
+			primary = member.OptimeDate
+		}
+	}
+	for _, member := range status.Members {
+		if member.Name != host {
+			continue
+		}
+		if member.StateStr == "PRIMARY" {
+			return nil
+		}
+		served := &servedBy{host: host, state: member.StateStr, known: !primary.IsZero()}
+		if served.known {
+			served.lag = primary.Sub(member.OptimeDate)
+		}
+		return served
+	}
+	return &servedBy{host: host}
+}
+
+// annotateServedBy adds the serving member to a successful read that was not
+// served by the primary.
+func annotateServedBy(ctx context.Context, client *mongo.Client, trace *readTrace, msg interface{}) interface{} {
+	mm, ok := msg.(mongoMsg)
+	host := trace.lastHost()
+	if !ok || mm.err != nil || host == "" {
+		return msg
+	}
+	mm.servedBy = memberStaleness(ctx, client, host)
+	return mm
+}
@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+// Bucket statistics reported by collStats for time series collections, in
+// the order they are shown.
+var bucketStatFields = []string{
+	"bucketsNs",
+	"bucketCount",
+	"avgBucketSize",
+	"avgNumMeasurementsPerCommit",
+	"numBucketInserts",
+	"numBucketUpdates",
+	"numBucketsOpenedDueToMetadata",
+	"numBucketsClosedDueToCount",
+	"numBucketsClosedDueToSize",
+	"numBucketsClosedDueToTimeForward",
+	"numBucketsClosedDueToTimeBackward",
+	"numBucketsClosedDueToMemoryThreshold",
+	"numCompressedBuckets",
+	"numCommits",
+	"numMeasurementsCommitted",
+}
+
+// stats shows the statistics of a collection: the current one, or the named
+// one of the current database. Time series collections also show their
+// configuration and bucket statistics, since their size and count describe
+// buckets rather than measurements.
+func (m *model) stats(args []string) (tea.Model, tea.Cmd) {
+	if len(args) > 1 || len(m.currentPath) == 0 || (len(args) == 0 && len(m.currentPath) < 2) {
+		m.err = fmt.Errorf("usage: stats [collection] (inside a database)")
+		return m, nil
+	}
+	db := m.currentPath[0]
+	var coll string
+	if len(args) == 1 {
+		coll = args[0]
+	} else {
+		coll = m.currentPath[1]
+	}
+
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		cur, err := m.store.ListCollections(ctx, db, bson.M{"name": coll})
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		var specs []struct {
+			Type    string   `bson:"type"`
+			Options bson.Raw `bson:"options"`
+		}
+		if err := cur.All(ctx, &specs); err != nil {
+			return mongoMsg{err: err}
+		}
+		if len(specs) == 0 {
+			return mongoMsg{err: fmt.Errorf("collection '%s' does not exist in database '%s'", coll, db)}
+		}
+		spec := specs[0]
+		if spec.Type == "view" {
+			return mongoMsg{err: fmt.Errorf("'%s' is a view and has no storage statistics, see `view show %s`", coll, coll)}
+		}
+
+		var raw bson.M
+		if err := m.store.RunCommand(ctx, db, bson.D{{Key: "collStats", Value: coll}}, &raw); err != nil {
+			return mongoMsg{err: err}
+		}
+
+		res := statsResult{fields: []statField{
+			{name: "ns", value: raw["ns"]},
+			{name: "type", value: spec.Type},
+			{name: "count", value: raw["count"]},
+			{name: "size", value: sizeStat(raw["size"])},
+			{name: "storageSize", value: sizeStat(raw["storageSize"])},
+			{name: "nindexes", value: raw["nindexes"]},
+			{name: "totalIndexSize", value: sizeStat(raw["totalIndexSize"])},
+		}}
+		if capped, _ := raw["capped"].(bool); capped {
+			res.fields = append(res.fields,
+				statField{name: "capped", value: true},
+				statField{name: "maxSize", value: sizeStat(raw["maxSize"])})
+		}
+		if spec.Type != "timeseries" {
+			return mongoMsg{result: res}
+		}
+
+		var collOptions struct {
+			TimeSeries struct {
+				TimeField            string `bson:"timeField"`
+				MetaField            string `bson:"metaField"`
+				Granularity          string `bson:"granularity"`
+				BucketMaxSpanSeconds int64  `bson:"bucketMaxSpanSeconds"`
+			} `bson:"timeseries"`
+			ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+		}
+		if err := bson.Unmarshal(spec.Options, &collOptions); err != nil {
+			return mongoMsg{err: err}
+		}
+		ts := collOptions.TimeSeries
+		res.fields = append(res.fields,
+			statField{name: "timeField", value: ts.TimeField},
+			statField{name: "metaField", value: orNone(ts.MetaField)},
+			statField{name: "granularity", value: orNone(ts.Granularity)},
+			statField{name: "bucketMaxSpanSeconds", value: ts.BucketMaxSpanSeconds})
+		if collOptions.ExpireAfterSeconds != nil {
+			res.fields = append(res.fields, statField{name: "expireAfterSeconds", value: *collOptions.ExpireAfterSeconds})
+		}
+
+		buckets, _ := raw["timeseries"].(bson.M)
+		for _, name := range bucketStatFields {
+			if v, ok := buckets[name]; ok {
+				if name == "avgBucketSize" {
+					v = sizeStat(v)
+				}
+				res.fields = append(res.fields, statField{name: "buckets." + name, value: v})
+			}
+		}
+		return mongoMsg{result: res}
+	})
+}
+
+// sizeStat formats a size in bytes from a stats command, or returns it
+// unchanged if it is not a number.
+func sizeStat(v interface{}) interface{} {
+	if n, ok := asInt64(v); ok {
+		return commands.FormatByteSize(n)
+	}
+	return v
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
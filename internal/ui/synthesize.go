@@ -0,0 +1,351 @@
+package ui
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+const (
+	defaultSynthesizeSample = 1000
+	synthesizeBatch         = 1000
+
+	// Strings are only reused verbatim when a field has at most this many
+	// distinct values, each seen at least twice, like a status or a country.
+	// Anything else is replaced by random text, so no personal data is
+	// copied.
+	maxCategories   = 20
+	minCategorySeen = 2
+)
+
+// docModel learns the shape of sampled documents: which fields they have,
+// in which order and how often, and what values each field takes.
+type docModel struct {
+	count  int
+	fields map[string]*valueModel
+	order  []string
+}
+
+// valueModel learns the values of one field, or of the elements of an array.
+type valueModel struct {
+	present  int
+	types    map[bsontype.Type]int
+	numbers  []float64
+	strings  map[string]int
+	lengths  []int // Lengths of strings
+	arrays   []int // Lengths of arrays
+	trues    int
+	earliest time.Time
+	latest   time.Time
+	doc      *docModel   // For embedded documents
+	elements *valueModel // For array elements
+}
+
+func newDocModel() *docModel {
+	return &docModel{fields: map[string]*valueModel{}}
+}
+
+func newValueModel() *valueModel {
+	return &valueModel{types: map[bsontype.Type]int{}, strings: map[string]int{}}
+}
+
+func (d *docModel) learn(doc bson.Raw) error {
+	elems, err := doc.Elements()
+	if err != nil {
+		return err
+	}
+	d.count++
+	for _, elem := range elems {
+		v := d.fields[elem.Key()]
+		if v == nil {
+			v = newValueModel()
+			d.fields[elem.Key()] = v
+			d.order = append(d.order, elem.Key())
+		}
+		v.present++
+		if err := v.learn(elem.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *valueModel) learn(value bson.RawValue) error {
+	v.types[value.Type]++
+	switch value.Type {
+	case bsontype.Double:
+		v.numbers = append(v.numbers, value.Double())
+	case bsontype.Int32:
+		v.numbers = append(v.numbers, float64(value.Int32()))
+	case bsontype.Int64:
+		v.numbers = append(v.numbers, float64(value.Int64()))
+	case bsontype.String:
+		s := value.StringValue()
+		v.strings[s]++
+		v.lengths = append(v.lengths, len(s))
+	case bsontype.Boolean:
+		if value.Boolean() {
+			v.trues++
+		}
+	case bsontype.DateTime:
+		t := value.Time()
+		if v.earliest.IsZero() || t.Before(v.earliest) {
+			v.earliest = t
+		}
+		if t.After(v.latest) {
+			v.latest = t
+		}
+	case bsontype.EmbeddedDocument:
+		if v.doc == nil {
+			v.doc = newDocModel()
+		}
+		return v.doc.learn(value.Document())
+	case bsontype.Array:
+		values, err := value.Array().Values()
+		if err != nil {
+			return err
+		}
+		v.arrays = append(v.arrays, len(values))
+		if v.elements == nil {
+			v.elements = newValueModel()
+		}
+		for _, element := range values {
+			v.elements.present++
+			if err := v.elements.learn(element); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// generate makes a document shaped like the sampled ones. _id is left to the
+// server.
+func (d *docModel) generate(rng *rand.Rand, top bool) bson.D {
+	doc := bson.D{}
+	for _, key := range d.order {
+		if top && key == "_id" {
+			continue
+		}
+		v := d.fields[key]
+		if rng.Float64() >= float64(v.present)/float64(d.count) {
+			continue
+		}
+		value, ok := v.generate(rng)
+		if ok {
+			doc = append(doc, bson.E{Key: key, Value: value})
+		}
+	}
+	return doc
+}
+
+func (v *valueModel) generate(rng *rand.Rand) (interface{}, bool) {
+	switch t := v.pickType(rng); t {
+	case bsontype.Double:
+		return v.number(rng), true
+	case bsontype.Int32:
+		return int32(math.Round(v.number(rng))), true
+	case bsontype.Int64:
+		return int64(math.Round(v.number(rng))), true
+	case bsontype.String:
+		return v.text(rng), true
+	case bsontype.Boolean:
+		return rng.Intn(v.types[t]) < v.trues, true
+	case bsontype.DateTime:
+		span := v.latest.Sub(v.earliest)
+		offset := time.Duration(0)
+		if span > 0 {
+			offset = time.Duration(rng.Int63n(int64(span)))
+		}
+		return primitive.NewDateTimeFromTime(v.earliest.Add(offset)), true
+	case bsontype.ObjectID:
+		return primitive.NewObjectID(), true
+	case bsontype.Null:
+		return nil, true
+	case bsontype.EmbeddedDocument:
+		return v.doc.generate(rng, false), true
+	case bsontype.Array:
+		n := v.arrays[rng.Intn(len(v.arrays))]
+		arr := bson.A{}
+		for i := 0; i < n && v.elements != nil; i++ {
+			if element, ok := v.elements.generate(rng); ok {
+				arr = append(arr, element)
+			}
+		}
+		return arr, true
+	default:
+		return nil, false // Rare types are left out rather than faked badly
+	}
+}
+
+// pickType chooses a BSON type with the frequency it was sampled with.
+func (v *valueModel) pickType(rng *rand.Rand) bsontype.Type {
+	types := make([]bsontype.Type, 0, len(v.types))
+	total := 0
+	for t, n := range v.types {
+		types = append(types, t)
+		total += n
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] }) // Deterministic for a seed
+	pick := rng.Intn(total)
+	for _, t := range types {
+		pick -= v.types[t]
+		if pick < 0 {
+			return t
+		}
+	}
+	return types[len(types)-1]
+}
+
+// number draws from a normal distribution with the sampled mean and
+// deviation, kept within the sampled range.
+func (v *valueModel) number(rng *rand.Rand) float64 {
+	lo, hi, sum := math.Inf(1), math.Inf(-1), 0.0
+	for _, n := range v.numbers {
+		lo, hi, sum = math.Min(lo, n), math.Max(hi, n), sum+n
+	}
+	mean := sum / float64(len(v.numbers))
+	variance := 0.0
+	for _, n := range v.numbers {
+		variance += (n - mean) * (n - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(v.numbers)))
+	return math.Max(lo, math.Min(hi, mean+rng.NormFloat64()*stddev))
+}
+
+// text reuses a category of a low-cardinality field, or makes up random
+// text as long as a sampled value.
+func (v *valueModel) text(rng *rand.Rand) string {
+	if len(v.strings) <= maxCategories {
+		categorical := true
+		total := 0
+		values := make([]string, 0, len(v.strings))
+		for s, n := range v.strings {
+			if n < minCategorySeen {
+				categorical = false
+				break
+			}
+			values = append(values, s)
+			total += n
+		}
+		if categorical {
+			sort.Strings(values)
+			pick := rng.Intn(total)
+			for _, s := range values {
+				pick -= v.strings[s]
+				if pick < 0 {
+					return s
+				}
+			}
+		}
+	}
+
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	n := v.lengths[rng.Intn(len(v.lengths))]
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letters[rng.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+// synthesizeFlagSet returns the flags of synthesize: --like, --count,
+// --sample and --seed.
+func synthesizeFlagSet() (fs *flag.FlagSet, like *string, count, sample *int, seed *int64) {
+	fs = commands.NewFlagSet("synthesize")
+	like = fs.String("like", "", "collection to learn from")
+	count = fs.Int("count", 0, "number of documents to generate")
+	sample = fs.Int("sample", defaultSynthesizeSample, "number of documents to learn from")
+	seed = fs.Int64("seed", 0, "random seed, for repeatable datasets")
+	return fs, like, count, sample, seed
+}
+
+// synthesize fills a collection, the current one by default, with fake
+// documents shaped like a sample of another collection.
+func (m *model) synthesize(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: synthesize [[db/]collection] --like <[db/]collection> --count N [--sample N] [--seed N]"
+
+	fs, like, count, sample, seed := synthesizeFlagSet()
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) > 1 || *like == "" || *count <= 0 || *sample <= 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	target := m.currentPath
+	if len(positional) == 1 {
+		target = m.resolvePath(positional[0])
+	}
+	if len(target) != 2 {
+		m.err = fmt.Errorf("synthesize: name a target collection or cd into one")
+		return m, nil
+	}
+	db, coll := target[0], target[1]
+	source := m.resolvePath(*like)
+	if len(source) != 2 {
+		m.err = fmt.Errorf("synthesize: '%s' does not name a collection", *like)
+		return m, nil
+	}
+	if source[0] == db && source[1] == coll {
+		m.err = fmt.Errorf("synthesize: generate into another collection than the one learned from")
+		return m, nil
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	n, sampleSize, rng := *count, *sample, rand.New(rand.NewSource(*seed))
+	return m, m.runWithTimeout(0, func(ctx context.Context) tea.Msg {
+		pipeline := mongo.Pipeline{{{Key: "$sample", Value: bson.D{{Key: "size", Value: sampleSize}}}}}
+		cur, err := m.store.Aggregate(ctx, source[0], source[1], pipeline)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		model := newDocModel()
+		for cur.Next(ctx) {
+			var doc bson.Raw
+			err := cur.Decode(&doc)
+			if err == nil {
+				err = model.learn(doc)
+			}
+			if err != nil {
+				cur.Close(ctx)
+				return mongoMsg{err: err}
+			}
+		}
+		cur.Close(ctx)
+		if err := cur.Err(); err != nil {
+			return mongoMsg{err: err}
+		}
+		if model.count == 0 {
+			return mongoMsg{err: fmt.Errorf("synthesize: %s.%s is empty, nothing to learn from", source[0], source[1])}
+		}
+
+		inserted := 0
+		for inserted < n {
+			batch := make([]interface{}, 0, synthesizeBatch)
+			for len(batch) < synthesizeBatch && inserted+len(batch) < n {
+				batch = append(batch, model.generate(rng, true))
+			}
+			ids, err := m.store.InsertMany(ctx, db, coll, batch)
+			inserted += len(ids)
+			if err != nil {
+				return mongoMsg{err: fmt.Errorf("synthesize: inserted %d documents, then: %w", inserted, err)}
+			}
+		}
+		m.names.InvalidateDB(db)
+		return mongoMsg{result: message(fmt.Sprintf("inserted %d synthetic documents learned from %d sampled documents of %s.%s",
+			inserted, model.count, source[0], source[1])), written: int64(inserted)}
+	})
+}
@@ -0,0 +1,27 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSynthesize(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop")
+
+	run(t, m, "synthesize fakes --like orders --count 5 --seed 1")
+	if got := output(t, m); got != "inserted 5 synthetic documents learned from 3 sampled documents of shop.orders\n" {
+		t.Errorf("synthesize: %q", got)
+	}
+	run(t, m, "cd fakes")
+	run(t, m, "analyze")
+	got := output(t, m)
+	for _, want := range []string{"5 documents sampled", "item", "qty"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("analyze of the synthetic documents lacks %q:\n%s", want, got)
+		}
+	}
+
+	expectError(t, m, "synthesize /shop/other --like /shop/customers --count 5", "shop.customers is empty, nothing to learn from")
+	expectError(t, m, "synthesize --like /shop/fakes --count 5", "generate into another collection than the one learned from")
+}
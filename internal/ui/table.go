@@ -0,0 +1,210 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxCellWidth is the widest a table cell gets before it is cut off.
+const maxCellWidth = 30
+
+// tableOptions are what documentTable needs to know besides the documents.
+type tableOptions struct {
+	computed []computedColumn // Computed columns of the collection
+	layout   []string         // Columns chosen with the columns command, nil for every field
+	width    int              // Width to fit the table to, 0 for any
+	scroll   int              // Columns scrolled past, after _id
+	sorted   string           // Column the documents are sorted by, - prefix for descending
+}
+
+// tableOptions returns the options of the table of the current collection.
+func (m model) tableOptions() tableOptions {
+	ns := strings.Join(m.currentPath, ".")
+	return tableOptions{computed: m.columns[ns], layout: m.layouts[ns], width: m.width, scroll: m.tableScroll, sorted: m.tableSort}
+}
+
+// documentTable renders documents as a table: one column per top-level
+// field, _id first and the rest sorted, followed by computed columns. With
+// a width other than 0, the columns that do not fit are left out and named
+// below the table. The table starts scroll columns past _id, which stays in
+// place so rows can still be told apart. A layout other than nil replaces
+// the columns with the fields and computed columns it names, in its order.
+// The column the documents are sorted by is marked with ▲ or ▼.
+func documentTable(l documentList, opts tableOptions) string {
+	names, rows := tableCells(l, opts.computed, opts.layout)
+	frozen := frozenColumns(names)
+	width, scroll := opts.width, opts.scroll
+
+	header := append([]string{}, names...)
+	for i, name := range header {
+		switch opts.sorted {
+		case name:
+			header[i] += " ▲"
+		case "-" + name:
+			header[i] += " ▼"
+		}
+	}
+
+	widths := make([]int, len(header))
+	for i, name := range header {
+		widths[i] = lipgloss.Width(name)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if w := lipgloss.Width(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	scroll = max(0, min(scroll, len(header)-frozen-1))
+	var shown []int // Indexes of the columns shown, in order
+	used := 0
+	for i := range header {
+		if i >= frozen && i < frozen+scroll {
+			continue // Scrolled past
+		}
+		w := widths[i]
+		if len(shown) > 0 {
+			w += 2 // Gap before the column
+		}
+		if width > 0 && len(shown) > 0 && used+w > width {
+			break
+		}
+		shown = append(shown, i)
+		used += w
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		var line strings.Builder
+		for n, i := range shown {
+			if n > 0 {
+				line.WriteString("  ")
+			}
+			line.WriteString(cells[i])
+			line.WriteString(strings.Repeat(" ", widths[i]-lipgloss.Width(cells[i])))
+		}
+		b.WriteString(strings.TrimRight(line.String(), " "))
+		b.WriteString("\n")
+	}
+	writeRow(header)
+	for _, row := range rows {
+		writeRow(row)
+	}
+	if left := names[frozen : frozen+scroll]; len(left) > 0 {
+		b.WriteString(fmt.Sprintf("%d columns to the left (←): %s\n", len(left), strings.Join(left, ", ")))
+	}
+	if hidden := names[shown[len(shown)-1]+1:]; len(hidden) > 0 {
+		b.WriteString(fmt.Sprintf("%d more columns do not fit (→): %s\n", len(hidden), strings.Join(hidden, ", ")))
+	}
+	b.WriteString(l.footer())
+	return b.String()
+}
+
+// tableCells returns the header and the formatted cells of the table of l.
+func tableCells(l documentList, computed []computedColumn, layout []string) ([]string, [][]string) {
+	if layout != nil {
+		rows := make([][]string, len(l.docs))
+		for i, doc := range l.docs {
+			for _, name := range layout {
+				rows[i] = append(rows[i], formatCell(columnValue(doc, name, computed)))
+			}
+		}
+		return layout, rows
+	}
+
+	seen := map[string]bool{}
+	var fields []string
+	for _, doc := range l.docs {
+		for field := range doc {
+			if !seen[field] {
+				seen[field] = true
+				fields = append(fields, field)
+			}
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i] == "_id" || fields[j] == "_id" {
+			return fields[i] == "_id"
+		}
+		return fields[i] < fields[j]
+	})
+
+	header := append([]string{}, fields...)
+	for _, c := range computed {
+		header = append(header, c.name)
+	}
+	rows := make([][]string, len(l.docs))
+	for i, doc := range l.docs {
+		row := make([]string, 0, len(header))
+		for _, field := range fields {
+			row = append(row, formatCell(doc[field]))
+		}
+		for _, c := range computed {
+			row = append(row, formatCell(c.eval(doc)))
+		}
+		rows[i] = row
+	}
+	return header, rows
+}
+
+// frozenColumns returns how many columns at the start of a table stay in
+// place when it is scrolled: the _id column, if there is one.
+func frozenColumns(header []string) int {
+	if len(header) > 0 && header[0] == "_id" {
+		return 1
+	}
+	return 0
+}
+
+// scrollTable scrolls the shown table by delta columns, reporting whether
+// there was a table to scroll.
+func (m *model) scrollTable(delta int) bool {
+	docs, ok := m.result.(documentList)
+	if !ok || !m.tableView {
+		return false
+	}
+	opts := m.tableOptions()
+	header, _ := tableCells(docs, opts.computed, opts.layout)
+	m.tableScroll = max(0, min(m.tableScroll+delta, len(header)-frozenColumns(header)-1))
+	return true
+}
+
+// formatCell formats a value for a table cell, cutting off long values.
+func formatCell(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+		if len(s) > 12 {
+			s = strconv.FormatFloat(v, 'f', 2, 64)
+		}
+	case primitive.ObjectID:
+		s = v.Hex()
+	case primitive.DateTime:
+		s = v.Time().UTC().Format("2006-01-02 15:04:05")
+	case bson.M, bson.A, bson.D:
+		text, err := bson.MarshalExtJSON(bson.M{"v": v}, false, false)
+		if err != nil {
+			s = fmt.Sprint(v)
+		} else {
+			s = strings.TrimSuffix(strings.TrimPrefix(string(text), `{"v":`), "}")
+		}
+	default:
+		s = fmt.Sprint(v)
+	}
+	s = strings.ReplaceAll(s, "\n", " ")
+	if runes := []rune(s); len(runes) > maxCellWidth {
+		s = string(runes[:maxCellWidth-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+)
+
+// loadLayouts reads the table columns chosen with the columns command in
+// earlier sessions. Losing them is not worth failing to start over.
+func loadLayouts() map[string][]string {
+	layouts := map[string][]string{}
+	state, err := config.LoadState()
+	if err != nil {
+		slog.Warn("loading state failed", "error", err)
+		return layouts
+	}
+	for ns, fields := range state.Columns {
+		layouts[ns] = fields
+	}
+	return layouts
+}
+
+// saveLayouts remembers the chosen table columns for later sessions, keeping
+// the rest of the state as it is.
+func saveLayouts(layouts map[string][]string) error {
+	state, err := config.LoadState()
+	if err != nil {
+		return err
+	}
+	state.Columns = layouts
+	return config.SaveState(state)
+}
+
+// columnLayout chooses which fields, in which order, the table of the current
+// collection shows. The choice is kept across sessions.
+func (m *model) columnLayout(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: columns [<field>,<field>,... | reset]"
+
+	db, coll, err := m.collectionPath("columns")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	ns := db + "." + coll
+
+	if len(args) == 0 {
+		if fields := m.layouts[ns]; len(fields) > 0 {
+			m.result = message(fmt.Sprintf("table columns of %s: %s", ns, strings.Join(fields, ", ")))
+		} else {
+			m.result = message(fmt.Sprintf("the table of %s shows every field", ns))
+		}
+		m.err = nil
+		return m, nil
+	}
+
+	var fields []string
+	if len(args) == 1 && args[0] == "reset" {
+		delete(m.layouts, ns)
+	} else {
+		seen := map[string]bool{}
+		for _, field := range strings.Split(strings.Join(args, ","), ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			if seen[field] {
+				m.err = fmt.Errorf("columns: '%s' is listed twice", field)
+				return m, nil
+			}
+			seen[field] = true
+			fields = append(fields, field)
+		}
+		if len(fields) == 0 {
+			m.err = fmt.Errorf(usage)
+			return m, nil
+		}
+		m.layouts[ns] = fields
+	}
+	m.tableScroll = 0
+
+	if err := saveLayouts(m.layouts); err != nil {
+		m.err = fmt.Errorf("columns: applied for this session, but saving failed: %w", err)
+		return m, nil
+	}
+	m.err = nil
+	if fields == nil {
+		m.result = message(fmt.Sprintf("the table of %s shows every field again", ns))
+	} else {
+		m.result = message(fmt.Sprintf("the table of %s shows %s", ns, strings.Join(fields, ", ")))
+	}
+	return m, nil
+}
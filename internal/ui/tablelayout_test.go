@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+)
+
+func TestColumnLayout(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+	run(t, m, "set table on")
+	run(t, m, "column add total = price * qty")
+	run(t, m, "columns item, total,qty")
+	run(t, m, "find")
+
+	header := strings.Fields(strings.Split(m.resultView(), "\n")[0])
+	if strings.Join(header, " ") != "item total qty" {
+		t.Fatalf("header = %q, want item total qty", header)
+	}
+	state, err := config.LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(state.Columns["shop.orders"], ","); got != "item,total,qty" {
+		t.Fatalf("saved layout = %q", got)
+	}
+	if got := loadLayouts()["shop.orders"]; len(got) != 3 {
+		t.Fatalf("layout not loaded back: %q", got)
+	}
+
+	run(t, m, "columns reset")
+	run(t, m, "find")
+	if header := strings.Split(m.resultView(), "\n")[0]; !strings.HasPrefix(header, "_id") {
+		t.Fatalf("header after reset = %q", header)
+	}
+	if state, _ := config.LoadState(); len(state.Columns) != 0 {
+		t.Fatalf("layout still saved after reset: %v", state.Columns)
+	}
+
+	run(t, m, "columns item,item")
+	if m.err == nil || !strings.Contains(m.err.Error(), "twice") {
+		t.Fatalf("duplicate column: err = %v", m.err)
+	}
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+// sortby sorts the shown documents by a field or computed column, ascending
+// unless the column has a - prefix. Sorting again by the column the
+// documents are sorted by turns the order around.
+func (m *model) sortby(args []string) (tea.Model, tea.Cmd) {
+	if len(args) != 1 || strings.TrimPrefix(args[0], "-") == "" {
+		m.err = fmt.Errorf("usage: sortby [-]<column>")
+		return m, nil
+	}
+	key := args[0]
+	if key == m.tableSort && !strings.HasPrefix(key, "-") {
+		key = "-" + key
+	}
+	return m, m.sortDocuments("sortby", key)
+}
+
+// sortKey sorts a table with Shift+↑ (ascending) and Shift+↓ (descending)
+// by its first column after _id, the one scrolled to with ← and →. It
+// reports whether it used the key.
+func (m *model) sortKey(msg tea.KeyMsg) (bool, tea.Cmd) {
+	docs, ok := m.result.(documentList)
+	if !ok || !m.tableView || m.prompt != nil || m.running != nil || m.err != nil || m.textInput.Value() != "" {
+		return false, nil
+	}
+	opts := m.tableOptions()
+	header, _ := tableCells(docs, opts.computed, opts.layout)
+	if len(header) == 0 {
+		return false, nil
+	}
+	column := header[min(frozenColumns(header)+m.tableScroll, len(header)-1)]
+	switch msg.Type {
+	case tea.KeyShiftUp:
+		return true, m.sortDocuments("sort", column)
+	case tea.KeyShiftDown:
+		return true, m.sortDocuments("sort", "-"+column)
+	}
+	return false, nil
+}
+
+// sortDocuments sorts the shown documents by key, a column with a - prefix
+// for descending. When they are all on screen they are sorted here; a paged
+// result with more pages is queried again with a sort on the server, so the
+// order covers every page. Computed columns only exist here, so their sort
+// only covers the page.
+func (m *model) sortDocuments(name, key string) tea.Cmd {
+	docs, ok := m.result.(documentList)
+	if !ok {
+		m.err = fmt.Errorf("%s: no documents shown, run find or ls in a collection first", name)
+		return nil
+	}
+	field := strings.TrimPrefix(key, "-")
+	computed := m.tableOptions().computed
+	if m.results != nil && !m.results.complete() && m.lastFind != nil && !isComputed(field, computed) {
+		args, _, err := commands.StripOption(m.lastFind, "--sort")
+		if err == nil {
+			args, _, err = commands.StripOption(args, "--sort-by")
+		}
+		if err != nil {
+			m.err = fmt.Errorf("%s: %w", name, err)
+			return nil
+		}
+		quoted, _ := json.Marshal(field)
+		direction := 1
+		if key != field {
+			direction = -1
+		}
+		_, cmd := m.find(append(args, "--sort", fmt.Sprintf("{%s: %d}", quoted, direction)))
+		if m.err == nil {
+			m.tableSort = key
+		}
+		return cmd
+	}
+	sortDocuments(docs.docs, field, key != field, computed)
+	m.tableSort = key
+	m.err = nil
+	return nil
+}
+
+// isComputed reports whether name is one of the computed columns.
+func isComputed(name string, computed []computedColumn) bool {
+	for _, c := range computed {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// items returns the item of each shown document, in order.
+func items(t *testing.T, m *model) string {
+	t.Helper()
+	docs, ok := m.result.(documentList)
+	if !ok {
+		t.Fatalf("result is %T, want documentList", m.result)
+	}
+	var names []string
+	for _, doc := range docs.docs {
+		names = append(names, doc["item"].(string))
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSortby(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+	run(t, m, "find --limit 0")
+
+	run(t, m, "sortby qty")
+	if got := items(t, m); got != "pear,apple,plum" {
+		t.Fatalf("sorted by qty: %s", got)
+	}
+	run(t, m, "sortby qty")
+	if got := items(t, m); got != "plum,apple,pear" || m.tableSort != "-qty" {
+		t.Fatalf("sorted by qty again: %s, tableSort %q", got, m.tableSort)
+	}
+	run(t, m, "set table on")
+	if header := strings.Split(m.resultView(), "\n")[0]; !strings.Contains(header, "qty ▼") {
+		t.Fatalf("sort column not marked: %q", header)
+	}
+
+	// Paged, so sorting asks the server to cover every page.
+	run(t, m, "find '{}' --limit 2")
+	run(t, m, "sortby -price")
+	if got := items(t, m); got != "pear,apple" {
+		t.Fatalf("first page sorted by -price: %s", got)
+	}
+	if m.results == nil || !strings.Contains(strings.Join(m.lastFind, " "), `--sort {"price": -1}`) {
+		t.Fatalf("not queried again with a sort: %q", m.lastFind)
+	}
+}
+
+func TestSortKey(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+	run(t, m, "set table on")
+	run(t, m, "find --limit 0")
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRight}) // From item to price
+	m.Update(tea.KeyMsg{Type: tea.KeyShiftDown})
+	if got := items(t, m); got != "pear,apple,plum" || m.tableSort != "-price" {
+		t.Fatalf("Shift+↓ on price: %s, tableSort %q", got, m.tableSort)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyShiftUp})
+	if got := items(t, m); got != "plum,apple,pear" {
+		t.Fatalf("Shift+↑ on price: %s", got)
+	}
+}
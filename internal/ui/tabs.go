@@ -0,0 +1,422 @@
+package ui
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+)
+
+// maxTabs is how many tabs can be open at once, one per Alt+<digit>.
+const maxTabs = 9
+
+var (
+	tabStyle       = lipgloss.NewStyle().Faint(true)
+	activeTabStyle = lipgloss.NewStyle().Reverse(true)
+)
+
+// teaPackage is the package of bubbletea's own messages, such as those that
+// quit or suspend the program, which must reach it as they are.
+var teaPackage = reflect.TypeOf(tea.QuitMsg{}).PkgPath()
+
+// connection is what a tab connects with: a connection string and the
+// profile it was saved as, if any.
+type connection struct {
+	uri      string
+	profile  string
+	env      string
+	readOnly string // What makes the session read-only whatever the deployment, "" if nothing
+
+	rememberPath bool // Go back to the profile's last path
+}
+
+// resolveConnection takes a connection string, or the name of a saved
+// profile instead of one.
+func resolveConnection(target string) (connection, error) {
+	if strings.Contains(target, "://") {
+		return connection{uri: target}, nil
+	}
+	profiles, err := config.LoadProfiles()
+	if err != nil {
+		return connection{}, fmt.Errorf("failed to load profiles: %w", err)
+	}
+	p, ok := profiles[target]
+	if !ok {
+		return connection{}, fmt.Errorf("no profile named %s; create one with connstr", target)
+	}
+	c := connection{uri: p.URI, profile: target, env: p.Env, rememberPath: p.RememberPath}
+	if p.ReadOnly {
+		c.readOnly = fmt.Sprintf("profile '%s'", target)
+	}
+	return c, nil
+}
+
+// openModel connects a session.
+func openModel(c connection, cfg config.Config) *model {
+	m := initialModel(c.uri, cfg)
+	if c.readOnly != "" {
+		m.readOnly, m.readOnlyForced = true, c.readOnly
+	}
+	m.env, m.profile, m.rememberLastPath = c.env, c.profile, c.rememberPath
+	if m.rememberLastPath && m.store != nil {
+		m.returnToLastPath()
+	}
+	return &m
+}
+
+// tab is a session of the workspace, with its own connection, path and
+// results.
+type tab struct {
+	id int
+	m  *model
+}
+
+// workspace holds the tabs, one of which is shown and gets the keys typed.
+// Each tab's commands run on while another is shown.
+type workspace struct {
+	tabs         []*tab
+	active       int
+	lastID       int
+	opening      int // Tabs still connecting
+	cfg          config.Config
+	readOnly     string // --read-only, forced on every tab
+	quitWhenDone bool   // Quit once no tab is running a command
+	width        int
+	height       int
+	connect      func(connection) *model
+}
+
+// tabScopedMsg is a message of a tab's command, delivered to that tab
+// whichever is shown.
+type tabScopedMsg struct {
+	tab int
+	msg tea.Msg
+}
+
+// tabCommandMsg carries a tab command from a tab to the workspace.
+type tabCommandMsg struct {
+	args []string
+}
+
+// quitMsg asks the workspace to quit, from the tab exit was typed in.
+type quitMsg struct{}
+
+// tabOpenedMsg is a new tab, once connected.
+type tabOpenedMsg struct {
+	m *model
+}
+
+// newWorkspace returns a workspace without tabs; add the first one.
+func newWorkspace(cfg config.Config, readOnly string) *workspace {
+	w := &workspace{cfg: cfg, readOnly: readOnly}
+	w.connect = func(c connection) *model { return openModel(c, w.cfg) }
+	return w
+}
+
+// newModel connects the session of a tab, read-only if the workspace is.
+func (w *workspace) newModel(c connection) *model {
+	if w.readOnly != "" {
+		c.readOnly = w.readOnly
+	}
+	return w.connect(c)
+}
+
+func (w *workspace) add(m *model) *tab {
+	w.lastID++
+	t := &tab{id: w.lastID, m: m}
+	w.tabs = append(w.tabs, t)
+	return t
+}
+
+func (w *workspace) Init() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, t := range w.tabs {
+		cmds = append(cmds, scoped(t.id, t.m.Init()))
+	}
+	return tea.Batch(cmds...)
+}
+
+// scoped makes the messages of a tab's command reach that tab.
+func scoped(id int, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return scopeMsg(id, cmd())
+	}
+}
+
+func scopeMsg(id int, msg tea.Msg) tea.Msg {
+	switch msg := msg.(type) {
+	case nil:
+		return nil
+	case tea.BatchMsg:
+		cmds := make(tea.BatchMsg, len(msg))
+		for i, cmd := range msg {
+			cmds[i] = scoped(id, cmd)
+		}
+		return cmds
+	}
+	if reflect.TypeOf(msg).PkgPath() == teaPackage {
+		return msg
+	}
+	return tabScopedMsg{tab: id, msg: msg}
+}
+
+func (w *workspace) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		w.width, w.height = msg.Width, msg.Height
+		w.resize()
+		return w, nil
+
+	case tea.KeyMsg:
+		if cmd, ok := w.tabKey(msg); ok {
+			return w, cmd
+		}
+
+	case tabScopedMsg:
+		t := w.find(msg.tab)
+		if t == nil {
+			return w, nil // The tab was closed
+		}
+		if c, ok := msg.msg.(tabCommandMsg); ok {
+			return w, w.tabCommand(t, c.args)
+		}
+		if _, ok := msg.msg.(quitMsg); ok {
+			return w, w.quit(t)
+		}
+		return w, w.update(t, msg.msg)
+
+	case tabOpenedMsg:
+		w.opening--
+		if len(w.tabs) == maxTabs {
+			return w, func() tea.Msg { msg.m.shutdown(); return nil }
+		}
+		t := w.add(msg.m)
+		w.active = len(w.tabs) - 1
+		w.resize()
+		return w, scoped(t.id, t.m.Init())
+	}
+	// Keys, and messages of commands run in sequence, which cannot be told
+	// apart, go to the tab shown
+	return w, w.update(w.tabs[w.active], msg)
+}
+
+func (w *workspace) update(t *tab, msg tea.Msg) tea.Cmd {
+	_, cmd := t.m.Update(msg)
+	if w.quitWhenDone && len(w.running()) == 0 {
+		return tea.Quit
+	}
+	return scoped(t.id, cmd)
+}
+
+// index returns the position of t among the tabs.
+func (w *workspace) index(t *tab) int {
+	for i, other := range w.tabs {
+		if other == t {
+			return i
+		}
+	}
+	return -1
+}
+
+func (w *workspace) find(id int) *tab {
+	for _, t := range w.tabs {
+		if t.id == id {
+			return t
+		}
+	}
+	return nil
+}
+
+// tabKey handles the keys that open and switch tabs. Terminals send Ctrl+Tab
+// as a plain Tab, so tabs are switched with Ctrl+PgDn and Ctrl+PgUp instead.
+func (w *workspace) tabKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch msg.Type {
+	case tea.KeyCtrlT:
+		return w.open(w.tabs[w.active].m.connection()), true
+	case tea.KeyCtrlPgDown:
+		w.active = (w.active + 1) % len(w.tabs)
+		return nil, true
+	case tea.KeyCtrlPgUp:
+		w.active = (w.active + len(w.tabs) - 1) % len(w.tabs)
+		return nil, true
+	case tea.KeyRunes:
+		if !msg.Alt || len(msg.Runes) != 1 || msg.Runes[0] < '1' || msg.Runes[0] > '9' {
+			return nil, false
+		}
+		if n := int(msg.Runes[0] - '0'); n <= len(w.tabs) {
+			w.active = n - 1
+		}
+		return nil, true
+	}
+	return nil, false
+}
+
+// open connects a new tab, which is shown once connected.
+func (w *workspace) open(c connection) tea.Cmd {
+	if len(w.tabs)+w.opening >= maxTabs {
+		m := w.tabs[w.active].m
+		m.err = fmt.Errorf("tab: %d tabs are open already", maxTabs)
+		return nil
+	}
+	w.opening++
+	return func() tea.Msg { return tabOpenedMsg{m: w.newModel(c)} }
+}
+
+// tabCommand runs `tab`, `tab new [connection string | profile]`,
+// `tab <n>` or `tab close` for the tab it was typed in.
+func (w *workspace) tabCommand(t *tab, args []string) tea.Cmd {
+	const usage = "usage: tab [new [connection string | profile] | <n> | close]"
+	m := t.m
+	m.err = nil
+	switch {
+	case len(args) == 0 || (len(args) == 1 && args[0] == "ls"):
+		m.result = w.tabList()
+	case args[0] == "new" && len(args) <= 2:
+		c := m.connection()
+		if len(args) == 2 {
+			var err error
+			if c, err = resolveConnection(args[1]); err != nil {
+				m.err = fmt.Errorf("tab new: %w", err)
+				return nil
+			}
+		}
+		return w.open(c)
+	case args[0] == "close" && len(args) == 1:
+		return w.close(t)
+	case len(args) == 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > len(w.tabs) {
+			m.err = fmt.Errorf("tab: no tab %s, there are %d", args[0], len(w.tabs))
+			return nil
+		}
+		w.active = n - 1
+	default:
+		m.err = fmt.Errorf(usage)
+	}
+	return nil
+}
+
+// close closes a tab and its connection. The last tab cannot be closed;
+// exit quits.
+func (w *workspace) close(t *tab) tea.Cmd {
+	if len(w.tabs) == 1 {
+		t.m.err = fmt.Errorf("tab close: this is the last tab, use exit to quit")
+		return nil
+	}
+	for i, other := range w.tabs {
+		if other == t {
+			w.tabs = append(w.tabs[:i], w.tabs[i+1:]...)
+			if w.active > i || w.active == len(w.tabs) {
+				w.active--
+			}
+			break
+		}
+	}
+	w.resize()
+	return func() tea.Msg { t.m.shutdown(); return nil }
+}
+
+func (w *workspace) tabList() result {
+	var b strings.Builder
+	for i, t := range w.tabs {
+		marker := " "
+		if i == w.active {
+			marker = "*"
+		}
+		b.WriteString(fmt.Sprintf("%s %d  %-40s %s", marker, i+1, t.m.tabLabel(), redactURI(t.m.connectionString)))
+		if t.m.running != nil {
+			b.WriteString(fmt.Sprintf("  running '%s'", t.m.running.label))
+		}
+		b.WriteString("\n")
+	}
+	return message(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// resize gives the tabs the screen, less the line of the tab bar while it
+// is shown.
+func (w *workspace) resize() {
+	height := w.height
+	if w.showBar() && height > 0 {
+		height--
+	}
+	for _, t := range w.tabs {
+		t.m.resize(tea.WindowSizeMsg{Width: w.width, Height: height})
+	}
+}
+
+func (w *workspace) showBar() bool {
+	return len(w.tabs) > 1 || w.opening > 0
+}
+
+func (w *workspace) View() string {
+	view := w.tabs[w.active].m.View()
+	if !w.showBar() {
+		return view
+	}
+	var labels []string
+	for i, t := range w.tabs {
+		label := fmt.Sprintf(" %d %s ", i+1, t.m.tabLabel())
+		if t.m.running != nil {
+			label += "● "
+		}
+		if i == w.active {
+			labels = append(labels, activeTabStyle.Render(label))
+		} else {
+			labels = append(labels, tabStyle.Render(label))
+		}
+	}
+	if w.opening > 0 {
+		labels = append(labels, tabStyle.Render(" connecting… "))
+	}
+	bar := strings.Join(labels, " ")
+	if w.width > 0 {
+		bar = ansi.Truncate(bar, w.width, "…")
+	}
+	return bar + "\n" + view
+}
+
+// shutdown shuts every tab down once the program has stopped.
+func (w *workspace) shutdown() {
+	for _, t := range w.tabs {
+		t.m.shutdown()
+	}
+}
+
+// connection returns what the session is connected with, for a tab of the
+// same connection.
+func (m *model) connection() connection {
+	return connection{uri: m.connectionString, profile: m.profile, env: m.env, readOnly: m.readOnlyForced, rememberPath: m.rememberLastPath}
+}
+
+// tabLabel names a tab by its connection and path.
+func (m *model) tabLabel() string {
+	return m.connectionName() + " /" + strings.Join(m.currentPath, "/")
+}
+
+// connectionName is the profile connected with or else the first host of
+// the connection string.
+func (m *model) connectionName() string {
+	if m.profile != "" {
+		return m.profile
+	}
+	if cs, err := connstring.Parse(m.connectionString); err == nil && len(cs.Hosts) > 0 {
+		return cs.Hosts[0]
+	}
+	return ""
+}
+
+// tabs asks the workspace to list, open, switch to or close tabs.
+func (m *model) tabs(args []string) (tea.Model, tea.Cmd) {
+	m.err = nil
+	return m, func() tea.Msg { return tabCommandMsg{args: args} }
+}
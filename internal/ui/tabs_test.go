@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+)
+
+func testWorkspace() *workspace {
+	w := newWorkspace(config.Config{}, "")
+	w.connect = func(connection) *model { return newTestModel(seededFake()) }
+	w.add(w.newModel(connection{}))
+	return w
+}
+
+// drainWorkspace is drain for the messages of a workspace's tabs.
+func drainWorkspace(w *workspace, cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	switch msg := cmd().(type) {
+	case nil:
+	case tea.BatchMsg:
+		for _, c := range msg {
+			drainWorkspace(w, c)
+		}
+	default:
+		if s, ok := msg.(tabScopedMsg); ok {
+			if _, tick := s.msg.(spinner.TickMsg); tick {
+				return
+			}
+		}
+		_, next := w.Update(msg)
+		drainWorkspace(w, next)
+	}
+}
+
+// finishWorkspace runs cmd like drainWorkspace, reporting whether it quits.
+func finishWorkspace(w *workspace, cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	quit := false
+	switch msg := cmd().(type) {
+	case tea.QuitMsg:
+		return true
+	case tea.BatchMsg:
+		for _, c := range msg {
+			quit = finishWorkspace(w, c) || quit
+		}
+	case tabScopedMsg:
+		if _, tick := msg.msg.(spinner.TickMsg); !tick {
+			_, next := w.Update(msg)
+			quit = finishWorkspace(w, next)
+		}
+	}
+	return quit
+}
+
+// typeInto types input in the tab shown and presses Enter, returning the
+// commands started without running them.
+func typeInto(w *workspace, input string) tea.Cmd {
+	w.tabs[w.active].m.textInput.SetValue(input)
+	_, cmd := w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return cmd
+}
+
+func TestTabs(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	w := testWorkspace()
+	drainWorkspace(w, typeInto(w, "cd shop/orders"))
+
+	_, cmd := w.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	drainWorkspace(w, cmd)
+	if len(w.tabs) != 2 || w.active != 1 {
+		t.Fatalf("Ctrl+T: %d tabs, tab %d shown", len(w.tabs), w.active+1)
+	}
+	first, second := w.tabs[0].m, w.tabs[1].m
+	if len(second.currentPath) != 0 {
+		t.Errorf("new tab starts in %v", second.currentPath)
+	}
+	drainWorkspace(w, typeInto(w, "cd shop"))
+
+	// A command keeps running in its tab while another is shown
+	w.Update(tea.KeyMsg{Type: tea.KeyCtrlPgUp})
+	count := typeInto(w, "count")
+	w.Update(tea.KeyMsg{Type: tea.KeyCtrlPgDown})
+	drainWorkspace(w, count)
+	if w.active != 1 || first.result == nil || !strings.Contains(first.result.String(), "3") || second.result != message("in /shop") {
+		t.Errorf("count in tab 1 while tab 2 is shown: tab 1 %v, tab 2 %v", first.result, second.result)
+	}
+	if strings.Join(first.currentPath, "/") != "shop/orders" || strings.Join(second.currentPath, "/") != "shop" {
+		t.Errorf("paths: %v and %v", first.currentPath, second.currentPath)
+	}
+	if view := w.View(); !strings.Contains(view, "1  /shop/orders") || !strings.Contains(view, "2  /shop") {
+		t.Errorf("tab bar: %q", strings.SplitN(view, "\n", 2)[0])
+	}
+
+	drainWorkspace(w, typeInto(w, "tab 1"))
+	if w.active != 0 {
+		t.Errorf("tab 1 shows tab %d", w.active+1)
+	}
+	drainWorkspace(w, typeInto(w, "tab close"))
+	if len(w.tabs) != 1 || w.tabs[0].m != second || w.active != 0 {
+		t.Fatalf("tab close: %d tabs", len(w.tabs))
+	}
+	drainWorkspace(w, typeInto(w, "tab close"))
+	if second.err == nil || !strings.Contains(second.err.Error(), "last tab") {
+		t.Errorf("closing the last tab: %v", second.err)
+	}
+}
+
+func TestQuitAsksAboutEveryTab(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	w := testWorkspace()
+	drainWorkspace(w, typeInto(w, "cd shop/orders"))
+	_, cmd := w.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	drainWorkspace(w, cmd)
+	first, second := w.tabs[0].m, w.tabs[1].m
+
+	w.Update(tea.KeyMsg{Type: tea.KeyCtrlPgUp})
+	count := typeInto(w, "count") // Left running in tab 1
+	w.Update(tea.KeyMsg{Type: tea.KeyCtrlPgDown})
+	msg := typeInto(w, "exit")()
+	if s, ok := msg.(tabScopedMsg); !ok || s.msg != (quitMsg{}) {
+		t.Fatalf("exit: %#v", msg)
+	}
+	_, cmd = w.Update(msg)
+	drainWorkspace(w, cmd)
+	if second.prompt == nil || !strings.Contains(second.prompt.label, "'count' in tab 1 is still running") {
+		t.Fatalf("exit in tab 2 while tab 1 runs count: prompt %+v", second.prompt)
+	}
+	if strings.Contains(second.prompt.label, "[d]etach") {
+		t.Errorf("count offered to detach: %s", second.prompt.label)
+	}
+
+	drainWorkspace(w, typeInto(w, "w"))
+	if !w.quitWhenDone {
+		t.Fatal("not waiting for tab 1")
+	}
+	if quit := finishWorkspace(w, count); first.running != nil || !quit {
+		t.Errorf("not quitting once tab 1 is done: running %v", first.running)
+	}
+}
+
+func TestQuitDetachesExport(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	w := testWorkspace()
+	m := w.tabs[0].m
+	drainWorkspace(w, typeInto(w, "cd shop/orders"))
+	path := filepath.Join(t.TempDir(), "orders.ndjson")
+	export := typeInto(w, "export "+path) // Left running
+
+	_, cmd := w.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	drainWorkspace(w, cmd)
+	if m.prompt == nil || !strings.Contains(m.prompt.label, "[d]etach to resume later") {
+		t.Fatalf("quit during an export: prompt %+v", m.prompt)
+	}
+	drainWorkspace(w, typeInto(w, "d"))
+	if quit := finishWorkspace(w, export); !quit {
+		t.Fatal("not quitting once the export is detached")
+	}
+	if _, err := os.Stat(checkpointName(path)); err != nil {
+		t.Errorf("no checkpoint: %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+	"github.com/nick-popovic/mon-go/internal/config"
+)
+
+// placeholderPattern matches {{name}}, {{name|default}} and generators such
+// as {{now()}} in template strings.
+var placeholderPattern = regexp.MustCompile(`{{\s*([^{}|]+?)\s*(?:\|([^{}]*))?}}`)
+
+// templateGenerators produce fresh values for generated fields.
+var templateGenerators = map[string]func() interface{}{
+	"now()": func() interface{} { return primitive.NewDateTimeFromTime(time.Now()) },
+	"oid()": func() interface{} { return primitive.NewObjectID() },
+}
+
+// templatesPath returns the file templates are kept in, next to the config
+// file, so they outlive the session.
+func templatesPath() (string, error) {
+	path, err := config.Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "templates.json"), nil
+}
+
+// loadTemplates reads the document templates, keyed by "db.collection".
+func loadTemplates() (map[string]string, error) {
+	templates := map[string]string{}
+	path, err := templatesPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return templates, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return templates, nil
+}
+
+func saveTemplates(templates map[string]string) error {
+	path, err := templatesPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(templates, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// template manages the document template of the current collection, used by
+// `insert --from-template`.
+func (m *model) template(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: template show | set '<json>' | rm"
+
+	db, coll, err := m.collectionPath("template")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	if len(args) == 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	templates, err := loadTemplates()
+	if err != nil {
+		m.err = fmt.Errorf("template: %w", err)
+		return m, nil
+	}
+	ns := db + "." + coll
+
+	switch {
+	case args[0] == "show" && len(args) == 1:
+		text, ok := templates[ns]
+		if !ok {
+			m.err = fmt.Errorf("template: no template for %s, see `template set`", ns)
+			return m, nil
+		}
+		m.err = nil
+		m.result = message(text)
+		return m, nil
+
+	case args[0] == "set" && len(args) == 2:
+		if _, err := commands.ParseDocument(args[1]); err != nil {
+			m.err = fmt.Errorf("template set: invalid document: %w", err)
+			return m, nil
+		}
+		templates[ns] = args[1]
+		if err := saveTemplates(templates); err != nil {
+			m.err = fmt.Errorf("template set: %w", err)
+			return m, nil
+		}
+		m.err = nil
+		m.result = message(fmt.Sprintf("template for %s saved", ns))
+		return m, nil
+
+	case args[0] == "rm" && len(args) == 1:
+		if _, ok := templates[ns]; !ok {
+			m.err = fmt.Errorf("template rm: no template for %s", ns)
+			return m, nil
+		}
+		delete(templates, ns)
+		if err := saveTemplates(templates); err != nil {
+			m.err = fmt.Errorf("template rm: %w", err)
+			return m, nil
+		}
+		m.err = nil
+		m.result = message(fmt.Sprintf("template for %s removed", ns))
+		return m, nil
+
+	default:
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+}
+
+// parseTemplateValues parses name=value arguments. Values are read as JSON
+// where possible, so age=30 is a number and active=true a boolean; anything
+// else is a string.
+func parseTemplateValues(args []string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, arg := range args {
+		name, raw, ok := strings.Cut(arg, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("expected name=value, got '%s'", arg)
+		}
+		values[name] = templateValue(raw)
+	}
+	return values, nil
+}
+
+func templateValue(raw string) interface{} {
+	var wrapper struct {
+		V interface{} `bson:"v"`
+	}
+	if err := bson.UnmarshalExtJSON([]byte(`{"v": `+raw+`}`), false, &wrapper); err == nil {
+		return wrapper.V
+	}
+	return raw
+}
+
+// fillTemplate builds a document from a template. A string that is just a
+// placeholder is replaced by the value itself, keeping its type; placeholders
+// inside longer strings are replaced by the value's text.
+func fillTemplate(template bson.D, values map[string]interface{}) (bson.D, error) {
+	filled, err := fillValue(template, values)
+	if err != nil {
+		return nil, err
+	}
+	return filled.(bson.D), nil
+}
+
+func fillValue(v interface{}, values map[string]interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case bson.D:
+		doc := make(bson.D, len(v))
+		for i, e := range v {
+			filled, err := fillValue(e.Value, values)
+			if err != nil {
+				return nil, err
+			}
+			doc[i] = bson.E{Key: e.Key, Value: filled}
+		}
+		return doc, nil
+	case bson.A:
+		arr := make(bson.A, len(v))
+		for i, item := range v {
+			filled, err := fillValue(item, values)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = filled
+		}
+		return arr, nil
+	case string:
+		if match := placeholderPattern.FindStringSubmatchIndex(v); match != nil && match[0] == 0 && match[1] == len(v) {
+			return resolvePlaceholder(v, match, values)
+		}
+		var err error
+		text := placeholderPattern.ReplaceAllStringFunc(v, func(placeholder string) string {
+			match := placeholderPattern.FindStringSubmatchIndex(placeholder)
+			value, resolveErr := resolvePlaceholder(placeholder, match, values)
+			if resolveErr != nil {
+				err = resolveErr
+			}
+			return fmt.Sprint(value)
+		})
+		return text, err
+	default:
+		return v, nil
+	}
+}
+
+// resolvePlaceholder returns the value of the placeholder at match in s.
+func resolvePlaceholder(s string, match []int, values map[string]interface{}) (interface{}, error) {
+	name := s[match[2]:match[3]]
+	if generate, ok := templateGenerators[name]; ok {
+		return generate(), nil
+	}
+	if value, ok := values[name]; ok {
+		return value, nil
+	}
+	if match[4] >= 0 {
+		return templateValue(s[match[4]:match[5]]), nil
+	}
+	return nil, fmt.Errorf("template needs %s=<value>", name)
+}
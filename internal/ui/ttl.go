@@ -0,0 +1,221 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+// ttlIndex is a single-field index and its expiry in seconds, which is -1
+// for indexes that do not expire documents.
+type ttlIndex struct {
+	collection string
+	name       string
+	field      string
+	seconds    int64
+}
+
+// ttlList is the result of `ttl ls`.
+type ttlList struct {
+	indexes []ttlIndex
+}
+
+func (l ttlList) String() string {
+	if len(l.indexes) == 0 {
+		return "no TTL indexes\n"
+	}
+
+	var b strings.Builder
+	for _, ix := range l.indexes {
+		expiry := time.Duration(ix.seconds) * time.Second
+		b.WriteString(fmt.Sprintf("%s  %s  %s expires after %ds (%s)\n", ix.collection, ix.name, ix.field, ix.seconds, expiry))
+	}
+	return b.String()
+}
+
+// listTTLIndexes returns the single-field indexes of db.coll, TTL or not, so
+// callers can also convert an existing index into a TTL index.
+func listTTLIndexes(ctx context.Context, st store.Store, db, coll string) ([]ttlIndex, error) {
+	cur, err := st.ListIndexes(ctx, db, coll)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var indexes []ttlIndex
+	for cur.Next(ctx) {
+		var spec struct {
+			Name               string      `bson:"name"`
+			Key                bson.D      `bson:"key"`
+			ExpireAfterSeconds interface{} `bson:"expireAfterSeconds"`
+		}
+		if err := cur.Decode(&spec); err != nil {
+			return nil, err
+		}
+		if len(spec.Key) != 1 {
+			continue // TTL indexes are always single-field
+		}
+		seconds, ok := asInt64(spec.ExpireAfterSeconds)
+		if !ok {
+			seconds = -1
+		}
+		indexes = append(indexes, ttlIndex{collection: coll, name: spec.Name, field: spec.Key[0].Key, seconds: seconds})
+	}
+	return indexes, cur.Err()
+}
+
+func (m *model) ttl(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: ttl ls | set <field> <seconds> | rm <field>"
+	if len(args) == 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	switch args[0] {
+	case "ls":
+		if len(m.currentPath) == 0 {
+			m.err = fmt.Errorf("ttl ls: cd into a database or collection first")
+			return m, nil
+		}
+		return m, m.listTTL()
+
+	case "set":
+		if len(args) != 3 {
+			m.err = fmt.Errorf("usage: ttl set <field> <seconds>")
+			return m, nil
+		}
+		seconds, err := strconv.ParseInt(args[2], 10, 32)
+		if err != nil || seconds < 0 {
+			m.err = fmt.Errorf("ttl set: invalid number of seconds: %s", args[2])
+			return m, nil
+		}
+		db, coll, err := m.collectionPath("ttl set")
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		if m.dryRun {
+			return m, m.dryRunTTL(db, coll, args[1], int32(seconds))
+		}
+		return m, m.setTTL(db, coll, args[1], int32(seconds))
+
+	case "rm":
+		if len(args) != 2 {
+			m.err = fmt.Errorf("usage: ttl rm <field>")
+			return m, nil
+		}
+		db, coll, err := m.collectionPath("ttl rm")
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		return m, m.removeTTL(db, coll, args[1])
+
+	default:
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+}
+
+// listTTL lists the TTL indexes of the current collection, or of every
+// collection in the current database.
+func (m *model) listTTL() tea.Cmd {
+	path := m.currentPath
+	return m.run(func(ctx context.Context) tea.Msg {
+		collNames := path[1:2]
+		if len(path) == 1 {
+			namespaces, err := m.store.ListNamespaces(ctx, path[0])
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			collNames = nil
+			for _, ns := range namespaces {
+				if ns.Kind == "collection" {
+					collNames = append(collNames, ns.Name)
+				}
+			}
+		}
+
+		var list ttlList
+		for _, name := range collNames {
+			indexes, err := listTTLIndexes(ctx, m.store, path[0], name)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			for _, ix := range indexes {
+				if ix.seconds >= 0 {
+					list.indexes = append(list.indexes, ix)
+				}
+			}
+		}
+		return mongoMsg{result: list}
+	})
+}
+
+// setTTL makes documents of db.coll expire seconds after the date in field.
+// An existing index on the field is changed with collMod rather than dropped
+// and rebuilt.
+func (m *model) setTTL(dbName, collName, field string, seconds int32) tea.Cmd {
+	return m.run(func(ctx context.Context) tea.Msg {
+		indexes, err := listTTLIndexes(ctx, m.store, dbName, collName)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+
+		for _, ix := range indexes {
+			if ix.field != field {
+				continue
+			}
+			cmd := bson.D{
+				{Key: "collMod", Value: collName},
+				{Key: "index", Value: bson.D{
+					{Key: "name", Value: ix.name},
+					{Key: "expireAfterSeconds", Value: seconds},
+				}},
+			}
+			if err := m.store.RunCommand(ctx, dbName, cmd, nil); err != nil {
+				return mongoMsg{err: err}
+			}
+			return mongoMsg{result: message(fmt.Sprintf("index '%s' now expires documents %ds after '%s'", ix.name, seconds, field))}
+		}
+
+		index := mongo.IndexModel{
+			Keys:    bson.D{{Key: field, Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(seconds),
+		}
+		name, err := m.store.CreateIndex(ctx, dbName, collName, index)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		return mongoMsg{result: message(fmt.Sprintf("created TTL index '%s' expiring documents %ds after '%s'", name, seconds, field))}
+	})
+}
+
+// removeTTL drops the TTL index on field of db.coll.
+func (m *model) removeTTL(dbName, collName, field string) tea.Cmd {
+	return m.run(func(ctx context.Context) tea.Msg {
+		indexes, err := listTTLIndexes(ctx, m.store, dbName, collName)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+
+		for _, ix := range indexes {
+			if ix.field == field && ix.seconds >= 0 {
+				if err := m.store.DropIndex(ctx, dbName, collName, ix.name); err != nil {
+					return mongoMsg{err: err}
+				}
+				return mongoMsg{result: message(fmt.Sprintf("dropped TTL index '%s'", ix.name))}
+			}
+		}
+		return mongoMsg{err: fmt.Errorf("no TTL index on '%s' in %s.%s", field, dbName, collName)}
+	})
+}
@@ -0,0 +1,30 @@
+package ui
+
+import "testing"
+
+func TestTTL(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+
+	run(t, m, "ttl ls")
+	if got := output(t, m); got != "no TTL indexes\n" {
+		t.Errorf("ttl ls without TTL indexes: %q", got)
+	}
+	run(t, m, "ttl set createdAt 3600")
+	if got := output(t, m); got != "created TTL index 'createdAt_1' expiring documents 3600s after 'createdAt'\n" {
+		t.Errorf("ttl set: %q", got)
+	}
+	run(t, m, "ttl ls")
+	if got := output(t, m); got != "orders  createdAt_1  createdAt expires after 3600s (1h0m0s)\n" {
+		t.Errorf("ttl ls: %q", got)
+	}
+	run(t, m, "ttl rm createdAt")
+	if got := output(t, m); got != "dropped TTL index 'createdAt_1'\n" {
+		t.Errorf("ttl rm: %q", got)
+	}
+	run(t, m, "ttl ls")
+	if got := output(t, m); got != "no TTL indexes\n" {
+		t.Errorf("ttl ls after rm: %q", got)
+	}
+	expectError(t, m, "ttl rm createdAt", "no TTL index on 'createdAt' in shop.orders")
+}
@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+// roleRef identifies a role by name and the database it is defined on.
+type roleRef struct {
+	Role string `bson:"role"`
+	DB   string `bson:"db"`
+}
+
+func (r roleRef) String() string {
+	return r.Role + "@" + r.DB
+}
+
+// userInfo is the subset of a usersInfo entry that mon-go displays.
+type userInfo struct {
+	User  string    `bson:"user"`
+	DB    string    `bson:"db"`
+	Roles []roleRef `bson:"roles"`
+}
+
+// userList is the result of `user ls`.
+type userList struct {
+	users []userInfo
+}
+
+func (l userList) String() string {
+	if len(l.users) == 0 {
+		return "no users\n"
+	}
+
+	width := 0
+	for _, u := range l.users {
+		if n := len(u.User) + 1 + len(u.DB); n > width {
+			width = n
+		}
+	}
+
+	var b strings.Builder
+	for _, u := range l.users {
+		roles := make([]string, len(u.Roles))
+		for i, r := range u.Roles {
+			roles[i] = r.String()
+		}
+		b.WriteString(fmt.Sprintf("%-*s  %s\n", width, u.User+"@"+u.DB, strings.Join(roles, ", ")))
+	}
+	return b.String()
+}
+
+// authDatabase returns the database user and role commands operate on: the
+// current database, or admin when at the root.
+func (m *model) authDatabase() string {
+	if len(m.currentPath) == 0 {
+		return "admin"
+	}
+	return m.currentPath[0]
+}
+
+// parseRoles turns "role" and "role@db" arguments into role documents,
+// defaulting the database to db.
+func parseRoles(args []string, db string) bson.A {
+	roles := bson.A{}
+	for _, arg := range args {
+		name, roleDB, found := strings.Cut(arg, "@")
+		if !found {
+			roleDB = db
+		}
+		roles = append(roles, bson.D{{Key: "role", Value: name}, {Key: "db", Value: roleDB}})
+	}
+	return roles
+}
+
+func (m *model) user(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: user ls | create <name> [role[@db]...] | drop <name> | grant <name> <role[@db]>... | revoke <name> <role[@db]>... | export <file> [--with-credentials] | import <file>"
+	if len(args) == 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	db := m.authDatabase()
+	switch args[0] {
+	case "ls":
+		return m, m.listUsers(db)
+
+	case "create":
+		if len(args) < 2 {
+			m.err = fmt.Errorf("usage: user create <name> [role[@db]...]")
+			return m, nil
+		}
+		name := args[1]
+		roles := parseRoles(args[2:], db)
+		m.ask(fmt.Sprintf("password for %s@%s: ", name, db), true, func(password string) tea.Cmd {
+			if password == "" {
+				return func() tea.Msg {
+					return mongoMsg{err: fmt.Errorf("password must not be empty")}
+				}
+			}
+			cmd := bson.D{{Key: "createUser", Value: name}, {Key: "pwd", Value: password}, {Key: "roles", Value: roles}}
+			return m.runUserCommand(db, cmd, fmt.Sprintf("user '%s' created on %s", name, db))
+		})
+		return m, nil
+
+	case "drop":
+		if len(args) != 2 {
+			m.err = fmt.Errorf("usage: user drop <name>")
+			return m, nil
+		}
+		cmd := bson.D{{Key: "dropUser", Value: args[1]}}
+		return m, m.runUserCommand(db, cmd, fmt.Sprintf("user '%s' dropped from %s", args[1], db))
+
+	case "grant", "revoke":
+		if len(args) < 3 {
+			m.err = fmt.Errorf("usage: user %s <name> <role[@db]>...", args[0])
+			return m, nil
+		}
+		name := args[1]
+		commandName, verb := "grantRolesToUser", "granted to"
+		if args[0] == "revoke" {
+			commandName, verb = "revokeRolesFromUser", "revoked from"
+		}
+		cmd := bson.D{{Key: commandName, Value: name}, {Key: "roles", Value: parseRoles(args[2:], db)}}
+		return m, m.runUserCommand(db, cmd, fmt.Sprintf("roles %s user '%s' on %s", verb, name, db))
+
+	case "export":
+		fs := commands.NewFlagSet("users export")
+		withCredentials := fs.Bool("with-credentials", false, "include password hashes")
+		positional, err := commands.ParseFlags(fs, args[1:])
+		if err != nil || len(positional) != 1 {
+			m.err = fmt.Errorf("usage: users export <file> [--with-credentials]")
+			return m, nil
+		}
+		return m, m.exportUsers(db, positional[0], *withCredentials)
+
+	case "import":
+		if len(args) != 2 {
+			m.err = fmt.Errorf("usage: users import <file>")
+			return m, nil
+		}
+		return m.importUsers(args[1])
+
+	default:
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+}
+
+// listUsers lists the users of db, or of every database when at the root.
+func (m *model) listUsers(db string) tea.Cmd {
+	return m.run(func(ctx context.Context) tea.Msg {
+		cmd := bson.D{{Key: "usersInfo", Value: 1}}
+		if len(m.currentPath) == 0 {
+			cmd = bson.D{{Key: "usersInfo", Value: bson.D{{Key: "forAllDBs", Value: true}}}}
+		}
+
+		var res struct {
+			Users []userInfo `bson:"users"`
+		}
+		if err := m.store.RunCommand(ctx, db, cmd, &res); err != nil {
+			return mongoMsg{err: err}
+		}
+		return mongoMsg{result: userList{users: res.Users}}
+	})
+}
+
+// runUserCommand runs a user administration command on db and reports done
+// on success.
+func (m *model) runUserCommand(db string, cmd bson.D, done string) tea.Cmd {
+	return m.run(func(ctx context.Context) tea.Msg {
+		if err := m.store.RunCommand(ctx, db, cmd, nil); err != nil {
+			return mongoMsg{err: err}
+		}
+		return mongoMsg{result: message(done)}
+	})
+}
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	store "github.com/nick-popovic/mon-go/internal/mongo"
+)
+
+func TestUsers(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop")
+
+	run(t, m, "user create alice read")
+	answer(t, m, "secret")
+	if got := output(t, m); got != "user 'alice' created on shop\n" {
+		t.Errorf("user create: %q", got)
+	}
+	run(t, m, "user create alice read")
+	answer(t, m, "secret")
+	if m.err == nil || !strings.Contains(m.err.Error(), "already exists") {
+		t.Errorf("creating alice twice: error %v", m.err)
+	}
+	run(t, m, "user create bob nosuchrole")
+	answer(t, m, "secret")
+	if m.err == nil || !strings.Contains(m.err.Error(), "Could not find role: nosuchrole@shop") {
+		t.Errorf("creating a user with a missing role: error %v", m.err)
+	}
+
+	run(t, m, "user grant alice readWrite dbAdmin@admin")
+	run(t, m, "user revoke alice read")
+	run(t, m, "user ls")
+	if got := output(t, m); got != "alice@shop  readWrite@shop, dbAdmin@admin\n" {
+		t.Errorf("user ls after grant and revoke: %q", got)
+	}
+	run(t, m, "cd /")
+	run(t, m, "user ls")
+	if got := output(t, m); !strings.Contains(got, "alice@shop") {
+		t.Errorf("user ls at the root lists the users of every database: %q", got)
+	}
+	run(t, m, "cd shop")
+
+	run(t, m, "user drop alice")
+	run(t, m, "user ls")
+	if got := output(t, m); got != "no users\n" {
+		t.Errorf("user ls after drop: %q", got)
+	}
+	expectError(t, m, "user drop alice", "User 'alice@shop' not found")
+}
+
+func TestRoles(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop")
+
+	run(t, m, "role ls")
+	if got := output(t, m); got != "no roles\n" {
+		t.Errorf("role ls without custom roles: %q", got)
+	}
+	run(t, m, "role ls --builtin")
+	if got := output(t, m); !strings.Contains(got, "readWrite@shop  builtin") {
+		t.Errorf("role ls --builtin: %q", got)
+	}
+	run(t, m, "role show read")
+	if got := output(t, m); !strings.Contains(got, "shop.*  collStats, dbStats, find") {
+		t.Errorf("role show read: %q", got)
+	}
+	expectError(t, m, "role show nosuchrole", "role 'nosuchrole' does not exist in database 'shop'")
+}
+
+// authFile is an export with a custom role inheriting from a builtin one
+// and a user without credentials who has it.
+const authFile = `{
+  "roles": [{"role": "reporter", "db": "shop", "roles": [{"role": "read", "db": "shop"}],
+             "privileges": [{"resource": {"db": "shop", "collection": "reports"}, "actions": ["insert"]}]}],
+  "users": [{"user": "carol", "db": "shop", "roles": [{"role": "reporter", "db": "shop"}]}]
+}`
+
+func TestUsersImportAndExport(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "auth.json")
+	if err := os.WriteFile(file, []byte(authFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop")
+
+	run(t, m, "user import "+file+" --dry-run")
+	if got := output(t, m); !strings.Contains(got, "would create 2 and skip 0") {
+		t.Errorf("import --dry-run: %q", got)
+	}
+	run(t, m, "user import "+file)
+	answer(t, m, "initial")
+	if got := output(t, m); got != "created 1 roles (0 already existed) and 1 users (0 already existed)\n" {
+		t.Errorf("import: %q", got)
+	}
+	run(t, m, "user import "+file)
+	answer(t, m, "initial")
+	if got := output(t, m); got != "created 0 roles (1 already existed) and 0 users (1 already existed)\n" {
+		t.Errorf("importing again: %q", got)
+	}
+
+	run(t, m, "role show reporter")
+	got := output(t, m)
+	for _, want := range []string{"inherits: read@shop", "shop.reports  insert", "shop.*        collStats, dbStats, find"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("role show of the imported role: %q, want %q", got, want)
+		}
+	}
+
+	// With their credentials, users are restored by inserting into
+	// admin.system.users.
+	exported := filepath.Join(dir, "exported.json")
+	run(t, m, "user export "+exported+" --with-credentials")
+	if got := output(t, m); got != "exported 1 roles and 1 users to "+exported+"\n" {
+		t.Errorf("export: %q", got)
+	}
+	other := newTestModel(store.NewFake())
+	run(t, other, "user import "+exported)
+	if got := output(t, other); got != "created 1 roles (0 already existed) and 1 users (0 already existed)\n" {
+		t.Errorf("import with credentials: %q", got)
+	}
+	if other.prompt != nil {
+		t.Error("importing users with credentials asked for a password")
+	}
+	run(t, other, "cd shop")
+	run(t, other, "user ls")
+	if got := output(t, other); got != "carol@shop  reporter@shop\n" {
+		t.Errorf("user ls after importing with credentials: %q", got)
+	}
+}
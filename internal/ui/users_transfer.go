@@ -0,0 +1,223 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Server error codes for principals that already exist.
+const (
+	errCodeRoleExists = 51002
+	errCodeUserExists = 51003
+)
+
+// authExport is the file format of `users export`: custom roles and users as
+// canonical Extended JSON, so it can be imported into another cluster.
+type authExport struct {
+	Roles []bson.M `bson:"roles"`
+	Users []bson.M `bson:"users"`
+}
+
+// Fields kept when exporting. Credentials, and the ids system.users needs
+// to restore them, are only kept when explicitly asked for.
+var (
+	exportedRoleFields       = []string{"role", "db", "privileges", "roles", "authenticationRestrictions"}
+	exportedUserFields       = []string{"user", "db", "roles", "customData", "mechanisms", "authenticationRestrictions"}
+	exportedCredentialFields = []string{"_id", "userId", "credentials"}
+)
+
+func pick(doc bson.M, fields []string) bson.M {
+	picked := bson.M{}
+	for _, f := range fields {
+		if v, ok := doc[f]; ok {
+			picked[f] = v
+		}
+	}
+	return picked
+}
+
+// exportUsers writes the custom roles and users of db, or of every database
+// at the root, to path.
+func (m *model) exportUsers(db, path string, withCredentials bool) tea.Cmd {
+	atRoot := len(m.currentPath) == 0
+	return m.run(func(ctx context.Context) tea.Msg {
+		dbs := []string{db}
+		if atRoot {
+			var err error
+			if dbs, err = m.store.ListDatabaseNames(ctx); err != nil {
+				return mongoMsg{err: err}
+			}
+		}
+
+		var export authExport
+		for _, name := range dbs {
+			var res struct {
+				Roles []bson.M `bson:"roles"`
+			}
+			cmd := bson.D{{Key: "rolesInfo", Value: 1}, {Key: "showPrivileges", Value: true}}
+			if err := m.store.RunCommand(ctx, name, cmd, &res); err != nil {
+				return mongoMsg{err: err}
+			}
+			for _, role := range res.Roles {
+				export.Roles = append(export.Roles, pick(role, exportedRoleFields))
+			}
+		}
+
+		usersInfo := interface{}(1)
+		if atRoot {
+			usersInfo = bson.D{{Key: "forAllDBs", Value: true}}
+		}
+		cmd := bson.D{{Key: "usersInfo", Value: usersInfo}, {Key: "showCredentials", Value: withCredentials}}
+		var res struct {
+			Users []bson.M `bson:"users"`
+		}
+		if err := m.store.RunCommand(ctx, db, cmd, &res); err != nil {
+			return mongoMsg{err: err}
+		}
+		for _, user := range res.Users {
+			exported := pick(user, exportedUserFields)
+			if withCredentials {
+				for k, v := range pick(user, exportedCredentialFields) {
+					exported[k] = v
+				}
+			}
+			export.Users = append(export.Users, exported)
+		}
+
+		data, err := bson.MarshalExtJSONIndent(export, true, false, "", "  ")
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+			return mongoMsg{err: err}
+		}
+		return mongoMsg{result: message(fmt.Sprintf("exported %d roles and %d users to %s", len(export.Roles), len(export.Users), path))}
+	})
+}
+
+// importUsers reads an export file and asks for an initial password if it
+// contains users without credentials.
+func (m *model) importUsers(path string) (tea.Model, tea.Cmd) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	var export authExport
+	if err := bson.UnmarshalExtJSON(data, true, &export); err != nil {
+		m.err = fmt.Errorf("users import: %s: %w", path, err)
+		return m, nil
+	}
+
+	if m.dryRun {
+		return m, m.dryRunUserImport(export)
+	}
+
+	withoutCredentials := 0
+	for _, user := range export.Users {
+		if _, ok := user["credentials"]; !ok {
+			withoutCredentials++
+		}
+	}
+	if withoutCredentials == 0 {
+		return m, m.applyUserImport(export, "")
+	}
+
+	m.ask(fmt.Sprintf("initial password for %d imported users: ", withoutCredentials), true, func(password string) tea.Cmd {
+		if password == "" {
+			return func() tea.Msg {
+				return mongoMsg{err: fmt.Errorf("password must not be empty")}
+			}
+		}
+		return m.applyUserImport(export, password)
+	})
+	return m, nil
+}
+
+// applyUserImport creates the roles and users of export, skipping those that
+// already exist. Roles are created before their inheritance is granted, so
+// their order in the file does not matter.
+func (m *model) applyUserImport(export authExport, password string) tea.Cmd {
+	return m.run(func(ctx context.Context) tea.Msg {
+		rolesCreated, rolesSkipped := 0, 0
+		var created []bson.M
+		for _, role := range export.Roles {
+			db, _ := role["db"].(string)
+			privileges := role["privileges"]
+			if privileges == nil {
+				privileges = bson.A{}
+			}
+			cmd := bson.D{{Key: "createRole", Value: role["role"]}, {Key: "privileges", Value: privileges}, {Key: "roles", Value: bson.A{}}}
+			if restrictions, ok := role["authenticationRestrictions"]; ok {
+				cmd = append(cmd, bson.E{Key: "authenticationRestrictions", Value: restrictions})
+			}
+			err := m.store.RunCommand(ctx, db, cmd, nil)
+			if hasErrorCode(err, errCodeRoleExists) {
+				rolesSkipped++
+				continue
+			}
+			if err != nil {
+				return mongoMsg{err: fmt.Errorf("creating role %v@%s: %w", role["role"], db, err)}
+			}
+			rolesCreated++
+			created = append(created, role)
+		}
+		for _, role := range created {
+			inherited, _ := role["roles"].(bson.A)
+			if len(inherited) == 0 {
+				continue
+			}
+			db, _ := role["db"].(string)
+			cmd := bson.D{{Key: "grantRolesToRole", Value: role["role"]}, {Key: "roles", Value: inherited}}
+			if err := m.store.RunCommand(ctx, db, cmd, nil); err != nil {
+				return mongoMsg{err: fmt.Errorf("granting roles to %v@%s: %w", role["role"], db, err)}
+			}
+		}
+
+		usersCreated, usersSkipped := 0, 0
+		for _, user := range export.Users {
+			db, _ := user["db"].(string)
+			var err error
+			if _, ok := user["credentials"]; ok {
+				// Restoring password hashes needs a direct write to
+				// system.users, like mongorestore does.
+				_, err = m.store.InsertOne(ctx, "admin", "system.users", user)
+				if mongo.IsDuplicateKeyError(err) {
+					usersSkipped++
+					continue
+				}
+			} else {
+				cmd := bson.D{{Key: "createUser", Value: user["user"]}, {Key: "pwd", Value: password}, {Key: "roles", Value: user["roles"]}}
+				for _, f := range []string{"customData", "mechanisms", "authenticationRestrictions"} {
+					if v, ok := user[f]; ok {
+						cmd = append(cmd, bson.E{Key: f, Value: v})
+					}
+				}
+				err = m.store.RunCommand(ctx, db, cmd, nil)
+				if hasErrorCode(err, errCodeUserExists) {
+					usersSkipped++
+					continue
+				}
+			}
+			if err != nil {
+				return mongoMsg{err: fmt.Errorf("creating user %v@%s: %w", user["user"], db, err)}
+			}
+			usersCreated++
+		}
+
+		return mongoMsg{result: message(fmt.Sprintf("created %d roles (%d already existed) and %d users (%d already existed)",
+			rolesCreated, rolesSkipped, usersCreated, usersSkipped))}
+	})
+}
+
+// hasErrorCode reports whether err is a server error with the given code.
+func hasErrorCode(err error, code int32) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == code
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+var sentStyle = lipgloss.NewStyle().Faint(true)
+
+type sentCommandsKey struct{}
+
+// sentCommands records the server commands an operation sends, for verbose
+// mode. It is carried in the operation's context and filled in by the
+// command monitor.
+type sentCommands struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// unshownFields are the fields the driver adds to every command, left out of
+// what verbose mode shows.
+var unshownFields = map[string]bool{"lsid": true, "$clusterTime": true, "$db": true, "txnNumber": true}
+
+// recordSent adds the command of e to the operation's sentCommands, if it
+// has any, as its name, target namespace and the rest of its fields, e.g.
+// find shop.orders {"filter": {"qty": {"$gt": 2}}, "sort": {"qty": 1}}.
+func recordSent(ctx context.Context, e *event.CommandStartedEvent) {
+	sent, ok := ctx.Value(sentCommandsKey{}).(*sentCommands)
+	if !ok {
+		return
+	}
+	line := describeCommand(e.DatabaseName, e.Command)
+	sent.mu.Lock()
+	sent.lines = append(sent.lines, line)
+	sent.mu.Unlock()
+}
+
+func describeCommand(db string, cmd bson.Raw) string {
+	elems, err := cmd.Elements()
+	if err != nil || len(elems) == 0 {
+		return fmt.Sprintf("<unreadable command on %s>", db)
+	}
+	name := elems[0].Key()
+	target := db
+	if coll, ok := elems[0].Value().StringValueOK(); ok {
+		target = db + "." + coll
+	}
+	rest := bson.D{}
+	for _, e := range elems[1:] {
+		if !unshownFields[e.Key()] {
+			rest = append(rest, bson.E{Key: e.Key(), Value: e.Value()})
+		}
+	}
+	line := name + " " + target
+	if len(rest) > 0 {
+		line += " " + extJSON(rest)
+	}
+	return line
+}
+
+func (s *sentCommands) all() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.lines...)
+}
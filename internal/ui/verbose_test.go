@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func TestRecordSent(t *testing.T) {
+	cmd, _ := bson.Marshal(bson.D{
+		{Key: "find", Value: "orders"},
+		{Key: "filter", Value: bson.D{{Key: "qty", Value: bson.D{{Key: "$gt", Value: 2}}}}},
+		{Key: "sort", Value: bson.D{{Key: "qty", Value: 1}}},
+		{Key: "lsid", Value: bson.D{{Key: "id", Value: "x"}}},
+		{Key: "$db", Value: "shop"},
+	})
+	ping, _ := bson.Marshal(bson.D{{Key: "ping", Value: 1}, {Key: "$db", Value: "admin"}})
+
+	sent := &sentCommands{}
+	ctx := context.WithValue(context.Background(), sentCommandsKey{}, sent)
+	recordSent(ctx, &event.CommandStartedEvent{DatabaseName: "shop", Command: cmd})
+	recordSent(ctx, &event.CommandStartedEvent{DatabaseName: "admin", Command: ping})
+	recordSent(context.Background(), &event.CommandStartedEvent{DatabaseName: "shop", Command: cmd}) // Not verbose
+
+	lines := sent.all()
+	want := []string{`find shop.orders {"filter":{"qty":{"$gt":2}},"sort":{"qty":1}}`, "ping admin"}
+	if len(lines) != len(want) {
+		t.Fatalf("got %q", lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("got  %s\nwant %s", lines[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+// viewResult is the result of `view show`: what a view reads from and the
+// pipeline it applies.
+type viewResult struct {
+	name     string
+	viewOn   string
+	pipeline bson.A
+}
+
+func (v viewResult) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s is a view on %s\n\n", v.name, v.viewOn))
+	text, err := prettyJSON(bson.D{{Key: "pipeline", Value: v.pipeline}})
+	if err != nil {
+		b.WriteString(fmt.Sprintf("%v\n", v.pipeline))
+		return b.String()
+	}
+	b.WriteString(highlightJSON(text))
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (m *model) view(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: view show [name] | create <name> <source> '<pipeline>'"
+	if len(args) == 0 || len(m.currentPath) == 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	db := m.currentPath[0]
+
+	switch args[0] {
+	case "show":
+		var name string
+		switch {
+		case len(args) == 2:
+			name = args[1]
+		case len(args) == 1 && len(m.currentPath) >= 2:
+			name = m.currentPath[1]
+		default:
+			m.err = fmt.Errorf("usage: view show [name]")
+			return m, nil
+		}
+		return m, m.showView(db, name)
+
+	case "create":
+		if len(args) != 4 {
+			m.err = fmt.Errorf("usage: view create <name> <source> '<pipeline>'")
+			return m, nil
+		}
+		pipeline, err := commands.ParsePipeline(args[3])
+		if err != nil {
+			m.err = fmt.Errorf("view create: invalid pipeline: %w", err)
+			return m, nil
+		}
+		deprecated, err := checkOperators(m.serverVersion, pipeline)
+		if err != nil {
+			m.err = fmt.Errorf("view create: %w", err)
+			return m, nil
+		}
+		name, source := args[1], args[2]
+		return m, m.run(func(ctx context.Context) tea.Msg {
+			if err := m.store.CreateView(ctx, db, name, source, pipeline); err != nil {
+				return mongoMsg{err: err}
+			}
+			m.names.InvalidateDB(db)
+			return mongoMsg{result: message(fmt.Sprintf("view '%s' on '%s' created in database '%s'", name, source, db)), warnings: deprecated}
+		})
+
+	default:
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+}
+
+// showView shows the definition of view name in db.
+func (m *model) showView(db, name string) tea.Cmd {
+	return m.run(func(ctx context.Context) tea.Msg {
+		cur, err := m.store.ListCollections(ctx, db, bson.M{"name": name})
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		defer cur.Close(ctx)
+
+		if !cur.Next(ctx) {
+			if err := cur.Err(); err != nil {
+				return mongoMsg{err: err}
+			}
+			return mongoMsg{err: fmt.Errorf("'%s' does not exist in database '%s'", name, db)}
+		}
+		var spec struct {
+			Type    string `bson:"type"`
+			Options struct {
+				ViewOn   string `bson:"viewOn"`
+				Pipeline bson.A `bson:"pipeline"`
+			} `bson:"options"`
+		}
+		if err := cur.Decode(&spec); err != nil {
+			return mongoMsg{err: err}
+		}
+		if spec.Type != "view" {
+			return mongoMsg{err: fmt.Errorf("'%s' is a %s, not a view", name, spec.Type)}
+		}
+		return mongoMsg{result: viewResult{name: name, viewOn: spec.Options.ViewOn, pipeline: spec.Options.Pipeline}}
+	})
+}
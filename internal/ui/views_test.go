@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestViews(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop")
+
+	run(t, m, `view create cheap orders '[{"$match": {"price": {"$lt": 1}}}]'`)
+	if got := output(t, m); got != "view 'cheap' on 'orders' created in database 'shop'\n" {
+		t.Errorf("view create: %q", got)
+	}
+	run(t, m, "view show cheap")
+	got := output(t, m)
+	for _, want := range []string{"cheap is a view on orders", `"$lt": 1`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("view show: %q, want %q", got, want)
+		}
+	}
+	expectError(t, m, "view show", "usage: view show [name]")
+	expectError(t, m, `view create cheap orders '[]'`, "already exists")
+
+	run(t, m, "cd cheap")
+	run(t, m, "view show")
+	if got := output(t, m); !strings.Contains(got, "cheap is a view on orders") {
+		t.Errorf("view show inside the view: %q", got)
+	}
+	expectError(t, m, "stats", "is a view and has no storage statistics")
+}
@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+const (
+	defaultVectorLimit = 10
+	candidatesPerLimit = 10 // Atlas suggests 10 to 20 candidates per result
+)
+
+// parseVector reads a query vector written as a JSON array of numbers.
+func parseVector(data []byte) ([]float64, error) {
+	var vector []float64
+	if err := json.Unmarshal(data, &vector); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of numbers: %w", err)
+	}
+	if len(vector) == 0 {
+		return nil, fmt.Errorf("the vector is empty")
+	}
+	return vector, nil
+}
+
+// vectorSearchQuery holds the options of `vsearch`.
+type vectorSearchQuery struct {
+	index, path string
+	vector      []float64
+	candidates  int
+	limit       int
+	exact       bool
+	filter      bson.D
+	withVector  bool
+}
+
+// pipeline returns the $vectorSearch aggregation, with each document's score
+// in _score and, unless asked for, without the embeddings, which would bury
+// the other fields.
+func (q vectorSearchQuery) pipeline() []bson.D {
+	search := bson.D{
+		{Key: "index", Value: q.index},
+		{Key: "path", Value: q.path},
+		{Key: "queryVector", Value: q.vector},
+		{Key: "limit", Value: q.limit},
+	}
+	if q.exact {
+		search = append(search, bson.E{Key: "exact", Value: true})
+	} else {
+		search = append(search, bson.E{Key: "numCandidates", Value: q.candidates})
+	}
+	if q.filter != nil {
+		search = append(search, bson.E{Key: "filter", Value: q.filter})
+	}
+	pipeline := []bson.D{
+		{{Key: "$vectorSearch", Value: search}},
+		{{Key: "$addFields", Value: bson.D{{Key: searchScoreField, Value: bson.D{{Key: "$meta", Value: "vectorSearchScore"}}}}}},
+	}
+	if !q.withVector {
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: bson.D{{Key: q.path, Value: 0}}}})
+	}
+	return pipeline
+}
+
+// vsearch runs an Atlas Vector Search query against the current collection.
+func (m *model) vsearch(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: vsearch <index> <path> --vector '<json array>' | --vector-file <file> [--candidates N] [--limit N] [--exact] [--filter '<filter>'] [--with-vector]"
+
+	db, coll, err := m.collectionPath("vsearch")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	fs := commands.NewFlagSet("vsearch")
+	inline := fs.String("vector", "", "query vector as a JSON array")
+	file := fs.String("vector-file", "", "file holding the query vector as a JSON array")
+	candidates := fs.Int("candidates", 0, "nearest neighbours to consider (numCandidates), 10 per result by default")
+	limit := fs.Int("limit", defaultVectorLimit, "documents to return")
+	exact := fs.Bool("exact", false, "run an exact (ENN) search instead of an approximate one")
+	filter := fs.String("filter", "", "pre-filter on fields indexed as filter fields")
+	withVector := fs.Bool("with-vector", false, "keep the embeddings in the results")
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) != 2 || (*inline == "") == (*file == "") || *limit <= 0 || *candidates < 0 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+
+	q := vectorSearchQuery{index: positional[0], path: positional[1], limit: *limit, candidates: *candidates, exact: *exact, withVector: *withVector}
+	data := []byte(*inline)
+	if *file != "" {
+		if data, err = os.ReadFile(*file); err != nil {
+			m.err = fmt.Errorf("vsearch: %w", err)
+			return m, nil
+		}
+	}
+	if q.vector, err = parseVector(data); err != nil {
+		m.err = fmt.Errorf("vsearch: invalid vector: %w", err)
+		return m, nil
+	}
+	if q.candidates == 0 {
+		q.candidates = q.limit * candidatesPerLimit
+	}
+	if q.candidates < q.limit {
+		m.err = fmt.Errorf("vsearch: --candidates must be at least --limit")
+		return m, nil
+	}
+	if *filter != "" {
+		if q.filter, err = commands.ParseDocument(*filter); err != nil {
+			m.err = fmt.Errorf("vsearch: invalid filter: %w", err)
+			return m, nil
+		}
+	}
+
+	pipeline := q.pipeline()
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		cur, err := m.store.Aggregate(ctx, db, coll, pipeline)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		defer cur.Close(ctx)
+		var docs documentList
+		if err := cur.All(ctx, &docs.docs); err != nil {
+			return mongoMsg{err: err}
+		}
+		if len(docs.docs) == 0 {
+			return mongoMsg{result: message(fmt.Sprintf("no results; check that %s is a vector search index on %s with %d dimensions", q.index, q.path, len(q.vector)))}
+		}
+		return mongoMsg{result: docs}
+	})
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseVector(t *testing.T) {
+	v, err := parseVector([]byte("[0.5, -1, 2e-3]"))
+	if err != nil || len(v) != 3 || v[2] != 0.002 {
+		t.Errorf("parseVector = %v, %v", v, err)
+	}
+	for _, s := range []string{"[]", `["a"]`, "{}", ""} {
+		if _, err := parseVector([]byte(s)); err == nil {
+			t.Errorf("%q: no error", s)
+		}
+	}
+}
+
+func TestVectorSearchPipeline(t *testing.T) {
+	q := vectorSearchQuery{index: "vec", path: "embedding", vector: []float64{1, 0}, candidates: 100, limit: 10, filter: bson.D{{Key: "lang", Value: "en"}}}
+	got := extJSON(bson.D{{Key: "p", Value: q.pipeline()}})
+	for _, want := range []string{`"numCandidates":100`, `"filter":{"lang":"en"}`, `"$meta":"vectorSearchScore"`, `"$project":{"embedding":0}`} {
+		if !strings.Contains(strings.ReplaceAll(got, " ", ""), want) {
+			t.Errorf("pipeline lacks %s:\n%s", want, got)
+		}
+	}
+
+	q.exact, q.withVector = true, true
+	got = extJSON(bson.D{{Key: "p", Value: q.pipeline()}})
+	if strings.Contains(got, "numCandidates") || strings.Contains(got, "$project") || !strings.Contains(got, "exact") {
+		t.Errorf("exact pipeline with vectors:\n%s", got)
+	}
+}
+
+func TestVsearchArgs(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+	file := filepath.Join(t.TempDir(), "q.json")
+	if err := os.WriteFile(file, []byte("[1, 2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	expectError(t, m, "vsearch vec embedding", "usage: vsearch")
+	expectError(t, m, "vsearch vec embedding --vector '[1]' --vector-file "+file, "usage: vsearch")
+	expectError(t, m, "vsearch vec embedding --vector-file "+file, "invalid vector")
+	expectError(t, m, "vsearch vec embedding --vector '[1]' --limit 50 --candidates 20", "at least --limit")
+}
+
+func TestVsearchOutsideAtlas(t *testing.T) {
+	m := newTestModel(seededFake())
+	run(t, m, "cd shop/orders")
+	expectError(t, m, "vsearch vec embedding --vector '[0.1, 0.2]'", "$vectorSearch stage is only allowed on MongoDB Atlas")
+}
@@ -0,0 +1,223 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// watchboardMinutes is how many of the most recent minutes are shown per
+// namespace.
+const watchboardMinutes = 3
+
+// changeCounts counts change events by kind.
+type changeCounts struct {
+	inserts, updates, deletes int
+}
+
+func (c *changeCounts) add(operationType string) {
+	switch operationType {
+	case "insert":
+		c.inserts++
+	case "update", "replace":
+		c.updates++
+	case "delete":
+		c.deletes++
+	}
+}
+
+// watchboard follows change streams on several namespaces and counts their
+// events per minute.
+type watchboard struct {
+	namespaces []string
+	started    time.Time
+	counts     map[string]map[time.Time]*changeCounts // namespace -> minute -> counts
+	totals     map[string]*changeCounts
+	errs       map[string]error
+	events     chan changeEventMsg
+	cancel     context.CancelFunc
+}
+
+// changeEventMsg is a change event, or the error that ended a namespace's
+// change stream.
+type changeEventMsg struct {
+	namespace     string
+	operationType string
+	at            time.Time
+	err           error
+}
+
+// watchboardTickMsg redraws the board so minutes roll over without events.
+type watchboardTickMsg struct{}
+
+func (m *model) watchboard(args []string) (tea.Model, tea.Cmd) {
+	if len(args) == 1 && args[0] == "stop" {
+		m.stopWatchboard()
+		return m, nil
+	}
+
+	var namespaces [][]string
+	for _, arg := range args {
+		path := m.resolvePath(arg)
+		if len(path) != 2 {
+			m.err = fmt.Errorf("watchboard: '%s' does not name a collection", arg)
+			return m, nil
+		}
+		namespaces = append(namespaces, path)
+	}
+	if len(namespaces) == 0 {
+		db, coll, err := m.collectionPath("watchboard")
+		if err != nil {
+			m.err = fmt.Errorf("usage: watchboard [[db/]collection...] | stop")
+			return m, nil
+		}
+		namespaces = append(namespaces, []string{db, coll})
+	}
+
+	m.stopWatchboard()
+	ctx, cancel := context.WithCancel(context.Background())
+	wb := &watchboard{
+		started: time.Now(),
+		counts:  map[string]map[time.Time]*changeCounts{},
+		totals:  map[string]*changeCounts{},
+		errs:    map[string]error{},
+		events:  make(chan changeEventMsg, 64),
+		cancel:  cancel,
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "operationType", Value: bson.D{
+			{Key: "$in", Value: bson.A{"insert", "update", "replace", "delete"}},
+		}}}}},
+	}
+	for _, ns := range namespaces {
+		name := ns[0] + "." + ns[1]
+		wb.namespaces = append(wb.namespaces, name)
+		wb.counts[name] = map[time.Time]*changeCounts{}
+		wb.totals[name] = &changeCounts{}
+		go followChangeStream(ctx, m.client.Database(ns[0]).Collection(ns[1]), pipeline, name, wb.events)
+	}
+
+	m.board = wb
+	m.result = nil
+	m.err = nil
+	return m, tea.Batch(wb.next(), watchboardTick())
+}
+
+// followChangeStream sends the events of coll's change stream to events until
+// ctx is cancelled or the stream fails.
+func followChangeStream(ctx context.Context, coll *mongo.Collection, pipeline mongo.Pipeline, name string, events chan<- changeEventMsg) {
+	send := func(event changeEventMsg) bool {
+		select {
+		case events <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	stream, err := coll.Watch(ctx, pipeline)
+	if err != nil {
+		send(changeEventMsg{namespace: name, err: err})
+		return
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var event struct {
+			OperationType string `bson:"operationType"`
+		}
+		if err := stream.Decode(&event); err != nil {
+			send(changeEventMsg{namespace: name, err: err})
+			return
+		}
+		if !send(changeEventMsg{namespace: name, operationType: event.OperationType, at: time.Now()}) {
+			return
+		}
+	}
+	if err := stream.Err(); err != nil && ctx.Err() == nil {
+		send(changeEventMsg{namespace: name, err: err})
+	}
+}
+
+// next waits for the next change event.
+func (wb *watchboard) next() tea.Cmd {
+	events := wb.events
+	return func() tea.Msg {
+		return <-events
+	}
+}
+
+func watchboardTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return watchboardTickMsg{}
+	})
+}
+
+// record counts an event in its namespace and minute.
+func (wb *watchboard) record(event changeEventMsg) {
+	if event.err != nil {
+		wb.errs[event.namespace] = event.err
+		return
+	}
+	minute := event.at.Truncate(time.Minute)
+	counts := wb.counts[event.namespace][minute]
+	if counts == nil {
+		counts = &changeCounts{}
+		wb.counts[event.namespace][minute] = counts
+	}
+	counts.add(event.operationType)
+	wb.totals[event.namespace].add(event.operationType)
+}
+
+// stopWatchboard closes the change streams of the running watchboard, if any.
+func (m *model) stopWatchboard() {
+	if m.board != nil {
+		m.board.cancel()
+		m.board = nil
+	}
+}
+
+func (wb *watchboard) String() string {
+	width := len("namespace")
+	for _, ns := range wb.namespaces {
+		if len(ns) > width {
+			width = len(ns)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("watchboard since %s (press Esc to stop)\n\n", wb.started.Format("15:04:05")))
+	b.WriteString(fmt.Sprintf("%-*s  %-6s  %8s  %8s  %8s\n", width, "namespace", "minute", "insert", "update", "delete"))
+
+	now := time.Now().Truncate(time.Minute)
+	for _, ns := range wb.namespaces {
+		if err := wb.errs[ns]; err != nil {
+			b.WriteString(fmt.Sprintf("%-*s  error: %v\n", width, ns, err))
+			continue
+		}
+
+		minutes := make([]time.Time, 0, len(wb.counts[ns]))
+		for minute := range wb.counts[ns] {
+			if now.Sub(minute) < watchboardMinutes*time.Minute {
+				minutes = append(minutes, minute)
+			}
+		}
+		sort.Slice(minutes, func(i, j int) bool { return minutes[i].After(minutes[j]) })
+
+		label := ns
+		for _, minute := range minutes {
+			c := wb.counts[ns][minute]
+			b.WriteString(fmt.Sprintf("%-*s  %-6s  %8d  %8d  %8d\n", width, label, minute.Format("15:04"), c.inserts, c.updates, c.deletes))
+			label = ""
+		}
+		t := wb.totals[ns]
+		b.WriteString(fmt.Sprintf("%-*s  %-6s  %8d  %8d  %8d\n", width, label, "total", t.inserts, t.updates, t.deletes))
+	}
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/nick-popovic/mon-go/internal/config"
+	"github.com/nick-popovic/mon-go/internal/release"
+)
+
+var (
+	whatsNewTitleStyle = lipgloss.NewStyle().Bold(true)
+	whatsNewNameStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	whatsNewBoxStyle   = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+)
+
+// whatsNew is the result of `whatsnew`: the release notes of one or more
+// versions, shown in a box.
+type whatsNew struct {
+	releases []release.Release
+	firstRun bool // Shown because of an upgrade rather than asked for
+}
+
+func (w whatsNew) String() string {
+	var b strings.Builder
+	for i, r := range w.releases {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(whatsNewTitleStyle.Render("New in mon-go " + r.Version))
+		b.WriteString("\n")
+		writeFeatures(&b, "Commands", r.Commands)
+		writeFeatures(&b, "Keys", r.Keys)
+		for _, note := range r.Notes {
+			b.WriteString("  " + note + "\n")
+		}
+	}
+	if w.firstRun {
+		b.WriteString("\nType a command to continue; `whatsnew` shows this again.")
+	}
+	return whatsNewBoxStyle.Render(strings.TrimSuffix(b.String(), "\n")) + "\n"
+}
+
+func writeFeatures(b *strings.Builder, title string, features []release.Feature) {
+	if len(features) == 0 {
+		return
+	}
+	width := 0
+	for _, f := range features {
+		width = max(width, len(f.Name))
+	}
+	b.WriteString(title + ":\n")
+	for _, f := range features {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", whatsNewNameStyle.Render(fmt.Sprintf("%-*s", width, f.Name)), f.Summary))
+	}
+}
+
+// whatsnew shows the notes of the current release, or with --all of every
+// release.
+func (m *model) whatsnew(args []string) (tea.Model, tea.Cmd) {
+	switch {
+	case len(args) == 0:
+		m.result = whatsNew{releases: release.All()[:1]}
+	case len(args) == 1 && args[0] == "--all":
+		m.result = whatsNew{releases: release.All()}
+	default:
+		m.err = fmt.Errorf("usage: whatsnew [--all]")
+		return m, nil
+	}
+	m.err = nil
+	return m, nil
+}
+
+// showWhatsNew puts the notes of the releases since the one last seen in
+// place of the result once after an upgrade, and remembers that they were
+// shown.
+func (m *model) showWhatsNew() {
+	state, err := config.LoadState()
+	if err != nil {
+		slog.Warn("loading state failed", "error", err)
+		return
+	}
+	if state.SeenVersion == release.Current() {
+		return
+	}
+	if m.err == nil && m.result == nil {
+		m.result = whatsNew{releases: release.Since(state.SeenVersion), firstRun: true}
+	}
+	state.SeenVersion = release.Current()
+	if err := config.SaveState(state); err != nil {
+		slog.Warn("saving state failed", "error", err)
+	}
+}
@@ -0,0 +1,317 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nick-popovic/mon-go/internal/commands"
+)
+
+// insert inserts a document into the current collection, given as JSON or
+// built from the collection's template with name=value arguments.
+func (m *model) insert(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: insert '<json>' | insert --from-template [name=value...]"
+
+	db, coll, err := m.collectionPath("insert")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	fs := commands.NewFlagSet("insert")
+	fromTemplate := fs.Bool("from-template", false, "build the document from the collection's template")
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	var doc bson.D
+	if *fromTemplate {
+		templates, err := loadTemplates()
+		if err != nil {
+			m.err = fmt.Errorf("insert: %w", err)
+			return m, nil
+		}
+		text, ok := templates[db+"."+coll]
+		if !ok {
+			m.err = fmt.Errorf("insert: no template for %s.%s, see `template set`", db, coll)
+			return m, nil
+		}
+		template, err := commands.ParseDocument(text)
+		if err != nil {
+			m.err = fmt.Errorf("insert: invalid template: %w", err)
+			return m, nil
+		}
+		values, err := parseTemplateValues(positional)
+		if err != nil {
+			m.err = fmt.Errorf("insert: %w", err)
+			return m, nil
+		}
+		if doc, err = fillTemplate(template, values); err != nil {
+			m.err = fmt.Errorf("insert: %w", err)
+			return m, nil
+		}
+	} else {
+		if len(positional) != 1 {
+			m.err = fmt.Errorf(usage)
+			return m, nil
+		}
+		if doc, err = commands.ParseDocument(positional[0]); err != nil {
+			m.err = fmt.Errorf("insert: invalid document: %w", err)
+			return m, nil
+		}
+	}
+
+	if m.dryRun {
+		return m, m.dryRunInsert(db, coll, doc)
+	}
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		id, err := m.store.InsertOne(ctx, db, coll, doc)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		m.names.InvalidateDB(db) // The collection may be new
+		return mongoMsg{result: message(fmt.Sprintf("inserted document with _id %v", id)), written: 1}
+	})
+}
+
+// updateSummary says what an update did: the document it inserted, if it
+// upserted one, or else how many it matched and modified.
+func updateSummary(res *mongo.UpdateResult) string {
+	if res.UpsertedID != nil {
+		return fmt.Sprintf("no document matched, inserted one with _id %s", valueJSON(res.UpsertedID))
+	}
+	return fmt.Sprintf("matched %d, modified %d", res.MatchedCount, res.ModifiedCount)
+}
+
+// update updates the first document of the current collection matching a
+// filter, or all of them with --many.
+func (m *model) update(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: update '<filter>' '<update>' [--many] [--upsert]"
+
+	db, coll, err := m.collectionPath("update")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	fs := commands.NewFlagSet("update")
+	many := fs.Bool("many", false, "update every matching document, not just the first")
+	upsert := fs.Bool("upsert", false, "insert a document built from the filter and update if none matches")
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) != 2 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	filter, err := commands.ParseDocument(positional[0])
+	if err != nil {
+		m.err = fmt.Errorf("update: invalid filter: %w", err)
+		return m, nil
+	}
+	update, err := parseUpdate(positional[1])
+	if err != nil {
+		m.err = fmt.Errorf("update: %w", err)
+		return m, nil
+	}
+	deprecated, err := checkOperators(m.serverVersion, filter)
+	if err != nil {
+		m.err = fmt.Errorf("update: %w", err)
+		return m, nil
+	}
+
+	if m.dryRun {
+		return m, m.run(func(ctx context.Context) tea.Msg {
+			n, err := m.store.CountDocuments(ctx, db, coll, filter)
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			switch {
+			case n == 0 && *upsert:
+				return mongoMsg{result: message(fmt.Sprintf("dry run: no document of %s.%s matches, so one would be inserted", db, coll)), warnings: deprecated}
+			case n > 1 && !*many:
+				return mongoMsg{result: message(fmt.Sprintf("dry run: %d documents of %s.%s match, the first would be updated (--many updates all)", n, db, coll)), warnings: deprecated}
+			}
+			return mongoMsg{result: message(fmt.Sprintf("dry run: would update %d documents of %s.%s", n, db, coll)), warnings: deprecated}
+		})
+	}
+
+	opts := options.Update().SetUpsert(*upsert)
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		var res *mongo.UpdateResult
+		var err error
+		if *many {
+			res, err = m.store.UpdateMany(ctx, db, coll, filter, update, opts)
+		} else {
+			res, err = m.store.UpdateOne(ctx, db, coll, filter, update, opts)
+		}
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		if res.UpsertedID != nil {
+			m.names.InvalidateDB(db) // The collection may be new
+		}
+		return mongoMsg{result: message(updateSummary(res)), warnings: deprecated, written: res.ModifiedCount + res.UpsertedCount}
+	})
+}
+
+// parseReplacement parses a whole document to replace one with. Update
+// operators are refused, since ReplaceOne would reject them anyway.
+func parseReplacement(s string) (bson.D, error) {
+	doc, err := commands.ParseDocument(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid replacement: %w", err)
+	}
+	for _, e := range doc {
+		if strings.HasPrefix(e.Key, "$") {
+			return nil, fmt.Errorf("the replacement has operator %s; use `update` to change some fields", e.Key)
+		}
+	}
+	return doc, nil
+}
+
+// replace replaces the first document of the current collection matching a
+// filter with a whole new one, keeping its _id.
+func (m *model) replace(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: replace '<filter>' '<document>' [--upsert]"
+
+	db, coll, err := m.collectionPath("replace")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	fs := commands.NewFlagSet("replace")
+	upsert := fs.Bool("upsert", false, "insert the document if none matches")
+	positional, err := commands.ParseFlags(fs, args)
+	if err != nil || len(positional) != 2 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	filter, err := commands.ParseDocument(positional[0])
+	if err != nil {
+		m.err = fmt.Errorf("replace: invalid filter: %w", err)
+		return m, nil
+	}
+	replacement, err := parseReplacement(positional[1])
+	if err != nil {
+		m.err = fmt.Errorf("replace: %w", err)
+		return m, nil
+	}
+	deprecated, err := checkOperators(m.serverVersion, filter)
+	if err != nil {
+		m.err = fmt.Errorf("replace: %w", err)
+		return m, nil
+	}
+
+	if m.dryRun {
+		return m, m.run(func(ctx context.Context) tea.Msg {
+			cur, err := m.store.Find(ctx, db, coll, filter, options.Find().SetLimit(1))
+			if err != nil {
+				return mongoMsg{err: err}
+			}
+			defer cur.Close(ctx)
+			if !cur.Next(ctx) {
+				if err := cur.Err(); err != nil {
+					return mongoMsg{err: err}
+				}
+				if *upsert {
+					return mongoMsg{result: message(fmt.Sprintf("dry run: no document of %s.%s matches, so this one would be inserted:\n%s", db, coll, extJSON(replacement))), warnings: deprecated}
+				}
+				return mongoMsg{result: message(fmt.Sprintf("dry run: no document of %s.%s matches, nothing would be replaced", db, coll)), warnings: deprecated}
+			}
+			var current bson.D
+			if err := cur.Decode(&current); err != nil {
+				return mongoMsg{err: err}
+			}
+			return mongoMsg{result: documentDiff{leftName: "current", rightName: "replacement (dry run)", left: current, right: replacement}, warnings: deprecated}
+		})
+	}
+
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		res, err := m.store.ReplaceOne(ctx, db, coll, filter, replacement, options.Replace().SetUpsert(*upsert))
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		if res.UpsertedID != nil {
+			m.names.InvalidateDB(db) // The collection may be new
+		}
+		return mongoMsg{result: message(updateSummary(res)), warnings: deprecated, written: res.ModifiedCount + res.UpsertedCount}
+	})
+}
+
+// groupDigits writes n with thousands separators, e.g. 14,382.
+func groupDigits(n int64) string {
+	if n < 0 {
+		return "-" + groupDigits(-n)
+	}
+	s := strconv.FormatInt(n, 10)
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// deletemany deletes every document of the current collection matching a
+// filter, once the user has typed how many that is.
+func (m *model) deletemany(args []string) (tea.Model, tea.Cmd) {
+	const usage = "usage: deletemany '<filter>'"
+
+	db, coll, err := m.collectionPath("deletemany")
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	if len(args) != 1 {
+		m.err = fmt.Errorf(usage)
+		return m, nil
+	}
+	filter, err := commands.ParseDocument(args[0])
+	if err != nil {
+		m.err = fmt.Errorf("deletemany: invalid filter: %w", err)
+		return m, nil
+	}
+	deprecated, err := checkOperators(m.serverVersion, filter)
+	if err != nil {
+		m.err = fmt.Errorf("deletemany: %w", err)
+		return m, nil
+	}
+
+	dryRun := m.dryRun
+	return m, m.run(func(ctx context.Context) tea.Msg {
+		n, err := m.store.CountDocuments(ctx, db, coll, filter)
+		if err != nil {
+			return mongoMsg{err: err}
+		}
+		switch {
+		case n == 0:
+			return mongoMsg{result: message(fmt.Sprintf("no document of %s.%s matches, nothing to delete", db, coll)), warnings: deprecated}
+		case dryRun:
+			return mongoMsg{result: message(fmt.Sprintf("dry run: would delete %s documents in %s.%s", groupDigits(n), db, coll)), warnings: deprecated}
+		}
+		count := groupDigits(n)
+		return confirmMsg{
+			result:   message(fmt.Sprintf("this will delete %s documents in %s.%s", count, db, coll)),
+			question: fmt.Sprintf("type %s to confirm: ", count),
+			expect:   count,
+			onYes: func() tea.Cmd {
+				return m.runWithTimeout(0, func(ctx context.Context) tea.Msg { // Mass deletes take a while
+					n, err := m.store.DeleteMany(ctx, db, coll, filter)
+					if err != nil {
+						return mongoMsg{err: err}
+					}
+					return mongoMsg{result: message(fmt.Sprintf("deleted %s documents", groupDigits(n))), written: n}
+				})
+			},
+			declined: "the count was not typed, nothing deleted",
+		}
+	})
+}